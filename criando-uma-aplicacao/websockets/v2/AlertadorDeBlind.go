@@ -3,25 +3,94 @@ package poquer
 import (
 	"fmt"
 	"io"
+	"sync"
 	"time"
 )
 
-// AlertadorDeBlind agenda alertas para quantias de blind
+// CancelarAlerta impede que um alerta agendado dispare. Depois que ela retorna,
+// o alerta não é mais escrito
+type CancelarAlerta func()
+
+// AlertadorDeBlind agenda alertas para os blinds de cada nível
 type AlertadorDeBlind interface {
-	AgendarAlertaPara(duracao time.Duration, quantia int, para io.Writer)
+	AgendarAlertaPara(duracao time.Duration, blinds Blinds, para io.Writer) CancelarAlerta
 }
 
 // AlertadorDeBlindFunc te permite implementar o AlertadorDeBlind com uma função
-type AlertadorDeBlindFunc func(duracao time.Duration, quantia int, para io.Writer)
+type AlertadorDeBlindFunc func(duracao time.Duration, blinds Blinds, para io.Writer) CancelarAlerta
 
 // AgendarAlertaPara é uma implementação de AlertadorDeBlind para AlertadorDeBlindFunc
-func (a AlertadorDeBlindFunc) AgendarAlertaPara(duracao time.Duration, quantia int, para io.Writer) {
-	a(duracao, quantia, para)
+func (a AlertadorDeBlindFunc) AgendarAlertaPara(duracao time.Duration, blinds Blinds, para io.Writer) CancelarAlerta {
+	return a(duracao, blinds, para)
+}
+
+// DestinoDeBlind pode ser implementado por destinos de alerta que preferem receber os
+// blinds em vez do texto do alerta
+type DestinoDeBlind interface {
+	AlertarBlind(blinds Blinds) error
+}
+
+// DestinoDeRelogio pode ser implementado por destinos de alerta que preferem receber o estado
+// dos blinds em vez do texto com o tempo que falta
+type DestinoDeRelogio interface {
+	AtualizarRelogio(estado EstadoDosBlinds) error
 }
 
-// Alertador agenda alertas e os imprime para "para"
-func Alertador(duracao time.Duration, quantia int, para io.Writer) {
-	time.AfterFunc(duracao, func() {
-		fmt.Fprintf(para, "Blind agora é %d\n", quantia)
-	})
+// Alertador agenda alertas no relógio do sistema e os imprime para "para"
+func Alertador(duracao time.Duration, blinds Blinds, para io.Writer) CancelarAlerta {
+	return NovoAlertador(RelogioDoSistema{})(duracao, blinds, para)
+}
+
+// NovoAlertador cria um alertador que agenda os alertas no relógio informado
+func NovoAlertador(relogio Relogio) AlertadorDeBlindFunc {
+	return func(duracao time.Duration, blinds Blinds, para io.Writer) CancelarAlerta {
+		var mu sync.Mutex
+		cancelado := false
+
+		timer := relogio.NovoTimer(duracao, func() {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !cancelado {
+				alertar(para, blinds)
+			}
+		})
+
+		return func() {
+			timer.Parar()
+			mu.Lock()
+			cancelado = true
+			mu.Unlock()
+		}
+	}
+}
+
+func alertar(para io.Writer, blinds Blinds) {
+	if destino, ok := para.(DestinoDeBlind); ok {
+		destino.AlertarBlind(blinds)
+		return
+	}
+	fmt.Fprintln(para, mensagemDeBlind(blinds))
+}
+
+func atualizarRelogio(para io.Writer, estado EstadoDosBlinds) {
+	if destino, ok := para.(DestinoDeRelogio); ok {
+		destino.AtualizarRelogio(estado)
+		return
+	}
+	fmt.Fprintln(para, estado)
+}
+
+func mensagemDeBlind(blinds Blinds) string {
+	mensagem := fmt.Sprintf("Blind agora é %d", blinds.BigBlind)
+
+	if blinds.SmallBlind > 0 {
+		mensagem = fmt.Sprintf("Blinds agora são %d/%d", blinds.SmallBlind, blinds.BigBlind)
+	}
+
+	if blinds.Ante > 0 {
+		mensagem += fmt.Sprintf(", ante %d", blinds.Ante)
+	}
+
+	return mensagem
 }
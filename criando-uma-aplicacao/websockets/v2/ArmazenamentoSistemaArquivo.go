@@ -99,7 +99,25 @@ func (s *SistemaArquivoArmazenamentoJogador) GravarVitoria(nome string) {
 	if jogador != nil {
 		jogador.Vitorias++
 	} else {
-		s.liga = append(s.liga, Jogador{nome, 1})
+		s.liga = append(s.liga, Jogador{Nome: nome, Vitorias: 1})
+	}
+
+	s.baseDeDados.Encode(s.liga)
+}
+
+// GravarColocacoes acrescenta a colocação de cada jogador às que ele já tem e soma o prêmio
+// aos seus ganhos
+func (s *SistemaArquivoArmazenamentoJogador) GravarColocacoes(colocacoes []Colocacao) {
+	for _, colocacao := range colocacoes {
+		jogador := s.liga.Encontrar(colocacao.Nome)
+
+		if jogador == nil {
+			s.liga = append(s.liga, Jogador{Nome: colocacao.Nome})
+			jogador = &s.liga[len(s.liga)-1]
+		}
+
+		jogador.Colocacoes = append(jogador.Colocacoes, colocacao.Posicao)
+		jogador.Ganhos += colocacao.Premio
 	}
 
 	s.baseDeDados.Encode(s.liga)
@@ -102,6 +102,26 @@ func TestArmazenamentoSistemaArquivo(t *testing.T) {
 		verificaPontuaçõesIguais(t, obtido, esperado)
 	})
 
+	t.Run("grava as colocações e os prêmios de um torneio e os mantém ao reabrir o arquivo", func(t *testing.T) {
+		baseDeDados, limparBaseDeDados := criarArquivoTemporario(t, `[
+			{"Nome": "Cleo", "Vitorias": 10, "Colocacoes": [2], "Ganhos": 300}]`)
+		defer limparBaseDeDados()
+
+		armazenamento, err := poquer.NovoSistemaArquivoArmazenamentoJogador(baseDeDados)
+
+		verificaSemErro(t, err)
+
+		armazenamento.GravarColocacoes([]poquer.Colocacao{{Nome: "Chris", Posicao: 1, Premio: 700}, {Nome: "Cleo", Posicao: 2, Premio: 300}})
+
+		reaberto, err := poquer.NovoSistemaArquivoArmazenamentoJogador(baseDeDados)
+
+		verificaSemErro(t, err)
+		verificaLiga(t, reaberto.ObterLiga(), []poquer.Jogador{
+			{Nome: "Cleo", Vitorias: 10, Colocacoes: []int{2, 2}, Ganhos: 600},
+			{Nome: "Chris", Colocacoes: []int{1}, Ganhos: 700},
+		})
+	})
+
 	t.Run("funciona com um arquivo vazio", func(t *testing.T) {
 		baseDeDados, limparBaseDeDados := criarArquivoTemporario(t, "")
 		defer limparBaseDeDados()
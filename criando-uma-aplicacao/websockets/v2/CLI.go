@@ -15,15 +15,41 @@ type CLI struct {
 	entrada              *bufio.Scanner
 	saida                io.Writer
 	jogo                 Jogo
+	estrutura            string
+	torneio              *ConfiguracaoDoTorneio
+}
+
+// OpcaoCLI configura uma CLI criada por NovaCLI
+type OpcaoCLI func(*CLI)
+
+// ComEstruturaDeBlinds escolhe a estrutura de blinds das partidas jogadas pela CLI
+func ComEstruturaDeBlinds(nome string) OpcaoCLI {
+	return func(cli *CLI) {
+		cli.estrutura = nome
+	}
+}
+
+// ComTorneio faz a CLI jogar torneios com as fichas, recompras e add-on da configuração.
+// Os jogadores são pedidos ao usuário no começo de cada partida
+func ComTorneio(configuracao ConfiguracaoDoTorneio) OpcaoCLI {
+	return func(cli *CLI) {
+		cli.torneio = &configuracao
+	}
 }
 
 // NovaCLI cria uma CLI para jogar pôquer
-func NovaCLI(entrada io.Reader, saida io.Writer, jogo Jogo) *CLI {
-	return &CLI{
+func NovaCLI(entrada io.Reader, saida io.Writer, jogo Jogo, opcoes ...OpcaoCLI) *CLI {
+	cli := &CLI{
 		entrada: bufio.NewScanner(entrada),
 		saida:   saida,
 		jogo:    jogo,
 	}
+
+	for _, opcao := range opcoes {
+		opcao(cli)
+	}
+
+	return cli
 }
 
 // PromptJogador é o texto pedindo o número de jogadores para o usuário
@@ -32,11 +58,33 @@ const PromptJogador = "Favor entrar o número de jogadores: "
 // ErrMsgEntradaJogadorIncorreta representa o texto dizendo ao usuário que ele inseriu um valor incorreto
 const ErrMsgEntradaJogadorIncorreta = "Valor inválido recebido para número de jogadores, favor tentar novamente com um número"
 
+// PromptInscritos é o texto pedindo os jogadores de um torneio para o usuário
+const PromptInscritos = "Favor entrar os nomes dos jogadores separados por vírgula: "
+
+// Comandos digitados durante um torneio, depois do nome do jogador, como em "Ruth saiu" ou
+// "Ruth tem 1500". "classificacao" mostra a situação do torneio, "lugares" mostra onde cada
+// jogador está sentado e "botao 2" passa o botão da mesa 2 para o próximo lugar ocupado
+const (
+	ComandoCLIEliminar      = " saiu"
+	ComandoCLIRecomprar     = " recomprou"
+	ComandoCLIAddOn         = " fez addon"
+	ComandoCLIFichas        = " tem "
+	ComandoCLIClassificacao = "classificacao"
+	ComandoCLILugares       = "lugares"
+	ComandoCLIBotao         = "botao "
+)
+
 // ErrMsgEntradaVencedorIncorreta representa o texto dizendo ao usuário que a declaração de vencedor foi errada
 const ErrMsgEntradaVencedorIncorreta = "entrada de vencedor incorreta, espera-se formato de 'NomeDoJogador venceu'"
 
-// JogarPoquer começa a jogo
+// JogarPoquer começa a jogo. Até o vencedor ser declarado, os comandos pausar, continuar,
+// avancar e voltar controlam o relógio de blinds
 func (cli *CLI) JogarPoquer() {
+	if cli.torneio != nil {
+		cli.jogarTorneio()
+		return
+	}
+
 	fmt.Fprint(cli.saida, PromptJogador)
 
 	numeroDeJogadores, err := strconv.Atoi(cli.lerLinha())
@@ -46,17 +94,201 @@ func (cli *CLI) JogarPoquer() {
 		return
 	}
 
-	cli.jogo.Começar(numeroDeJogadores, cli.saida)
+	partida, err := cli.jogo.Começar(ConfiguracaoDaPartida{NumeroDeJogadores: numeroDeJogadores, Estrutura: cli.estrutura}, cli.saida)
+
+	if err != nil {
+		fmt.Fprintln(cli.saida, err)
+		return
+	}
+
+	for {
+		entrada := cli.lerLinha()
 
-	entradaVencedor := cli.lerLinha()
-	vencedor, err := extrairJogador(entradaVencedor)
+		if comandoDeBlinds(entrada) {
+			cli.controlarBlinds(partida, entrada)
+			continue
+		}
+
+		vencedor, err := extrairJogador(entrada)
+
+		if err != nil {
+			fmt.Fprint(cli.saida, ErrMsgEntradaVencedorIncorreta)
+			partida.Abandonar()
+			return
+		}
+
+		partida.Terminar(vencedor)
+		return
+	}
+}
+
+// jogarTorneio começa um torneio entre os jogadores digitados. Ele acaba quando resta um
+// jogador ou quando o vencedor é declarado
+func (cli *CLI) jogarTorneio() {
+	fmt.Fprint(cli.saida, PromptInscritos)
+
+	configuracao := *cli.torneio
+	configuracao.Jogadores = separarNomes(cli.lerLinha())
+
+	partida, err := cli.jogo.Começar(ConfiguracaoDaPartida{
+		NumeroDeJogadores: len(configuracao.Jogadores),
+		Estrutura:         cli.estrutura,
+		Torneio:           &configuracao,
+	}, cli.saida)
 
 	if err != nil {
-		fmt.Fprint(cli.saida, ErrMsgEntradaVencedorIncorreta)
+		fmt.Fprintln(cli.saida, err)
+		return
+	}
+
+	controle, ok := partida.(ControleDeTorneio)
+
+	if !ok {
+		fmt.Fprintln(cli.saida, ErrSemTorneio)
+		partida.Abandonar()
+		return
+	}
+
+	for {
+		entrada := cli.lerLinha()
+
+		if comandoDeBlinds(entrada) {
+			cli.controlarBlinds(partida, entrada)
+			continue
+		}
+
+		if entrada == ComandoCLIClassificacao {
+			fmt.Fprintln(cli.saida, controle.Classificacao())
+			continue
+		}
+
+		if entrada == ComandoCLILugares || strings.HasPrefix(entrada, ComandoCLIBotao) {
+			cli.controlarLugares(partida, entrada)
+			continue
+		}
+
+		if comando, jogador, fichas, ok := extrairComandoDeTorneio(entrada); ok {
+			if err := executarComandoDeTorneio(controle, comando, jogador, fichas); err != nil {
+				fmt.Fprintln(cli.saida, err)
+				continue
+			}
+
+			classificacao := controle.Classificacao()
+			fmt.Fprintln(cli.saida, classificacao)
+			cli.mostrarMudancasDeLugar(partida)
+
+			if classificacao.Encerrado {
+				return
+			}
+			continue
+		}
+
+		vencedor, err := extrairJogador(entrada)
+
+		if err != nil {
+			fmt.Fprint(cli.saida, ErrMsgEntradaVencedorIncorreta)
+			partida.Abandonar()
+			return
+		}
+
+		if err := VerificarVencedor(partida, vencedor); err != nil {
+			fmt.Fprintln(cli.saida, err)
+			continue
+		}
+
+		partida.Terminar(vencedor)
+		fmt.Fprintln(cli.saida, controle.Classificacao())
 		return
 	}
+}
+
+// controlarBlinds aplica um comando digitado (pausar, continuar, avancar ou voltar) e mostra o
+// estado dos blinds em seguida
+func (cli *CLI) controlarBlinds(partida Partida, comando string) {
+	if err := executarComando(partida, comando); err != nil {
+		fmt.Fprintln(cli.saida, err)
+		return
+	}
+
+	fmt.Fprintln(cli.saida, partida.Estado())
+}
+
+// controlarLugares mostra as mesas do torneio, depois de avançar o botão quando o comando é
+// "botao" seguido do número da mesa
+func (cli *CLI) controlarLugares(partida Partida, comando string) {
+	controle, ok := partida.(ControleDeLugares)
+
+	if !ok {
+		fmt.Fprintln(cli.saida, ErrSemTorneio)
+		return
+	}
+
+	if comando != ComandoCLILugares {
+		mesa, err := strconv.Atoi(strings.TrimPrefix(comando, ComandoCLIBotao))
+
+		if err != nil {
+			fmt.Fprintf(cli.saida, "número de mesa inválido: %q\n", strings.TrimPrefix(comando, ComandoCLIBotao))
+			return
+		}
+
+		if err := controle.AvancarBotao(mesa); err != nil {
+			fmt.Fprintln(cli.saida, err)
+			return
+		}
+	}
+
+	fmt.Fprintln(cli.saida, controle.Lugares())
+}
+
+// mostrarMudancasDeLugar avisa quem precisa trocar de lugar depois de uma eliminação ou recompra
+func (cli *CLI) mostrarMudancasDeLugar(partida Partida) {
+	controle, ok := partida.(ControleDeLugares)
+
+	if !ok {
+		return
+	}
+
+	for _, mudanca := range controle.Lugares().Mudancas {
+		fmt.Fprintln(cli.saida, mudanca)
+	}
+}
+
+func separarNomes(entrada string) []string {
+	var nomes []string
+
+	for _, nome := range strings.Split(entrada, ",") {
+		if nome = strings.TrimSpace(nome); nome != "" {
+			nomes = append(nomes, nome)
+		}
+	}
+
+	return nomes
+}
+
+// extrairComandoDeTorneio reconhece entradas como "Ruth saiu", "Ruth recomprou",
+// "Ruth fez addon" e "Ruth tem 1500"
+func extrairComandoDeTorneio(entrada string) (comando, jogador string, fichas int, ok bool) {
+	sufixos := map[string]string{
+		ComandoCLIEliminar:  ComandoEliminar,
+		ComandoCLIRecomprar: ComandoRecomprar,
+		ComandoCLIAddOn:     ComandoAddOn,
+	}
+
+	for sufixo, comando := range sufixos {
+		if strings.HasSuffix(entrada, sufixo) {
+			return comando, strings.TrimSuffix(entrada, sufixo), 0, true
+		}
+	}
+
+	if i := strings.LastIndex(entrada, ComandoCLIFichas); i > 0 {
+		fichas, err := strconv.Atoi(entrada[i+len(ComandoCLIFichas):])
+
+		if err == nil {
+			return ComandoFichas, entrada[:i], fichas, true
+		}
+	}
 
-	cli.jogo.Terminar(vencedor)
+	return "", "", 0, false
 }
 
 func extrairJogador(userInput string) (string, error) {
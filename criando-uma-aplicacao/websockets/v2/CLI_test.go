@@ -3,7 +3,10 @@ package poquer_test
 import (
 	"bytes"
 	"io"
+	"math/rand"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,24 +18,134 @@ var ArmazenamentoJogadorTosco = &poquer.EsbocoDeArmazenamentoJogador{}
 var EntradaTosca = &bytes.Buffer{}
 var SaidaTosca = &bytes.Buffer{}
 
+// JogoEspiao guarda as chamadas que recebeu. O servidor chama o jogo na goroutine de cada
+// conexão, então os campos são protegidos e os testes os leem pelos métodos
 type JogoEspiao struct {
-	ComecouASerChamado    bool
-	ComecouASerChamadoCom int
-	AlertaDeBlind         []byte
-
-	TerminouDeSerChamado    bool
-	TerminouDeSerChamadoCom string
+	AlertaDeBlind []byte
+
+	mu                      sync.Mutex
+	comecouASerChamado      bool
+	comecouASerChamadoCom   int
+	comecouComEstrutura     string
+	terminouDeSerChamado    bool
+	terminouDeSerChamadoCom string
+	abandonouDeSerChamado   bool
+	comandos                []string
 }
 
-func (j *JogoEspiao) Começar(numeroDeJogadores int, saida io.Writer) {
-	j.ComecouASerChamado = true
-	j.ComecouASerChamadoCom = numeroDeJogadores
+const idPartidaEspiao = "espiao"
+
+func (j *JogoEspiao) Começar(configuracao poquer.ConfiguracaoDaPartida, saida io.Writer) (poquer.Partida, error) {
+	j.mu.Lock()
+	j.comecouASerChamado = true
+	j.comecouASerChamadoCom = configuracao.NumeroDeJogadores
+	j.comecouComEstrutura = configuracao.Estrutura
+	j.mu.Unlock()
+
 	saida.Write(j.AlertaDeBlind)
+	return j, nil
+}
+
+func (j *JogoEspiao) Partida(id string) (poquer.Partida, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j, j.comecouASerChamado && id == idPartidaEspiao
+}
+
+func (j *JogoEspiao) ID() string {
+	return idPartidaEspiao
 }
 
 func (j *JogoEspiao) Terminar(vencedor string) {
-	j.TerminouDeSerChamado = true
-	j.TerminouDeSerChamadoCom = vencedor
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.terminouDeSerChamado = true
+	j.terminouDeSerChamadoCom = vencedor
+}
+
+func (j *JogoEspiao) Abandonar() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.abandonouDeSerChamado = true
+}
+
+func (j *JogoEspiao) Pausar() error {
+	j.registrarComando(poquer.ComandoPausar)
+	return nil
+}
+
+func (j *JogoEspiao) Continuar() error {
+	j.registrarComando(poquer.ComandoContinuar)
+	return nil
+}
+
+func (j *JogoEspiao) AvancarNivel() error {
+	j.registrarComando(poquer.ComandoAvancar)
+	return nil
+}
+
+func (j *JogoEspiao) VoltarNivel() error {
+	j.registrarComando(poquer.ComandoVoltar)
+	return nil
+}
+
+func (j *JogoEspiao) Estado() poquer.EstadoDosBlinds {
+	return poquer.EstadoDosBlinds{Nivel: 1, Blinds: blindUnico(100), ProximosBlinds: blindUnico(200), Restante: 10 * time.Minute, Pausado: len(j.Comandos())%2 == 1}
+}
+
+func (j *JogoEspiao) registrarComando(comando string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.comandos = append(j.comandos, comando)
+}
+
+// ComecouASerChamado diz se Começar foi chamado
+func (j *JogoEspiao) ComecouASerChamado() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.comecouASerChamado
+}
+
+// ComecouASerChamadoCom retorna o número de jogadores da última chamada de Começar
+func (j *JogoEspiao) ComecouASerChamadoCom() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.comecouASerChamadoCom
+}
+
+// ComecouComEstrutura retorna a estrutura da última chamada de Começar
+func (j *JogoEspiao) ComecouComEstrutura() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.comecouComEstrutura
+}
+
+// TerminouDeSerChamado diz se Terminar foi chamado
+func (j *JogoEspiao) TerminouDeSerChamado() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.terminouDeSerChamado
+}
+
+// TerminouDeSerChamadoCom retorna o vencedor da última chamada de Terminar
+func (j *JogoEspiao) TerminouDeSerChamadoCom() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.terminouDeSerChamadoCom
+}
+
+// AbandonouDeSerChamado diz se Abandonar foi chamado
+func (j *JogoEspiao) AbandonouDeSerChamado() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.abandonouDeSerChamado
+}
+
+// Comandos retorna uma cópia dos comandos recebidos, em ordem
+func (j *JogoEspiao) Comandos() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]string(nil), j.comandos...)
 }
 
 func usuarioEnvia(mensagens ...string) io.Reader {
@@ -65,6 +178,104 @@ func TestCLI(t *testing.T) {
 		verificaTerminosChamadosCom(t, jogo, "Cleo")
 	})
 
+	t.Run("aceita comandos de blind antes de o vencedor ser declarado", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+
+		saida := &bytes.Buffer{}
+		entrada := usuarioEnvia("5", "pausar", "continuar", "Chris venceu")
+
+		poquer.NovaCLI(entrada, saida, jogo).JogarPoquer()
+
+		verificaComandos(t, jogo, poquer.ComandoPausar, poquer.ComandoContinuar)
+		verificaTerminosChamadosCom(t, jogo, "Chris")
+		verificaMensagensEnviadasParaUsuario(t, saida,
+			poquer.PromptJogador,
+			"Nível 1: blind 100, próximo blind 200 em 10m0s (pausado)\n",
+			"Nível 1: blind 100, próximo blind 200 em 10m0s\n",
+		)
+	})
+
+	t.Run("começa o jogo com a estrutura de blinds escolhida", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+
+		entrada := usuarioEnvia("4", "Cleo venceu")
+
+		poquer.NovaCLI(entrada, SaidaTosca, jogo, poquer.ComEstruturaDeBlinds("turbo")).JogarPoquer()
+
+		verificaJogoComeçadoCom(t, jogo, 4)
+		if jogo.ComecouComEstrutura() != "turbo" {
+			t.Errorf("esperava a estrutura turbo, obtido %q", jogo.ComecouComEstrutura())
+		}
+	})
+
+	t.Run("imprime o erro quando a estrutura de blinds não existe e não começa o jogo", func(t *testing.T) {
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+
+		saida := &bytes.Buffer{}
+		entrada := usuarioEnvia("4", "Cleo venceu")
+
+		poquer.NovaCLI(entrada, saida, jogo, poquer.ComEstruturaDeBlinds("maratona")).JogarPoquer()
+
+		if !strings.Contains(saida.String(), poquer.ErrEstruturaDesconhecida.Error()) {
+			t.Errorf("esperava o erro de estrutura desconhecida, obtido %q", saida.String())
+		}
+
+		if jogo.Partidas() != 0 {
+			t.Error("nenhuma partida deveria ter começado")
+		}
+	})
+
+	t.Run("joga um torneio até restar um jogador, mostrando a classificação", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+
+		saida := &bytes.Buffer{}
+		entrada := usuarioEnvia("Cleo, Chris, Ruth", "Chris saiu", "Ruth tem 1800", "Tiest saiu", "Cleo saiu")
+
+		poquer.NovaCLI(entrada, saida, jogo, poquer.ComTorneio(poquer.ConfiguracaoDoTorneio{FichasIniciais: 1000})).JogarPoquer()
+
+		verificaMensagensEnviadasParaUsuario(t, saida,
+			poquer.PromptInscritos,
+			"Cleo: 1000 fichas\nRuth: 1000 fichas\n3º lugar: Chris\n",
+			"Ruth: 1800 fichas\nCleo: 1000 fichas\n3º lugar: Chris\n",
+			"jogador não inscrito no torneio: Tiest\n",
+			"1º lugar: Ruth\n2º lugar: Cleo\n3º lugar: Chris\n",
+		)
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Ruth")
+		verificaColocacoesGravadas(t, armazenamento, []poquer.Colocacao{
+			{Nome: "Ruth", Posicao: 1},
+			{Nome: "Cleo", Posicao: 2},
+			{Nome: "Chris", Posicao: 3},
+		})
+	})
+
+	t.Run("mostra os lugares, avança o botão e avisa quem muda de mesa", func(t *testing.T) {
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, &poquer.EsbocoDeArmazenamentoJogador{})
+
+		saida := &bytes.Buffer{}
+		entrada := usuarioEnvia("Cleo, Chris, Ruth, Tiest", "lugares", "botao 2", "botao 3", "botao x", "Cleo saiu", "Chris saiu", "Ruth venceu")
+
+		poquer.NovaCLI(entrada, saida, jogo, poquer.ComTorneio(poquer.ConfiguracaoDoTorneio{
+			FichasIniciais: 1000,
+			LugaresPorMesa: 3,
+			Aleatorio:      rand.New(rand.NewSource(1)),
+		})).JogarPoquer()
+
+		esperados := []string{
+			"Mesa 1, botão no lugar ",
+			"Mesa 2, botão no lugar ",
+			poquer.ErrMesaDesconhecida.Error(),
+			`número de mesa inválido: "x"`,
+			"muda da mesa ",
+		}
+
+		for _, esperado := range esperados {
+			if !strings.Contains(saida.String(), esperado) {
+				t.Errorf("esperava %q na saída, obtido\n%s", esperado, saida.String())
+			}
+		}
+	})
+
 	t.Run("imprime um erro quando um valor não numérico é inserido e não começa a jogo", func(t *testing.T) {
 		jogo := &JogoEspiao{}
 
@@ -86,6 +297,7 @@ func TestCLI(t *testing.T) {
 		poquer.NovaCLI(entrada, saida, jogo).JogarPoquer()
 
 		verificaPartidaNaoFinalizada(t, jogo)
+		verificaPartidaAbandonada(t, jogo)
 		verificaMensagensEnviadasParaUsuario(t, saida, poquer.PromptJogador, poquer.ErrMsgEntradaVencedorIncorreta)
 	})
 }
@@ -94,24 +306,44 @@ func verificaJogoComeçadoCom(t *testing.T, jogo *JogoEspiao, numeroDeJogadoresD
 	t.Helper()
 
 	passou := tentarNovamenteAte(500*time.Millisecond, func() bool {
-		return jogo.ComecouASerChamadoCom == numeroDeJogadoresDesejados
+		return jogo.ComecouASerChamadoCom() == numeroDeJogadoresDesejados
 	})
 
 	if !passou {
-		t.Errorf("esperava Começar chamado com %d mas obteve %d", numeroDeJogadoresDesejados, jogo.ComecouASerChamadoCom)
+		t.Errorf("esperava Começar chamado com %d mas obteve %d", numeroDeJogadoresDesejados, jogo.ComecouASerChamadoCom())
+	}
+}
+
+func verificaComandos(t *testing.T, jogo *JogoEspiao, comandos ...string) {
+	t.Helper()
+
+	if !reflect.DeepEqual(jogo.Comandos(), comandos) {
+		t.Errorf("esperava os comandos %v, obtido %v", comandos, jogo.Comandos())
 	}
 }
 
 func verificaPartidaNaoFinalizada(t *testing.T, jogo *JogoEspiao) {
 	t.Helper()
-	if jogo.TerminouDeSerChamado {
+	if jogo.TerminouDeSerChamado() {
 		t.Errorf("jogo não deveria ter finalizado")
 	}
 }
 
+func verificaPartidaAbandonada(t *testing.T, jogo *JogoEspiao) {
+	t.Helper()
+
+	passou := tentarNovamenteAte(500*time.Millisecond, func() bool {
+		return jogo.AbandonouDeSerChamado()
+	})
+
+	if !passou {
+		t.Errorf("esperava que o jogo fosse abandonado")
+	}
+}
+
 func verificaPartidaNaoIniciada(t *testing.T, jogo *JogoEspiao) {
 	t.Helper()
-	if jogo.ComecouASerChamado {
+	if jogo.ComecouASerChamado() {
 		t.Errorf("jogo não deveria ter começado")
 	}
 }
@@ -120,11 +352,11 @@ func verificaTerminosChamadosCom(t *testing.T, jogo *JogoEspiao, vencedor string
 	t.Helper()
 
 	passou := tentarNovamenteAte(500*time.Millisecond, func() bool {
-		return jogo.TerminouDeSerChamadoCom == vencedor
+		return jogo.TerminouDeSerChamadoCom() == vencedor
 	})
 
 	if !passou {
-		t.Errorf("esperava chamada de término com '%s' mas obteve '%s' ", vencedor, jogo.TerminouDeSerChamadoCom)
+		t.Errorf("esperava chamada de término com '%s' mas obteve '%s' ", vencedor, jogo.TerminouDeSerChamadoCom())
 	}
 }
 
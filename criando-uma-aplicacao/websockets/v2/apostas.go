@@ -0,0 +1,649 @@
+package poquer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Rodada é uma das rodadas de apostas de uma mão
+type Rodada int
+
+// Rodadas de uma mão, na ordem em que acontecem
+const (
+	PreFlop Rodada = iota
+	Flop
+	Turn
+	River
+	Showdown
+)
+
+var nomesDasRodadas = [...]string{"pré-flop", "flop", "turn", "river", "showdown"}
+
+func (r Rodada) String() string {
+	if r < PreFlop || r > Showdown {
+		return "?"
+	}
+	return nomesDasRodadas[r]
+}
+
+// TipoDeAcao é o que um jogador faz na sua vez
+type TipoDeAcao string
+
+// Ações de um jogador
+const (
+	Desistir TipoDeAcao = "desistir"
+	Passar   TipoDeAcao = "passar"
+	Pagar    TipoDeAcao = "pagar"
+	Apostar  TipoDeAcao = "apostar"
+	Aumentar TipoDeAcao = "aumentar"
+	AllIn    TipoDeAcao = "allin"
+)
+
+// Apostas obrigatórias, postadas automaticamente quando a mão começa
+const (
+	PostarAnte       TipoDeAcao = "ante"
+	PostarSmallBlind TipoDeAcao = "small_blind"
+	PostarBigBlind   TipoDeAcao = "big_blind"
+)
+
+var (
+	// ErrForaDaVez indica que não é a vez do jogador agir
+	ErrForaDaVez = errors.New("não é a vez do jogador")
+	// ErrAcaoInvalida indica que a ação não é permitida agora
+	ErrAcaoInvalida = errors.New("ação inválida")
+	// ErrApostaMinima indica que a aposta ou o aumento é menor que o mínimo
+	ErrApostaMinima = errors.New("aposta menor que o mínimo")
+	// ErrApostasEncerradas indica que a mão já não aceita ações
+	ErrApostasEncerradas = errors.New("as apostas da mão já terminaram")
+	// ErrPoteDistribuido indica que o pote da mão já foi distribuído
+	ErrPoteDistribuido = errors.New("o pote já foi distribuído")
+	// ErrIntervalo indica que não se joga uma mão durante um intervalo
+	ErrIntervalo = errors.New("a partida está em um intervalo")
+)
+
+// Acao é o que um jogador faz na sua vez. Ao apostar ou aumentar, Quantia é o total que o
+// jogador passa a ter apostado na rodada, e não só o que ele acrescenta
+type Acao struct {
+	Tipo    TipoDeAcao `json:"tipo"`
+	Quantia int        `json:"quantia,omitempty"`
+}
+
+func (a Acao) String() string {
+	if a.Quantia > 0 {
+		return fmt.Sprintf("%s %d", a.Tipo, a.Quantia)
+	}
+	return string(a.Tipo)
+}
+
+// Lugar é um jogador sentado na mão com as fichas que tem antes dela
+type Lugar struct {
+	Nome   string `json:"nome"`
+	Fichas int    `json:"fichas"`
+}
+
+// ConfiguracaoDasApostas começa as apostas de uma mão. Os lugares estão na ordem da mesa e
+// Botao é o índice do dealer. Sem small blind, como nos níveis de blind único, o small
+// blind é metade do big blind
+type ConfiguracaoDasApostas struct {
+	Jogadores []Lugar
+	Botao     int
+	Blinds    Blinds
+}
+
+// Validar confere se as apostas podem começar com essa configuração
+func (c ConfiguracaoDasApostas) Validar() error {
+	if len(c.Jogadores) < 2 {
+		return fmt.Errorf("uma mão precisa de pelo menos 2 jogadores, obtido %d", len(c.Jogadores))
+	}
+
+	for _, jogador := range c.Jogadores {
+		if jogador.Fichas < 1 {
+			return fmt.Errorf("o jogador %s não tem fichas para jogar a mão", jogador.Nome)
+		}
+	}
+
+	if c.Botao < 0 || c.Botao >= len(c.Jogadores) {
+		return fmt.Errorf("o botão deve estar em um dos %d lugares, obtido %d", len(c.Jogadores), c.Botao)
+	}
+
+	if c.Blinds.BigBlind < 1 {
+		return fmt.Errorf("o big blind deve ser positivo, obtido %d", c.Blinds.BigBlind)
+	}
+
+	return nil
+}
+
+// EstadoDoJogador descreve um jogador durante as apostas. Aposta é o que ele apostou na
+// rodada atual e TotalApostado o que ele pôs no pote na mão inteira, antes incluído
+type EstadoDoJogador struct {
+	Nome          string `json:"nome"`
+	Fichas        int    `json:"fichas"`
+	Aposta        int    `json:"aposta,omitempty"`
+	TotalApostado int    `json:"totalApostado,omitempty"`
+	Desistiu      bool   `json:"desistiu,omitempty"`
+	AllIn         bool   `json:"allIn,omitempty"`
+}
+
+// Opcoes descreve o que o jogador da vez pode fazer. ParaPagar é quanto falta para ele
+// igualar a maior aposta; ApostaMinima e ApostaMaxima limitam a Quantia de apostar ou
+// aumentar e ficam zeradas quando ele não pode
+type Opcoes struct {
+	Jogador      int  `json:"jogador"`
+	PodePassar   bool `json:"podePassar"`
+	ParaPagar    int  `json:"paraPagar,omitempty"`
+	ApostaMinima int  `json:"apostaMinima,omitempty"`
+	ApostaMaxima int  `json:"apostaMaxima,omitempty"`
+}
+
+// Pote é o pote principal ou um pote paralelo, que só os Elegiveis podem ganhar
+type Pote struct {
+	Quantia   int   `json:"quantia"`
+	Elegiveis []int `json:"elegiveis"`
+}
+
+type apostador struct {
+	EstadoDoJogador
+	agiu         bool
+	podeAumentar bool
+}
+
+// Apostas é a máquina de estados das apostas de uma mão: posta os antes e os blinds, recebe
+// as ações na ordem da mesa, passa de uma rodada para outra e calcula os potes. Ela não
+// conhece as cartas; quem dá as cartas só precisa avaliá-las no showdown para Distribuir
+type Apostas struct {
+	configuracao   ConfiguracaoDasApostas
+	jogadores      []*apostador
+	rodada         Rodada
+	vez            int
+	maiorAposta    int
+	aumentoMinimo  int
+	encerrada      bool
+	distribuido    bool
+	historicoAcoes []AcaoRegistrada
+}
+
+// AcaoRegistrada é uma ação de um jogador, incluindo os antes e os blinds postados. A
+// Quantia dos antes, blinds e de pagar é o que o jogador pôs no pote; a de apostar, aumentar
+// e all-in é o total que ele passou a ter apostado na rodada
+type AcaoRegistrada struct {
+	Rodada  Rodada
+	Jogador int
+	Acao    Acao
+}
+
+// NovasApostas começa as apostas de uma mão postando os antes e os blinds
+func NovasApostas(configuracao ConfiguracaoDasApostas) (*Apostas, error) {
+	if err := configuracao.Validar(); err != nil {
+		return nil, err
+	}
+
+	a := &Apostas{configuracao: configuracao}
+
+	for _, lugar := range configuracao.Jogadores {
+		a.jogadores = append(a.jogadores, &apostador{
+			EstadoDoJogador: EstadoDoJogador{Nome: lugar.Nome, Fichas: lugar.Fichas},
+			podeAumentar:    true,
+		})
+	}
+
+	a.postarBlinds()
+	return a, nil
+}
+
+// NovasApostasNoNivel começa as apostas de uma mão com os blinds do nível atual da partida
+func NovasApostasNoNivel(controle ControleDeBlinds, jogadores []Lugar, botao int) (*Apostas, error) {
+	estado := controle.Estado()
+
+	if estado.Intervalo {
+		return nil, ErrIntervalo
+	}
+
+	return NovasApostas(ConfiguracaoDasApostas{Jogadores: jogadores, Botao: botao, Blinds: estado.Blinds})
+}
+
+func (a *Apostas) postarBlinds() {
+	blinds := a.configuracao.Blinds
+	smallBlind := blinds.SmallBlind
+
+	if smallBlind == 0 {
+		smallBlind = blinds.BigBlind / 2
+	}
+
+	if blinds.Ante > 0 {
+		for i, jogador := range a.jogadores {
+			ante := minimo(blinds.Ante, jogador.Fichas)
+			jogador.Fichas -= ante
+			jogador.TotalApostado += ante
+			jogador.AllIn = jogador.Fichas == 0
+			a.registrar(i, Acao{Tipo: PostarAnte, Quantia: ante})
+		}
+	}
+
+	posicaoDoSmall := a.proximo(a.configuracao.Botao)
+
+	// no mano a mano o botão posta o small blind
+	if len(a.jogadores) == 2 {
+		posicaoDoSmall = a.configuracao.Botao
+	}
+	posicaoDoBig := a.proximo(posicaoDoSmall)
+
+	a.postar(posicaoDoSmall, smallBlind, PostarSmallBlind)
+	a.postar(posicaoDoBig, blinds.BigBlind, PostarBigBlind)
+
+	a.maiorAposta = blinds.BigBlind
+	a.aumentoMinimo = blinds.BigBlind
+	a.vez = posicaoDoBig
+	a.passarAVez()
+}
+
+func (a *Apostas) postar(posicao, quantia int, tipo TipoDeAcao) {
+	jogador := a.jogadores[posicao]
+
+	if jogador.AllIn {
+		return
+	}
+
+	quantia = minimo(quantia, jogador.Fichas)
+	a.colocar(jogador, quantia)
+	a.registrar(posicao, Acao{Tipo: tipo, Quantia: quantia})
+}
+
+// colocar move fichas do jogador para a sua aposta na rodada
+func (a *Apostas) colocar(jogador *apostador, quantia int) {
+	jogador.Fichas -= quantia
+	jogador.Aposta += quantia
+	jogador.TotalApostado += quantia
+	jogador.AllIn = jogador.Fichas == 0
+}
+
+func (a *Apostas) registrar(jogador int, acao Acao) {
+	a.historicoAcoes = append(a.historicoAcoes, AcaoRegistrada{Rodada: a.rodada, Jogador: jogador, Acao: acao})
+}
+
+// Agir aplica a ação do jogador da vez e passa a vez, a rodada ou encerra as apostas
+func (a *Apostas) Agir(jogador int, acao Acao) error {
+	if a.encerrada {
+		return ErrApostasEncerradas
+	}
+
+	if jogador != a.vez {
+		return fmt.Errorf("%w: é a vez de %s", ErrForaDaVez, a.jogadores[a.vez].Nome)
+	}
+
+	apostador := a.jogadores[jogador]
+	paraPagar := a.maiorAposta - apostador.Aposta
+	registrada := acao
+
+	switch acao.Tipo {
+	case Desistir:
+		apostador.Desistiu = true
+		registrada.Quantia = 0
+
+	case Passar:
+		if paraPagar > 0 {
+			return fmt.Errorf("%w: não dá para passar com %d para pagar", ErrAcaoInvalida, paraPagar)
+		}
+		registrada.Quantia = 0
+
+	case Pagar:
+		if paraPagar == 0 {
+			return fmt.Errorf("%w: não há aposta para pagar", ErrAcaoInvalida)
+		}
+		registrada.Quantia = minimo(paraPagar, apostador.Fichas)
+		a.colocar(apostador, registrada.Quantia)
+
+	case Apostar, Aumentar:
+		if err := a.validarAumento(apostador, acao); err != nil {
+			return err
+		}
+		a.aumentar(apostador, acao.Quantia)
+
+	case AllIn:
+		total := apostador.Aposta + apostador.Fichas
+
+		if total > a.maiorAposta && !apostador.podeAumentar {
+			return fmt.Errorf("%w: a ação não foi reaberta por um aumento completo", ErrAcaoInvalida)
+		}
+
+		registrada.Quantia = total
+		a.aumentar(apostador, total)
+
+	default:
+		return fmt.Errorf("%w: %q, use %s, %s, %s, %s, %s ou %s", ErrAcaoInvalida, acao.Tipo, Desistir, Passar, Pagar, Apostar, Aumentar, AllIn)
+	}
+
+	apostador.agiu = true
+	apostador.podeAumentar = false
+	a.registrar(jogador, registrada)
+	a.passarAVez()
+	return nil
+}
+
+func (a *Apostas) validarAumento(apostador *apostador, acao Acao) error {
+	if acao.Tipo == Apostar && a.maiorAposta > 0 {
+		return fmt.Errorf("%w: já há uma aposta de %d, aumente", ErrAcaoInvalida, a.maiorAposta)
+	}
+
+	if acao.Tipo == Aumentar && a.maiorAposta == 0 {
+		return fmt.Errorf("%w: não há aposta para aumentar, aposte", ErrAcaoInvalida)
+	}
+
+	if !apostador.podeAumentar {
+		return fmt.Errorf("%w: a ação não foi reaberta por um aumento completo", ErrAcaoInvalida)
+	}
+
+	maximo := apostador.Aposta + apostador.Fichas
+	if acao.Quantia > maximo {
+		return fmt.Errorf("%w: o jogador só tem %d para apostar", ErrAcaoInvalida, maximo)
+	}
+
+	if minima := a.maiorAposta + a.aumentoMinimo; acao.Quantia < minima && acao.Quantia < maximo {
+		return fmt.Errorf("%w: o mínimo é %d", ErrApostaMinima, minima)
+	}
+
+	return nil
+}
+
+// aumentar leva a aposta do jogador ao total recebido. Um aumento completo reabre a ação
+// para todos; um all-in menor que o aumento mínimo só obriga os outros a pagar
+func (a *Apostas) aumentar(apostador *apostador, total int) {
+	a.colocar(apostador, total-apostador.Aposta)
+
+	if total <= a.maiorAposta {
+		return
+	}
+
+	aumento := total - a.maiorAposta
+	completo := aumento >= a.aumentoMinimo
+	a.maiorAposta = total
+
+	if completo {
+		a.aumentoMinimo = aumento
+	}
+
+	for _, outro := range a.jogadores {
+		if outro == apostador {
+			continue
+		}
+
+		outro.agiu = false
+		if completo {
+			outro.podeAumentar = true
+		}
+	}
+}
+
+// passarAVez encontra o próximo jogador que precisa agir ou, se não há, termina a rodada
+func (a *Apostas) passarAVez() {
+	if a.emJogo() < 2 {
+		a.encerrada = true
+		return
+	}
+
+	for i, posicao := 0, a.proximo(a.vez); i < len(a.jogadores); i, posicao = i+1, a.proximo(posicao) {
+		if a.precisaAgir(a.jogadores[posicao]) {
+			a.vez = posicao
+			return
+		}
+	}
+
+	a.proximaRodada()
+}
+
+// precisaAgir indica se o jogador ainda deve agir na rodada. Quem é o único que ainda pode
+// apostar não age depois de igualar a maior aposta, porque ninguém pode pagar um aumento
+func (a *Apostas) precisaAgir(jogador *apostador) bool {
+	if jogador.Desistiu || jogador.AllIn {
+		return false
+	}
+
+	if a.podemApostar() < 2 && jogador.Aposta >= a.maiorAposta {
+		return false
+	}
+
+	return !jogador.agiu || jogador.Aposta < a.maiorAposta
+}
+
+// proximaRodada recolhe as apostas e começa a rodada seguinte pelo primeiro jogador depois
+// do botão. Quando menos de dois jogadores ainda podem apostar, as rodadas passam direto
+// até o showdown
+func (a *Apostas) proximaRodada() {
+	for a.rodada < Showdown {
+		a.rodada++
+		a.maiorAposta = 0
+		a.aumentoMinimo = a.configuracao.Blinds.BigBlind
+
+		for _, jogador := range a.jogadores {
+			jogador.Aposta = 0
+			jogador.agiu = false
+			jogador.podeAumentar = true
+		}
+
+		if a.rodada == Showdown {
+			break
+		}
+
+		if a.podemApostar() >= 2 {
+			a.vez = a.configuracao.Botao
+			a.passarAVez()
+			return
+		}
+	}
+
+	a.encerrada = true
+}
+
+// emJogo conta os jogadores que não desistiram
+func (a *Apostas) emJogo() int {
+	total := 0
+	for _, jogador := range a.jogadores {
+		if !jogador.Desistiu {
+			total++
+		}
+	}
+	return total
+}
+
+// podemApostar conta os jogadores que não desistiram nem estão all-in
+func (a *Apostas) podemApostar() int {
+	total := 0
+	for _, jogador := range a.jogadores {
+		if !jogador.Desistiu && !jogador.AllIn {
+			total++
+		}
+	}
+	return total
+}
+
+func (a *Apostas) proximo(posicao int) int {
+	return (posicao + 1) % len(a.jogadores)
+}
+
+// Rodada retorna a rodada atual; Showdown quando as apostas terminaram com mais de um
+// jogador na mão
+func (a *Apostas) Rodada() Rodada {
+	return a.rodada
+}
+
+// Encerrada indica que não há mais ações: só um jogador não desistiu ou a mão chegou ao
+// showdown
+func (a *Apostas) Encerrada() bool {
+	return a.encerrada
+}
+
+// Vez retorna o índice do jogador que deve agir, ou -1 se as apostas terminaram
+func (a *Apostas) Vez() int {
+	if a.encerrada {
+		return -1
+	}
+	return a.vez
+}
+
+// Opcoes descreve o que o jogador da vez pode fazer
+func (a *Apostas) Opcoes() Opcoes {
+	if a.encerrada {
+		return Opcoes{Jogador: -1}
+	}
+
+	jogador := a.jogadores[a.vez]
+	opcoes := Opcoes{
+		Jogador:    a.vez,
+		PodePassar: jogador.Aposta == a.maiorAposta,
+		ParaPagar:  minimo(a.maiorAposta-jogador.Aposta, jogador.Fichas),
+	}
+
+	maximo := jogador.Aposta + jogador.Fichas
+	if jogador.podeAumentar && maximo > a.maiorAposta {
+		opcoes.ApostaMinima = minimo(a.maiorAposta+a.aumentoMinimo, maximo)
+		opcoes.ApostaMaxima = maximo
+	}
+
+	return opcoes
+}
+
+// Blinds retorna os blinds e o ante com que a mão começou
+func (a *Apostas) Blinds() Blinds {
+	return a.configuracao.Blinds
+}
+
+// Jogadores retorna o estado de cada jogador, na ordem da mesa
+func (a *Apostas) Jogadores() []EstadoDoJogador {
+	estados := make([]EstadoDoJogador, len(a.jogadores))
+	for i, jogador := range a.jogadores {
+		estados[i] = jogador.EstadoDoJogador
+	}
+	return estados
+}
+
+// Acoes retorna as ações da mão na ordem em que aconteceram, começando pelos antes e blinds
+func (a *Apostas) Acoes() []AcaoRegistrada {
+	return append([]AcaoRegistrada(nil), a.historicoAcoes...)
+}
+
+// Pote retorna o total de fichas apostadas na mão
+func (a *Apostas) Pote() int {
+	total := 0
+	for _, jogador := range a.jogadores {
+		total += jogador.TotalApostado
+	}
+	return total
+}
+
+// Potes divide o que foi apostado no pote principal e nos potes paralelos criados pelos
+// all-ins. Cada pote só pode ser ganho por quem não desistiu e apostou até o seu nível
+func (a *Apostas) Potes() []Pote {
+	var niveis []int
+	for _, jogador := range a.jogadores {
+		if !jogador.Desistiu && !contemInt(niveis, jogador.TotalApostado) {
+			niveis = append(niveis, jogador.TotalApostado)
+		}
+	}
+	sort.Ints(niveis)
+
+	var potes []Pote
+	anterior, distribuido := 0, 0
+
+	for _, nivel := range niveis {
+		pote := Pote{}
+
+		for i, jogador := range a.jogadores {
+			pote.Quantia += minimo(jogador.TotalApostado, nivel) - minimo(jogador.TotalApostado, anterior)
+
+			if !jogador.Desistiu && jogador.TotalApostado >= nivel {
+				pote.Elegiveis = append(pote.Elegiveis, i)
+			}
+		}
+
+		if pote.Quantia > 0 {
+			potes = append(potes, pote)
+			distribuido += pote.Quantia
+		}
+		anterior = nivel
+	}
+
+	// o que quem desistiu apostou acima de todos os que continuam fica no último pote
+	if sobra := a.Pote() - distribuido; sobra > 0 && len(potes) > 0 {
+		potes[len(potes)-1].Quantia += sobra
+	}
+
+	return potes
+}
+
+// Distribuir entrega os potes aos vencedores e retorna quanto cada jogador ganhou. Se só
+// um jogador não desistiu, ele leva tudo e as combinações podem ser nil; no showdown elas
+// são as de cada jogador, na ordem da mesa. As fichas que não dividem por igual vão para os
+// primeiros vencedores depois do botão
+func (a *Apostas) Distribuir(combinacoes []Combinacao) ([]int, error) {
+	if a.distribuido {
+		return nil, ErrPoteDistribuido
+	}
+
+	if !a.encerrada {
+		return nil, fmt.Errorf("%w: as apostas ainda não terminaram", ErrAcaoInvalida)
+	}
+
+	if a.emJogo() > 1 && len(combinacoes) != len(a.jogadores) {
+		return nil, fmt.Errorf("o showdown precisa das combinações dos %d jogadores, obtido %d", len(a.jogadores), len(combinacoes))
+	}
+
+	ganhos := make([]int, len(a.jogadores))
+
+	for _, pote := range a.Potes() {
+		elegiveis := a.aPartirDoBotao(pote.Elegiveis)
+		vencedores := elegiveis
+
+		if len(elegiveis) > 1 {
+			candidatas := make([]Combinacao, len(elegiveis))
+			for i, posicao := range elegiveis {
+				candidatas[i] = combinacoes[posicao]
+			}
+
+			vencedores = nil
+			for _, i := range Vencedores(candidatas) {
+				vencedores = append(vencedores, elegiveis[i])
+			}
+		}
+
+		for i, parte := range DividirPote(pote.Quantia, len(vencedores)) {
+			ganhos[vencedores[i]] += parte
+		}
+	}
+
+	for i, jogador := range a.jogadores {
+		jogador.Fichas += ganhos[i]
+	}
+
+	a.distribuido = true
+	return ganhos, nil
+}
+
+// aPartirDoBotao ordena as posições começando pela primeira depois do botão
+func (a *Apostas) aPartirDoBotao(posicoes []int) []int {
+	ordenadas := append([]int(nil), posicoes...)
+	distancia := func(posicao int) int {
+		return (posicao - a.configuracao.Botao - 1 + len(a.jogadores)) % len(a.jogadores)
+	}
+
+	sort.Slice(ordenadas, func(i, j int) bool {
+		return distancia(ordenadas[i]) < distancia(ordenadas[j])
+	})
+
+	return ordenadas
+}
+
+func minimo(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func contemInt(valores []int, procurado int) bool {
+	for _, valor := range valores {
+		if valor == procurado {
+			return true
+		}
+	}
+	return false
+}
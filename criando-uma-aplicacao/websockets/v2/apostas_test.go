@@ -0,0 +1,408 @@
+package poquer_test
+
+import (
+	"reflect"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+var blindsDe5e10 = poquer.Blinds{SmallBlind: 5, BigBlind: 10}
+
+func TestApostas(t *testing.T) {
+	t.Run("posta os antes e os blinds e começa pelo jogador depois do big blind", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, poquer.Blinds{SmallBlind: 5, BigBlind: 10, Ante: 1}, 1000, 1000, 1000, 1000))
+
+		verificaFichas(t, apostas, []int{999, 994, 989, 999})
+		verificaVez(t, apostas, 3)
+
+		if pote := apostas.Pote(); pote != 19 {
+			t.Errorf("esperava 19 no pote, obtido %d", pote)
+		}
+
+		opcoes := apostas.Opcoes()
+		esperado := poquer.Opcoes{Jogador: 3, ParaPagar: 10, ApostaMinima: 20, ApostaMaxima: 999}
+		if opcoes != esperado {
+			t.Errorf("opções obtidas %+v, esperadas %+v", opcoes, esperado)
+		}
+	})
+
+	t.Run("sem small blind na estrutura, o small blind é metade do blind", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, poquer.Blinds{BigBlind: 100}, 1000, 1000, 1000))
+
+		verificaFichas(t, apostas, []int{1000, 950, 900})
+	})
+
+	t.Run("no mano a mano o botão posta o small blind, fala primeiro no pré-flop e por último depois", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(1, blindsDe5e10, 1000, 1000))
+
+		verificaFichas(t, apostas, []int{990, 995})
+		verificaVez(t, apostas, 1)
+
+		agir(t, apostas, 1, poquer.Pagar, 0)
+		agir(t, apostas, 0, poquer.Passar, 0)
+
+		verificaRodada(t, apostas, poquer.Flop)
+		verificaVez(t, apostas, 0)
+	})
+
+	t.Run("o big blind pode passar quando todos pagam e a rodada seguinte começa depois do botão", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 1000, 1000))
+
+		agir(t, apostas, 0, poquer.Pagar, 0)
+		agir(t, apostas, 1, poquer.Pagar, 0)
+
+		verificaRodada(t, apostas, poquer.PreFlop)
+		verificaVez(t, apostas, 2)
+
+		agir(t, apostas, 2, poquer.Passar, 0)
+
+		verificaRodada(t, apostas, poquer.Flop)
+		verificaVez(t, apostas, 1)
+
+		if opcoes := apostas.Opcoes(); !opcoes.PodePassar || opcoes.ApostaMinima != 10 {
+			t.Errorf("no flop o jogador deveria poder passar ou apostar 10, obtido %+v", opcoes)
+		}
+	})
+
+	t.Run("recusa ações fora da vez", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 1000, 1000))
+
+		verificaErro(t, apostas.Agir(1, poquer.Acao{Tipo: poquer.Pagar}), poquer.ErrForaDaVez)
+	})
+
+	t.Run("um aumento define o aumento mínimo seguinte", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 1000, 1000))
+
+		agir(t, apostas, 0, poquer.Aumentar, 30)
+
+		if opcoes := apostas.Opcoes(); opcoes.ApostaMinima != 50 || opcoes.ParaPagar != 25 {
+			t.Errorf("esperava pagar 25 ou aumentar para 50, obtido %+v", opcoes)
+		}
+
+		verificaErro(t, apostas.Agir(1, poquer.Acao{Tipo: poquer.Aumentar, Quantia: 45}), poquer.ErrApostaMinima)
+		agir(t, apostas, 1, poquer.Aumentar, 50)
+		agir(t, apostas, 2, poquer.Aumentar, 70)
+
+		verificaFichas(t, apostas, []int{970, 950, 930})
+	})
+
+	t.Run("quem desiste fica fora das rodadas seguintes", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 1000, 1000))
+
+		agir(t, apostas, 0, poquer.Pagar, 0)
+		agir(t, apostas, 1, poquer.Desistir, 0)
+		agir(t, apostas, 2, poquer.Passar, 0)
+
+		for _, rodada := range []poquer.Rodada{poquer.Flop, poquer.Turn, poquer.River} {
+			verificaRodada(t, apostas, rodada)
+			verificaVez(t, apostas, 2)
+			agir(t, apostas, 2, poquer.Apostar, 20)
+			agir(t, apostas, 0, poquer.Pagar, 0)
+		}
+
+		verificaRodada(t, apostas, poquer.Showdown)
+		verificaVez(t, apostas, -1)
+		verificaFichas(t, apostas, []int{930, 995, 930})
+
+		if !apostas.Encerrada() || apostas.Pote() != 145 {
+			t.Errorf("esperava as apostas encerradas com 145 no pote, obtido %d", apostas.Pote())
+		}
+	})
+
+	t.Run("quando todos desistem o último jogador leva o pote sem showdown", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 1000, 1000))
+
+		_, err := apostas.Distribuir(nil)
+		verificaErro(t, err, poquer.ErrAcaoInvalida)
+
+		agir(t, apostas, 0, poquer.Desistir, 0)
+		agir(t, apostas, 1, poquer.Desistir, 0)
+
+		verificaVez(t, apostas, -1)
+		verificaRodada(t, apostas, poquer.PreFlop)
+		verificaErro(t, apostas.Agir(2, poquer.Acao{Tipo: poquer.Passar}), poquer.ErrApostasEncerradas)
+
+		ganhos, err := apostas.Distribuir(nil)
+		verificaSemErro(t, err)
+		verificaGanhos(t, ganhos, []int{0, 0, 15})
+		verificaFichas(t, apostas, []int{1000, 995, 1005})
+
+		_, err = apostas.Distribuir(nil)
+		verificaErro(t, err, poquer.ErrPoteDistribuido)
+	})
+
+	t.Run("all-ins criam potes paralelos e as rodadas passam direto para o showdown", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 50, 100, 300))
+
+		agir(t, apostas, 0, poquer.AllIn, 0)
+		agir(t, apostas, 1, poquer.AllIn, 0)
+		agir(t, apostas, 2, poquer.Pagar, 0)
+
+		verificaRodada(t, apostas, poquer.Showdown)
+
+		potes := apostas.Potes()
+		esperado := []poquer.Pote{
+			{Quantia: 150, Elegiveis: []int{0, 1, 2}},
+			{Quantia: 100, Elegiveis: []int{1, 2}},
+		}
+		if !reflect.DeepEqual(potes, esperado) {
+			t.Errorf("potes obtidos %+v, esperados %+v", potes, esperado)
+		}
+
+		ganhos, err := apostas.Distribuir([]poquer.Combinacao{
+			deveAvaliar(t, "Ah Ad Ac 7s 2d"),
+			deveAvaliar(t, "Kh Kd Kc 7s 2d"),
+			deveAvaliar(t, "Qh Qd Qc 7s 2d"),
+		})
+
+		verificaSemErro(t, err)
+		verificaGanhos(t, ganhos, []int{150, 100, 0})
+		verificaFichas(t, apostas, []int{150, 100, 200})
+	})
+
+	t.Run("quem desiste contribui para os potes mas não pode ganhá-los", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 40, 1000))
+
+		agir(t, apostas, 0, poquer.Aumentar, 100)
+		agir(t, apostas, 1, poquer.AllIn, 0)
+		agir(t, apostas, 2, poquer.Desistir, 0)
+
+		potes := apostas.Potes()
+		esperado := []poquer.Pote{
+			{Quantia: 90, Elegiveis: []int{0, 1}},
+			{Quantia: 60, Elegiveis: []int{0}},
+		}
+		if !reflect.DeepEqual(potes, esperado) {
+			t.Errorf("potes obtidos %+v, esperados %+v", potes, esperado)
+		}
+
+		ganhos, err := apostas.Distribuir([]poquer.Combinacao{
+			deveAvaliar(t, "Qh Qd Qc 7s 2d"),
+			deveAvaliar(t, "Ah Ad Ac 7s 2d"),
+			{},
+		})
+
+		verificaSemErro(t, err)
+		verificaGanhos(t, ganhos, []int{60, 90, 0})
+	})
+
+	t.Run("um all-in menor que o aumento mínimo não reabre a ação para quem já agiu", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 120, 1000))
+
+		agir(t, apostas, 0, poquer.Aumentar, 100)
+		agir(t, apostas, 1, poquer.AllIn, 0)
+
+		if opcoes := apostas.Opcoes(); opcoes.ApostaMinima != 210 {
+			t.Errorf("quem ainda não agiu pode aumentar para 210, obtido %+v", opcoes)
+		}
+
+		agir(t, apostas, 2, poquer.Pagar, 0)
+
+		if opcoes := apostas.Opcoes(); opcoes.ParaPagar != 20 || opcoes.ApostaMinima != 0 {
+			t.Errorf("esperava só pagar 20, obtido %+v", opcoes)
+		}
+
+		verificaErro(t, apostas.Agir(0, poquer.Acao{Tipo: poquer.Aumentar, Quantia: 300}), poquer.ErrAcaoInvalida)
+		verificaErro(t, apostas.Agir(0, poquer.Acao{Tipo: poquer.AllIn}), poquer.ErrAcaoInvalida)
+		agir(t, apostas, 0, poquer.Pagar, 0)
+
+		verificaRodada(t, apostas, poquer.Flop)
+	})
+
+	t.Run("um big blind all-in ao postar não impede o showdown", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 6))
+
+		verificaFichas(t, apostas, []int{995, 0})
+		verificaVez(t, apostas, 0)
+
+		agir(t, apostas, 0, poquer.Pagar, 0)
+
+		verificaRodada(t, apostas, poquer.Showdown)
+	})
+
+	t.Run("divide o pote e dá a ficha ímpar ao primeiro depois do botão", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(0, poquer.Blinds{SmallBlind: 5, BigBlind: 10, Ante: 1}, 1000, 1000, 1000))
+
+		agir(t, apostas, 0, poquer.Desistir, 0)
+		agir(t, apostas, 1, poquer.Pagar, 0)
+		agir(t, apostas, 2, poquer.Passar, 0)
+
+		for apostas.Vez() != -1 {
+			agir(t, apostas, apostas.Vez(), poquer.Passar, 0)
+		}
+
+		ganhos, err := apostas.Distribuir([]poquer.Combinacao{
+			{},
+			deveAvaliar(t, "Ah Kd Qc Js 9h"),
+			deveAvaliar(t, "As Kc Qd Jh 9c"),
+		})
+
+		verificaSemErro(t, err)
+		verificaGanhos(t, ganhos, []int{0, 12, 11})
+	})
+
+	t.Run("registra as ações na ordem, começando pelos blinds", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(1, blindsDe5e10, 1000, 1000))
+
+		agir(t, apostas, 1, poquer.Aumentar, 30)
+		agir(t, apostas, 0, poquer.Pagar, 0)
+		agir(t, apostas, 0, poquer.Apostar, 40)
+		agir(t, apostas, 1, poquer.Desistir, 0)
+
+		esperado := []poquer.AcaoRegistrada{
+			{Rodada: poquer.PreFlop, Jogador: 1, Acao: poquer.Acao{Tipo: poquer.PostarSmallBlind, Quantia: 5}},
+			{Rodada: poquer.PreFlop, Jogador: 0, Acao: poquer.Acao{Tipo: poquer.PostarBigBlind, Quantia: 10}},
+			{Rodada: poquer.PreFlop, Jogador: 1, Acao: poquer.Acao{Tipo: poquer.Aumentar, Quantia: 30}},
+			{Rodada: poquer.PreFlop, Jogador: 0, Acao: poquer.Acao{Tipo: poquer.Pagar, Quantia: 20}},
+			{Rodada: poquer.Flop, Jogador: 0, Acao: poquer.Acao{Tipo: poquer.Apostar, Quantia: 40}},
+			{Rodada: poquer.Flop, Jogador: 1, Acao: poquer.Acao{Tipo: poquer.Desistir}},
+		}
+
+		if acoes := apostas.Acoes(); !reflect.DeepEqual(acoes, esperado) {
+			t.Errorf("ações obtidas %+v, esperadas %+v", acoes, esperado)
+		}
+	})
+}
+
+func TestAcoesInvalidas(t *testing.T) {
+	casos := map[string]struct {
+		acao poquer.Acao
+		erro error
+	}{
+		"passar com aposta para pagar": {poquer.Acao{Tipo: poquer.Passar}, poquer.ErrAcaoInvalida},
+		"apostar quando já há aposta":  {poquer.Acao{Tipo: poquer.Apostar, Quantia: 50}, poquer.ErrAcaoInvalida},
+		"aumentar menos que o mínimo":  {poquer.Acao{Tipo: poquer.Aumentar, Quantia: 15}, poquer.ErrApostaMinima},
+		"aumentar mais que as fichas":  {poquer.Acao{Tipo: poquer.Aumentar, Quantia: 1001}, poquer.ErrAcaoInvalida},
+		"ação desconhecida":            {poquer.Acao{Tipo: "blefar"}, poquer.ErrAcaoInvalida},
+		"aumentar sem dizer a quantia": {poquer.Acao{Tipo: poquer.Aumentar}, poquer.ErrApostaMinima},
+		"aumentar só até o big blind":  {poquer.Acao{Tipo: poquer.Aumentar, Quantia: 10}, poquer.ErrApostaMinima},
+	}
+
+	for nome, caso := range casos {
+		t.Run("recusa "+nome, func(t *testing.T) {
+			apostas := deveComeçarApostas(t, mesaCom(0, blindsDe5e10, 1000, 1000, 1000))
+
+			verificaErro(t, apostas.Agir(0, caso.acao), caso.erro)
+			verificaVez(t, apostas, 0)
+			verificaFichas(t, apostas, []int{1000, 995, 990})
+		})
+	}
+
+	t.Run("recusa pagar ou aumentar sem aposta no flop", func(t *testing.T) {
+		apostas := deveComeçarApostas(t, mesaCom(1, blindsDe5e10, 1000, 1000))
+
+		agir(t, apostas, 1, poquer.Pagar, 0)
+		agir(t, apostas, 0, poquer.Passar, 0)
+
+		verificaErro(t, apostas.Agir(0, poquer.Acao{Tipo: poquer.Pagar}), poquer.ErrAcaoInvalida)
+		verificaErro(t, apostas.Agir(0, poquer.Acao{Tipo: poquer.Aumentar, Quantia: 20}), poquer.ErrAcaoInvalida)
+		verificaErro(t, apostas.Agir(0, poquer.Acao{Tipo: poquer.Apostar, Quantia: 5}), poquer.ErrApostaMinima)
+	})
+}
+
+func TestConfiguracaoDasApostas(t *testing.T) {
+	invalidas := map[string]poquer.ConfiguracaoDasApostas{
+		"um jogador":         mesaCom(0, blindsDe5e10, 1000),
+		"jogador sem fichas": mesaCom(0, blindsDe5e10, 1000, 0),
+		"botão fora da mesa": mesaCom(2, blindsDe5e10, 1000, 1000),
+		"sem blinds":         mesaCom(0, poquer.Blinds{}, 1000, 1000),
+	}
+
+	for nome, configuracao := range invalidas {
+		t.Run("recusa "+nome, func(t *testing.T) {
+			if _, err := poquer.NovasApostas(configuracao); err == nil {
+				t.Error("esperava um erro")
+			}
+		})
+	}
+}
+
+func TestNovasApostasNoNivel(t *testing.T) {
+	jogadores := []poquer.Lugar{{Nome: "Cleo", Fichas: 1000}, {Nome: "Chris", Fichas: 1000}}
+
+	t.Run("posta os blinds do nível atual da partida", func(t *testing.T) {
+		controle := &poquer.ControleDeBlindsFixo{Atual: poquer.EstadoDosBlinds{Nivel: 3, Blinds: poquer.Blinds{SmallBlind: 25, BigBlind: 50, Ante: 5}}}
+
+		apostas, err := poquer.NovasApostasNoNivel(controle, jogadores, 0)
+
+		verificaSemErro(t, err)
+		verificaFichas(t, apostas, []int{970, 945})
+	})
+
+	t.Run("não começa uma mão durante um intervalo", func(t *testing.T) {
+		controle := &poquer.ControleDeBlindsFixo{Atual: poquer.EstadoDosBlinds{Nivel: 4, Blinds: blindsDe5e10, Intervalo: true}}
+
+		_, err := poquer.NovasApostasNoNivel(controle, jogadores, 0)
+
+		verificaErro(t, err, poquer.ErrIntervalo)
+	})
+}
+
+func mesaCom(botao int, blinds poquer.Blinds, fichas ...int) poquer.ConfiguracaoDasApostas {
+	nomes := []string{"Cleo", "Chris", "Ruth", "Tiest", "Pepper", "Floyd"}
+	configuracao := poquer.ConfiguracaoDasApostas{Botao: botao, Blinds: blinds}
+
+	for i, quantia := range fichas {
+		configuracao.Jogadores = append(configuracao.Jogadores, poquer.Lugar{Nome: nomes[i], Fichas: quantia})
+	}
+
+	return configuracao
+}
+
+func deveComeçarApostas(t *testing.T, configuracao poquer.ConfiguracaoDasApostas) *poquer.Apostas {
+	t.Helper()
+
+	apostas, err := poquer.NovasApostas(configuracao)
+
+	if err != nil {
+		t.Fatalf("não foi possível começar as apostas, %v", err)
+	}
+
+	return apostas
+}
+
+func agir(t *testing.T, apostas *poquer.Apostas, jogador int, tipo poquer.TipoDeAcao, quantia int) {
+	t.Helper()
+
+	if err := apostas.Agir(jogador, poquer.Acao{Tipo: tipo, Quantia: quantia}); err != nil {
+		t.Fatalf("o jogador %d não conseguiu %s %d, %v", jogador, tipo, quantia, err)
+	}
+}
+
+func verificaVez(t *testing.T, apostas *poquer.Apostas, esperado int) {
+	t.Helper()
+
+	if vez := apostas.Vez(); vez != esperado {
+		t.Errorf("esperava a vez do jogador %d, obtido %d", esperado, vez)
+	}
+}
+
+func verificaRodada(t *testing.T, apostas *poquer.Apostas, esperado poquer.Rodada) {
+	t.Helper()
+
+	if rodada := apostas.Rodada(); rodada != esperado {
+		t.Errorf("esperava o %v, obtido %v", esperado, rodada)
+	}
+}
+
+func verificaFichas(t *testing.T, apostas *poquer.Apostas, esperado []int) {
+	t.Helper()
+
+	var obtido []int
+	for _, jogador := range apostas.Jogadores() {
+		obtido = append(obtido, jogador.Fichas)
+	}
+
+	if !reflect.DeepEqual(obtido, esperado) {
+		t.Errorf("fichas obtidas %v, esperadas %v", obtido, esperado)
+	}
+}
+
+func verificaGanhos(t *testing.T, obtido, esperado []int) {
+	t.Helper()
+
+	if !reflect.DeepEqual(obtido, esperado) {
+		t.Errorf("ganhos obtidos %v, esperados %v", obtido, esperado)
+	}
+}
@@ -0,0 +1,329 @@
+package poquer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Categoria é o tipo de jogo formado por cinco cartas, da carta alta ao royal flush
+type Categoria int
+
+// Categorias das mãos, da mais fraca para a mais forte
+const (
+	CartaAlta Categoria = iota
+	Par
+	DoisPares
+	Trinca
+	Sequencia
+	Flush
+	FullHouse
+	Quadra
+	StraightFlush
+	RoyalFlush
+)
+
+var nomesDasCategorias = [...]string{
+	"carta alta",
+	"par",
+	"dois pares",
+	"trinca",
+	"sequência",
+	"flush",
+	"full house",
+	"quadra",
+	"straight flush",
+	"royal flush",
+}
+
+func (c Categoria) String() string {
+	if c < CartaAlta || c > RoyalFlush {
+		return "?"
+	}
+	return nomesDasCategorias[c]
+}
+
+// Combinacao é o melhor jogo de cinco cartas que um jogador forma com as cartas fechadas e
+// as da mesa. Cartas vem na ordem em que desempatam, por exemplo a trinca antes do par no
+// full house
+type Combinacao struct {
+	Categoria Categoria
+	Cartas    []Carta
+	forca     int
+}
+
+// Comparar retorna um número positivo se a combinação vence a outra, negativo se perde e
+// zero se empatam e dividem o pote
+func (c Combinacao) Comparar(outra Combinacao) int {
+	return c.forca - outra.forca
+}
+
+func (c Combinacao) String() string {
+	return fmt.Sprintf("%s: %s", c.Categoria, EscreverCartas(c.Cartas))
+}
+
+// Avaliar encontra a melhor combinação de cinco entre cinco e sete cartas
+func Avaliar(cartas []Carta) (Combinacao, error) {
+	if len(cartas) < 5 || len(cartas) > 7 {
+		return Combinacao{}, fmt.Errorf("um jogo precisa de 5 a 7 cartas, obtido %d", len(cartas))
+	}
+
+	vistas := map[Carta]bool{}
+	for _, carta := range cartas {
+		if carta.Valor < Dois || carta.Valor > As || carta.Naipe < Paus || carta.Naipe > Espadas {
+			return Combinacao{}, fmt.Errorf("carta %v inválida", carta)
+		}
+
+		if vistas[carta] {
+			return Combinacao{}, fmt.Errorf("a carta %v aparece mais de uma vez", carta)
+		}
+		vistas[carta] = true
+	}
+
+	ordenadas := append([]Carta(nil), cartas...)
+	sort.SliceStable(ordenadas, func(i, j int) bool {
+		return ordenadas[i].Valor > ordenadas[j].Valor
+	})
+
+	categoria, valores, naipe := classificar(ordenadas)
+	return novaCombinacao(categoria, valores, escolherCartas(ordenadas, valores, naipe)), nil
+}
+
+// classificar retorna a categoria e os cinco valores que desempatam o jogo. Quando o jogo
+// depende de um naipe, ele também é retornado; caso contrário o naipe é -1
+func classificar(ordenadas []Carta) (Categoria, []Valor, Naipe) {
+	var contagem [As + 1]int
+	var porNaipe [Espadas + 1][]Valor
+
+	for _, carta := range ordenadas {
+		contagem[carta.Valor]++
+		porNaipe[carta.Naipe] = append(porNaipe[carta.Naipe], carta.Valor)
+	}
+
+	for naipe, valores := range porNaipe {
+		if len(valores) < 5 {
+			continue
+		}
+
+		if topo := maiorSequencia(valores); topo != 0 {
+			if topo == As {
+				return RoyalFlush, valoresDaSequencia(topo), Naipe(naipe)
+			}
+			return StraightFlush, valoresDaSequencia(topo), Naipe(naipe)
+		}
+	}
+
+	var quadras, trincas, pares, avulsas []Valor
+	for valor := As; valor >= Dois; valor-- {
+		switch contagem[valor] {
+		case 4:
+			quadras = append(quadras, valor)
+		case 3:
+			trincas = append(trincas, valor)
+		case 2:
+			pares = append(pares, valor)
+		case 1:
+			avulsas = append(avulsas, valor)
+		}
+	}
+
+	if len(quadras) > 0 {
+		return Quadra, append(repetir(quadras[0], 4), maioresExceto(contagem, quadras[0], 1)...), -1
+	}
+
+	if len(trincas) > 0 && (len(trincas) > 1 || len(pares) > 0) {
+		par := Valor(0)
+		if len(trincas) > 1 {
+			par = trincas[1]
+		}
+		if len(pares) > 0 && pares[0] > par {
+			par = pares[0]
+		}
+		return FullHouse, append(repetir(trincas[0], 3), repetir(par, 2)...), -1
+	}
+
+	for naipe, valores := range porNaipe {
+		if len(valores) >= 5 {
+			return Flush, valores[:5], Naipe(naipe)
+		}
+	}
+
+	var distintos []Valor
+	for valor := As; valor >= Dois; valor-- {
+		if contagem[valor] > 0 {
+			distintos = append(distintos, valor)
+		}
+	}
+
+	if topo := maiorSequencia(distintos); topo != 0 {
+		return Sequencia, valoresDaSequencia(topo), -1
+	}
+
+	if len(trincas) > 0 {
+		return Trinca, append(repetir(trincas[0], 3), maioresExceto(contagem, trincas[0], 2)...), -1
+	}
+
+	if len(pares) > 1 {
+		valores := append(repetir(pares[0], 2), repetir(pares[1], 2)...)
+		return DoisPares, append(valores, maioresExceto(contagem, pares[0], 1, pares[1])...), -1
+	}
+
+	if len(pares) > 0 {
+		return Par, append(repetir(pares[0], 2), maioresExceto(contagem, pares[0], 3)...), -1
+	}
+
+	return CartaAlta, avulsas[:5], -1
+}
+
+// maiorSequencia retorna o valor mais alto de cinco valores seguidos entre os recebidos, em
+// ordem decrescente, ou zero se não há sequência. O Ás também vale um, no A-2-3-4-5
+func maiorSequencia(valores []Valor) Valor {
+	var presentes [As + 1]bool
+	for _, valor := range valores {
+		presentes[valor] = true
+	}
+
+	for topo := As; topo >= Seis; topo-- {
+		completa := true
+		for valor := topo - 4; valor <= topo; valor++ {
+			if !presentes[valor] {
+				completa = false
+				break
+			}
+		}
+
+		if completa {
+			return topo
+		}
+	}
+
+	if presentes[As] && presentes[Dois] && presentes[Tres] && presentes[Quatro] && presentes[Cinco] {
+		return Cinco
+	}
+
+	return 0
+}
+
+func valoresDaSequencia(topo Valor) []Valor {
+	if topo == Cinco {
+		return []Valor{Cinco, Quatro, Tres, Dois, As}
+	}
+	return []Valor{topo, topo - 1, topo - 2, topo - 3, topo - 4}
+}
+
+func repetir(valor Valor, vezes int) []Valor {
+	valores := make([]Valor, vezes)
+	for i := range valores {
+		valores[i] = valor
+	}
+	return valores
+}
+
+// maioresExceto retorna os n maiores valores presentes que não estão entre os excluídos
+func maioresExceto(contagem [As + 1]int, excluido Valor, n int, outrosExcluidos ...Valor) []Valor {
+	var valores []Valor
+
+	for valor := As; valor >= Dois && len(valores) < n; valor-- {
+		if contagem[valor] == 0 || valor == excluido || contem(outrosExcluidos, valor) {
+			continue
+		}
+		valores = append(valores, valor)
+	}
+
+	return valores
+}
+
+func contem(valores []Valor, procurado Valor) bool {
+	for _, valor := range valores {
+		if valor == procurado {
+			return true
+		}
+	}
+	return false
+}
+
+// escolherCartas pega uma carta para cada valor do jogo, do naipe do jogo quando ele tem um
+func escolherCartas(ordenadas []Carta, valores []Valor, naipe Naipe) []Carta {
+	usadas := make([]bool, len(ordenadas))
+	cartas := make([]Carta, 0, len(valores))
+
+	for _, valor := range valores {
+		for i, carta := range ordenadas {
+			if usadas[i] || carta.Valor != valor || (naipe >= 0 && carta.Naipe != naipe) {
+				continue
+			}
+
+			usadas[i] = true
+			cartas = append(cartas, carta)
+			break
+		}
+	}
+
+	return cartas
+}
+
+func novaCombinacao(categoria Categoria, valores []Valor, cartas []Carta) Combinacao {
+	forca := int(categoria)
+	for _, valor := range valores {
+		forca = forca<<4 | int(valor)
+	}
+
+	return Combinacao{Categoria: categoria, Cartas: cartas, forca: forca}
+}
+
+// Vencedores retorna os índices das combinações que ganham o pote; mais de um índice é um
+// empate em que o pote é dividido
+func Vencedores(combinacoes []Combinacao) []int {
+	var vencedores []int
+
+	for i, combinacao := range combinacoes {
+		if len(vencedores) == 0 {
+			vencedores = []int{i}
+			continue
+		}
+
+		switch comparacao := combinacao.Comparar(combinacoes[vencedores[0]]); {
+		case comparacao > 0:
+			vencedores = []int{i}
+		case comparacao == 0:
+			vencedores = append(vencedores, i)
+		}
+	}
+
+	return vencedores
+}
+
+// AvaliarJogadores avalia as cartas fechadas de cada jogador com as cartas da mesa
+func AvaliarJogadores(cartasFechadas [][]Carta, mesa []Carta) ([]Combinacao, error) {
+	combinacoes := make([]Combinacao, len(cartasFechadas))
+
+	for i, cartas := range cartasFechadas {
+		combinacao, err := Avaliar(append(append([]Carta(nil), cartas...), mesa...))
+
+		if err != nil {
+			return nil, fmt.Errorf("problema ao avaliar as cartas do jogador %d, %v", i+1, err)
+		}
+
+		combinacoes[i] = combinacao
+	}
+
+	return combinacoes, nil
+}
+
+// DividirPote divide o pote entre os vencedores. As fichas que não dividem por igual vão,
+// uma a uma, para os primeiros vencedores, que são os primeiros à esquerda do botão
+func DividirPote(pote, vencedores int) []int {
+	if vencedores < 1 {
+		return nil
+	}
+
+	partes := make([]int, vencedores)
+
+	for i := range partes {
+		partes[i] = pote / vencedores
+		if i < pote%vencedores {
+			partes[i]++
+		}
+	}
+
+	return partes
+}
@@ -0,0 +1,182 @@
+package poquer_test
+
+import (
+	"reflect"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestAvaliar(t *testing.T) {
+	casos := []struct {
+		cartas    string
+		categoria poquer.Categoria
+		melhores  string
+	}{
+		{"Ah Kh Qh Jh Th", poquer.RoyalFlush, "Ah Kh Qh Jh Th"},
+		{"9s 8s 7s 6s 5s Ad Ac", poquer.StraightFlush, "9s 8s 7s 6s 5s"},
+		{"5d 4d 3d 2d Ad Kd Ks", poquer.StraightFlush, "5d 4d 3d 2d Ad"},
+		{"Qc Qd Qh Qs 2c 3c Kd", poquer.Quadra, "Qc Qd Qh Qs Kd"},
+		{"7c 7d 7h 2s 2c 9d Jh", poquer.FullHouse, "7c 7d 7h 2s 2c"},
+		{"7c 7d 7h 9s 9c 9d 2h", poquer.FullHouse, "9s 9c 9d 7c 7d"},
+		{"7c 7d 7h 2s 2c Kd Kh", poquer.FullHouse, "7c 7d 7h Kd Kh"},
+		{"Ac 9c 7c 4c 2c Kc Kd", poquer.Flush, "Ac Kc 9c 7c 4c"},
+		{"9c 8d 7h 6s 5c 4d Th", poquer.Sequencia, "Th 9c 8d 7h 6s"},
+		{"Ac 2d 3h 4s 5c Kd Kh", poquer.Sequencia, "5c 4s 3h 2d Ac"},
+		{"Ac Kd Qh Js Tc", poquer.Sequencia, "Ac Kd Qh Js Tc"},
+		{"8c 8d 8h As 3c Kd 2h", poquer.Trinca, "8c 8d 8h As Kd"},
+		{"Jc Jd 4h 4s 9c 9d 2h", poquer.DoisPares, "Jc Jd 9c 9d 4h"},
+		{"Jc Jd 4h 4s 2c 2d Ah", poquer.DoisPares, "Jc Jd 4h 4s Ah"},
+		{"Tc Td As 8h 5c 3d 2h", poquer.Par, "Tc Td As 8h 5c"},
+		{"Ac Jd 9h 7s 5c 3d 2h", poquer.CartaAlta, "Ac Jd 9h 7s 5c"},
+		{"Ac Kd Qh Js 9c", poquer.CartaAlta, "Ac Kd Qh Js 9c"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.cartas, func(t *testing.T) {
+			combinacao, err := poquer.Avaliar(deveLerCartas(t, caso.cartas))
+
+			verificaSemErro(t, err)
+
+			if combinacao.Categoria != caso.categoria {
+				t.Errorf("categoria obtida %v, esperada %v", combinacao.Categoria, caso.categoria)
+			}
+
+			if melhores := poquer.EscreverCartas(combinacao.Cartas); melhores != caso.melhores {
+				t.Errorf("melhores cartas obtidas %q, esperadas %q", melhores, caso.melhores)
+			}
+		})
+	}
+
+	invalidas := []string{"Ah Kh Qh Jh", "Ah Kh Qh Jh Th 9h 8h 7h", "Ah Ah Qh Jh Th"}
+
+	for _, cartas := range invalidas {
+		t.Run("recusa "+cartas, func(t *testing.T) {
+			if _, err := poquer.Avaliar(deveLerCartas(t, cartas)); err == nil {
+				t.Error("esperava um erro")
+			}
+		})
+	}
+}
+
+func TestCompararCombinacoes(t *testing.T) {
+	// cada mão vence a seguinte
+	ordenadas := []string{
+		"As Ks Qs Js Ts",
+		"Kh Qh Jh Th 9h",
+		"5c 4c 3c 2c Ac",
+		"Ac Ad Ah As 2c",
+		"Kc Kd Kh Ks Ac",
+		"Kc Kd Kh Ks Qc",
+		"3c 3d 3h 2s 2c",
+		"2c 2d 2h As Ac",
+		"Ac Kc 9c 7c 4c",
+		"Ac Qc Jc 9c 8c",
+		"Ac Qc Jc 9c 7c",
+		"Ac Kd Qh Js Tc",
+		"6c 5d 4h 3s 2c",
+		"5c 4d 3h 2s Ac",
+		"Ac Ad Ah Ks Qc",
+		"Ac Ad Ah Ks Jc",
+		"Kc Kd 2h 2s Ac",
+		"Qc Qd Jh Js Ac",
+		"Qc Qd Jh Js Kc",
+		"Ac Ad Kh Qs Jc",
+		"Ac Ad Kh Qs Tc",
+		"Kc Kd Ah Qs Jc",
+		"Ac Kd Qh Js 9c",
+		"Ac Kd Qh Js 8c",
+		"7c 5d 4h 3s 2c",
+	}
+
+	for i := 0; i < len(ordenadas)-1; i++ {
+		t.Run(ordenadas[i]+" vence "+ordenadas[i+1], func(t *testing.T) {
+			melhor := deveAvaliar(t, ordenadas[i])
+			pior := deveAvaliar(t, ordenadas[i+1])
+
+			if melhor.Comparar(pior) <= 0 || pior.Comparar(melhor) >= 0 {
+				t.Errorf("%v deveria vencer %v", melhor, pior)
+			}
+		})
+	}
+
+	t.Run("mãos com os mesmos valores em naipes diferentes empatam", func(t *testing.T) {
+		uma := deveAvaliar(t, "Ac Kd Qh Js 9c 3d 2h")
+		outra := deveAvaliar(t, "As Kh Qd Jc 9s 4c 2d")
+
+		if uma.Comparar(outra) != 0 {
+			t.Errorf("%v e %v deveriam empatar", uma, outra)
+		}
+	})
+}
+
+func TestVencedores(t *testing.T) {
+	casos := []struct {
+		nome       string
+		fechadas   []string
+		mesa       string
+		vencedores []int
+	}{
+		{"o kicker desempata", []string{"Ah Kd", "Ac Qd", "7h 2c"}, "As 9c 6d 4h 3s", []int{0}},
+		{"a mesa joga e todos dividem", []string{"2h 3d", "2c 3h"}, "Ac Kd Qh Js Tc", []int{0, 1}},
+		{"flush maior vence flush menor", []string{"Ah 2c", "Kh Qh"}, "9h 7h 4h 3c 2h", []int{0}},
+		{"sequência dividida com cartas diferentes", []string{"Ac 8h", "As 8d", "Kd Kh"}, "Th 9c 7s 6d 2c", []int{0, 1}},
+		{"full house maior", []string{"9c 9d", "Jc Jd"}, "9h Jh 4s 4c 2d", []int{1}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			fechadas := make([][]poquer.Carta, len(caso.fechadas))
+			for i, cartas := range caso.fechadas {
+				fechadas[i] = deveLerCartas(t, cartas)
+			}
+
+			combinacoes, err := poquer.AvaliarJogadores(fechadas, deveLerCartas(t, caso.mesa))
+			verificaSemErro(t, err)
+
+			if vencedores := poquer.Vencedores(combinacoes); !reflect.DeepEqual(vencedores, caso.vencedores) {
+				t.Errorf("vencedores obtidos %v, esperados %v", vencedores, caso.vencedores)
+			}
+		})
+	}
+
+	t.Run("recusa cartas repetidas entre o jogador e a mesa", func(t *testing.T) {
+		_, err := poquer.AvaliarJogadores([][]poquer.Carta{deveLerCartas(t, "Ah Kd")}, deveLerCartas(t, "Ah 9c 6d 4h 3s"))
+
+		if err == nil {
+			t.Error("esperava um erro")
+		}
+	})
+}
+
+func TestDividirPote(t *testing.T) {
+	casos := []struct {
+		pote       int
+		vencedores int
+		partes     []int
+	}{
+		{100, 1, []int{100}},
+		{100, 2, []int{50, 50}},
+		{100, 3, []int{34, 33, 33}},
+		{101, 4, []int{26, 25, 25, 25}},
+		{100, 0, nil},
+	}
+
+	for _, caso := range casos {
+		if partes := poquer.DividirPote(caso.pote, caso.vencedores); !reflect.DeepEqual(partes, caso.partes) {
+			t.Errorf("dividir %d entre %d: obtido %v, esperado %v", caso.pote, caso.vencedores, partes, caso.partes)
+		}
+	}
+}
+
+func deveAvaliar(t testing.TB, cartas string) poquer.Combinacao {
+	t.Helper()
+
+	combinacao, err := poquer.Avaliar(deveLerCartas(t, cartas))
+
+	if err != nil {
+		t.Fatalf("não foi possível avaliar %q, %v", cartas, err)
+	}
+
+	return combinacao
+}
@@ -0,0 +1,229 @@
+package poquer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// ErrBaralhoSemCartas indica que o baralho não tem cartas suficientes para distribuir
+var ErrBaralhoSemCartas = errors.New("o baralho não tem cartas suficientes")
+
+// Valor é o valor de uma carta, do Dois ao Ás
+type Valor int
+
+// Valores das cartas, em ordem crescente
+const (
+	Dois Valor = iota + 2
+	Tres
+	Quatro
+	Cinco
+	Seis
+	Sete
+	Oito
+	Nove
+	Dez
+	Valete
+	Dama
+	Rei
+	As
+)
+
+// Naipe é o naipe de uma carta
+type Naipe int
+
+// Naipes do baralho
+const (
+	Paus Naipe = iota
+	Ouros
+	Copas
+	Espadas
+)
+
+// As cartas são escritas como nos históricos de mãos: o valor (2-9, T, J, Q, K, A) seguido
+// do naipe (c de paus, d de ouros, h de copas, s de espadas), por exemplo "Ah" ou "Td"
+const (
+	simbolosDosValores = "23456789TJQKA"
+	simbolosDosNaipes  = "cdhs"
+)
+
+var nomesDosNaipes = [...]string{"paus", "ouros", "copas", "espadas"}
+
+func (v Valor) String() string {
+	if v < Dois || v > As {
+		return "?"
+	}
+	return string(simbolosDosValores[v-Dois])
+}
+
+func (n Naipe) String() string {
+	if n < Paus || n > Espadas {
+		return "?"
+	}
+	return nomesDosNaipes[n]
+}
+
+// Carta é uma das 52 cartas do baralho
+type Carta struct {
+	Valor Valor
+	Naipe Naipe
+}
+
+func (c Carta) String() string {
+	if c.Naipe < Paus || c.Naipe > Espadas {
+		return c.Valor.String() + "?"
+	}
+	return c.Valor.String() + string(simbolosDosNaipes[c.Naipe])
+}
+
+// MarshalJSON escreve a carta como texto, por exemplo "Ah"
+func (c Carta) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// UnmarshalJSON lê a carta no formato de LerCarta
+func (c *Carta) UnmarshalJSON(dados []byte) error {
+	var texto string
+
+	if err := json.Unmarshal(dados, &texto); err != nil {
+		return fmt.Errorf("carta deve ser um texto como \"Ah\", %v", err)
+	}
+
+	carta, err := LerCarta(texto)
+
+	if err != nil {
+		return err
+	}
+
+	*c = carta
+	return nil
+}
+
+// LerCarta converte uma carta escrita como "Ah" ou "Td"
+func LerCarta(texto string) (Carta, error) {
+	if len(texto) != 2 {
+		return Carta{}, fmt.Errorf("carta %q inválida, use o valor e o naipe como Ah ou Td", texto)
+	}
+
+	valor := strings.IndexByte(simbolosDosValores, strings.ToUpper(texto[:1])[0])
+	naipe := strings.IndexByte(simbolosDosNaipes, strings.ToLower(texto[1:])[0])
+
+	if valor < 0 || naipe < 0 {
+		return Carta{}, fmt.Errorf("carta %q inválida, use o valor e o naipe como Ah ou Td", texto)
+	}
+
+	return Carta{Valor: Valor(valor) + Dois, Naipe: Naipe(naipe)}, nil
+}
+
+// LerCartas converte cartas separadas por espaços, como "Ah Kd 7c"
+func LerCartas(texto string) ([]Carta, error) {
+	var cartas []Carta
+
+	for _, campo := range strings.Fields(texto) {
+		carta, err := LerCarta(campo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cartas = append(cartas, carta)
+	}
+
+	return cartas, nil
+}
+
+// EscreverCartas escreve as cartas separadas por espaços, no formato lido por LerCartas
+func EscreverCartas(cartas []Carta) string {
+	textos := make([]string, len(cartas))
+
+	for i, carta := range cartas {
+		textos[i] = carta.String()
+	}
+
+	return strings.Join(textos, " ")
+}
+
+// Baralho distribui as cartas do topo. A aleatoriedade do embaralhamento é recebida de fora
+// para que uma semente fixa reproduza as mesmas mãos
+type Baralho struct {
+	cartas []Carta
+}
+
+// NovoBaralho cria um baralho com as 52 cartas em ordem, de 2c a As
+func NovoBaralho() *Baralho {
+	cartas := make([]Carta, 0, 52)
+
+	for naipe := Paus; naipe <= Espadas; naipe++ {
+		for valor := Dois; valor <= As; valor++ {
+			cartas = append(cartas, Carta{Valor: valor, Naipe: naipe})
+		}
+	}
+
+	return &Baralho{cartas: cartas}
+}
+
+// NovoBaralhoEmbaralhado cria um baralho completo embaralhado com a aleatoriedade recebida
+func NovoBaralhoEmbaralhado(aleatorio *rand.Rand) *Baralho {
+	baralho := NovoBaralho()
+	baralho.Embaralhar(aleatorio)
+	return baralho
+}
+
+// NovoBaralhoCom cria um baralho que distribui as cartas na ordem recebida, útil para
+// reproduzir uma mão conhecida
+func NovoBaralhoCom(cartas []Carta) *Baralho {
+	return &Baralho{cartas: append([]Carta(nil), cartas...)}
+}
+
+// Embaralhar embaralha as cartas que restam no baralho
+func (b *Baralho) Embaralhar(aleatorio *rand.Rand) {
+	aleatorio.Shuffle(len(b.cartas), func(i, j int) {
+		b.cartas[i], b.cartas[j] = b.cartas[j], b.cartas[i]
+	})
+}
+
+// Restantes retorna quantas cartas ainda podem ser distribuídas
+func (b *Baralho) Restantes() int {
+	return len(b.cartas)
+}
+
+// Dar tira n cartas do topo do baralho
+func (b *Baralho) Dar(n int) ([]Carta, error) {
+	if n > len(b.cartas) {
+		return nil, fmt.Errorf("%w: pedidas %d, restam %d", ErrBaralhoSemCartas, n, len(b.cartas))
+	}
+
+	cartas := append([]Carta(nil), b.cartas[:n]...)
+	b.cartas = b.cartas[n:]
+	return cartas, nil
+}
+
+// DarCartasFechadas dá duas cartas a cada jogador, uma de cada vez, como na mesa
+func (b *Baralho) DarCartasFechadas(jogadores int) ([][]Carta, error) {
+	if jogadores*2 > len(b.cartas) {
+		return nil, fmt.Errorf("%w: %d jogadores precisam de %d cartas, restam %d", ErrBaralhoSemCartas, jogadores, jogadores*2, len(b.cartas))
+	}
+
+	maos := make([][]Carta, jogadores)
+
+	for volta := 0; volta < 2; volta++ {
+		for jogador := range maos {
+			carta, _ := b.Dar(1)
+			maos[jogador] = append(maos[jogador], carta[0])
+		}
+	}
+
+	return maos, nil
+}
+
+// DarMesa queima uma carta e abre n cartas comunitárias: 3 no flop, 1 no turn e 1 no river
+func (b *Baralho) DarMesa(n int) ([]Carta, error) {
+	if n+1 > len(b.cartas) {
+		return nil, fmt.Errorf("%w: pedidas %d e uma queimada, restam %d", ErrBaralhoSemCartas, n, len(b.cartas))
+	}
+
+	b.Dar(1)
+	return b.Dar(n)
+}
@@ -0,0 +1,125 @@
+package poquer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestCartas(t *testing.T) {
+	t.Run("lê e escreve as cartas no formato dos históricos de mãos", func(t *testing.T) {
+		cartas, err := poquer.LerCartas("Ah td 2C Ks")
+
+		verificaSemErro(t, err)
+
+		esperado := []poquer.Carta{
+			{Valor: poquer.As, Naipe: poquer.Copas},
+			{Valor: poquer.Dez, Naipe: poquer.Ouros},
+			{Valor: poquer.Dois, Naipe: poquer.Paus},
+			{Valor: poquer.Rei, Naipe: poquer.Espadas},
+		}
+
+		if !reflect.DeepEqual(cartas, esperado) {
+			t.Errorf("obtido %v, esperado %v", cartas, esperado)
+		}
+
+		if texto := poquer.EscreverCartas(cartas); texto != "Ah Td 2c Ks" {
+			t.Errorf("obtido %q", texto)
+		}
+	})
+
+	for _, texto := range []string{"A", "1h", "Ax", "Ahh"} {
+		t.Run("recusa a carta "+texto, func(t *testing.T) {
+			if _, err := poquer.LerCarta(texto); err == nil {
+				t.Errorf("esperava um erro ao ler %q", texto)
+			}
+		})
+	}
+}
+
+func TestBaralho(t *testing.T) {
+	t.Run("tem as 52 cartas sem repetir", func(t *testing.T) {
+		baralho := poquer.NovoBaralhoEmbaralhado(rand.New(rand.NewSource(1)))
+
+		cartas, err := baralho.Dar(52)
+		verificaSemErro(t, err)
+
+		vistas := map[poquer.Carta]bool{}
+		for _, carta := range cartas {
+			if vistas[carta] {
+				t.Fatalf("a carta %v apareceu duas vezes", carta)
+			}
+			vistas[carta] = true
+		}
+
+		if len(vistas) != 52 || baralho.Restantes() != 0 {
+			t.Errorf("esperava 52 cartas distribuídas e nenhuma restante, obtido %d e %d", len(vistas), baralho.Restantes())
+		}
+
+		_, err = baralho.Dar(1)
+		verificaErro(t, err, poquer.ErrBaralhoSemCartas)
+	})
+
+	t.Run("a mesma semente embaralha as cartas na mesma ordem", func(t *testing.T) {
+		primeiro, _ := poquer.NovoBaralhoEmbaralhado(rand.New(rand.NewSource(42))).Dar(52)
+		segundo, _ := poquer.NovoBaralhoEmbaralhado(rand.New(rand.NewSource(42))).Dar(52)
+		outro, _ := poquer.NovoBaralhoEmbaralhado(rand.New(rand.NewSource(7))).Dar(52)
+
+		if !reflect.DeepEqual(primeiro, segundo) {
+			t.Error("a mesma semente deveria embaralhar igual")
+		}
+
+		if reflect.DeepEqual(primeiro, outro) {
+			t.Error("sementes diferentes deveriam embaralhar diferente")
+		}
+	})
+
+	t.Run("dá as cartas fechadas uma a uma e queima uma carta antes de cada rodada da mesa", func(t *testing.T) {
+		cartas := deveLerCartas(t, "Ah Kd Qc Js Th 9d 2c 3c 4c 5c 6c 7c 8c 9c 2h")
+		baralho := poquer.NovoBaralhoCom(cartas)
+
+		fechadas, err := baralho.DarCartasFechadas(3)
+		verificaSemErro(t, err)
+
+		esperado := [][]poquer.Carta{
+			deveLerCartas(t, "Ah Js"),
+			deveLerCartas(t, "Kd Th"),
+			deveLerCartas(t, "Qc 9d"),
+		}
+
+		if !reflect.DeepEqual(fechadas, esperado) {
+			t.Errorf("cartas fechadas obtidas %v, esperadas %v", fechadas, esperado)
+		}
+
+		flop, _ := baralho.DarMesa(3)
+		turn, _ := baralho.DarMesa(1)
+		river, _ := baralho.DarMesa(1)
+		mesa := poquer.EscreverCartas(append(append(flop, turn...), river...))
+
+		if mesa != "3c 4c 5c 7c 9c" {
+			t.Errorf("mesa obtida %q", mesa)
+		}
+
+		_, err = baralho.DarMesa(1)
+		verificaErro(t, err, poquer.ErrBaralhoSemCartas)
+	})
+
+	t.Run("recusa dar cartas fechadas a jogadores demais", func(t *testing.T) {
+		_, err := poquer.NovoBaralho().DarCartasFechadas(27)
+		verificaErro(t, err, poquer.ErrBaralhoSemCartas)
+	})
+}
+
+func deveLerCartas(t testing.TB, texto string) []poquer.Carta {
+	t.Helper()
+
+	cartas, err := poquer.LerCartas(texto)
+
+	if err != nil {
+		t.Fatalf("não foi possível ler as cartas %q, %v", texto, err)
+	}
+
+	return cartas
+}
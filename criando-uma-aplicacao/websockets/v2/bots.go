@@ -0,0 +1,222 @@
+package poquer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// Situacao é o que um jogador sabe quando chega a sua vez: o seu lugar, as suas cartas
+// fechadas e o que todos veem da mesa
+type Situacao struct {
+	Lugar  int
+	Cartas []Carta
+	Mesa   EstadoDaMesa
+}
+
+// Opcoes retorna o que o jogador pode fazer na sua vez
+func (s Situacao) Opcoes() Opcoes {
+	if s.Mesa.Opcoes == nil {
+		return Opcoes{Jogador: s.Lugar}
+	}
+	return *s.Mesa.Opcoes
+}
+
+// passarOuPagar é a ação que continua na mão pelo menor custo
+func (s Situacao) passarOuPagar() Acao {
+	if s.Opcoes().PodePassar {
+		return Acao{Tipo: Passar}
+	}
+	return Acao{Tipo: Pagar}
+}
+
+// passarOuDesistir é a ação que não coloca mais nenhuma ficha no pote
+func (s Situacao) passarOuDesistir() Acao {
+	if s.Opcoes().PodePassar {
+		return Acao{Tipo: Passar}
+	}
+	return Acao{Tipo: Desistir}
+}
+
+// apostarAte aposta ou aumenta para o total, que é limitado às apostas permitidas. Sem
+// poder aumentar, o jogador só continua na mão
+func (s Situacao) apostarAte(total int) Acao {
+	opcoes := s.Opcoes()
+
+	if opcoes.ApostaMinima == 0 {
+		return s.passarOuPagar()
+	}
+
+	if total < opcoes.ApostaMinima {
+		total = opcoes.ApostaMinima
+	}
+
+	if total >= opcoes.ApostaMaxima {
+		return Acao{Tipo: AllIn}
+	}
+
+	for _, jogador := range s.Mesa.Jogadores {
+		if jogador.Aposta > 0 {
+			return Acao{Tipo: Aumentar, Quantia: total}
+		}
+	}
+
+	return Acao{Tipo: Apostar, Quantia: total}
+}
+
+// Estrategia decide o que um bot faz na sua vez. Uma ação recusada pela mesa vira passar
+// ou, se não der para passar, desistir
+type Estrategia interface {
+	Decidir(situacao Situacao) Acao
+}
+
+// EstrategiaFunc permite usar uma função como Estrategia
+type EstrategiaFunc func(situacao Situacao) Acao
+
+// Decidir chama a função
+func (f EstrategiaFunc) Decidir(situacao Situacao) Acao {
+	return f(situacao)
+}
+
+// EstrategiaDePagar nunca desiste nem aumenta: passa quando pode e paga qualquer aposta
+type EstrategiaDePagar struct{}
+
+// Decidir passa ou paga
+func (EstrategiaDePagar) Decidir(situacao Situacao) Acao {
+	return situacao.passarOuPagar()
+}
+
+// EstrategiaAleatoria escolhe uma ação permitida ao acaso: passa ou paga na metade das vezes,
+// aumenta um valor qualquer em um quarto delas e vai all-in raramente
+type EstrategiaAleatoria struct {
+	aleatorio *rand.Rand
+}
+
+// NovaEstrategiaAleatoria cria uma EstrategiaAleatoria que sorteia com a aleatoriedade
+// recebida, para que uma semente fixa reproduza as decisões
+func NovaEstrategiaAleatoria(aleatorio *rand.Rand) *EstrategiaAleatoria {
+	return &EstrategiaAleatoria{aleatorio: aleatorio}
+}
+
+// Decidir sorteia a ação
+func (e *EstrategiaAleatoria) Decidir(situacao Situacao) Acao {
+	opcoes := situacao.Opcoes()
+	sorteio := e.aleatorio.Intn(20)
+
+	switch {
+	case sorteio < 4:
+		return situacao.passarOuDesistir()
+	case sorteio < 14:
+		return situacao.passarOuPagar()
+	case sorteio < 19 && opcoes.ApostaMinima > 0:
+		return situacao.apostarAte(opcoes.ApostaMinima + e.aleatorio.Intn(opcoes.ApostaMaxima-opcoes.ApostaMinima+1))
+	case opcoes.ApostaMinima > 0:
+		return Acao{Tipo: AllIn}
+	default:
+		return situacao.passarOuPagar()
+	}
+}
+
+// Limites da EstrategiaDeForca: a partir da forcaParaAumentar o bot aumenta e abaixo da
+// forcaParaPagar ele só continua se puder passar
+const (
+	forcaParaPagar    = 0.45
+	forcaParaAumentar = 0.75
+)
+
+// forcaDasCategorias é a força de cada categoria de jogo depois do flop, de 0 a 1
+var forcaDasCategorias = [...]float64{0.2, 0.5, 0.65, 0.75, 0.82, 0.86, 0.92, 0.97, 1, 1}
+
+// EstrategiaDeForca joga pela força das suas cartas: aumenta com jogos fortes, paga com
+// jogos razoáveis e desiste dos fracos quando há aposta
+type EstrategiaDeForca struct{}
+
+// Decidir aumenta o mínimo, paga ou desiste conforme a ForcaDaMao
+func (EstrategiaDeForca) Decidir(situacao Situacao) Acao {
+	forca := ForcaDaMao(situacao.Cartas, situacao.Mesa.Cartas)
+
+	switch {
+	case forca >= forcaParaAumentar:
+		return situacao.apostarAte(situacao.Opcoes().ApostaMinima)
+	case forca >= forcaParaPagar:
+		return situacao.passarOuPagar()
+	default:
+		return situacao.passarOuDesistir()
+	}
+}
+
+// ForcaDaMao estima de 0 a 1 o quanto as cartas fechadas valem com as cartas da mesa. Antes
+// do flop, pares e cartas altas do mesmo naipe ou seguidas valem mais; depois, vale a
+// categoria do jogo, descontada quando o jogo está todo na mesa
+func ForcaDaMao(fechadas, mesa []Carta) float64 {
+	if len(fechadas) != 2 {
+		return 0
+	}
+
+	if len(mesa) < 3 {
+		return forcaAntesDoFlop(fechadas[0], fechadas[1])
+	}
+
+	combinacao, err := Avaliar(append(append([]Carta(nil), fechadas...), mesa...))
+
+	if err != nil {
+		return 0
+	}
+
+	forca := forcaDasCategorias[combinacao.Categoria]
+
+	if len(mesa) == 5 {
+		if daMesa, err := Avaliar(mesa); err == nil && daMesa.Categoria == combinacao.Categoria {
+			forca = forcaDasCategorias[CartaAlta]
+		}
+	}
+
+	return forca
+}
+
+func forcaAntesDoFlop(primeira, segunda Carta) float64 {
+	alta, baixa := primeira.Valor, segunda.Valor
+	if baixa > alta {
+		alta, baixa = baixa, alta
+	}
+
+	if alta == baixa {
+		return 0.55 + float64(alta)/30
+	}
+
+	forca := float64(alta+baixa) / 30
+
+	if primeira.Naipe == segunda.Naipe {
+		forca += 0.05
+	}
+
+	if alta-baixa == 1 {
+		forca += 0.03
+	}
+
+	return forca
+}
+
+// Nomes das estratégias aceitas por NovaEstrategia
+const (
+	EstrategiaPagar     = "pagar"
+	EstrategiaAleatorio = "aleatorio"
+	EstrategiaForca     = "forca"
+)
+
+// ErrEstrategiaDesconhecida indica que não há estratégia de bot com o nome informado
+var ErrEstrategiaDesconhecida = errors.New("estratégia de bot desconhecida")
+
+// NovaEstrategia cria a estratégia com o nome; a aleatória sorteia com a aleatoriedade recebida
+func NovaEstrategia(nome string, aleatorio *rand.Rand) (Estrategia, error) {
+	switch nome {
+	case EstrategiaPagar:
+		return EstrategiaDePagar{}, nil
+	case EstrategiaAleatorio:
+		return NovaEstrategiaAleatoria(aleatorio), nil
+	case EstrategiaForca:
+		return EstrategiaDeForca{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q, use %s, %s ou %s", ErrEstrategiaDesconhecida, nome, EstrategiaPagar, EstrategiaAleatorio, EstrategiaForca)
+	}
+}
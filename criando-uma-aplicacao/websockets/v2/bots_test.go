@@ -0,0 +1,186 @@
+package poquer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestEstrategias(t *testing.T) {
+	paraPagar := poquer.Opcoes{ParaPagar: 10, ApostaMinima: 20, ApostaMaxima: 1000}
+	paraPassar := poquer.Opcoes{PodePassar: true, ApostaMinima: 10, ApostaMaxima: 1000}
+
+	casos := []struct {
+		nome       string
+		estrategia poquer.Estrategia
+		cartas     string
+		mesa       string
+		opcoes     poquer.Opcoes
+		esperada   poquer.Acao
+	}{
+		{"quem paga passa quando pode", poquer.EstrategiaDePagar{}, "7c 2d", "", paraPassar, poquer.Acao{Tipo: poquer.Passar}},
+		{"quem paga paga qualquer aposta", poquer.EstrategiaDePagar{}, "7c 2d", "", paraPagar, poquer.Acao{Tipo: poquer.Pagar}},
+		{"a força aumenta com um par de ases", poquer.EstrategiaDeForca{}, "Ac Ad", "", paraPagar, poquer.Acao{Tipo: poquer.Aumentar, Quantia: 20}},
+		{"a força desiste de 7 e 2 quando há aposta", poquer.EstrategiaDeForca{}, "7c 2d", "", paraPagar, poquer.Acao{Tipo: poquer.Desistir}},
+		{"a força passa com 7 e 2 quando pode", poquer.EstrategiaDeForca{}, "7c 2d", "", paraPassar, poquer.Acao{Tipo: poquer.Passar}},
+		{"a força paga com um par pequeno", poquer.EstrategiaDeForca{}, "3c 3d", "", paraPagar, poquer.Acao{Tipo: poquer.Pagar}},
+		{"a força aposta o flush no flop", poquer.EstrategiaDeForca{}, "7h 2h", "Kh 9h 4h", paraPassar, poquer.Acao{Tipo: poquer.Apostar, Quantia: 10}},
+		{"a força vai all-in quando o mínimo é tudo", poquer.EstrategiaDeForca{}, "Ac Ad", "", poquer.Opcoes{ParaPagar: 10, ApostaMinima: 50, ApostaMaxima: 50}, poquer.Acao{Tipo: poquer.AllIn}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			situacao := novaSituacao(t, caso.cartas, caso.mesa, caso.opcoes)
+
+			if obtida := caso.estrategia.Decidir(situacao); obtida != caso.esperada {
+				t.Errorf("obtida %v, esperada %v", obtida, caso.esperada)
+			}
+		})
+	}
+
+	t.Run("a estratégia aleatória repete as decisões com a mesma semente", func(t *testing.T) {
+		decidir := func(semente int64) []poquer.Acao {
+			estrategia := poquer.NovaEstrategiaAleatoria(rand.New(rand.NewSource(semente)))
+			situacao := novaSituacao(t, "7c 2d", "", paraPagar)
+
+			var acoes []poquer.Acao
+			for i := 0; i < 50; i++ {
+				acoes = append(acoes, estrategia.Decidir(situacao))
+			}
+			return acoes
+		}
+
+		primeiras, segundas := decidir(3), decidir(3)
+
+		if !reflect.DeepEqual(primeiras, segundas) {
+			t.Error("a mesma semente deveria repetir as decisões")
+		}
+
+		tipos := map[poquer.TipoDeAcao]bool{}
+		for _, acao := range primeiras {
+			tipos[acao.Tipo] = true
+			if acao.Tipo == poquer.Aumentar && (acao.Quantia < 20 || acao.Quantia >= 1000) {
+				t.Errorf("aumento fora das opções, %v", acao)
+			}
+		}
+
+		if len(tipos) < 3 {
+			t.Errorf("esperava ações variadas, obtido %v", tipos)
+		}
+	})
+
+	t.Run("cria as estratégias pelo nome", func(t *testing.T) {
+		for _, nome := range []string{poquer.EstrategiaPagar, poquer.EstrategiaAleatorio, poquer.EstrategiaForca} {
+			if _, err := poquer.NovaEstrategia(nome, rand.New(rand.NewSource(1))); err != nil {
+				t.Errorf("não criou a estratégia %s, %v", nome, err)
+			}
+		}
+
+		_, err := poquer.NovaEstrategia("blefe", nil)
+		verificaErro(t, err, poquer.ErrEstrategiaDesconhecida)
+	})
+}
+
+func TestForcaDaMao(t *testing.T) {
+	ordem := []struct {
+		cartas string
+		mesa   string
+	}{
+		{"7c 2d", ""},
+		{"9c 8c", ""},
+		{"Kh Qh", ""},
+		{"Ac Ad", ""},
+	}
+
+	anterior := -1.0
+	for _, mao := range ordem {
+		forca := poquer.ForcaDaMao(deveLerCartas(t, mao.cartas), deveLerCartas(t, mao.mesa))
+		if forca <= anterior {
+			t.Errorf("%s deveria ser mais forte que a mão anterior, obtido %.2f", mao.cartas, forca)
+		}
+		anterior = forca
+	}
+
+	doJogador := poquer.ForcaDaMao(deveLerCartas(t, "Ks 2d"), deveLerCartas(t, "Kc 9h 5d 3s 7c"))
+	daMesa := poquer.ForcaDaMao(deveLerCartas(t, "Ah 2d"), deveLerCartas(t, "Kc Kh 5d 3s 7c"))
+
+	if daMesa >= doJogador {
+		t.Errorf("um par que está todo na mesa deveria valer menos, obtido %.2f e %.2f", daMesa, doJogador)
+	}
+}
+
+func TestBotsNaMesa(t *testing.T) {
+	t.Run("o bot age assim que chega a sua vez", func(t *testing.T) {
+		mesa, destino, _ := novaMesaDeTeste(t, 2, 1000, nil)
+
+		sentar(t, mesa, "Cleo", 0)
+		if _, err := mesa.SentarBot("Bot", poquer.EstrategiaDePagar{}); err != nil {
+			t.Fatal(err)
+		}
+		mesa.Começar()
+
+		agirNaMesa(t, mesa, 0, poquer.Pagar)
+
+		estado := destino.ultimoEstado()
+		verificaEstadoDaMesa(t, estado, 1, "flop", 0, 20)
+
+		if !estado.Jogadores[1].Bot || estado.Jogadores[0].Bot {
+			t.Errorf("apenas o lugar 1 deveria ser um bot, obtido %+v", estado.Jogadores)
+		}
+
+		agirNaMesa(t, mesa, 0, poquer.Passar)
+		verificaEstadoDaMesa(t, destino.ultimoEstado(), 1, "turn", 0, 20)
+	})
+
+	t.Run("uma ação recusada vira passar ou desistir", func(t *testing.T) {
+		mesa, destino, _ := novaMesaDeTeste(t, 2, 1000, nil)
+		teimoso := poquer.EstrategiaFunc(func(poquer.Situacao) poquer.Acao {
+			return poquer.Acao{Tipo: poquer.Apostar, Quantia: 1}
+		})
+
+		mesa.SentarBot("Teimoso", teimoso)
+		sentar(t, mesa, "Cleo", 1)
+		mesa.Começar()
+
+		if len(destino.Resultados) != 1 || destino.Resultados[0].Jogadores[0].Nome != "Cleo" {
+			t.Fatalf("o bot deveria desistir diante do big blind, obtido %+v", destino.Resultados)
+		}
+
+		verificaEstadoDaMesa(t, destino.ultimoEstado(), 2, "pré-flop", 1, 15)
+	})
+
+	t.Run("ninguém senta com o nome de um bot", func(t *testing.T) {
+		mesa, _, _ := novaMesaDeTeste(t, 3, 1000, nil)
+		mesa.SentarBot("Bot", poquer.EstrategiaDeForca{})
+
+		_, err := mesa.Sentar("Bot")
+		verificaErro(t, err, poquer.ErrLugarOcupado)
+
+		_, err = mesa.SentarBot("Bot", poquer.EstrategiaDeForca{})
+		verificaErro(t, err, poquer.ErrLugarOcupado)
+	})
+
+	t.Run("os nomes de bot são reservados mesmo antes de o bot sentar", func(t *testing.T) {
+		mesa, _, _ := novaMesaDeTeste(t, 3, 1000, nil)
+
+		_, err := mesa.Sentar(poquer.NomeDeBot(2))
+		verificaErro(t, err, poquer.ErrNomeDeBot)
+
+		if _, err := mesa.SentarBot(poquer.NomeDeBot(2), poquer.EstrategiaDeForca{}); err != nil {
+			t.Errorf("o bot deveria sentar com o seu nome, %v", err)
+		}
+	})
+}
+
+func novaSituacao(t *testing.T, cartas, mesa string, opcoes poquer.Opcoes) poquer.Situacao {
+	t.Helper()
+
+	estado := poquer.EstadoDaMesa{Cartas: deveLerCartas(t, mesa), Opcoes: &opcoes}
+	if len(estado.Cartas) == 0 {
+		estado.Jogadores = []poquer.JogadorNaMesa{{Nome: "Big blind", Aposta: 10}, {Nome: "Bot"}}
+	}
+
+	return poquer.Situacao{Lugar: 1, Cartas: deveLerCartas(t, cartas), Mesa: estado}
+}
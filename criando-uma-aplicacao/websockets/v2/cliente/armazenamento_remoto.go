@@ -0,0 +1,264 @@
+package cliente
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+const (
+	tempoLimitePadrao = 5 * time.Second
+	tentativasPadrao  = 3
+	esperaPadrao      = 200 * time.Millisecond
+)
+
+// VitoriaPendente é uma vitória que ainda não foi aceita pelo servidor
+type VitoriaPendente struct {
+	Nome  string
+	Chave string
+}
+
+// ArmazenamentoRemoto implementa poquer.ArmazenamentoJogador repassando as chamadas para um
+// ServidorJogador. Vitórias que não puderam ser enviadas ficam numa fila e são reenviadas
+// na próxima chamada, sempre com a mesma chave de idempotência
+type ArmazenamentoRemoto struct {
+	cliente       *ClienteJogador
+	tentativas    int
+	espera        time.Duration
+	tempoLimite   time.Duration
+	arquivoDaFila *os.File
+
+	mu         sync.Mutex
+	fila       []VitoriaPendente
+	baseDaFila *json.Encoder
+	liga       poquer.Liga
+	pontuações map[string]int
+}
+
+// OpcaoArmazenamentoRemoto altera a configuração padrão de um ArmazenamentoRemoto
+type OpcaoArmazenamentoRemoto func(*ArmazenamentoRemoto)
+
+// ComTempoLimite define quanto tempo cada requisição pode levar
+func ComTempoLimite(tempoLimite time.Duration) OpcaoArmazenamentoRemoto {
+	return func(a *ArmazenamentoRemoto) {
+		a.tempoLimite = tempoLimite
+	}
+}
+
+// ComTentativas define quantas vezes uma requisição é tentada e quanto esperar entre as tentativas
+func ComTentativas(tentativas int, espera time.Duration) OpcaoArmazenamentoRemoto {
+	return func(a *ArmazenamentoRemoto) {
+		a.tentativas = tentativas
+		a.espera = espera
+	}
+}
+
+// ComFilaEmArquivo guarda as vitórias pendentes no arquivo, para que sobrevivam a reinícios
+func ComFilaEmArquivo(arquivo *os.File) OpcaoArmazenamentoRemoto {
+	return func(a *ArmazenamentoRemoto) {
+		a.arquivoDaFila = arquivo
+	}
+}
+
+// NovoArmazenamentoRemoto cria um ArmazenamentoRemoto para o servidor em urlBase
+func NovoArmazenamentoRemoto(urlBase string, opcoes ...OpcaoArmazenamentoRemoto) (*ArmazenamentoRemoto, error) {
+	a := &ArmazenamentoRemoto{
+		tentativas:  tentativasPadrao,
+		espera:      esperaPadrao,
+		tempoLimite: tempoLimitePadrao,
+		pontuações:  map[string]int{},
+	}
+
+	for _, opcao := range opcoes {
+		opcao(a)
+	}
+
+	a.cliente = NovoClienteJogador(urlBase, &http.Client{Timeout: a.tempoLimite})
+
+	if a.arquivoDaFila != nil {
+		if err := a.carregarFila(); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// ObtemPontuacaoDoJogador retorna a pontuação do servidor ou, se ele não responder, a última conhecida
+func (a *ArmazenamentoRemoto) ObtemPontuacaoDoJogador(nome string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.enviarPendentes()
+
+	var pontuação int
+	err := a.tentar(func() error {
+		var err error
+		pontuação, err = a.cliente.ObtemPontuacao(nome)
+		return err
+	})
+
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrJogadorNaoEncontrado):
+		pontuação = 0
+	default:
+		log.Printf("usando a última pontuação conhecida de %s, %v", nome, err)
+		return a.pontuações[nome]
+	}
+
+	a.pontuações[nome] = pontuação
+	return pontuação
+}
+
+// GravarVitoria envia a vitória para o servidor, enfileirando-a caso ele não esteja disponível
+func (a *ArmazenamentoRemoto) GravarVitoria(nome string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.fila = append(a.fila, VitoriaPendente{Nome: nome, Chave: novaChave()})
+	a.salvarFila()
+
+	a.enviarPendentes()
+}
+
+// ObterLiga retorna a liga do servidor ou, se ele não responder, a última conhecida
+func (a *ArmazenamentoRemoto) ObterLiga() poquer.Liga {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.enviarPendentes()
+
+	var liga poquer.Liga
+	err := a.tentar(func() error {
+		var err error
+		liga, err = a.cliente.ObterLiga()
+		return err
+	})
+
+	if err != nil {
+		log.Printf("usando a última liga conhecida, %v", err)
+		return a.liga
+	}
+
+	a.liga = liga
+	return liga
+}
+
+// Pendentes retorna as vitórias que ainda não foram aceitas pelo servidor
+func (a *ArmazenamentoRemoto) Pendentes() []VitoriaPendente {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]VitoriaPendente(nil), a.fila...)
+}
+
+// Sincronizar tenta enviar as vitórias pendentes, retornando quantas ainda faltam
+func (a *ArmazenamentoRemoto) Sincronizar() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.enviarPendentes()
+	return len(a.fila)
+}
+
+func (a *ArmazenamentoRemoto) enviarPendentes() {
+	enviadas := 0
+
+	for _, pendente := range a.fila {
+		err := a.tentar(func() error {
+			return a.cliente.GravarVitoriaComChave(pendente.Nome, pendente.Chave)
+		})
+
+		if err != nil && temporario(err) {
+			log.Printf("vitória de %s continua pendente, %v", pendente.Nome, err)
+			break
+		}
+
+		if err != nil {
+			log.Printf("descartando a vitória de %s recusada pelo servidor, %v", pendente.Nome, err)
+		}
+
+		enviadas++
+	}
+
+	if enviadas > 0 {
+		a.fila = a.fila[enviadas:]
+		a.salvarFila()
+	}
+}
+
+func (a *ArmazenamentoRemoto) tentar(operacao func() error) error {
+	var err error
+
+	for tentativa := 1; tentativa <= a.tentativas; tentativa++ {
+		err = operacao()
+
+		if err == nil || !temporario(err) {
+			return err
+		}
+
+		if tentativa < a.tentativas {
+			time.Sleep(a.espera * time.Duration(tentativa))
+		}
+	}
+
+	return err
+}
+
+// temporario indica se vale a pena tentar de novo: falhas de rede, limite de requisições e erros do servidor
+func temporario(err error) bool {
+	var erroDeStatus *ErroDeStatus
+
+	if !errors.As(err, &erroDeStatus) {
+		return !errors.Is(err, ErrJogadorNaoEncontrado)
+	}
+
+	return erroDeStatus.Status == http.StatusTooManyRequests || erroDeStatus.Status >= http.StatusInternalServerError
+}
+
+func (a *ArmazenamentoRemoto) carregarFila() error {
+	a.arquivoDaFila.Seek(0, 0)
+
+	info, err := a.arquivoDaFila.Stat()
+
+	if err != nil {
+		return fmt.Errorf("problema ao obter informações do arquivo %s, %v", a.arquivoDaFila.Name(), err)
+	}
+
+	if info.Size() > 0 {
+		if err := json.NewDecoder(a.arquivoDaFila).Decode(&a.fila); err != nil {
+			return fmt.Errorf("problema ao carregar as vitórias pendentes do arquivo %s, %v", a.arquivoDaFila.Name(), err)
+		}
+	}
+
+	a.baseDaFila = json.NewEncoder(&poquer.Tape{File: a.arquivoDaFila})
+	return nil
+}
+
+func (a *ArmazenamentoRemoto) salvarFila() {
+	if a.baseDaFila == nil {
+		return
+	}
+
+	if a.fila == nil {
+		a.fila = []VitoriaPendente{}
+	}
+
+	a.baseDaFila.Encode(a.fila)
+}
+
+func novaChave() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,197 @@
+package cliente_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+	"github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2/cliente"
+)
+
+// servidorInstavel repassa as requisições para o servidor real, exceto quando está fora do ar
+type servidorInstavel struct {
+	mu          sync.Mutex
+	real        http.Handler
+	foraDoAr    bool
+	falhasAte   int
+	requisicoes int
+}
+
+func (s *servidorInstavel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requisicoes++
+	falhar := s.foraDoAr || s.requisicoes <= s.falhasAte
+	s.mu.Unlock()
+
+	if falhar {
+		http.Error(w, "fora do ar", http.StatusServiceUnavailable)
+		return
+	}
+
+	s.real.ServeHTTP(w, r)
+}
+
+func (s *servidorInstavel) definirForaDoAr(foraDoAr bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.foraDoAr = foraDoAr
+}
+
+func novoServidorInstavel(t *testing.T, armazenamento poquer.ArmazenamentoJogador) (*servidorInstavel, *httptest.Server) {
+	t.Helper()
+
+	real, err := poquer.NovoServidorJogador(armazenamento, jogoTosco{})
+
+	if err != nil {
+		t.Fatal("problema ao criar o servidor do jogador", err)
+	}
+
+	instavel := &servidorInstavel{real: real}
+	return instavel, httptest.NewServer(instavel)
+}
+
+func TestArmazenamentoRemoto(t *testing.T) {
+	t.Run("repassa as chamadas para o servidor", func(t *testing.T) {
+		baseDeDados, limpar := criarArquivoTemporario(t, "[]")
+		defer limpar()
+
+		armazenamentoDoServidor, err := poquer.NovoSistemaArquivoArmazenamentoJogador(baseDeDados)
+		verificaSemErro(t, err)
+
+		servidor := novoServidor(t, armazenamentoDoServidor)
+		defer servidor.Close()
+
+		remoto, err := cliente.NovoArmazenamentoRemoto(servidor.URL)
+		verificaSemErro(t, err)
+
+		remoto.GravarVitoria("Pepper")
+		remoto.GravarVitoria("Pepper")
+		remoto.GravarVitoria("Cleo")
+
+		verificaPontuacao(t, remoto.ObtemPontuacaoDoJogador("Pepper"), 2)
+		verificaPontuacao(t, remoto.ObtemPontuacaoDoJogador("Apollo"), 0)
+
+		liga := remoto.ObterLiga()
+		if len(liga) != 2 || liga[0].Nome != "Pepper" {
+			t.Errorf("liga inesperada %v", liga)
+		}
+	})
+
+	t.Run("tenta de novo quando o servidor falha temporariamente", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		instavel, servidor := novoServidorInstavel(t, armazenamento)
+		defer servidor.Close()
+		instavel.falhasAte = 2
+
+		remoto, err := cliente.NovoArmazenamentoRemoto(servidor.URL, cliente.ComTentativas(3, 0))
+		verificaSemErro(t, err)
+
+		remoto.GravarVitoria("Chris")
+
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris")
+		verificaPendentes(t, remoto, 0)
+	})
+
+	t.Run("enfileira vitórias enquanto o servidor está fora do ar e as envia uma única vez depois", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{Pontuações: map[string]int{"Chris": 4}}
+		instavel, servidor := novoServidorInstavel(t, armazenamento)
+		defer servidor.Close()
+
+		remoto, err := cliente.NovoArmazenamentoRemoto(servidor.URL, cliente.ComTentativas(2, 0))
+		verificaSemErro(t, err)
+
+		verificaPontuacao(t, remoto.ObtemPontuacaoDoJogador("Chris"), 4)
+
+		instavel.definirForaDoAr(true)
+		remoto.GravarVitoria("Chris")
+
+		verificaPendentes(t, remoto, 1)
+		verificaPontuacao(t, remoto.ObtemPontuacaoDoJogador("Chris"), 4)
+
+		instavel.definirForaDoAr(false)
+
+		if pendentes := remoto.Sincronizar(); pendentes != 0 {
+			t.Fatalf("obtido %d vitórias pendentes, esperado 0", pendentes)
+		}
+		remoto.Sincronizar()
+
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris")
+	})
+
+	t.Run("desiste de requisições que passam do tempo limite", func(t *testing.T) {
+		lento := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}))
+		defer lento.Close()
+
+		remoto, err := cliente.NovoArmazenamentoRemoto(lento.URL, cliente.ComTempoLimite(10*time.Millisecond), cliente.ComTentativas(1, 0))
+		verificaSemErro(t, err)
+
+		remoto.GravarVitoria("Chris")
+
+		verificaPendentes(t, remoto, 1)
+	})
+
+	t.Run("mantém a fila no arquivo entre execuções", func(t *testing.T) {
+		arquivoDaFila, limpar := criarArquivoTemporario(t, "")
+		defer limpar()
+
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		instavel, servidor := novoServidorInstavel(t, armazenamento)
+		defer servidor.Close()
+		instavel.definirForaDoAr(true)
+
+		remoto, err := cliente.NovoArmazenamentoRemoto(servidor.URL, cliente.ComTentativas(1, 0), cliente.ComFilaEmArquivo(arquivoDaFila))
+		verificaSemErro(t, err)
+		remoto.GravarVitoria("Cleo")
+
+		instavel.definirForaDoAr(false)
+
+		reiniciado, err := cliente.NovoArmazenamentoRemoto(servidor.URL, cliente.ComFilaEmArquivo(arquivoDaFila))
+		verificaSemErro(t, err)
+		verificaPendentes(t, reiniciado, 1)
+
+		reiniciado.Sincronizar()
+
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Cleo")
+		verificaPendentes(t, reiniciado, 0)
+	})
+}
+
+func criarArquivoTemporario(t *testing.T, dadosIniciais string) (*os.File, func()) {
+	t.Helper()
+
+	arquivoTemporario, err := ioutil.TempFile("", "db")
+
+	if err != nil {
+		t.Fatalf("não foi possível criar arquivo temporário %v", err)
+	}
+
+	arquivoTemporario.Write([]byte(dadosIniciais))
+
+	removerArquivo := func() {
+		arquivoTemporario.Close()
+		os.Remove(arquivoTemporario.Name())
+	}
+
+	return arquivoTemporario, removerArquivo
+}
+
+func verificaPontuacao(t *testing.T, obtido, esperado int) {
+	t.Helper()
+	if obtido != esperado {
+		t.Errorf("obtido %d esperado %d", obtido, esperado)
+	}
+}
+
+func verificaPendentes(t *testing.T, remoto *cliente.ArmazenamentoRemoto, esperado int) {
+	t.Helper()
+	if obtido := len(remoto.Pendentes()); obtido != esperado {
+		t.Errorf("obtido %d vitórias pendentes, esperado %d", obtido, esperado)
+	}
+}
@@ -0,0 +1,140 @@
+// Package cliente fala com um ServidorJogador via HTTP seguindo o documento servido em /openapi.json
+package cliente
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+// ErrJogadorNaoEncontrado é retornado quando o servidor não conhece vitórias do jogador
+var ErrJogadorNaoEncontrado = errors.New("jogador não encontrado")
+
+// ErroDeStatus representa uma resposta com um status que a operação não esperava
+type ErroDeStatus struct {
+	Operacao string
+	Status   int
+	Corpo    string
+}
+
+func (e *ErroDeStatus) Error() string {
+	return fmt.Sprintf("%s retornou status %d: %s", e.Operacao, e.Status, e.Corpo)
+}
+
+// ClienteJogador é um cliente tipado para as rotas do ServidorJogador
+type ClienteJogador struct {
+	urlBase string
+	http    *http.Client
+}
+
+// NovoClienteJogador cria um ClienteJogador para o servidor em urlBase. Se httpCliente
+// for nil, http.DefaultClient é usado
+func NovoClienteJogador(urlBase string, httpCliente *http.Client) *ClienteJogador {
+	if httpCliente == nil {
+		httpCliente = http.DefaultClient
+	}
+
+	return &ClienteJogador{
+		urlBase: strings.TrimSuffix(urlBase, "/"),
+		http:    httpCliente,
+	}
+}
+
+// ObterLiga retorna a tabela da liga
+func (c *ClienteJogador) ObterLiga() (poquer.Liga, error) {
+	resposta, err := c.http.Get(c.urlBase + "/liga")
+
+	if err != nil {
+		return nil, fmt.Errorf("problema ao obter a liga, %v", err)
+	}
+	defer resposta.Body.Close()
+
+	if resposta.StatusCode != http.StatusOK {
+		return nil, novoErroDeStatus("obterLiga", resposta)
+	}
+
+	return poquer.NovaLiga(resposta.Body)
+}
+
+// ObtemPontuacao retorna o número de vitórias do jogador
+func (c *ClienteJogador) ObtemPontuacao(nome string) (int, error) {
+	resposta, err := c.http.Get(c.urlDoJogador(nome))
+
+	if err != nil {
+		return 0, fmt.Errorf("problema ao obter a pontuação de %s, %v", nome, err)
+	}
+	defer resposta.Body.Close()
+
+	switch resposta.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, ErrJogadorNaoEncontrado
+	default:
+		return 0, novoErroDeStatus("obtemPontuacao", resposta)
+	}
+
+	corpo, err := ioutil.ReadAll(resposta.Body)
+
+	if err != nil {
+		return 0, fmt.Errorf("problema ao ler a pontuação de %s, %v", nome, err)
+	}
+
+	pontuação, err := strconv.Atoi(string(corpo))
+
+	if err != nil {
+		return 0, fmt.Errorf("pontuação inválida para %s %q, %v", nome, corpo, err)
+	}
+
+	return pontuação, nil
+}
+
+// GravarVitoria grava uma vitória para o jogador
+func (c *ClienteJogador) GravarVitoria(nome string) error {
+	return c.GravarVitoriaComChave(nome, "")
+}
+
+// GravarVitoriaComChave grava uma vitória enviando uma chave de idempotência, de forma que
+// repetir a chamada com a mesma chave não grava a vitória duas vezes
+func (c *ClienteJogador) GravarVitoriaComChave(nome, chave string) error {
+	requisicao, err := http.NewRequest(http.MethodPost, c.urlDoJogador(nome), nil)
+
+	if err != nil {
+		return fmt.Errorf("problema ao criar a requisição de vitória de %s, %v", nome, err)
+	}
+
+	if chave != "" {
+		requisicao.Header.Set("Idempotency-Key", chave)
+	}
+
+	resposta, err := c.http.Do(requisicao)
+
+	if err != nil {
+		return fmt.Errorf("problema ao gravar a vitória de %s, %v", nome, err)
+	}
+	defer resposta.Body.Close()
+
+	if resposta.StatusCode != http.StatusAccepted {
+		return novoErroDeStatus("gravarVitoria", resposta)
+	}
+
+	return nil
+}
+
+func (c *ClienteJogador) urlDoJogador(nome string) string {
+	return c.urlBase + "/jogadores/" + url.PathEscape(nome)
+}
+
+func novoErroDeStatus(operacao string, resposta *http.Response) *ErroDeStatus {
+	corpo, _ := ioutil.ReadAll(resposta.Body)
+	return &ErroDeStatus{
+		Operacao: operacao,
+		Status:   resposta.StatusCode,
+		Corpo:    strings.TrimSpace(string(corpo)),
+	}
+}
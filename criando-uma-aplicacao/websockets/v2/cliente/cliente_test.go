@@ -0,0 +1,120 @@
+package cliente_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+	"github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2/cliente"
+)
+
+// TestMain muda para o diretório do pacote poquer, onde o servidor encontra jogo.html
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+type jogoTosco struct{}
+
+func (j jogoTosco) Começar(configuracao poquer.ConfiguracaoDaPartida, destinoDosAlertas io.Writer) (poquer.Partida, error) {
+	return j, nil
+}
+func (j jogoTosco) Partida(id string) (poquer.Partida, bool) { return j, false }
+func (jogoTosco) ID() string                                 { return "" }
+func (jogoTosco) Terminar(vencedor string)                   {}
+func (jogoTosco) Abandonar()                                 {}
+func (jogoTosco) Pausar() error                              { return nil }
+func (jogoTosco) Continuar() error                           { return nil }
+func (jogoTosco) AvancarNivel() error                        { return nil }
+func (jogoTosco) VoltarNivel() error                         { return nil }
+func (jogoTosco) Estado() poquer.EstadoDosBlinds             { return poquer.EstadoDosBlinds{} }
+
+func novoServidor(t *testing.T, armazenamento poquer.ArmazenamentoJogador) *httptest.Server {
+	t.Helper()
+
+	servidor, err := poquer.NovoServidorJogador(armazenamento, jogoTosco{})
+
+	if err != nil {
+		t.Fatal("problema ao criar o servidor do jogador", err)
+	}
+
+	return httptest.NewServer(servidor)
+}
+
+func TestClienteJogador(t *testing.T) {
+	armazenamento := &poquer.EsbocoDeArmazenamentoJogador{
+		Pontuações: map[string]int{"Pepper": 20},
+		Liga:       []poquer.Jogador{{Nome: "Pepper", Vitorias: 20}},
+	}
+	servidor := novoServidor(t, armazenamento)
+	defer servidor.Close()
+
+	c := cliente.NovoClienteJogador(servidor.URL, nil)
+
+	t.Run("obtém a liga", func(t *testing.T) {
+		liga, err := c.ObterLiga()
+		verificaSemErro(t, err)
+
+		if !reflect.DeepEqual(liga, poquer.Liga(armazenamento.Liga)) {
+			t.Errorf("obtido %v, esperado %v", liga, armazenamento.Liga)
+		}
+	})
+
+	t.Run("obtém a pontuação de um jogador", func(t *testing.T) {
+		pontuação, err := c.ObtemPontuacao("Pepper")
+		verificaSemErro(t, err)
+
+		if pontuação != 20 {
+			t.Errorf("obtido %d, esperado 20", pontuação)
+		}
+	})
+
+	t.Run("retorna ErrJogadorNaoEncontrado para jogadores sem vitórias", func(t *testing.T) {
+		_, err := c.ObtemPontuacao("Apollo")
+
+		if err != cliente.ErrJogadorNaoEncontrado {
+			t.Errorf("obtido %v, esperado %v", err, cliente.ErrJogadorNaoEncontrado)
+		}
+	})
+
+	t.Run("grava uma vitória", func(t *testing.T) {
+		err := c.GravarVitoria("Chris Martin")
+		verificaSemErro(t, err)
+
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris Martin")
+	})
+}
+
+func TestClienteJogadorErros(t *testing.T) {
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "fora do ar", http.StatusServiceUnavailable)
+	}))
+	defer servidor.Close()
+
+	c := cliente.NovoClienteJogador(servidor.URL, nil)
+
+	err := c.GravarVitoria("Chris")
+
+	var erroDeStatus *cliente.ErroDeStatus
+	if !errors.As(err, &erroDeStatus) {
+		t.Fatalf("esperava um ErroDeStatus, obtido %v", err)
+	}
+
+	if erroDeStatus.Status != http.StatusServiceUnavailable || erroDeStatus.Corpo != "fora do ar" {
+		t.Errorf("obtido %+v", erroDeStatus)
+	}
+}
+
+func verificaSemErro(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("não esperava um erro mas obteve um, %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+package cliente_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+	"github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2/cliente"
+)
+
+type especificacao struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]esquema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operacao struct {
+	OperationID string                         `json:"operationId"`
+	Parameters  []parametro                    `json:"parameters"`
+	Responses   map[string]respostaDocumentada `json:"responses"`
+}
+
+type parametro struct {
+	Nome string `json:"name"`
+	Em   string `json:"in"`
+}
+
+type respostaDocumentada struct {
+	Content map[string]struct {
+		Schema esquema `json:"schema"`
+	} `json:"content"`
+}
+
+type esquema struct {
+	Ref        string             `json:"$ref"`
+	Tipo       string             `json:"type"`
+	Required   []string           `json:"required"`
+	Properties map[string]esquema `json:"properties"`
+	Items      *esquema           `json:"items"`
+}
+
+// requisicoesGravadas guarda as requisições que o cliente faz antes de entregá-las ao servidor
+type requisicoesGravadas struct {
+	ultima *http.Request
+}
+
+func (r *requisicoesGravadas) RoundTrip(requisicao *http.Request) (*http.Response, error) {
+	r.ultima = requisicao
+	return http.DefaultTransport.RoundTrip(requisicao)
+}
+
+// TestClienteSegueAEspecificacao confere cada método do ClienteJogador contra o documento
+// servido em /openapi.json: a operação chamada, os cabeçalhos enviados, os status tratados e
+// o esquema da resposta decodificada
+func TestClienteSegueAEspecificacao(t *testing.T) {
+	armazenamento := &poquer.EsbocoDeArmazenamentoJogador{
+		Pontuações: map[string]int{"Pepper": 20},
+		Liga:       []poquer.Jogador{{Nome: "Pepper", Vitorias: 20}},
+	}
+	servidor := novoServidor(t, armazenamento)
+	defer servidor.Close()
+
+	spec := obterEspecificacao(t, servidor.URL)
+	gravadas := &requisicoesGravadas{}
+	c := cliente.NovoClienteJogador(servidor.URL, &http.Client{Transport: gravadas})
+
+	casos := []struct {
+		metodo        string
+		operacao      string
+		chamar        func() error
+		statusAceitos []int
+		resposta      reflect.Type
+	}{
+		{
+			metodo:        "ObterLiga",
+			operacao:      "obterLiga",
+			chamar:        func() error { _, err := c.ObterLiga(); return err },
+			statusAceitos: []int{http.StatusOK},
+			resposta:      reflect.TypeOf(poquer.Liga{}),
+		},
+		{
+			metodo:        "ObtemPontuacao",
+			operacao:      "obtemPontuacao",
+			chamar:        func() error { _, err := c.ObtemPontuacao("Pepper"); return err },
+			statusAceitos: []int{http.StatusOK, http.StatusNotFound},
+			resposta:      reflect.TypeOf(0),
+		},
+		{
+			metodo:        "GravarVitoria",
+			operacao:      "gravarVitoria",
+			chamar:        func() error { return c.GravarVitoria("Chris") },
+			statusAceitos: []int{http.StatusAccepted},
+		},
+		{
+			metodo:        "GravarVitoriaComChave",
+			operacao:      "gravarVitoria",
+			chamar:        func() error { return c.GravarVitoriaComChave("Chris", "chave-1") },
+			statusAceitos: []int{http.StatusAccepted},
+		},
+	}
+
+	t.Run("todos os métodos do cliente são conferidos", func(t *testing.T) {
+		var conferidos, metodos []string
+		for _, caso := range casos {
+			conferidos = append(conferidos, caso.metodo)
+		}
+
+		tipo := reflect.TypeOf(c)
+		for i := 0; i < tipo.NumMethod(); i++ {
+			metodos = append(metodos, tipo.Method(i).Name)
+		}
+		sort.Strings(conferidos)
+
+		if strings.Join(conferidos, " ") != strings.Join(metodos, " ") {
+			t.Errorf("esperava conferir os métodos %v, conferidos %v", metodos, conferidos)
+		}
+	})
+
+	for _, caso := range casos {
+		caso := caso
+		t.Run(caso.metodo+" chama "+caso.operacao+" como documentado", func(t *testing.T) {
+			if err := caso.chamar(); err != nil {
+				t.Fatalf("a chamada falhou, %v", err)
+			}
+
+			requisicao := gravadas.ultima
+			caminho, op := operacaoDocumentada(t, spec, requisicao.Method, requisicao.URL.EscapedPath())
+
+			if op.OperationID != caso.operacao {
+				t.Fatalf("esperava a operação %s, obtido %s em %s %s", caso.operacao, op.OperationID, requisicao.Method, caminho)
+			}
+
+			cabecalhos := cabecalhosDocumentados(spec, caminho, op)
+			for cabecalho := range requisicao.Header {
+				if !cabecalhos[http.CanonicalHeaderKey(cabecalho)] {
+					t.Errorf("o cabeçalho %s não está documentado para %s", cabecalho, op.OperationID)
+				}
+			}
+
+			for _, status := range caso.statusAceitos {
+				if _, existe := op.Responses[strconv.Itoa(status)]; !existe {
+					t.Errorf("o cliente trata o status %d, que não está documentado para %s", status, op.OperationID)
+				}
+			}
+
+			if caso.resposta != nil {
+				documentada := op.Responses[strconv.Itoa(caso.statusAceitos[0])]
+				if len(documentada.Content) != 1 {
+					t.Fatalf("esperava um único tipo de conteúdo para %s, obtido %v", op.OperationID, documentada.Content)
+				}
+				for _, conteudo := range documentada.Content {
+					verificaEsquema(t, spec, conteudo.Schema, caso.resposta, op.OperationID)
+				}
+			}
+		})
+	}
+}
+
+func obterEspecificacao(t *testing.T, urlBase string) especificacao {
+	t.Helper()
+
+	resposta, err := http.Get(urlBase + "/openapi.json")
+	if err != nil {
+		t.Fatalf("problema ao obter a especificação, %v", err)
+	}
+	defer resposta.Body.Close()
+
+	var spec especificacao
+	if err := json.NewDecoder(resposta.Body).Decode(&spec); err != nil {
+		t.Fatalf("a especificação não é um JSON válido, %v", err)
+	}
+
+	return spec
+}
+
+func operacaoDocumentada(t *testing.T, spec especificacao, metodo, caminho string) (string, operacao) {
+	t.Helper()
+
+	for modelo, operacoes := range spec.Paths {
+		if !caminhoCorresponde(modelo, caminho) {
+			continue
+		}
+		if bruta, existe := operacoes[strings.ToLower(metodo)]; existe {
+			var op operacao
+			if err := json.Unmarshal(bruta, &op); err != nil {
+				t.Fatalf("operação %s %s inválida, %v", metodo, modelo, err)
+			}
+			return modelo, op
+		}
+	}
+
+	t.Fatalf("%s %s não está documentado", metodo, caminho)
+	return "", operacao{}
+}
+
+func caminhoCorresponde(modelo, caminho string) bool {
+	partesModelo := strings.Split(modelo, "/")
+	partesCaminho := strings.Split(caminho, "/")
+
+	if len(partesModelo) != len(partesCaminho) {
+		return false
+	}
+
+	for i, parte := range partesModelo {
+		if strings.HasPrefix(parte, "{") {
+			continue
+		}
+		if parte != partesCaminho[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cabecalhosDocumentados junta os cabeçalhos declarados no caminho e na operação
+func cabecalhosDocumentados(spec especificacao, caminho string, op operacao) map[string]bool {
+	parametros := op.Parameters
+
+	var doCaminho []parametro
+	if bruta, existe := spec.Paths[caminho]["parameters"]; existe {
+		json.Unmarshal(bruta, &doCaminho)
+	}
+
+	cabecalhos := map[string]bool{}
+	for _, p := range append(parametros, doCaminho...) {
+		if p.Em == "header" {
+			cabecalhos[http.CanonicalHeaderKey(p.Nome)] = true
+		}
+	}
+	return cabecalhos
+}
+
+// verificaEsquema confere que o tipo Go em que o cliente decodifica a resposta tem a forma do
+// esquema documentado: os mesmos campos nos objetos e o mesmo tipo nas listas e números
+func verificaEsquema(t *testing.T, spec especificacao, documentado esquema, tipo reflect.Type, operacao string) {
+	t.Helper()
+
+	if documentado.Ref != "" {
+		nome := strings.TrimPrefix(documentado.Ref, "#/components/schemas/")
+		referido, existe := spec.Components.Schemas[nome]
+		if !existe {
+			t.Fatalf("%s referencia o esquema desconhecido %s", operacao, documentado.Ref)
+		}
+		verificaEsquema(t, spec, referido, tipo, operacao)
+		return
+	}
+
+	switch documentado.Tipo {
+	case "array":
+		if tipo.Kind() != reflect.Slice || documentado.Items == nil {
+			t.Fatalf("%s documenta uma lista, mas o cliente usa %v", operacao, tipo)
+		}
+		verificaEsquema(t, spec, *documentado.Items, tipo.Elem(), operacao)
+	case "integer":
+		if tipo.Kind() != reflect.Int {
+			t.Errorf("%s documenta um inteiro, mas o cliente usa %v", operacao, tipo)
+		}
+	case "string":
+		if tipo.Kind() != reflect.String {
+			t.Errorf("%s documenta um texto, mas o cliente usa %v", operacao, tipo)
+		}
+	case "object":
+		verificaCampos(t, spec, documentado, tipo, operacao)
+	default:
+		t.Errorf("%s usa o tipo %q, que o teste não sabe conferir", operacao, documentado.Tipo)
+	}
+}
+
+func verificaCampos(t *testing.T, spec especificacao, documentado esquema, tipo reflect.Type, operacao string) {
+	t.Helper()
+
+	if tipo.Kind() != reflect.Struct {
+		t.Fatalf("%s documenta um objeto, mas o cliente usa %v", operacao, tipo)
+	}
+
+	campos := map[string]reflect.StructField{}
+	for i := 0; i < tipo.NumField(); i++ {
+		campo := tipo.Field(i)
+		campos[nomeNoJSON(campo)] = campo
+	}
+
+	for nome, propriedade := range documentado.Properties {
+		campo, existe := campos[nome]
+		if !existe {
+			t.Errorf("%s documenta o campo %s, que %v não tem", operacao, nome, tipo)
+			continue
+		}
+		verificaEsquema(t, spec, propriedade, campo.Type, operacao)
+	}
+
+	for nome := range campos {
+		if _, existe := documentado.Properties[nome]; !existe {
+			t.Errorf("o campo %s de %v não está documentado em %s", nome, tipo, operacao)
+		}
+	}
+
+	for _, nome := range documentado.Required {
+		if strings.Contains(campos[nome].Tag.Get("json"), "omitempty") {
+			t.Errorf("o campo obrigatório %s de %v pode ser omitido", nome, tipo)
+		}
+	}
+}
+
+func nomeNoJSON(campo reflect.StructField) string {
+	if nome := strings.Split(campo.Tag.Get("json"), ",")[0]; nome != "" {
+		return nome
+	}
+	return campo.Name
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+// equidade calcula a equidade das mãos recebidas nos argumentos, como em
+// cli equidade -maos "Ah As, Kd Kc" -mesa "Ac Kc 2s"
+func equidade(argumentos []string) int {
+	comando := flag.NewFlagSet("equidade", flag.ContinueOnError)
+	maos := comando.String("maos", "", "cartas fechadas de cada jogador separadas por vírgula, como \"Ah As, Kd Kc\"")
+	mesa := comando.String("mesa", "", "cartas da mesa, como \"Ac Kc 2s\"; vazio calcula antes do flop")
+	simulacoes := comando.Int("simulacoes", poquer.SimulacoesPadrao, "quantas mesas sortear quando há mesas possíveis demais para avaliar todas")
+	trabalhadores := comando.Int("trabalhadores", runtime.NumCPU(), "quantas goroutines avaliam as mesas")
+	semente := comando.Int64("semente", 0, "semente dos sorteios; 0 sorteia mesas diferentes a cada execução")
+
+	if err := comando.Parse(argumentos); err != nil {
+		return 2
+	}
+
+	fechadas, err := poquer.LerMaos(*maos)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cartasDaMesa, err := poquer.LerCartas(*mesa)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	opcoes := []poquer.OpcaoEquidade{poquer.ComSimulacoes(*simulacoes), poquer.ComTrabalhadores(*trabalhadores)}
+	if *semente != 0 {
+		opcoes = append(opcoes, poquer.ComSemente(*semente))
+	}
+
+	resultado, err := poquer.CalcularEquidade(fechadas, cartasDaMesa, opcoes...)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	resultado.Escrever(os.Stdout)
+	return 0
+}
@@ -1,27 +1,107 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+	"github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2/cliente"
 )
 
 const nomeArquivoBaseDeDados = "jogo.db.json"
+const nomeArquivoFila = "jogo.fila.json"
 
 func main() {
-	armazenamento, close, err := poquer.SistemaArquivoArmazenamentoJogadorDoArquivo(nomeArquivoBaseDeDados)
+	if len(os.Args) > 1 && os.Args[1] == "equidade" {
+		os.Exit(equidade(os.Args[2:]))
+	}
+
+	urlServidor := flag.String("servidor", "", "URL de um servidor de pôquer onde as vitórias serão gravadas, em vez de "+nomeArquivoBaseDeDados)
+	intervaloDoRelogio := flag.Duration("relogio", time.Minute, "de quanto em quanto tempo o tempo até o próximo blind é mostrado; 0 desliga")
+	diretorioDeEstruturas := flag.String("estruturas", "", "diretório com estruturas de blinds em arquivos .json, .yaml ou .yml, além das predefinidas")
+	nomeDaEstrutura := flag.String("estrutura", poquer.EstruturaPadrao, "nome da estrutura de blinds da partida")
+	fichasIniciais := flag.Int("fichas", 0, "fichas iniciais de cada jogador; com fichas, a partida é um torneio entre jogadores com nome")
+	niveisDeRecompra := flag.Int("recompras", 0, "por quantos níveis de blind um torneio aceita recompras e add-ons")
+	fichasDoAddOn := flag.Int("addon", 0, "fichas do add-on de um torneio; 0 desliga")
+	buyIn := flag.Int("buyin", 0, "quanto custa cada inscrição, recompra e add-on de um torneio; 0 joga sem prêmios")
+	lugaresPorMesa := flag.Int("lugares", poquer.MaximoDeLugares, "quantos jogadores cabem em cada mesa de um torneio; os inscritos são sorteados nas mesas")
+	premiacao := flag.String("premiacao", "", "percentuais dos prêmios de cada colocação separados por vírgula, como 50,30,20; vazio usa a premiação pelo número de inscritos")
+	flag.Parse()
+
+	estruturas, err := poquer.CatalogoDeEstruturasDoDiretorio(*diretorioDeEstruturas)
 
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("problema ao carregar as estruturas de blinds, %v", err)
+	}
+
+	var armazenamento poquer.ArmazenamentoJogador
+
+	if *urlServidor != "" {
+		remoto, fechar := armazenamentoRemoto(*urlServidor)
+		defer fechar()
+		armazenamento = remoto
+	} else {
+		local, fechar, err := poquer.SistemaArquivoArmazenamentoJogadorDoArquivo(nomeArquivoBaseDeDados)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fechar()
+		armazenamento = local
 	}
-	defer close()
 
-	jogo := poquer.NovoTexasHoldem(poquer.AlertadorDeBlindFunc(poquer.Alertador), armazenamento)
-	cli := poquer.NovaCLI(os.Stdin, os.Stdout, jogo)
+	jogo := poquer.NovoTexasHoldem(poquer.AlertadorDeBlindFunc(poquer.Alertador), armazenamento, poquer.ComIntervaloDoRelogio(*intervaloDoRelogio), poquer.ComEstruturas(estruturas))
+	opcoes := []poquer.OpcaoCLI{poquer.ComEstruturaDeBlinds(*nomeDaEstrutura)}
+
+	if *fichasIniciais > 0 {
+		percentuais, err := poquer.LerPremiacao(*premiacao)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		opcoes = append(opcoes, poquer.ComTorneio(poquer.ConfiguracaoDoTorneio{
+			FichasIniciais:   *fichasIniciais,
+			NiveisDeRecompra: *niveisDeRecompra,
+			FichasDoAddOn:    *fichasDoAddOn,
+			BuyIn:            *buyIn,
+			Premiacao:        percentuais,
+			LugaresPorMesa:   *lugaresPorMesa,
+		}))
+	}
+
+	cli := poquer.NovaCLI(os.Stdin, os.Stdout, jogo, opcoes...)
 
 	fmt.Println("Vamos jogar pôquer")
 	fmt.Println("Digite o nome para gravar uma vitória")
+	if *fichasIniciais > 0 {
+		fmt.Println("No torneio, digite 'Nome saiu', 'Nome recomprou', 'Nome fez addon', 'Nome tem 1500', 'classificacao', 'lugares' ou 'botao 1' ao fim de cada mão na mesa 1")
+	}
 	cli.JogarPoquer()
 }
+
+func armazenamentoRemoto(urlServidor string) (*cliente.ArmazenamentoRemoto, func()) {
+	fila, err := os.OpenFile(nomeArquivoFila, os.O_RDWR|os.O_CREATE, 0666)
+
+	if err != nil {
+		log.Fatalf("problema ao abrir %s %v", nomeArquivoFila, err)
+	}
+
+	armazenamento, err := cliente.NovoArmazenamentoRemoto(urlServidor, cliente.ComFilaEmArquivo(fila))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fechar := func() {
+		if pendentes := armazenamento.Sincronizar(); pendentes > 0 {
+			fmt.Printf("%d vitória(s) serão enviadas para %s na próxima execução\n", pendentes, urlServidor)
+		}
+		fila.Close()
+	}
+
+	return armazenamento, fechar
+}
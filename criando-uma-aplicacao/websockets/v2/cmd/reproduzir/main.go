@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "uso: %s [historico.txt ...]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Joga de novo cada mão dos históricos, ou da entrada padrão, e confere que ela termina como o histórico conta.")
+		flag.PrintDefaults()
+	}
+	verboso := flag.Bool("v", false, "mostra também as mãos que se repetem")
+	flag.Parse()
+
+	arquivos := flag.Args()
+	if len(arquivos) == 0 {
+		arquivos = []string{"-"}
+	}
+
+	reproduzidas, divergentes := 0, 0
+
+	for _, caminho := range arquivos {
+		maos, err := lerHistorico(caminho)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, mao := range maos {
+			reproduzidas++
+
+			if err := poquer.Reproduzir(mao); err != nil {
+				divergentes++
+				fmt.Printf("%s: jogo %s, %v\n", caminho, mao.Jogo, err)
+				continue
+			}
+
+			if *verboso {
+				fmt.Printf("%s: jogo %s, mão #%d reproduzida\n", caminho, mao.Jogo, mao.Resultado.Mao)
+			}
+		}
+	}
+
+	fmt.Printf("%d mãos reproduzidas, %d divergentes\n", reproduzidas, divergentes)
+
+	if divergentes > 0 {
+		os.Exit(1)
+	}
+}
+
+func lerHistorico(caminho string) ([]poquer.HistoricoDaMao, error) {
+	var entrada io.Reader = os.Stdin
+
+	if caminho != "-" {
+		arquivo, err := os.Open(caminho)
+
+		if err != nil {
+			return nil, fmt.Errorf("problema ao abrir %s, %v", caminho, err)
+		}
+		defer arquivo.Close()
+
+		entrada = arquivo
+	}
+
+	maos, err := poquer.LerHistorico(entrada)
+
+	if err != nil {
+		return nil, fmt.Errorf("problema ao ler %s, %v", caminho, err)
+	}
+
+	return maos, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func main() {
+	bots := flag.String("bots", "forca,pagar,aleatorio", "estratégias dos bots separadas por vírgula: pagar, aleatorio ou forca")
+	partidas := flag.Int("partidas", 100, "quantas partidas jogar")
+	fichasIniciais := flag.Int("fichas", 1000, "fichas iniciais de cada bot")
+	nomeDaEstrutura := flag.String("estrutura", "turbo", "nome da estrutura de blinds")
+	diretorioDeEstruturas := flag.String("estruturas", "", "diretório com estruturas de blinds em arquivos .json, .yaml ou .yml, além das predefinidas")
+	maosPorNivel := flag.Int("maos", poquer.MaosPorNivelPadrao, "de quantas em quantas mãos os blinds sobem")
+	arquivoDoHistorico := flag.String("historico", "", "arquivo onde escrever o histórico de todas as mãos, que pode ser conferido com o reproduzir")
+	semente := flag.Int64("semente", 1, "semente que embaralha as cartas e guia os bots aleatórios; a mesma semente repete a simulação")
+	flag.Parse()
+
+	estruturas, err := poquer.CatalogoDeEstruturasDoDiretorio(*diretorioDeEstruturas)
+
+	if err != nil {
+		log.Fatalf("problema ao carregar as estruturas de blinds, %v", err)
+	}
+
+	estrutura, err := estruturas.Estrutura(*nomeDaEstrutura)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	competidores, err := criarBots(*bots, rand.New(rand.NewSource(*semente)))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	configuracao := poquer.ConfiguracaoDaSimulacao{
+		Bots:           competidores,
+		Partidas:       *partidas,
+		FichasIniciais: *fichasIniciais,
+		Estrutura:      estrutura,
+		MaosPorNivel:   *maosPorNivel,
+		Semente:        *semente,
+	}
+
+	if *arquivoDoHistorico != "" {
+		historico, err := os.Create(*arquivoDoHistorico)
+
+		if err != nil {
+			log.Fatalf("problema ao criar %s, %v", *arquivoDoHistorico, err)
+		}
+		defer historico.Close()
+
+		configuracao.Historico = historico
+	}
+
+	resultado, err := poquer.Simular(configuracao)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resultado.Escrever(os.Stdout)
+}
+
+// criarBots cria um bot para cada estratégia da lista; estratégias repetidas ganham um número
+func criarBots(lista string, aleatorio *rand.Rand) ([]poquer.Bot, error) {
+	var bots []poquer.Bot
+	vezes := map[string]int{}
+
+	for _, nome := range strings.Split(lista, ",") {
+		nome = strings.TrimSpace(nome)
+		estrategia, err := poquer.NovaEstrategia(nome, aleatorio)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vezes[nome]++
+		if vezes[nome] > 1 {
+			nome = fmt.Sprintf("%s %d", nome, vezes[nome])
+		}
+
+		bots = append(bots, poquer.Bot{Nome: nome, Estrategia: estrategia})
+	}
+
+	return bots, nil
+}
@@ -1,16 +1,31 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
 )
 
 const nomeArquivoBaseDeDados = "jogo.db.json"
+const nomeArquivoIdempotencia = "jogo.idempotencia.json"
+const janelaIdempotencia = 24 * time.Hour
 
 func main() {
+	intervaloDoRelogio := flag.Duration("relogio", time.Second, "de quanto em quanto tempo o tempo até o próximo blind é enviado aos jogos; 0 desliga")
+	diretorioDeEstruturas := flag.String("estruturas", "", "diretório com estruturas de blinds em arquivos .json, .yaml ou .yml, além das predefinidas")
+	diretorioDeMaos := flag.String("maos", "maos", "diretório onde fica o histórico das mãos de cada jogo com mesa")
+	flag.Parse()
+
+	estruturas, err := poquer.CatalogoDeEstruturasDoDiretorio(*diretorioDeEstruturas)
+
+	if err != nil {
+		log.Fatalf("problema ao carregar as estruturas de blinds, %v", err)
+	}
+
 	db, err := os.OpenFile(nomeArquivoBaseDeDados, os.O_RDWR|os.O_CREATE, 0666)
 
 	if err != nil {
@@ -23,9 +38,22 @@ func main() {
 		log.Fatalf("problema ao criar sistema de arquivo de armazenamento do jogador, %v ", err)
 	}
 
-	jogo := poquer.NovoTexasHoldem(poquer.AlertadorDeBlindFunc(poquer.Alertador), armazenamento)
+	idempotencia, fechar, err := poquer.SistemaArquivoArmazenamentoIdempotenciaDoArquivo(nomeArquivoIdempotencia, janelaIdempotencia, poquer.RelogioDoSistema{})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fechar()
+
+	maos, err := poquer.NovoDiretorioArmazenamentoDeMaos(*diretorioDeMaos)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jogo := poquer.NovoTexasHoldem(poquer.AlertadorDeBlindFunc(poquer.Alertador), armazenamento, poquer.ComIntervaloDoRelogio(*intervaloDoRelogio), poquer.ComEstruturas(estruturas))
 
-	servidor, err := poquer.NovoServidorJogador(armazenamento, jogo)
+	servidor, err := poquer.NovoServidorJogador(armazenamento, jogo, poquer.ComIdempotencia(idempotencia), poquer.ComArmazenamentoDeMaos(maos))
 
 	if err != nil {
 		log.Fatalf("problema ao criar o servidor do jogador %v", err)
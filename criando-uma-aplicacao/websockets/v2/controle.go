@@ -0,0 +1,102 @@
+package poquer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Comandos que controlam o relógio de blinds de uma partida
+const (
+	ComandoPausar    = "pausar"
+	ComandoContinuar = "continuar"
+	ComandoAvancar   = "avancar"
+	ComandoVoltar    = "voltar"
+)
+
+var (
+	// ErrPartidaFinalizada indica que a partida já terminou ou foi abandonada
+	ErrPartidaFinalizada = errors.New("a partida já terminou")
+	// ErrBlindsPausados indica que os blinds já estão pausados
+	ErrBlindsPausados = errors.New("os blinds já estão pausados")
+	// ErrBlindsEmAndamento indica que os blinds não estão pausados
+	ErrBlindsEmAndamento = errors.New("os blinds não estão pausados")
+	// ErrUltimoNivel indica que não há nível de blind depois do atual
+	ErrUltimoNivel = errors.New("a partida já está no último nível de blind")
+	// ErrPrimeiroNivel indica que não há nível de blind antes do atual
+	ErrPrimeiroNivel = errors.New("a partida já está no primeiro nível de blind")
+)
+
+// ControleDeBlinds manipula o relógio de blinds de uma partida em andamento
+type ControleDeBlinds interface {
+	Pausar() error
+	Continuar() error
+	AvancarNivel() error
+	VoltarNivel() error
+	Estado() EstadoDosBlinds
+}
+
+// EstadoDosBlinds descreve o nível de blind atual e quanto falta para o próximo
+type EstadoDosBlinds struct {
+	// Nivel começa em 1
+	Nivel  int
+	Blinds Blinds
+	// ProximosBlinds fica vazio no último nível e quando o próximo é um intervalo
+	ProximosBlinds Blinds
+	Restante       time.Duration
+	Pausado        bool
+	// Intervalo indica que a partida está em um intervalo; Blinds são os de antes dele
+	Intervalo bool
+}
+
+func (e EstadoDosBlinds) String() string {
+	estado := fmt.Sprintf("Nível %d: %v", e.Nivel, e.Blinds)
+
+	if e.Intervalo {
+		estado = fmt.Sprintf("Intervalo (%v)", e.Blinds)
+	}
+
+	if e.ProximosBlinds.BigBlind != 0 {
+		proximo := "próximo"
+		if e.ProximosBlinds.SmallBlind > 0 {
+			proximo = "próximos"
+		}
+		estado += fmt.Sprintf(", %s %v em %v", proximo, e.ProximosBlinds, e.Restante.Round(time.Second))
+	} else if e.Restante > 0 {
+		estado += fmt.Sprintf(", intervalo em %v", e.Restante.Round(time.Second))
+	}
+
+	if e.Pausado {
+		estado += " (pausado)"
+	}
+
+	return estado
+}
+
+// executarComando aplica um dos comandos de blind à partida
+func executarComando(controle ControleDeBlinds, comando string) error {
+	switch comando {
+	case ComandoPausar:
+		return controle.Pausar()
+	case ComandoContinuar:
+		return controle.Continuar()
+	case ComandoAvancar:
+		return controle.AvancarNivel()
+	case ComandoVoltar:
+		return controle.VoltarNivel()
+	default:
+		return errComandoDesconhecido(comando)
+	}
+}
+
+func errComandoDesconhecido(comando string) error {
+	return fmt.Errorf("comando %q desconhecido, use %s, %s, %s ou %s", comando, ComandoPausar, ComandoContinuar, ComandoAvancar, ComandoVoltar)
+}
+
+func comandoDeBlinds(texto string) bool {
+	switch texto {
+	case ComandoPausar, ComandoContinuar, ComandoAvancar, ComandoVoltar:
+		return true
+	}
+	return false
+}
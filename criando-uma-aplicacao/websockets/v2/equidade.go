@@ -0,0 +1,406 @@
+package poquer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrCartaRepetida indica que a mesma carta foi dada a mais de um jogador ou também está na mesa
+var ErrCartaRepetida = errors.New("a mesma carta aparece mais de uma vez")
+
+// SimulacoesPadrao é quantas mesas são sorteadas quando a equidade não é calculada por
+// todas as mesas possíveis
+const SimulacoesPadrao = 100000
+
+// simulacoesEntreCancelamentos é de quantas em quantas mesas sorteadas cada trabalhador
+// confere se o cálculo foi cancelado
+const simulacoesEntreCancelamentos = 1024
+
+// LimiteExaustivoPadrao é até quantas mesas possíveis a equidade é calculada por todas elas,
+// o que já cobre qualquer mão a partir do flop. Com mais mesas, elas são sorteadas
+const LimiteExaustivoPadrao = 50000
+
+// EquidadeDoJogador é quanto do pote as cartas fechadas de um jogador ganham: em quantas
+// mesas ele vence sozinho, em quantas divide o pote e a Equidade, o percentual do pote que
+// lhe cabe na média, contando cada empate como a sua parte da divisão
+type EquidadeDoJogador struct {
+	Cartas   []Carta `json:"cartas"`
+	Vitorias int     `json:"vitorias"`
+	Empates  int     `json:"empates"`
+	Equidade float64 `json:"equidade"`
+}
+
+// ResultadoDaEquidade tem a equidade de cada jogador, na ordem das cartas fechadas, e em
+// quantas mesas ela foi calculada. Exaustivo indica que todas as mesas possíveis foram
+// avaliadas e que o resultado é exato
+type ResultadoDaEquidade struct {
+	Exaustivo bool                `json:"exaustivo"`
+	Mesas     int                 `json:"mesas"`
+	Jogadores []EquidadeDoJogador `json:"jogadores"`
+}
+
+// Escrever mostra a equidade de cada jogador em uma tabela
+func (r ResultadoDaEquidade) Escrever(saida io.Writer) error {
+	tabela := tabwriter.NewWriter(saida, 0, 0, 2, ' ', 0)
+
+	calculo := "sorteadas"
+	if r.Exaustivo {
+		calculo = "possíveis"
+	}
+
+	fmt.Fprintf(tabela, "%d mesas %s\n", r.Mesas, calculo)
+	fmt.Fprintln(tabela, "cartas\tvitórias\tempates\tequidade %")
+	for _, jogador := range r.Jogadores {
+		fmt.Fprintf(tabela, "%s\t%d\t%d\t%.2f\n", EscreverCartas(jogador.Cartas), jogador.Vitorias, jogador.Empates, jogador.Equidade)
+	}
+
+	return tabela.Flush()
+}
+
+// LerMaos converte as cartas fechadas de vários jogadores, separadas por vírgulas, como
+// "Ah As, Kd Kc"
+func LerMaos(texto string) ([][]Carta, error) {
+	var fechadas [][]Carta
+
+	for _, mao := range strings.Split(texto, ",") {
+		cartas, err := LerCartas(mao)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fechadas = append(fechadas, cartas)
+	}
+
+	return fechadas, nil
+}
+
+// OpcaoEquidade altera como CalcularEquidade avalia as mesas
+type OpcaoEquidade func(*calculoDeEquidade)
+
+// ComSimulacoes define quantas mesas são sorteadas quando o cálculo não é exaustivo
+func ComSimulacoes(simulacoes int) OpcaoEquidade {
+	return func(c *calculoDeEquidade) {
+		c.simulacoes = simulacoes
+	}
+}
+
+// ComTrabalhadores define quantas goroutines avaliam as mesas; o padrão é uma por CPU
+func ComTrabalhadores(trabalhadores int) OpcaoEquidade {
+	return func(c *calculoDeEquidade) {
+		c.trabalhadores = trabalhadores
+	}
+}
+
+// ComSemente faz os sorteios repetirem as mesmas mesas para o mesmo número de trabalhadores
+func ComSemente(semente int64) OpcaoEquidade {
+	return func(c *calculoDeEquidade) {
+		c.semente = semente
+	}
+}
+
+// ComLimiteExaustivo define até quantas mesas possíveis o cálculo avalia todas elas; com 0,
+// as mesas são sempre sorteadas
+func ComLimiteExaustivo(limite int) OpcaoEquidade {
+	return func(c *calculoDeEquidade) {
+		c.limiteExaustivo = limite
+	}
+}
+
+// ComContexto interrompe o cálculo quando o contexto é cancelado; CalcularEquidade então
+// retorna o erro do contexto
+func ComContexto(contexto context.Context) OpcaoEquidade {
+	return func(c *calculoDeEquidade) {
+		c.contexto = contexto
+	}
+}
+
+type calculoDeEquidade struct {
+	contexto        context.Context
+	fechadas        [][]Carta
+	mesa            []Carta
+	restantes       []Carta
+	simulacoes      int
+	trabalhadores   int
+	semente         int64
+	limiteExaustivo int
+}
+
+// contagemDeEquidade acumula as vitórias, os empates e as partes do pote de cada jogador
+// nas mesas avaliadas por um trabalhador
+type contagemDeEquidade struct {
+	mesas    int
+	vitorias []int
+	empates  []int
+	partes   []float64
+	cartas   []Carta
+}
+
+// CalcularEquidade calcula a chance de cada jogador ganhar o pote com as suas cartas
+// fechadas e as cartas que já estão na mesa, completando a mesa de todas as formas
+// possíveis quando elas não passam do limite exaustivo ou sorteando as cartas que faltam
+func CalcularEquidade(fechadas [][]Carta, mesa []Carta, opcoes ...OpcaoEquidade) (ResultadoDaEquidade, error) {
+	calculo := &calculoDeEquidade{
+		contexto:        context.Background(),
+		fechadas:        fechadas,
+		mesa:            mesa,
+		simulacoes:      SimulacoesPadrao,
+		trabalhadores:   runtime.NumCPU(),
+		semente:         time.Now().UnixNano(),
+		limiteExaustivo: LimiteExaustivoPadrao,
+	}
+
+	for _, opcao := range opcoes {
+		opcao(calculo)
+	}
+
+	if err := calculo.validar(); err != nil {
+		return ResultadoDaEquidade{}, err
+	}
+
+	faltam := 5 - len(mesa)
+	exaustivo := combinacoesPossiveis(len(calculo.restantes), faltam) <= calculo.limiteExaustivo
+
+	var contagens []*contagemDeEquidade
+	if exaustivo {
+		contagens = calculo.avaliarTodas(faltam)
+	} else {
+		contagens = calculo.sortear(faltam)
+	}
+
+	if err := calculo.contexto.Err(); err != nil {
+		return ResultadoDaEquidade{}, fmt.Errorf("problema ao calcular a equidade, %w", err)
+	}
+
+	return calculo.resultado(exaustivo, contagens), nil
+}
+
+func (c *calculoDeEquidade) validar() error {
+	if len(c.fechadas) < 2 || len(c.fechadas) > MaximoDeLugares {
+		return fmt.Errorf("a equidade precisa de 2 a %d jogadores, obtido %d", MaximoDeLugares, len(c.fechadas))
+	}
+
+	switch len(c.mesa) {
+	case 0, 3, 4, 5:
+	default:
+		return fmt.Errorf("a mesa deve ter 0, 3, 4 ou 5 cartas, obtido %d", len(c.mesa))
+	}
+
+	if c.simulacoes < 1 {
+		return fmt.Errorf("o número de simulações deve ser positivo, obtido %d", c.simulacoes)
+	}
+
+	if c.trabalhadores < 1 {
+		c.trabalhadores = 1
+	}
+
+	usadas := map[Carta]bool{}
+	usar := func(carta Carta) error {
+		if carta.Valor < Dois || carta.Valor > As || carta.Naipe < Paus || carta.Naipe > Espadas {
+			return fmt.Errorf("carta %v inválida", carta)
+		}
+
+		if usadas[carta] {
+			return fmt.Errorf("%w: %v", ErrCartaRepetida, carta)
+		}
+		usadas[carta] = true
+		return nil
+	}
+
+	for i, cartas := range c.fechadas {
+		if len(cartas) != 2 {
+			return fmt.Errorf("o jogador %d deve ter 2 cartas fechadas, obtido %d", i+1, len(cartas))
+		}
+
+		for _, carta := range cartas {
+			if err := usar(carta); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, carta := range c.mesa {
+		if err := usar(carta); err != nil {
+			return err
+		}
+	}
+
+	c.restantes = nil
+	for _, carta := range NovoBaralho().cartas {
+		if !usadas[carta] {
+			c.restantes = append(c.restantes, carta)
+		}
+	}
+
+	return nil
+}
+
+// avaliarTodas divide as mesas possíveis entre os trabalhadores pela primeira carta que
+// falta: cada trabalhador recebe uma carta e completa a mesa com as que vêm depois dela.
+// Nenhuma carta nova é distribuída depois que o cálculo é cancelado
+func (c *calculoDeEquidade) avaliarTodas(faltam int) []*contagemDeEquidade {
+	if faltam == 0 {
+		contagem := c.novaContagem()
+		contagem.avaliar(c.fechadas, c.mesa)
+		return []*contagemDeEquidade{contagem}
+	}
+
+	primeiras := make(chan int)
+	contagens := make([]*contagemDeEquidade, c.trabalhadores)
+	var grupo sync.WaitGroup
+
+	for t := range contagens {
+		contagens[t] = c.novaContagem()
+		grupo.Add(1)
+
+		go func(contagem *contagemDeEquidade) {
+			defer grupo.Done()
+
+			mesa := append(append([]Carta(nil), c.mesa...), make([]Carta, faltam)...)
+			for primeira := range primeiras {
+				mesa[len(c.mesa)] = c.restantes[primeira]
+				c.completar(contagem, mesa, len(c.mesa)+1, primeira+1)
+			}
+		}(contagens[t])
+	}
+
+distribuir:
+	for primeira := 0; primeira <= len(c.restantes)-faltam; primeira++ {
+		select {
+		case primeiras <- primeira:
+		case <-c.contexto.Done():
+			break distribuir
+		}
+	}
+	close(primeiras)
+	grupo.Wait()
+
+	return contagens
+}
+
+// completar preenche a mesa a partir da posição com as cartas restantes a partir de inicio,
+// avaliando cada mesa completa
+func (c *calculoDeEquidade) completar(contagem *contagemDeEquidade, mesa []Carta, posicao, inicio int) {
+	if posicao == len(mesa) {
+		contagem.avaliar(c.fechadas, mesa)
+		return
+	}
+
+	for i := inicio; i <= len(c.restantes)-(len(mesa)-posicao); i++ {
+		mesa[posicao] = c.restantes[i]
+		c.completar(contagem, mesa, posicao+1, i+1)
+	}
+}
+
+// sortear divide as simulações entre os trabalhadores, cada um com o seu gerador de números
+// aleatórios derivado da semente
+func (c *calculoDeEquidade) sortear(faltam int) []*contagemDeEquidade {
+	contagens := make([]*contagemDeEquidade, c.trabalhadores)
+	var grupo sync.WaitGroup
+
+	for t := range contagens {
+		simulacoes := c.simulacoes / c.trabalhadores
+		if t < c.simulacoes%c.trabalhadores {
+			simulacoes++
+		}
+
+		contagens[t] = c.novaContagem()
+		grupo.Add(1)
+
+		go func(contagem *contagemDeEquidade, aleatorio *rand.Rand, simulacoes int) {
+			defer grupo.Done()
+
+			baralho := append([]Carta(nil), c.restantes...)
+			mesa := append(append([]Carta(nil), c.mesa...), make([]Carta, faltam)...)
+
+			for s := 0; s < simulacoes; s++ {
+				if s%simulacoesEntreCancelamentos == 0 && c.contexto.Err() != nil {
+					return
+				}
+
+				for i := 0; i < faltam; i++ {
+					j := i + aleatorio.Intn(len(baralho)-i)
+					baralho[i], baralho[j] = baralho[j], baralho[i]
+					mesa[len(c.mesa)+i] = baralho[i]
+				}
+				contagem.avaliar(c.fechadas, mesa)
+			}
+		}(contagens[t], rand.New(rand.NewSource(c.semente+int64(t))), simulacoes)
+	}
+
+	grupo.Wait()
+	return contagens
+}
+
+func (c *calculoDeEquidade) novaContagem() *contagemDeEquidade {
+	return &contagemDeEquidade{
+		vitorias: make([]int, len(c.fechadas)),
+		empates:  make([]int, len(c.fechadas)),
+		partes:   make([]float64, len(c.fechadas)),
+		cartas:   make([]Carta, 7),
+	}
+}
+
+// avaliar soma uma mesa completa à contagem. As cartas já foram validadas, então Avaliar
+// não tem como falhar
+func (c *contagemDeEquidade) avaliar(fechadas [][]Carta, mesa []Carta) {
+	combinacoes := make([]Combinacao, len(fechadas))
+
+	copy(c.cartas[2:], mesa)
+	for i, cartas := range fechadas {
+		copy(c.cartas, cartas)
+		combinacoes[i], _ = Avaliar(c.cartas)
+	}
+
+	vencedores := Vencedores(combinacoes)
+	for _, vencedor := range vencedores {
+		if len(vencedores) == 1 {
+			c.vitorias[vencedor]++
+		} else {
+			c.empates[vencedor]++
+		}
+		c.partes[vencedor] += 1 / float64(len(vencedores))
+	}
+
+	c.mesas++
+}
+
+// resultado junta as contagens dos trabalhadores, sempre na mesma ordem para que a mesma
+// semente dê o mesmo resultado
+func (c *calculoDeEquidade) resultado(exaustivo bool, contagens []*contagemDeEquidade) ResultadoDaEquidade {
+	resultado := ResultadoDaEquidade{Exaustivo: exaustivo, Jogadores: make([]EquidadeDoJogador, len(c.fechadas))}
+	partes := make([]float64, len(c.fechadas))
+
+	for _, contagem := range contagens {
+		resultado.Mesas += contagem.mesas
+		for i := range c.fechadas {
+			resultado.Jogadores[i].Vitorias += contagem.vitorias[i]
+			resultado.Jogadores[i].Empates += contagem.empates[i]
+			partes[i] += contagem.partes[i]
+		}
+	}
+
+	for i, cartas := range c.fechadas {
+		resultado.Jogadores[i].Cartas = cartas
+		resultado.Jogadores[i].Equidade = 100 * partes[i] / float64(resultado.Mesas)
+	}
+
+	return resultado
+}
+
+// combinacoesPossiveis é de quantas formas k cartas podem ser escolhidas entre n
+func combinacoesPossiveis(n, k int) int {
+	combinacoes := 1
+	for i := 0; i < k; i++ {
+		combinacoes = combinacoes * (n - i) / (i + 1)
+	}
+	return combinacoes
+}
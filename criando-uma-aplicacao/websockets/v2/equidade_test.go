@@ -0,0 +1,309 @@
+package poquer_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestCalcularEquidade(t *testing.T) {
+	t.Run("ases contra reis antes do flop", func(t *testing.T) {
+		resultado := deveCalcularEquidade(t, []string{"Ah As", "Kd Kc"}, "", poquer.ComSimulacoes(20000), poquer.ComSemente(1))
+
+		if resultado.Exaustivo || resultado.Mesas != 20000 {
+			t.Errorf("esperava 20000 mesas sorteadas, obtido %+v", resultado)
+		}
+
+		verificaEquidade(t, resultado, 1.5, 81.9, 18.1)
+	})
+
+	t.Run("avalia todas as mesas a partir do flop", func(t *testing.T) {
+		// os reis só vencem com o último rei sem o último ás, 43 das 990 mesas
+		resultado := deveCalcularEquidade(t, []string{"Ah Ad", "Kh Kd"}, "Ac Kc 2s")
+
+		if !resultado.Exaustivo || resultado.Mesas != 990 {
+			t.Fatalf("esperava as 990 mesas possíveis, obtido %+v", resultado)
+		}
+
+		if resultado.Jogadores[0].Vitorias != 947 || resultado.Jogadores[1].Vitorias != 43 {
+			t.Errorf("obtido %+v", resultado.Jogadores)
+		}
+
+		verificaEquidade(t, resultado, 1e-9, 100*947/990.0, 100*43/990.0)
+	})
+
+	t.Run("a mesa completa tem um único resultado", func(t *testing.T) {
+		resultado := deveCalcularEquidade(t, []string{"Ah Kh", "Qs Qd"}, "2c 7d 9h Ts 3s")
+
+		if !resultado.Exaustivo || resultado.Mesas != 1 {
+			t.Fatalf("esperava uma mesa, obtido %+v", resultado)
+		}
+
+		verificaEquidade(t, resultado, 0, 0, 100)
+	})
+
+	t.Run("quem joga com a mesa divide o pote", func(t *testing.T) {
+		resultado := deveCalcularEquidade(t, []string{"2c 3d", "2h 3s", "4c 5c"}, "Ah Kh Qh Jh Th")
+
+		for i, jogador := range resultado.Jogadores {
+			if jogador.Vitorias != 0 || jogador.Empates != 1 {
+				t.Errorf("o jogador %d deveria empatar, obtido %+v", i+1, jogador)
+			}
+		}
+
+		verificaEquidade(t, resultado, 1e-9, 100/3.0, 100/3.0, 100/3.0)
+	})
+
+	t.Run("a mesma semente repete os sorteios", func(t *testing.T) {
+		calcular := func() poquer.ResultadoDaEquidade {
+			return deveCalcularEquidade(t, []string{"7c 2d", "9h 8h", "As Ks"}, "", poquer.ComSimulacoes(5000), poquer.ComSemente(7), poquer.ComTrabalhadores(3))
+		}
+
+		if primeiro, segundo := calcular(), calcular(); !reflect.DeepEqual(primeiro, segundo) {
+			t.Errorf("obtido %+v e %+v", primeiro, segundo)
+		}
+	})
+
+	t.Run("o número de trabalhadores não muda o resultado exato", func(t *testing.T) {
+		sozinho := deveCalcularEquidade(t, []string{"Ah Ad", "Kh Kd"}, "Ac Kc 2s", poquer.ComTrabalhadores(1))
+		emParalelo := deveCalcularEquidade(t, []string{"Ah Ad", "Kh Kd"}, "Ac Kc 2s", poquer.ComTrabalhadores(4))
+
+		if sozinho.Mesas != emParalelo.Mesas || sozinho.Jogadores[1].Vitorias != emParalelo.Jogadores[1].Vitorias {
+			t.Errorf("obtido %+v e %+v", sozinho, emParalelo)
+		}
+	})
+
+	t.Run("sorteia as mesas quando o limite exaustivo é zero", func(t *testing.T) {
+		resultado := deveCalcularEquidade(t, []string{"Ah Ad", "Kh Kd"}, "Ac Kc 2s", poquer.ComLimiteExaustivo(0), poquer.ComSimulacoes(3000), poquer.ComSemente(2))
+
+		if resultado.Exaustivo || resultado.Mesas != 3000 {
+			t.Fatalf("esperava 3000 mesas sorteadas, obtido %+v", resultado)
+		}
+
+		verificaEquidade(t, resultado, 2, 100*947/990.0, 100*43/990.0)
+	})
+
+	t.Run("para quando o contexto é cancelado", func(t *testing.T) {
+		contexto, cancelar := context.WithCancel(context.Background())
+		cancelar()
+
+		casos := map[string]string{"sorteando": "", "avaliando todas": "Ac Kc 2s"}
+
+		for nome, mesa := range casos {
+			_, err := poquer.CalcularEquidade(lerMaos(t, []string{"Ah Ad", "Kh Kd"}), deveLerCartas(t, mesa), poquer.ComContexto(contexto))
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("%s: esperava %v, obtido %v", nome, context.Canceled, err)
+			}
+		}
+	})
+
+	t.Run("recusa cartas e jogadores inválidos", func(t *testing.T) {
+		casos := map[string]struct {
+			jogadores []string
+			mesa      string
+			opcoes    []poquer.OpcaoEquidade
+		}{
+			"um jogador":           {[]string{"Ah As"}, "", nil},
+			"três cartas fechadas": {[]string{"Ah As Ad", "Kd Kc"}, "", nil},
+			"mesa com duas cartas": {[]string{"Ah As", "Kd Kc"}, "2c 3c", nil},
+			"sem simulações":       {[]string{"Ah As", "Kd Kc"}, "", []poquer.OpcaoEquidade{poquer.ComSimulacoes(0)}},
+		}
+
+		for nome, caso := range casos {
+			if _, err := poquer.CalcularEquidade(lerMaos(t, caso.jogadores), deveLerCartas(t, caso.mesa), caso.opcoes...); err == nil {
+				t.Errorf("esperava um erro para %s", nome)
+			}
+		}
+
+		if _, err := poquer.LerMaos("Ah As, Kd Zz"); err == nil {
+			t.Error("esperava um erro para a carta Zz")
+		}
+
+		_, err := poquer.CalcularEquidade(lerMaos(t, []string{"Ah As", "Kd Ah"}), nil)
+		verificaErro(t, err, poquer.ErrCartaRepetida)
+
+		_, err = poquer.CalcularEquidade(lerMaos(t, []string{"Ah As", "Kd Kc"}), deveLerCartas(t, "2c 3c Kc"))
+		verificaErro(t, err, poquer.ErrCartaRepetida)
+	})
+}
+
+func TestEscreverEquidade(t *testing.T) {
+	resultado := deveCalcularEquidade(t, []string{"Ah Ad", "Kh Kd"}, "Ac Kc 2s")
+
+	saida := &bytes.Buffer{}
+	resultado.Escrever(saida)
+
+	esperado := strings.Join([]string{
+		"990 mesas possíveis",
+		"cartas  vitórias  empates  equidade %",
+		"Ah Ad   947       0        95.66",
+		"Kh Kd   43        0        4.34",
+		"",
+	}, "\n")
+
+	if saida.String() != esperado {
+		t.Errorf("obtido\n%s\nesperado\n%s", saida.String(), esperado)
+	}
+}
+
+func TestCalcularEquidadePeloServidor(t *testing.T) {
+	servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogoTosco)
+
+	t.Run("retorna a equidade de cada jogador", func(t *testing.T) {
+		corpo := `{"jogadores": [["Ah", "Ad"], ["Kh", "Kd"]], "mesa": ["Ac", "Kc", "2s"]}`
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, httptest.NewRequest(http.MethodPost, "/equidade", strings.NewReader(corpo)))
+
+		verificaStatus(t, resposta, http.StatusOK)
+		verificaTipoDoConteudo(t, resposta, "application/json")
+
+		var resultado poquer.ResultadoDaEquidade
+		if err := json.NewDecoder(resposta.Body).Decode(&resultado); err != nil {
+			t.Fatalf("não foi possível ler a resposta, %v", err)
+		}
+
+		if !resultado.Exaustivo || resultado.Jogadores[1].Vitorias != 43 || poquer.EscreverCartas(resultado.Jogadores[1].Cartas) != "Kh Kd" {
+			t.Errorf("obtido %+v", resultado)
+		}
+	})
+
+	casos := map[string]struct {
+		metodo string
+		corpo  string
+		status int
+	}{
+		"método não aceito":   {http.MethodGet, "", http.StatusMethodNotAllowed},
+		"JSON inválido":       {http.MethodPost, `{"jogadores": `, http.StatusBadRequest},
+		"carta inválida":      {http.MethodPost, `{"jogadores": [["Ah", "Zz"], ["Kh", "Kd"]]}`, http.StatusBadRequest},
+		"carta repetida":      {http.MethodPost, `{"jogadores": [["Ah", "Ad"], ["Ah", "Kd"]]}`, http.StatusBadRequest},
+		"simulações demais":   {http.MethodPost, `{"jogadores": [["Ah", "Ad"], ["Kh", "Kd"]], "simulacoes": 100000000}`, http.StatusBadRequest},
+		"corpo grande demais": {http.MethodPost, `{"jogadores": [["Ah", "Ad"], ["Kh", "Kd"]]` + strings.Repeat(" ", 5000) + `}`, http.StatusBadRequest},
+	}
+
+	for nome, caso := range casos {
+		t.Run(nome, func(t *testing.T) {
+			resposta := httptest.NewRecorder()
+			servidor.ServeHTTP(resposta, httptest.NewRequest(caso.metodo, "/equidade", strings.NewReader(caso.corpo)))
+			verificaStatus(t, resposta, caso.status)
+		})
+	}
+
+	t.Run("retorna 429 quando um cliente pede cálculos demais", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogoTosco, poquer.ComLimitesDeTaxa(
+			poquer.NovoLimitadorDeTaxa(1, 10*time.Second, relogio),
+			poquer.NovoLimitadorDeTaxa(10, time.Second, relogio),
+		))
+
+		servidor.ServeHTTP(httptest.NewRecorder(), novoPedidoDeEquidade(context.Background()))
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novoPedidoDeEquidade(context.Background()))
+
+		verificaStatus(t, resposta, http.StatusTooManyRequests)
+		verificaRetryAfter(t, resposta, "10")
+	})
+
+	t.Run("retorna 503 quando não há vaga para outro cálculo", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogoTosco, poquer.ComCalculosDeEquidadeSimultaneos(0))
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novoPedidoDeEquidade(context.Background()))
+
+		verificaStatus(t, resposta, http.StatusServiceUnavailable)
+		verificaRetryAfter(t, resposta, "1")
+	})
+
+	t.Run("não responde a quem desistiu do pedido", func(t *testing.T) {
+		contexto, cancelar := context.WithCancel(context.Background())
+		cancelar()
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novoPedidoDeEquidade(contexto))
+
+		if resposta.Body.Len() != 0 {
+			t.Errorf("esperava nenhuma resposta, obtido %q", resposta.Body.String())
+		}
+	})
+}
+
+func novoPedidoDeEquidade(contexto context.Context) *http.Request {
+	corpo := `{"jogadores": [["Ah", "As"], ["Kh", "Kd"]], "simulacoes": 1000}`
+	return httptest.NewRequest(http.MethodPost, "/equidade", strings.NewReader(corpo)).WithContext(contexto)
+}
+
+func BenchmarkCalcularEquidade(b *testing.B) {
+	casos := []struct {
+		nome      string
+		jogadores []string
+		mesa      string
+		opcoes    []poquer.OpcaoEquidade
+	}{
+		{"pré-flop sorteado com um trabalhador", []string{"Ah As", "Kd Kc"}, "", []poquer.OpcaoEquidade{poquer.ComSimulacoes(10000), poquer.ComTrabalhadores(1)}},
+		{"pré-flop sorteado em paralelo", []string{"Ah As", "Kd Kc"}, "", []poquer.OpcaoEquidade{poquer.ComSimulacoes(10000), poquer.ComTrabalhadores(runtime.NumCPU())}},
+		{"flop exaustivo com um trabalhador", []string{"Ah Ad", "Kh Kd", "9s 8s"}, "Ac Kc 2s", []poquer.OpcaoEquidade{poquer.ComTrabalhadores(1)}},
+		{"flop exaustivo em paralelo", []string{"Ah Ad", "Kh Kd", "9s 8s"}, "Ac Kc 2s", []poquer.OpcaoEquidade{poquer.ComTrabalhadores(runtime.NumCPU())}},
+	}
+
+	for _, caso := range casos {
+		fechadas := lerMaos(b, caso.jogadores)
+		mesa := deveLerCartas(b, caso.mesa)
+
+		b.Run(caso.nome, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := poquer.CalcularEquidade(fechadas, mesa, caso.opcoes...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func deveCalcularEquidade(t *testing.T, jogadores []string, mesa string, opcoes ...poquer.OpcaoEquidade) poquer.ResultadoDaEquidade {
+	t.Helper()
+
+	resultado, err := poquer.CalcularEquidade(lerMaos(t, jogadores), deveLerCartas(t, mesa), opcoes...)
+	verificaSemErro(t, err)
+
+	return resultado
+}
+
+func lerMaos(t testing.TB, jogadores []string) [][]poquer.Carta {
+	t.Helper()
+
+	fechadas, err := poquer.LerMaos(strings.Join(jogadores, ","))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fechadas
+}
+
+func verificaEquidade(t *testing.T, resultado poquer.ResultadoDaEquidade, tolerancia float64, esperadas ...float64) {
+	t.Helper()
+
+	total := 0.0
+	for i, esperada := range esperadas {
+		obtida := resultado.Jogadores[i].Equidade
+		total += obtida
+
+		if math.Abs(obtida-esperada) > tolerancia {
+			t.Errorf("o jogador %d deveria ter %.2f%% de equidade, obtido %.2f%%", i+1, esperada, obtida)
+		}
+	}
+
+	if math.Abs(total-100) > 1e-9 {
+		t.Errorf("as equidades deveriam somar 100%%, obtido %.4f%%", total)
+	}
+}
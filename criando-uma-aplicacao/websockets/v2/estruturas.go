@@ -0,0 +1,395 @@
+package poquer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// EstruturaPadrao é a estrutura usada quando nenhuma é escolhida
+const EstruturaPadrao = "padrao"
+
+// ErrEstruturaDesconhecida indica que não há estrutura de blinds com o nome pedido
+var ErrEstruturaDesconhecida = errors.New("estrutura de blinds desconhecida")
+
+// Duracao é uma time.Duration escrita em JSON como texto, por exemplo "15m" ou "1h30m"
+type Duracao time.Duration
+
+// MarshalJSON escreve a duração como texto
+func (d Duracao) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON lê a duração no formato de time.ParseDuration
+func (d *Duracao) UnmarshalJSON(dados []byte) error {
+	var texto string
+
+	if err := json.Unmarshal(dados, &texto); err != nil {
+		return fmt.Errorf("duração deve ser um texto como \"15m\", %v", err)
+	}
+
+	duracao, err := time.ParseDuration(texto)
+
+	if err != nil {
+		return fmt.Errorf("duração %q inválida, %v", texto, err)
+	}
+
+	*d = Duracao(duracao)
+	return nil
+}
+
+// Blinds são as apostas obrigatórias de um nível. Sem small blind, o nível tem um único
+// blind, o BigBlind, como nas primeiras versões do jogo
+type Blinds struct {
+	SmallBlind int `json:"smallBlind,omitempty"`
+	BigBlind   int `json:"bigBlind,omitempty"`
+	Ante       int `json:"ante,omitempty"`
+}
+
+func (b Blinds) String() string {
+	texto := fmt.Sprintf("blind %d", b.BigBlind)
+
+	if b.SmallBlind > 0 {
+		texto = fmt.Sprintf("blinds %d/%d", b.SmallBlind, b.BigBlind)
+	}
+
+	if b.Ante > 0 {
+		texto += fmt.Sprintf(" ante %d", b.Ante)
+	}
+
+	return texto
+}
+
+// NivelDeBlind é um nível de uma estrutura de blinds. Um intervalo não tem blinds: os
+// do nível anterior continuam valendo até ele acabar
+type NivelDeBlind struct {
+	Blinds
+	Duracao   Duracao `json:"duracao,omitempty"`
+	Intervalo bool    `json:"intervalo,omitempty"`
+}
+
+// EstruturaDeBlinds define os níveis de blind de uma partida e quanto tempo cada um dura
+type EstruturaDeBlinds struct {
+	Nome      string `json:"nome"`
+	Descricao string `json:"descricao,omitempty"`
+	// DuracaoDoNivel vale para os níveis sem duração própria. Zero faz cada nível durar
+	// 5 minutos mais um minuto por jogador
+	DuracaoDoNivel Duracao        `json:"duracaoDoNivel,omitempty"`
+	Niveis         []NivelDeBlind `json:"niveis"`
+}
+
+// Validar confere se a estrutura pode ser usada em uma partida
+func (e EstruturaDeBlinds) Validar() error {
+	if e.Nome == "" {
+		return errors.New("a estrutura precisa de um nome")
+	}
+
+	if len(e.Niveis) == 0 {
+		return fmt.Errorf("a estrutura %s precisa de pelo menos um nível", e.Nome)
+	}
+
+	if e.DuracaoDoNivel < 0 {
+		return fmt.Errorf("a estrutura %s tem duracaoDoNivel negativa", e.Nome)
+	}
+
+	blindAnterior := 0
+	for i, nivel := range e.Niveis {
+		if err := nivel.validar(blindAnterior); err != nil {
+			return fmt.Errorf("estrutura %s, nível %d: %v", e.Nome, i+1, err)
+		}
+
+		if i == 0 && nivel.Intervalo {
+			return fmt.Errorf("estrutura %s: a partida não pode começar com um intervalo", e.Nome)
+		}
+
+		if !nivel.Intervalo {
+			blindAnterior = nivel.BigBlind
+		}
+	}
+
+	return nil
+}
+
+func (n NivelDeBlind) validar(blindAnterior int) error {
+	if n.Duracao < 0 {
+		return errors.New("a duração não pode ser negativa")
+	}
+
+	if n.Intervalo {
+		if n.Blinds != (Blinds{}) {
+			return errors.New("um intervalo não tem blinds nem ante")
+		}
+		if n.Duracao == 0 {
+			return errors.New("um intervalo precisa de duração")
+		}
+		return nil
+	}
+
+	if n.BigBlind <= 0 {
+		return fmt.Errorf("o big blind deve ser positivo, obtido %d", n.BigBlind)
+	}
+
+	if n.BigBlind < blindAnterior {
+		return fmt.Errorf("o big blind %d é menor que o do nível anterior, %d", n.BigBlind, blindAnterior)
+	}
+
+	if n.SmallBlind < 0 || n.SmallBlind > n.BigBlind {
+		return fmt.Errorf("o small blind deve ficar entre 0 e o big blind, obtido %d", n.SmallBlind)
+	}
+
+	if n.Ante < 0 || n.Ante > n.BigBlind {
+		return fmt.Errorf("o ante deve ficar entre 0 e o big blind, obtido %d", n.Ante)
+	}
+
+	return nil
+}
+
+// duracaoDoNivel calcula quanto tempo o nível dura em uma partida com o número de jogadores
+func (e EstruturaDeBlinds) duracaoDoNivel(nivel, numeroDeJogadores int) time.Duration {
+	if duracao := e.Niveis[nivel].Duracao; duracao > 0 {
+		return time.Duration(duracao)
+	}
+
+	if e.DuracaoDoNivel > 0 {
+		return time.Duration(e.DuracaoDoNivel)
+	}
+
+	return time.Duration(5+numeroDeJogadores) * time.Minute
+}
+
+// blindsEm retorna os blinds que valem no nível; durante um intervalo, valem os do nível anterior
+func (e EstruturaDeBlinds) blindsEm(nivel int) Blinds {
+	for ; nivel >= 0; nivel-- {
+		if !e.Niveis[nivel].Intervalo {
+			return e.Niveis[nivel].Blinds
+		}
+	}
+	return Blinds{}
+}
+
+// LerEstrutura decodifica uma estrutura de blinds em JSON e a valida
+func LerEstrutura(leitor io.Reader) (EstruturaDeBlinds, error) {
+	var estrutura EstruturaDeBlinds
+
+	decodificador := json.NewDecoder(leitor)
+	decodificador.DisallowUnknownFields()
+
+	if err := decodificador.Decode(&estrutura); err != nil {
+		return estrutura, fmt.Errorf("problema ao ler a estrutura de blinds, %v", err)
+	}
+
+	return estrutura, estrutura.Validar()
+}
+
+// LerEstruturaYAML decodifica uma estrutura de blinds em YAML, com os mesmos campos e a
+// mesma validação de LerEstrutura
+func LerEstruturaYAML(leitor io.Reader) (EstruturaDeBlinds, error) {
+	dados, err := ioutil.ReadAll(leitor)
+
+	if err != nil {
+		return EstruturaDeBlinds{}, fmt.Errorf("problema ao ler a estrutura de blinds, %v", err)
+	}
+
+	convertida, err := yamlParaJSON(dados)
+
+	if err != nil {
+		return EstruturaDeBlinds{}, fmt.Errorf("problema ao ler a estrutura de blinds, %v", err)
+	}
+
+	return LerEstrutura(bytes.NewReader(convertida))
+}
+
+// CarregarEstruturasDoDiretorio lê todas as estruturas dos arquivos .json, .yaml e .yml do
+// diretório
+func CarregarEstruturasDoDiretorio(diretorio string) ([]EstruturaDeBlinds, error) {
+	var arquivos []string
+
+	for _, padrao := range []string{"*.json", "*.yaml", "*.yml"} {
+		encontrados, err := filepath.Glob(filepath.Join(diretorio, padrao))
+
+		if err != nil {
+			return nil, fmt.Errorf("problema ao listar as estruturas em %s, %v", diretorio, err)
+		}
+
+		arquivos = append(arquivos, encontrados...)
+	}
+
+	sort.Strings(arquivos)
+
+	var estruturas []EstruturaDeBlinds
+
+	for _, caminho := range arquivos {
+		estrutura, err := carregarEstrutura(caminho)
+
+		if err != nil {
+			return nil, err
+		}
+
+		estruturas = append(estruturas, estrutura)
+	}
+
+	return estruturas, nil
+}
+
+func carregarEstrutura(caminho string) (EstruturaDeBlinds, error) {
+	arquivo, err := os.Open(caminho)
+
+	if err != nil {
+		return EstruturaDeBlinds{}, fmt.Errorf("problema ao abrir a estrutura %s, %v", caminho, err)
+	}
+	defer arquivo.Close()
+
+	ler := LerEstrutura
+	if extensao := filepath.Ext(caminho); extensao == ".yaml" || extensao == ".yml" {
+		ler = LerEstruturaYAML
+	}
+
+	estrutura, err := ler(arquivo)
+
+	if err != nil {
+		return estrutura, fmt.Errorf("%s: %v", caminho, err)
+	}
+
+	return estrutura, nil
+}
+
+// CatalogoDeEstruturas guarda as estruturas de blinds que podem ser escolhidas para uma partida.
+// Ele não muda depois de criado, então pode ser lido por várias partidas ao mesmo tempo
+type CatalogoDeEstruturas struct {
+	estruturas map[string]EstruturaDeBlinds
+}
+
+// NovoCatalogoDeEstruturas cria um catálogo com as estruturas predefinidas mais as informadas.
+// Uma estrutura com o nome de uma predefinida a substitui
+func NovoCatalogoDeEstruturas(estruturas ...EstruturaDeBlinds) (*CatalogoDeEstruturas, error) {
+	catalogo := &CatalogoDeEstruturas{estruturas: map[string]EstruturaDeBlinds{}}
+
+	for _, estrutura := range EstruturasPredefinidas() {
+		catalogo.estruturas[estrutura.Nome] = estrutura
+	}
+
+	vistas := map[string]bool{}
+	for _, estrutura := range estruturas {
+		if err := estrutura.Validar(); err != nil {
+			return nil, err
+		}
+
+		if vistas[estrutura.Nome] {
+			return nil, fmt.Errorf("a estrutura %s foi definida mais de uma vez", estrutura.Nome)
+		}
+
+		vistas[estrutura.Nome] = true
+		catalogo.estruturas[estrutura.Nome] = estrutura
+	}
+
+	return catalogo, nil
+}
+
+// CatalogoDeEstruturasDoDiretorio cria um catálogo com as predefinidas e as estruturas dos
+// arquivos .json, .yaml e .yml do diretório; sem diretório, apenas as predefinidas
+func CatalogoDeEstruturasDoDiretorio(diretorio string) (*CatalogoDeEstruturas, error) {
+	if diretorio == "" {
+		return NovoCatalogoDeEstruturas()
+	}
+
+	estruturas, err := CarregarEstruturasDoDiretorio(diretorio)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NovoCatalogoDeEstruturas(estruturas...)
+}
+
+// Estrutura retorna a estrutura com o nome; um nome vazio retorna a EstruturaPadrao
+func (c *CatalogoDeEstruturas) Estrutura(nome string) (EstruturaDeBlinds, error) {
+	if nome == "" {
+		nome = EstruturaPadrao
+	}
+
+	estrutura, existe := c.estruturas[nome]
+
+	if !existe {
+		return estrutura, fmt.Errorf("%w: %s", ErrEstruturaDesconhecida, nome)
+	}
+
+	return estrutura, nil
+}
+
+// Estruturas retorna todas as estruturas ordenadas pelo nome
+func (c *CatalogoDeEstruturas) Estruturas() []EstruturaDeBlinds {
+	estruturas := make([]EstruturaDeBlinds, 0, len(c.estruturas))
+	for _, estrutura := range c.estruturas {
+		estruturas = append(estruturas, estrutura)
+	}
+
+	sort.Slice(estruturas, func(i, j int) bool {
+		return estruturas[i].Nome < estruturas[j].Nome
+	})
+
+	return estruturas
+}
+
+// EstruturasPredefinidas são as estruturas disponíveis sem nenhum arquivo. A padrão mantém
+// o blind único das primeiras versões do jogo
+func EstruturasPredefinidas() []EstruturaDeBlinds {
+	return []EstruturaDeBlinds{
+		{
+			Nome:      EstruturaPadrao,
+			Descricao: "Blind único de 100 a 8000, com níveis de 5 minutos mais um por jogador",
+			Niveis:    niveisComBlindUnico(100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000),
+		},
+		{
+			Nome:           "turbo",
+			Descricao:      "Small e big blinds de 50/100 a 4000/8000 com níveis de 5 minutos",
+			DuracaoDoNivel: Duracao(5 * time.Minute),
+			Niveis:         niveisComSmallBlind(100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000),
+		},
+		{
+			Nome:           "torneio",
+			Descricao:      "Níveis de 20 minutos com antes a partir do quinto nível e intervalos de 10 minutos",
+			DuracaoDoNivel: Duracao(20 * time.Minute),
+			Niveis: []NivelDeBlind{
+				nivel(25, 50, 0), nivel(50, 100, 0), nivel(75, 150, 0), nivel(100, 200, 0),
+				{Intervalo: true, Duracao: Duracao(10 * time.Minute)},
+				nivel(150, 300, 25), nivel(200, 400, 50), nivel(300, 600, 75), nivel(400, 800, 100),
+				{Intervalo: true, Duracao: Duracao(10 * time.Minute)},
+				nivel(600, 1200, 200), nivel(800, 1600, 200), nivel(1000, 2000, 300), nivel(1500, 3000, 400),
+				nivel(2000, 4000, 500), nivel(3000, 6000, 1000),
+			},
+		},
+	}
+}
+
+func nivel(smallBlind, bigBlind, ante int) NivelDeBlind {
+	return NivelDeBlind{Blinds: Blinds{SmallBlind: smallBlind, BigBlind: bigBlind, Ante: ante}}
+}
+
+func niveisComBlindUnico(blinds ...int) []NivelDeBlind {
+	niveis := make([]NivelDeBlind, len(blinds))
+	for i, blind := range blinds {
+		niveis[i] = nivel(0, blind, 0)
+	}
+	return niveis
+}
+
+// niveisComSmallBlind cria níveis com small blind de metade do big blind
+func niveisComSmallBlind(bigBlinds ...int) []NivelDeBlind {
+	niveis := make([]NivelDeBlind, len(bigBlinds))
+	for i, bigBlind := range bigBlinds {
+		niveis[i] = nivel(bigBlind/2, bigBlind, 0)
+	}
+	return niveis
+}
+
+// FonteDeEstruturas pode ser implementada por um Jogo para informar as estruturas que aceita
+type FonteDeEstruturas interface {
+	Estruturas() []EstruturaDeBlinds
+}
@@ -0,0 +1,334 @@
+package poquer_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+const estruturaCaseira = `{
+	"nome": "caseira",
+	"descricao": "Jogo de quinta-feira",
+	"duracaoDoNivel": "15m",
+	"niveis": [
+		{"bigBlind": 50},
+		{"smallBlind": 50, "bigBlind": 100, "duracao": "20m"},
+		{"intervalo": true, "duracao": "10m"},
+		{"smallBlind": 100, "bigBlind": 200, "ante": 25}
+	]
+}`
+
+func TestLerEstrutura(t *testing.T) {
+	t.Run("lê níveis, small e big blinds, antes, intervalos e durações", func(t *testing.T) {
+		estrutura, err := poquer.LerEstrutura(strings.NewReader(estruturaCaseira))
+		verificaSemErro(t, err)
+
+		esperado := poquer.EstruturaDeBlinds{
+			Nome:           "caseira",
+			Descricao:      "Jogo de quinta-feira",
+			DuracaoDoNivel: poquer.Duracao(15 * time.Minute),
+			Niveis: []poquer.NivelDeBlind{
+				{Blinds: blindUnico(50)},
+				{Blinds: poquer.Blinds{SmallBlind: 50, BigBlind: 100}, Duracao: poquer.Duracao(20 * time.Minute)},
+				{Intervalo: true, Duracao: poquer.Duracao(10 * time.Minute)},
+				{Blinds: poquer.Blinds{SmallBlind: 100, BigBlind: 200, Ante: 25}},
+			},
+		}
+
+		if !reflect.DeepEqual(estrutura, esperado) {
+			t.Errorf("obtido %+v, esperado %+v", estrutura, esperado)
+		}
+	})
+
+	invalidas := map[string]string{
+		"sem nome":                    `{"niveis": [{"bigBlind": 100}]}`,
+		"sem níveis":                  `{"nome": "vazia", "niveis": []}`,
+		"big blind zero":              `{"nome": "x", "niveis": [{"smallBlind": 0}]}`,
+		"big blind diminuindo":        `{"nome": "x", "niveis": [{"bigBlind": 200}, {"bigBlind": 100}]}`,
+		"small blind maior que o big": `{"nome": "x", "niveis": [{"smallBlind": 200, "bigBlind": 100}]}`,
+		"ante maior que o big blind":  `{"nome": "x", "niveis": [{"bigBlind": 100, "ante": 150}]}`,
+		"começa com intervalo":        `{"nome": "x", "niveis": [{"intervalo": true, "duracao": "5m"}, {"bigBlind": 100}]}`,
+		"intervalo sem duração":       `{"nome": "x", "niveis": [{"bigBlind": 100}, {"intervalo": true}]}`,
+		"intervalo com blind":         `{"nome": "x", "niveis": [{"bigBlind": 100}, {"intervalo": true, "bigBlind": 200, "duracao": "5m"}]}`,
+		"duração inválida":            `{"nome": "x", "niveis": [{"bigBlind": 100, "duracao": "quinze"}]}`,
+		"duração negativa":            `{"nome": "x", "duracaoDoNivel": "-5m", "niveis": [{"bigBlind": 100}]}`,
+		"campo desconhecido":          `{"nome": "x", "niveis": [{"blind": 100}]}`,
+		"conteúdo que não é JSON":     `nome: x`,
+	}
+
+	for nome, conteudo := range invalidas {
+		t.Run("recusa estrutura com "+nome, func(t *testing.T) {
+			if _, err := poquer.LerEstrutura(strings.NewReader(conteudo)); err == nil {
+				t.Errorf("esperava um erro para %s", conteudo)
+			}
+		})
+	}
+
+	t.Run("as estruturas predefinidas são válidas", func(t *testing.T) {
+		for _, estrutura := range poquer.EstruturasPredefinidas() {
+			if err := estrutura.Validar(); err != nil {
+				t.Errorf("estrutura %s inválida, %v", estrutura.Nome, err)
+			}
+		}
+	})
+}
+
+const estruturaCaseiraEmYAML = `# a mesma estrutura de estruturaCaseira
+nome: caseira
+descricao: "Jogo de quinta-feira"
+duracaoDoNivel: 15m
+niveis:
+- bigBlind: 50
+- smallBlind: 50
+  bigBlind: 100
+  duracao: 20m # a segunda rodada é mais longa
+- {intervalo: true, duracao: 10m}
+-
+  smallBlind: 100
+  bigBlind: 200
+  ante: 25
+`
+
+func TestLerEstruturaYAML(t *testing.T) {
+	t.Run("lê a mesma estrutura que o JSON", func(t *testing.T) {
+		esperado, err := poquer.LerEstrutura(strings.NewReader(estruturaCaseira))
+		verificaSemErro(t, err)
+
+		estrutura, err := poquer.LerEstruturaYAML(strings.NewReader(estruturaCaseiraEmYAML))
+		verificaSemErro(t, err)
+
+		if !reflect.DeepEqual(estrutura, esperado) {
+			t.Errorf("obtido %+v, esperado %+v", estrutura, esperado)
+		}
+	})
+
+	t.Run("lê listas recuadas, aspas simples e valores com #", func(t *testing.T) {
+		estrutura, err := poquer.LerEstruturaYAML(strings.NewReader(`
+nome: 'quinta #2'
+descricao: "Jogo d'água"
+niveis:
+  - bigBlind: 100
+    ante: 0
+  - bigBlind: 200
+`))
+		verificaSemErro(t, err)
+
+		esperado := poquer.EstruturaDeBlinds{
+			Nome:      "quinta #2",
+			Descricao: "Jogo d'água",
+			Niveis:    []poquer.NivelDeBlind{{Blinds: blindUnico(100)}, {Blinds: blindUnico(200)}},
+		}
+
+		if !reflect.DeepEqual(estrutura, esperado) {
+			t.Errorf("obtido %+v, esperado %+v", estrutura, esperado)
+		}
+	})
+
+	invalidas := map[string]string{
+		"sem níveis":             "nome: vazia\nniveis: []",
+		"campo desconhecido":     "nome: x\nniveis:\n- blind: 100",
+		"duração inválida":       "nome: x\nniveis:\n- bigBlind: 100\n  duracao: quinze",
+		"blind que não é número": "nome: x\nniveis:\n- bigBlind: cem",
+		"chave repetida":         "nome: x\nnome: y\nniveis: [{bigBlind: 100}]",
+		"recuo inesperado":       "nome: x\n  descricao: y\nniveis: [{bigBlind: 100}]",
+		"recuo com tab":          "nome: x\nniveis:\n\t- bigBlind: 100",
+		"lista não fechada":      "nome: x\nniveis: [{bigBlind: 100}",
+		"aspas não fechadas":     "nome: \"x\nniveis: [{bigBlind: 100}]",
+		"texto em várias linhas": "nome: x\ndescricao: |\n  longa\nniveis: [{bigBlind: 100}]",
+		"vários documentos":      "nome: x\nniveis: [{bigBlind: 100}]\n---\nnome: y",
+		"linha que não é chave":  "nome: x\nniveis\n",
+	}
+
+	for nome, conteudo := range invalidas {
+		t.Run("recusa estrutura com "+nome, func(t *testing.T) {
+			if _, err := poquer.LerEstruturaYAML(strings.NewReader(conteudo)); err == nil {
+				t.Errorf("esperava um erro para %q", conteudo)
+			}
+		})
+	}
+}
+
+func TestCatalogoDeEstruturas(t *testing.T) {
+	t.Run("carrega as estruturas de um diretório junto das predefinidas", func(t *testing.T) {
+		diretorio := criarDiretorioDeEstruturas(t, map[string]string{
+			"caseira.json": estruturaCaseira,
+			"leia-me.txt":  "ignorado",
+		})
+
+		estruturas, err := poquer.CarregarEstruturasDoDiretorio(diretorio)
+		verificaSemErro(t, err)
+
+		catalogo, err := poquer.NovoCatalogoDeEstruturas(estruturas...)
+		verificaSemErro(t, err)
+
+		verificaNomesDasEstruturas(t, catalogo.Estruturas(), "caseira", "padrao", "torneio", "turbo")
+
+		padrao, err := catalogo.Estrutura("")
+		verificaSemErro(t, err)
+		if padrao.Nome != poquer.EstruturaPadrao {
+			t.Errorf("sem nome deveria usar a estrutura %s, obtido %s", poquer.EstruturaPadrao, padrao.Nome)
+		}
+	})
+
+	t.Run("aponta o arquivo com a estrutura inválida", func(t *testing.T) {
+		diretorio := criarDiretorioDeEstruturas(t, map[string]string{
+			"quebrada.json": `{"nome": "quebrada", "niveis": []}`,
+		})
+
+		_, err := poquer.CarregarEstruturasDoDiretorio(diretorio)
+
+		if err == nil || !strings.Contains(err.Error(), "quebrada.json") {
+			t.Errorf("esperava um erro citando quebrada.json, obtido %v", err)
+		}
+	})
+
+	t.Run("carrega as estruturas em YAML", func(t *testing.T) {
+		diretorio := criarDiretorioDeEstruturas(t, map[string]string{
+			"caseira.yaml": estruturaCaseiraEmYAML,
+			"rapida.yml":   "nome: rapida\nniveis: [{bigBlind: 100}, {bigBlind: 200}]",
+		})
+
+		estruturas, err := poquer.CarregarEstruturasDoDiretorio(diretorio)
+		verificaSemErro(t, err)
+
+		catalogo, err := poquer.NovoCatalogoDeEstruturas(estruturas...)
+		verificaSemErro(t, err)
+
+		verificaNomesDasEstruturas(t, catalogo.Estruturas(), "caseira", "padrao", "rapida", "torneio", "turbo")
+	})
+
+	t.Run("aponta o arquivo YAML com a estrutura inválida", func(t *testing.T) {
+		diretorio := criarDiretorioDeEstruturas(t, map[string]string{
+			"quebrada.yml": "nome: quebrada\nniveis: []",
+		})
+
+		_, err := poquer.CarregarEstruturasDoDiretorio(diretorio)
+
+		if err == nil || !strings.Contains(err.Error(), "quebrada.yml") {
+			t.Errorf("esperava um erro citando quebrada.yml, obtido %v", err)
+		}
+	})
+
+	t.Run("recusa nomes repetidos e informa estruturas desconhecidas", func(t *testing.T) {
+		caseira, _ := poquer.LerEstrutura(strings.NewReader(estruturaCaseira))
+
+		if _, err := poquer.NovoCatalogoDeEstruturas(caseira, caseira); err == nil {
+			t.Error("esperava um erro para estruturas com o mesmo nome")
+		}
+
+		catalogo, _ := poquer.NovoCatalogoDeEstruturas()
+		_, err := catalogo.Estrutura("maratona")
+
+		if !errors.Is(err, poquer.ErrEstruturaDesconhecida) {
+			t.Errorf("esperava %v, obtido %v", poquer.ErrEstruturaDesconhecida, err)
+		}
+	})
+}
+
+func TestPartidaComEstrutura(t *testing.T) {
+	caseira, _ := poquer.LerEstrutura(strings.NewReader(estruturaCaseira))
+	catalogo, _ := poquer.NovoCatalogoDeEstruturas(caseira)
+
+	t.Run("agenda os alertas com as durações da estrutura, sem alertar os intervalos", func(t *testing.T) {
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, ArmazenamentoJogadorTosco, poquer.ComEstruturas(catalogo))
+
+		_, err := jogo.Começar(poquer.ConfiguracaoDaPartida{NumeroDeJogadores: 5, Estrutura: "caseira"}, ioutil.Discard)
+		verificaSemErro(t, err)
+
+		verificaCasosAgendados([]poquer.AlertaAgendado{
+			{Em: 0, Blinds: blindUnico(50)},
+			{Em: 15 * time.Minute, Blinds: poquer.Blinds{SmallBlind: 50, BigBlind: 100}},
+			{Em: 45 * time.Minute, Blinds: poquer.Blinds{SmallBlind: 100, BigBlind: 200, Ante: 25}},
+		}, t, alertadorDeBlind)
+
+		if len(alertadorDeBlind.Alertas) != 3 {
+			t.Errorf("esperava 3 alertas, obtido %v", alertadorDeBlind.Alertas)
+		}
+	})
+
+	t.Run("mostra o intervalo no estado dos blinds", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco, poquer.ComEstruturas(catalogo), poquer.ComRelogio(relogio))
+
+		partida, err := jogo.Começar(poquer.ConfiguracaoDaPartida{NumeroDeJogadores: 5, Estrutura: "caseira"}, ioutil.Discard)
+		verificaSemErro(t, err)
+
+		relogio.Avancar(30 * time.Minute)
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 2, Blinds: poquer.Blinds{SmallBlind: 50, BigBlind: 100}, Restante: 5 * time.Minute})
+
+		relogio.Avancar(6 * time.Minute)
+		estado := partida.Estado()
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 3, Blinds: poquer.Blinds{SmallBlind: 50, BigBlind: 100}, ProximosBlinds: poquer.Blinds{SmallBlind: 100, BigBlind: 200, Ante: 25}, Restante: 9 * time.Minute, Intervalo: true})
+
+		if estado.String() != "Intervalo (blinds 50/100), próximos blinds 100/200 ante 25 em 9m0s" {
+			t.Errorf("texto do estado inesperado: %q", estado.String())
+		}
+	})
+
+	t.Run("alerta small e big blinds na estrutura turbo", func(t *testing.T) {
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, ArmazenamentoJogadorTosco)
+
+		_, err := jogo.Começar(poquer.ConfiguracaoDaPartida{NumeroDeJogadores: 5, Estrutura: "turbo"}, ioutil.Discard)
+		verificaSemErro(t, err)
+
+		verificaCasosAgendados([]poquer.AlertaAgendado{
+			{Em: 0, Blinds: poquer.Blinds{SmallBlind: 50, BigBlind: 100}},
+			{Em: 5 * time.Minute, Blinds: poquer.Blinds{SmallBlind: 100, BigBlind: 200}},
+			{Em: 10 * time.Minute, Blinds: poquer.Blinds{SmallBlind: 150, BigBlind: 300}},
+		}, t, alertadorDeBlind)
+	})
+
+	t.Run("recusa estruturas desconhecidas", func(t *testing.T) {
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+
+		_, err := jogo.Começar(poquer.ConfiguracaoDaPartida{NumeroDeJogadores: 5, Estrutura: "maratona"}, ioutil.Discard)
+
+		if !errors.Is(err, poquer.ErrEstruturaDesconhecida) {
+			t.Errorf("esperava %v, obtido %v", poquer.ErrEstruturaDesconhecida, err)
+		}
+
+		if jogo.Partidas() != 0 {
+			t.Errorf("nenhuma partida deveria ter começado")
+		}
+	})
+}
+
+func criarDiretorioDeEstruturas(t *testing.T, arquivos map[string]string) string {
+	t.Helper()
+
+	diretorio, err := ioutil.TempDir("", "estruturas")
+	if err != nil {
+		t.Fatalf("não foi possível criar o diretório temporário %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(diretorio) })
+
+	for nome, conteudo := range arquivos {
+		if err := ioutil.WriteFile(filepath.Join(diretorio, nome), []byte(conteudo), 0644); err != nil {
+			t.Fatalf("não foi possível escrever %s %v", nome, err)
+		}
+	}
+
+	return diretorio
+}
+
+func verificaNomesDasEstruturas(t *testing.T, estruturas []poquer.EstruturaDeBlinds, esperados ...string) {
+	t.Helper()
+
+	var obtidos []string
+	for _, estrutura := range estruturas {
+		obtidos = append(obtidos, estrutura.Nome)
+	}
+
+	if !reflect.DeepEqual(obtidos, esperados) {
+		t.Errorf("obtido %v, esperado %v", obtidos, esperados)
+	}
+}
@@ -0,0 +1,676 @@
+package poquer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrHistoricoDivergente indica que a mão jogada de novo não termina como o histórico conta
+var ErrHistoricoDivergente = errors.New("a mão não reproduz o histórico")
+
+// LugarNoHistorico é um jogador da mão com as fichas que ele tinha antes dela
+type LugarNoHistorico struct {
+	Lugar  int
+	Nome   string
+	Fichas int
+}
+
+// AcaoNoHistorico é uma ação da mão, incluindo os antes e os blinds postados, feita pelo
+// jogador sentado no lugar
+type AcaoNoHistorico struct {
+	Rodada Rodada
+	Lugar  int
+	Acao   Acao
+}
+
+// HistoricoDaMao registra uma mão do começo ao fim: quem a jogou, os blinds, o botão, todas
+// as ações e o resultado. Lugar e Botao são lugares da mesa e as cartas fechadas só aparecem
+// no resultado, para quem as mostrou no showdown
+type HistoricoDaMao struct {
+	Jogo      string
+	Blinds    Blinds
+	Botao     int
+	Lugares   []LugarNoHistorico
+	Acoes     []AcaoNoHistorico
+	Resultado ResultadoDaMao
+}
+
+// RegistroDeMaos é implementado pelo DestinoDaMesa que guarda o histórico de cada mão
+// terminada
+type RegistroDeMaos interface {
+	RegistrarMao(historico HistoricoDaMao)
+}
+
+// Escrever escreve a mão no formato de texto do histórico, terminada por uma linha em branco:
+//
+//	Mão #2 do jogo ABCD: blinds 10/20
+//	Lugar 1: Cleo (990 fichas)
+//	Lugar 2: Chris (1010 fichas)
+//	Botão no lugar 2
+//	*** pré-flop ***
+//	Chris: small_blind 10
+//	Cleo: big_blind 20
+//	Chris: desistir
+//	Cleo ganha 30
+func (h HistoricoDaMao) Escrever(saida io.Writer) error {
+	var texto strings.Builder
+
+	fmt.Fprintf(&texto, "Mão #%d", h.Resultado.Mao)
+	if h.Jogo != "" {
+		fmt.Fprintf(&texto, " do jogo %s", h.Jogo)
+	}
+	fmt.Fprintf(&texto, ": blinds %d/%d", h.Blinds.SmallBlind, h.Blinds.BigBlind)
+	if h.Blinds.Ante > 0 {
+		fmt.Fprintf(&texto, ", ante %d", h.Blinds.Ante)
+	}
+	texto.WriteString("\n")
+
+	nomes := map[int]string{}
+	for _, lugar := range h.Lugares {
+		nomes[lugar.Lugar] = lugar.Nome
+		fmt.Fprintf(&texto, "Lugar %d: %s (%d fichas)\n", lugar.Lugar+1, lugar.Nome, lugar.Fichas)
+	}
+	fmt.Fprintf(&texto, "Botão no lugar %d\n", h.Botao+1)
+
+	for rodada := PreFlop; rodada <= h.ultimaRodada(); rodada++ {
+		fmt.Fprintf(&texto, "*** %s ***", rodada)
+		if abertas := cartasAbertasNa(rodada); abertas > 0 && rodada != Showdown && abertas <= len(h.Resultado.Cartas) {
+			fmt.Fprintf(&texto, " [%s]", EscreverCartas(h.Resultado.Cartas[:abertas]))
+		}
+		texto.WriteString("\n")
+
+		for _, acao := range h.Acoes {
+			if acao.Rodada == rodada {
+				fmt.Fprintf(&texto, "%s: %s\n", nomes[acao.Lugar], acao.Acao)
+			}
+		}
+	}
+
+	for _, jogador := range h.Resultado.Jogadores {
+		if len(jogador.Cartas) > 0 {
+			fmt.Fprintf(&texto, "%s mostra [%s] (%s)\n", jogador.Nome, EscreverCartas(jogador.Cartas), jogador.Combinacao)
+		}
+	}
+
+	for _, jogador := range h.Resultado.Jogadores {
+		if jogador.Ganhos > 0 {
+			fmt.Fprintf(&texto, "%s ganha %d\n", jogador.Nome, jogador.Ganhos)
+		}
+	}
+
+	for _, nome := range h.Resultado.Eliminados {
+		fmt.Fprintf(&texto, "%s foi eliminado\n", nome)
+	}
+
+	if h.Resultado.Vencedor != "" {
+		fmt.Fprintf(&texto, "%s venceu o jogo\n", h.Resultado.Vencedor)
+	}
+
+	texto.WriteString("\n")
+
+	_, err := io.WriteString(saida, texto.String())
+	return err
+}
+
+// ultimaRodada é a rodada em que a mão terminou, pelas cartas abertas e mostradas
+func (h HistoricoDaMao) ultimaRodada() Rodada {
+	for _, jogador := range h.Resultado.Jogadores {
+		if len(jogador.Cartas) > 0 {
+			return Showdown
+		}
+	}
+
+	switch len(h.Resultado.Cartas) {
+	case 0:
+		return PreFlop
+	case 3:
+		return Flop
+	case 4:
+		return Turn
+	default:
+		return River
+	}
+}
+
+// cartasAbertasNa é quantas cartas comunitárias estão abertas durante a rodada
+func cartasAbertasNa(rodada Rodada) int {
+	return [...]int{PreFlop: 0, Flop: 3, Turn: 4, River: 5, Showdown: 5}[rodada]
+}
+
+// EscreverHistorico escreve as mãos, uma depois da outra, no formato de texto do histórico
+func EscreverHistorico(saida io.Writer, maos []HistoricoDaMao) error {
+	for _, mao := range maos {
+		if err := mao.Escrever(saida); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LerHistorico lê as mãos escritas por EscreverHistorico
+func LerHistorico(entrada io.Reader) ([]HistoricoDaMao, error) {
+	leitor := &leitorDeHistorico{}
+	linhas := bufio.NewScanner(entrada)
+
+	for numero := 1; linhas.Scan(); numero++ {
+		if err := leitor.ler(strings.TrimRight(linhas.Text(), "\r")); err != nil {
+			return nil, fmt.Errorf("problema ao ler a linha %d do histórico, %v", numero, err)
+		}
+	}
+
+	if err := linhas.Err(); err != nil {
+		return nil, fmt.Errorf("problema ao ler o histórico, %v", err)
+	}
+
+	leitor.terminarMao()
+	return leitor.maos, nil
+}
+
+// leitorDeHistorico monta as mãos do histórico uma linha de cada vez
+type leitorDeHistorico struct {
+	maos      []HistoricoDaMao
+	mao       *HistoricoDaMao
+	rodada    Rodada
+	mostradas map[string]*MaoMostrada
+}
+
+func (l *leitorDeHistorico) ler(linha string) error {
+	if linha == "" {
+		l.terminarMao()
+		return nil
+	}
+
+	if strings.HasPrefix(linha, "Mão #") {
+		l.terminarMao()
+		return l.lerCabecalho(linha)
+	}
+
+	if l.mao == nil {
+		return fmt.Errorf("esperava o começo de uma mão, obtido %q", linha)
+	}
+
+	switch {
+	case strings.HasPrefix(linha, "Lugar "):
+		return l.lerLugar(linha)
+	case strings.HasPrefix(linha, "Botão no lugar "):
+		botao, err := strconv.Atoi(strings.TrimPrefix(linha, "Botão no lugar "))
+		l.mao.Botao = botao - 1
+		return err
+	case strings.HasPrefix(linha, "*** "):
+		return l.lerRodada(linha)
+	}
+
+	nome, resto, ok := l.nomeNoComeço(linha)
+
+	if !ok {
+		return fmt.Errorf("a linha %q não começa com o nome de um jogador da mão", linha)
+	}
+
+	if strings.HasPrefix(resto, ": ") {
+		return l.lerAcao(nome, strings.TrimPrefix(resto, ": "))
+	}
+
+	return l.lerResultado(nome, strings.TrimPrefix(resto, " "))
+}
+
+func (l *leitorDeHistorico) lerCabecalho(linha string) error {
+	mao := HistoricoDaMao{}
+	inicio, blinds := linha, ""
+
+	if i := strings.Index(linha, ": blinds "); i >= 0 {
+		inicio, blinds = linha[:i], linha[i+len(": blinds "):]
+	} else {
+		return fmt.Errorf("o cabeçalho %q não tem os blinds", linha)
+	}
+
+	numero := strings.TrimPrefix(inicio, "Mão #")
+	if i := strings.Index(numero, " do jogo "); i >= 0 {
+		numero, mao.Jogo = numero[:i], numero[i+len(" do jogo "):]
+	}
+
+	var err error
+	if mao.Resultado.Mao, err = strconv.Atoi(numero); err != nil {
+		return fmt.Errorf("número da mão inválido em %q", linha)
+	}
+
+	if i := strings.Index(blinds, ", ante "); i >= 0 {
+		if mao.Blinds.Ante, err = strconv.Atoi(blinds[i+len(", ante "):]); err != nil {
+			return fmt.Errorf("ante inválido em %q", linha)
+		}
+		blinds = blinds[:i]
+	}
+
+	if _, err := fmt.Sscanf(blinds, "%d/%d", &mao.Blinds.SmallBlind, &mao.Blinds.BigBlind); err != nil {
+		return fmt.Errorf("blinds inválidos em %q", linha)
+	}
+
+	l.mao = &mao
+	l.rodada = PreFlop
+	l.mostradas = map[string]*MaoMostrada{}
+	return nil
+}
+
+func (l *leitorDeHistorico) lerLugar(linha string) error {
+	var lugar LugarNoHistorico
+
+	dois := strings.Index(linha, ": ")
+	parentese := strings.LastIndex(linha, " (")
+
+	if dois < 0 || parentese < dois {
+		return fmt.Errorf("lugar inválido em %q", linha)
+	}
+
+	numero, err := strconv.Atoi(linha[len("Lugar "):dois])
+	if err != nil {
+		return fmt.Errorf("lugar inválido em %q", linha)
+	}
+
+	if _, err := fmt.Sscanf(linha[parentese:], " (%d fichas)", &lugar.Fichas); err != nil {
+		return fmt.Errorf("fichas inválidas em %q", linha)
+	}
+
+	lugar.Lugar = numero - 1
+	lugar.Nome = linha[dois+2 : parentese]
+	l.mao.Lugares = append(l.mao.Lugares, lugar)
+	return nil
+}
+
+func (l *leitorDeHistorico) lerRodada(linha string) error {
+	fim := strings.Index(linha[len("*** "):], " ***")
+
+	if fim < 0 {
+		return fmt.Errorf("rodada inválida em %q", linha)
+	}
+
+	nome := linha[len("*** ") : len("*** ")+fim]
+	cartas := strings.TrimSpace(linha[len("*** ")+fim+len(" ***"):])
+
+	encontrada := false
+	for rodada, nomeDaRodada := range nomesDasRodadas {
+		if nomeDaRodada == nome {
+			l.rodada, encontrada = Rodada(rodada), true
+		}
+	}
+
+	if !encontrada {
+		return fmt.Errorf("rodada desconhecida %q", nome)
+	}
+
+	if cartas == "" {
+		return nil
+	}
+
+	abertas, err := LerCartas(strings.Trim(cartas, "[]"))
+	if err != nil {
+		return err
+	}
+
+	l.mao.Resultado.Cartas = abertas
+	return nil
+}
+
+func (l *leitorDeHistorico) lerAcao(nome, texto string) error {
+	partes := strings.Fields(texto)
+
+	if len(partes) == 0 || len(partes) > 2 {
+		return fmt.Errorf("ação inválida %q", texto)
+	}
+
+	acao := Acao{Tipo: TipoDeAcao(partes[0])}
+
+	if len(partes) == 2 {
+		quantia, err := strconv.Atoi(partes[1])
+		if err != nil {
+			return fmt.Errorf("quantia inválida em %q", texto)
+		}
+		acao.Quantia = quantia
+	}
+
+	l.mao.Acoes = append(l.mao.Acoes, AcaoNoHistorico{Rodada: l.rodada, Lugar: l.lugarDe(nome), Acao: acao})
+	return nil
+}
+
+func (l *leitorDeHistorico) lerResultado(nome, texto string) error {
+	switch {
+	case texto == "foi eliminado":
+		l.mao.Resultado.Eliminados = append(l.mao.Resultado.Eliminados, nome)
+
+	case texto == "venceu o jogo":
+		l.mao.Resultado.Vencedor = nome
+
+	case strings.HasPrefix(texto, "ganha "):
+		ganhos, err := strconv.Atoi(strings.TrimPrefix(texto, "ganha "))
+		if err != nil {
+			return fmt.Errorf("ganhos inválidos em %q", texto)
+		}
+		l.mostrada(nome).Ganhos = ganhos
+
+	case strings.HasPrefix(texto, "mostra ["):
+		fecha := strings.Index(texto, "]")
+		if fecha < 0 || !strings.HasPrefix(texto[fecha:], "] (") || !strings.HasSuffix(texto, ")") {
+			return fmt.Errorf("cartas mostradas inválidas em %q", texto)
+		}
+
+		cartas, err := LerCartas(texto[len("mostra ["):fecha])
+		if err != nil {
+			return err
+		}
+
+		mostrada := l.mostrada(nome)
+		mostrada.Cartas = cartas
+		mostrada.Combinacao = texto[fecha+len("] (") : len(texto)-1]
+
+	default:
+		return fmt.Errorf("resultado desconhecido %q", texto)
+	}
+
+	return nil
+}
+
+func (l *leitorDeHistorico) mostrada(nome string) *MaoMostrada {
+	if l.mostradas[nome] == nil {
+		l.mostradas[nome] = &MaoMostrada{Lugar: l.lugarDe(nome), Nome: nome}
+	}
+	return l.mostradas[nome]
+}
+
+// nomeNoComeço encontra o jogador da mão cujo nome começa a linha, preferindo o mais longo
+// para que "Bot 1" não seja confundido com "Bot 10"
+func (l *leitorDeHistorico) nomeNoComeço(linha string) (nome, resto string, ok bool) {
+	for _, lugar := range l.mao.Lugares {
+		if len(lugar.Nome) <= len(nome) || !strings.HasPrefix(linha, lugar.Nome) {
+			continue
+		}
+
+		depois := linha[len(lugar.Nome):]
+		if strings.HasPrefix(depois, ": ") || strings.HasPrefix(depois, " ") {
+			nome, resto, ok = lugar.Nome, depois, true
+		}
+	}
+	return nome, resto, ok
+}
+
+func (l *leitorDeHistorico) lugarDe(nome string) int {
+	for _, lugar := range l.mao.Lugares {
+		if lugar.Nome == nome {
+			return lugar.Lugar
+		}
+	}
+	return -1
+}
+
+// terminarMao coloca o que cada jogador ganhou e mostrou no resultado, na ordem da mesa
+func (l *leitorDeHistorico) terminarMao() {
+	if l.mao == nil {
+		return
+	}
+
+	for _, mostrada := range l.mostradas {
+		l.mao.Resultado.Jogadores = append(l.mao.Resultado.Jogadores, *mostrada)
+	}
+
+	sort.Slice(l.mao.Resultado.Jogadores, func(i, j int) bool {
+		return l.mao.Resultado.Jogadores[i].Lugar < l.mao.Resultado.Jogadores[j].Lugar
+	})
+
+	l.maos = append(l.maos, *l.mao)
+	l.mao = nil
+}
+
+// Reproduzir joga a mão de novo com as apostas e o avaliador do jogo, repetindo as ações do
+// histórico, e confere que ela termina com as mesmas ações, cartas, ganhos e eliminações.
+// Uma mão que não se repete retorna um erro com ErrHistoricoDivergente
+func Reproduzir(historico HistoricoDaMao) error {
+	lugares := make([]Lugar, len(historico.Lugares))
+	naMao := make([]int, len(historico.Lugares))
+	indices := map[int]int{}
+
+	for i, lugar := range historico.Lugares {
+		lugares[i] = Lugar{Nome: lugar.Nome, Fichas: lugar.Fichas}
+		naMao[i] = lugar.Lugar
+		indices[lugar.Lugar] = i
+	}
+
+	botao, existe := indices[historico.Botao]
+	if !existe {
+		return divergencia(historico, "o botão está no lugar %d, que não joga a mão", historico.Botao+1)
+	}
+
+	apostas, err := NovasApostas(ConfiguracaoDasApostas{Jogadores: lugares, Botao: botao, Blinds: historico.Blinds})
+	if err != nil {
+		return divergencia(historico, "a mão não começa, %v", err)
+	}
+
+	for _, registrada := range historico.Acoes {
+		if apostaObrigatoria(registrada.Acao.Tipo) {
+			continue
+		}
+
+		indice, existe := indices[registrada.Lugar]
+		if !existe {
+			return divergencia(historico, "o lugar %d age sem jogar a mão", registrada.Lugar+1)
+		}
+
+		if err := apostas.Agir(indice, registrada.Acao); err != nil {
+			return divergencia(historico, "%s não pode %s, %v", lugares[indice].Nome, registrada.Acao, err)
+		}
+	}
+
+	if !apostas.Encerrada() {
+		return divergencia(historico, "as apostas não terminaram depois da última ação")
+	}
+
+	if refeitas := acoesNoHistorico(apostas.Acoes(), naMao); !reflect.DeepEqual(refeitas, historico.Acoes) {
+		return divergencia(historico, "as ações foram %v, no histórico %v", refeitas, historico.Acoes)
+	}
+
+	cartas := historico.Resultado.Cartas
+	if abertas := cartasAbertasNa(apostas.Rodada()); len(cartas) != abertas {
+		return divergencia(historico, "a mão terminou no %s com %d cartas abertas, no histórico %d", apostas.Rodada(), abertas, len(cartas))
+	}
+
+	fechadas := make([][]Carta, len(lugares))
+	for _, mostrada := range historico.Resultado.Jogadores {
+		if indice, existe := indices[mostrada.Lugar]; existe {
+			fechadas[indice] = mostrada.Cartas
+		}
+	}
+
+	var combinacoes []Combinacao
+	if apostas.Rodada() == Showdown {
+		combinacoes = make([]Combinacao, len(lugares))
+
+		for i, estado := range apostas.Jogadores() {
+			if estado.Desistiu {
+				continue
+			}
+
+			if combinacoes[i], err = Avaliar(append(append([]Carta(nil), fechadas[i]...), cartas...)); err != nil {
+				return divergencia(historico, "%s chegou ao showdown sem mostrar as cartas", lugares[i].Nome)
+			}
+		}
+	}
+
+	ganhos, err := apostas.Distribuir(combinacoes)
+	if err != nil {
+		return divergencia(historico, "o pote não foi distribuído, %v", err)
+	}
+
+	resultado := resultadoDasApostas(historico.Resultado.Mao, cartas, apostas, naMao, fechadas, combinacoes, ganhos)
+
+	var restantes []string
+	for _, estado := range apostas.Jogadores() {
+		if estado.Fichas > 0 {
+			restantes = append(restantes, estado.Nome)
+		}
+	}
+	if len(restantes) == 1 {
+		resultado.Vencedor = restantes[0]
+	}
+
+	if !reflect.DeepEqual(resultado, historico.Resultado) {
+		return divergencia(historico, "o resultado foi %+v, no histórico %+v", resultado, historico.Resultado)
+	}
+
+	return nil
+}
+
+func divergencia(historico HistoricoDaMao, formato string, argumentos ...interface{}) error {
+	return fmt.Errorf("%w: mão #%d, %s", ErrHistoricoDivergente, historico.Resultado.Mao, fmt.Sprintf(formato, argumentos...))
+}
+
+func apostaObrigatoria(tipo TipoDeAcao) bool {
+	return tipo == PostarAnte || tipo == PostarSmallBlind || tipo == PostarBigBlind
+}
+
+// acoesNoHistorico troca a posição de cada ação na mão pelo lugar da mesa
+func acoesNoHistorico(acoes []AcaoRegistrada, naMao []int) []AcaoNoHistorico {
+	registradas := make([]AcaoNoHistorico, len(acoes))
+	for i, acao := range acoes {
+		registradas[i] = AcaoNoHistorico{Rodada: acao.Rodada, Lugar: naMao[acao.Jogador], Acao: acao.Acao}
+	}
+	return registradas
+}
+
+// resultadoDasApostas conta o que cada jogador da mão ganhou e, no showdown, mostrou, e
+// quem ficou sem fichas. As apostas já devem ter sido distribuídas
+func resultadoDasApostas(mao int, cartas []Carta, apostas *Apostas, naMao []int, fechadas [][]Carta, combinacoes []Combinacao, ganhos []int) ResultadoDaMao {
+	resultado := ResultadoDaMao{Mao: mao, Cartas: cartas}
+
+	for i, estado := range apostas.Jogadores() {
+		mostrada := MaoMostrada{Lugar: naMao[i], Nome: estado.Nome, Ganhos: ganhos[i]}
+		if combinacoes != nil && !estado.Desistiu {
+			mostrada.Cartas = fechadas[i]
+			mostrada.Combinacao = combinacoes[i].String()
+		}
+
+		if mostrada.Cartas != nil || mostrada.Ganhos > 0 {
+			resultado.Jogadores = append(resultado.Jogadores, mostrada)
+		}
+
+		if estado.Fichas == 0 {
+			resultado.Eliminados = append(resultado.Eliminados, estado.Nome)
+		}
+	}
+
+	return resultado
+}
+
+// ArmazenamentoDeMaos guarda o histórico das mãos de cada jogo
+type ArmazenamentoDeMaos interface {
+	GravarMao(historico HistoricoDaMao) error
+	ObterMaos(jogo string) ([]HistoricoDaMao, error)
+}
+
+// ArmazenamentoDeMaosEmMemoria guarda os históricos enquanto o servidor está no ar
+type ArmazenamentoDeMaosEmMemoria struct {
+	mu   sync.Mutex
+	maos map[string][]HistoricoDaMao
+}
+
+// NovoArmazenamentoDeMaosEmMemoria cria um ArmazenamentoDeMaosEmMemoria vazio
+func NovoArmazenamentoDeMaosEmMemoria() *ArmazenamentoDeMaosEmMemoria {
+	return &ArmazenamentoDeMaosEmMemoria{maos: map[string][]HistoricoDaMao{}}
+}
+
+// GravarMao acrescenta a mão ao histórico do jogo dela
+func (a *ArmazenamentoDeMaosEmMemoria) GravarMao(historico HistoricoDaMao) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.maos[historico.Jogo] = append(a.maos[historico.Jogo], historico)
+	return nil
+}
+
+// ObterMaos retorna as mãos do jogo na ordem em que foram jogadas
+func (a *ArmazenamentoDeMaosEmMemoria) ObterMaos(jogo string) ([]HistoricoDaMao, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]HistoricoDaMao(nil), a.maos[jogo]...), nil
+}
+
+// DiretorioArmazenamentoDeMaos guarda o histórico de cada jogo em um arquivo de texto do
+// diretório, com o código do jogo como nome
+type DiretorioArmazenamentoDeMaos struct {
+	mu        sync.Mutex
+	diretorio string
+}
+
+// NovoDiretorioArmazenamentoDeMaos cria o diretório, se ele ainda não existe, e guarda os
+// históricos nele
+func NovoDiretorioArmazenamentoDeMaos(diretorio string) (*DiretorioArmazenamentoDeMaos, error) {
+	if err := os.MkdirAll(diretorio, 0755); err != nil {
+		return nil, fmt.Errorf("problema ao criar o diretório de históricos %s, %v", diretorio, err)
+	}
+
+	return &DiretorioArmazenamentoDeMaos{diretorio: diretorio}, nil
+}
+
+// GravarMao acrescenta a mão ao arquivo do jogo dela
+func (d *DiretorioArmazenamentoDeMaos) GravarMao(historico HistoricoDaMao) error {
+	caminho, valido := d.caminho(historico.Jogo)
+
+	if !valido {
+		return fmt.Errorf("código de jogo inválido para um arquivo, %q", historico.Jogo)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	arquivo, err := os.OpenFile(caminho, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return fmt.Errorf("problema ao abrir %s, %v", caminho, err)
+	}
+	defer arquivo.Close()
+
+	return historico.Escrever(arquivo)
+}
+
+// ObterMaos lê as mãos do arquivo do jogo; um jogo sem arquivo não tem mãos
+func (d *DiretorioArmazenamentoDeMaos) ObterMaos(jogo string) ([]HistoricoDaMao, error) {
+	caminho, valido := d.caminho(jogo)
+
+	if !valido {
+		return nil, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	arquivo, err := os.Open(caminho)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("problema ao abrir %s, %v", caminho, err)
+	}
+	defer arquivo.Close()
+
+	return LerHistorico(arquivo)
+}
+
+// caminho retorna o arquivo do jogo, recusando códigos que sairiam do diretório
+func (d *DiretorioArmazenamentoDeMaos) caminho(jogo string) (string, bool) {
+	if jogo == "" {
+		return "", false
+	}
+
+	for _, letra := range jogo {
+		if !strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_", letra) {
+			return "", false
+		}
+	}
+
+	return filepath.Join(d.diretorio, jogo+".txt"), true
+}
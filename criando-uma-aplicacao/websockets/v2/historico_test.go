@@ -0,0 +1,277 @@
+package poquer_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+const maoAteOShowdown = `Mão #7 do jogo ABCD: blinds 10/20, ante 5
+Lugar 1: Bot 1 (500 fichas)
+Lugar 2: Bot 10 (100 fichas)
+Lugar 4: Cleo (1000 fichas)
+Botão no lugar 4
+*** pré-flop ***
+Bot 1: ante 5
+Bot 10: ante 5
+Cleo: ante 5
+Bot 1: small_blind 10
+Bot 10: big_blind 20
+Cleo: pagar 20
+Bot 1: aumentar 60
+Bot 10: allin 95
+Cleo: pagar 75
+Bot 1: pagar 35
+*** flop *** [Kc 9h 5d]
+Bot 1: passar
+Cleo: apostar 100
+Bot 1: desistir
+*** turn *** [Kc 9h 5d 3s]
+*** river *** [Kc 9h 5d 3s 7c]
+*** showdown ***
+Bot 10 mostra [Ks Kd] (trinca: Ks Kd Kc 9h 7c)
+Cleo mostra [Ah Qh] (carta alta: Ah Kc Qh 9h 7c)
+Bot 10 ganha 300
+Cleo ganha 100
+`
+
+func TestHistoricoDaMao(t *testing.T) {
+	t.Run("lê e escreve o mesmo texto", func(t *testing.T) {
+		maos := deveLerHistorico(t, maoAteOShowdown+"\n")
+
+		if len(maos) != 1 {
+			t.Fatalf("esperava uma mão, obtido %d", len(maos))
+		}
+
+		mao := maos[0]
+		esperado := poquer.HistoricoDaMao{
+			Jogo:   "ABCD",
+			Blinds: poquer.Blinds{SmallBlind: 10, BigBlind: 20, Ante: 5},
+			Botao:  3,
+			Lugares: []poquer.LugarNoHistorico{
+				{Lugar: 0, Nome: "Bot 1", Fichas: 500},
+				{Lugar: 1, Nome: "Bot 10", Fichas: 100},
+				{Lugar: 3, Nome: "Cleo", Fichas: 1000},
+			},
+		}
+
+		if mao.Jogo != esperado.Jogo || mao.Blinds != esperado.Blinds || mao.Botao != esperado.Botao || !reflect.DeepEqual(mao.Lugares, esperado.Lugares) {
+			t.Errorf("obtido %+v, esperado %+v", mao, esperado)
+		}
+
+		if len(mao.Acoes) != 13 || mao.Acoes[9] != (poquer.AcaoNoHistorico{Rodada: poquer.PreFlop, Lugar: 0, Acao: poquer.Acao{Tipo: poquer.Pagar, Quantia: 35}}) {
+			t.Errorf("ações lidas errado, obtido %+v", mao.Acoes)
+		}
+
+		mostrada := mao.Resultado.Jogadores[0]
+		if mostrada.Lugar != 1 || mostrada.Nome != "Bot 10" || mostrada.Ganhos != 300 || poquer.EscreverCartas(mostrada.Cartas) != "Ks Kd" {
+			t.Errorf("resultado lido errado, obtido %+v", mao.Resultado)
+		}
+
+		saida := &bytes.Buffer{}
+		verificaSemErro(t, poquer.EscreverHistorico(saida, maos))
+
+		if saida.String() != maoAteOShowdown+"\n" {
+			t.Errorf("obtido\n%s\nesperado\n%s", saida.String(), maoAteOShowdown)
+		}
+	})
+
+	t.Run("recusa um texto que não é um histórico", func(t *testing.T) {
+		invalidos := []string{
+			"Lugar 1: Cleo (10 fichas)\n",
+			"Mão #1: sem blinds\n",
+			"Mão #1: blinds 5/10\nLugar 1: Cleo (10 fichas)\nChris: pagar\n",
+			"Mão #1: blinds 5/10\nLugar 1: Cleo (10 fichas)\n*** quinta rua ***\n",
+			"Mão #1: blinds 5/10\nLugar 1: Cleo (10 fichas)\nCleo ganha tudo\n",
+		}
+
+		for _, texto := range invalidos {
+			if _, err := poquer.LerHistorico(strings.NewReader(texto)); err == nil {
+				t.Errorf("esperava um erro ao ler %q", texto)
+			}
+		}
+	})
+}
+
+func TestReproduzir(t *testing.T) {
+	t.Run("as mãos de uma mesa se repetem do começo ao fim", func(t *testing.T) {
+		var vencedor string
+		mesa, destino, _ := novaMesaDeTeste(t, 4, 200, func(nome string) { vencedor = nome })
+
+		aleatorio := rand.New(rand.NewSource(7))
+		for _, nome := range []string{"Bot 1", "Bot 10", "Cleo", "Chris"} {
+			mesa.SentarBot(nome, poquer.NovaEstrategiaAleatoria(aleatorio))
+		}
+		mesa.Começar()
+
+		if vencedor == "" || len(destino.Historicos) != len(destino.Resultados) {
+			t.Fatalf("esperava o histórico de cada mão até o fim do jogo, obtido %d históricos e %d resultados", len(destino.Historicos), len(destino.Resultados))
+		}
+
+		texto := &bytes.Buffer{}
+		verificaSemErro(t, poquer.EscreverHistorico(texto, destino.Historicos))
+		lidas := deveLerHistorico(t, texto.String())
+
+		if !reflect.DeepEqual(lidas, destino.Historicos) {
+			t.Fatalf("o histórico mudou ao ser escrito e lido:\n%s", texto)
+		}
+
+		for _, mao := range lidas {
+			if err := poquer.Reproduzir(mao); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if ultima := lidas[len(lidas)-1]; ultima.Resultado.Vencedor != vencedor {
+			t.Errorf("a última mão deveria ter o vencedor %s, obtido %+v", vencedor, ultima.Resultado)
+		}
+	})
+
+	t.Run("a mão do texto se repete", func(t *testing.T) {
+		verificaSemErro(t, poquer.Reproduzir(deveLerHistorico(t, maoAteOShowdown)[0]))
+	})
+
+	adulteracoes := map[string]func(*poquer.HistoricoDaMao){
+		"outro ganhador": func(h *poquer.HistoricoDaMao) {
+			h.Resultado.Jogadores[0].Ganhos, h.Resultado.Jogadores[1].Ganhos = 100, 300
+		},
+		"outras cartas mostradas": func(h *poquer.HistoricoDaMao) {
+			h.Resultado.Jogadores[1].Cartas = deveLerCartas(t, "9c 9d")
+		},
+		"uma ação a menos": func(h *poquer.HistoricoDaMao) {
+			h.Acoes = h.Acoes[:len(h.Acoes)-1]
+		},
+		"uma ação fora da vez": func(h *poquer.HistoricoDaMao) {
+			h.Acoes[6].Lugar = 3
+		},
+		"fichas diferentes": func(h *poquer.HistoricoDaMao) {
+			h.Lugares[1].Fichas = 150
+		},
+		"cartas da mesa a menos": func(h *poquer.HistoricoDaMao) {
+			h.Resultado.Cartas = h.Resultado.Cartas[:4]
+		},
+		"um eliminado a mais": func(h *poquer.HistoricoDaMao) {
+			h.Resultado.Eliminados = []string{"Bot 1"}
+		},
+	}
+
+	for nome, adulterar := range adulteracoes {
+		t.Run("aponta "+nome, func(t *testing.T) {
+			mao := deveLerHistorico(t, maoAteOShowdown)[0]
+			adulterar(&mao)
+
+			err := poquer.Reproduzir(mao)
+			if !errors.Is(err, poquer.ErrHistoricoDivergente) {
+				t.Errorf("esperava %v, obtido %v", poquer.ErrHistoricoDivergente, err)
+			}
+		})
+	}
+}
+
+func TestArmazenamentoDeMaos(t *testing.T) {
+	diretorio, err := ioutil.TempDir("", "maos")
+	verificaSemErro(t, err)
+	defer os.RemoveAll(diretorio)
+
+	noDiretorio, err := poquer.NovoDiretorioArmazenamentoDeMaos(diretorio)
+	verificaSemErro(t, err)
+
+	armazenamentos := map[string]poquer.ArmazenamentoDeMaos{
+		"em memória":   poquer.NovoArmazenamentoDeMaosEmMemoria(),
+		"no diretório": noDiretorio,
+	}
+
+	for nome, armazenamento := range armazenamentos {
+		t.Run(nome, func(t *testing.T) {
+			mao := deveLerHistorico(t, maoAteOShowdown)[0]
+			outra := mao
+			outra.Jogo = "WXYZ"
+
+			verificaSemErro(t, armazenamento.GravarMao(mao))
+			verificaSemErro(t, armazenamento.GravarMao(outra))
+			verificaSemErro(t, armazenamento.GravarMao(mao))
+
+			maos, err := armazenamento.ObterMaos("ABCD")
+			verificaSemErro(t, err)
+
+			if !reflect.DeepEqual(maos, []poquer.HistoricoDaMao{mao, mao}) {
+				t.Errorf("esperava as duas mãos do jogo ABCD, obtido %+v", maos)
+			}
+
+			maos, err = armazenamento.ObterMaos("NADA")
+			verificaSemErro(t, err)
+
+			if len(maos) != 0 {
+				t.Errorf("um jogo sem mãos não deveria ter histórico, obtido %+v", maos)
+			}
+		})
+	}
+
+	t.Run("o diretório não aceita códigos que saem dele", func(t *testing.T) {
+		mao := deveLerHistorico(t, maoAteOShowdown)[0]
+		mao.Jogo = "../ABCD"
+
+		if err := noDiretorio.GravarMao(mao); err == nil {
+			t.Error("esperava um erro ao gravar fora do diretório")
+		}
+
+		if maos, err := noDiretorio.ObterMaos("../ABCD"); err != nil || len(maos) != 0 {
+			t.Errorf("esperava nenhuma mão, obtido %v e %v", maos, err)
+		}
+	})
+}
+
+func TestObterMaosDoJogo(t *testing.T) {
+	maos := poquer.NovoArmazenamentoDeMaosEmMemoria()
+	verificaSemErro(t, maos.GravarMao(deveLerHistorico(t, maoAteOShowdown)[0]))
+	servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogoTosco, poquer.ComArmazenamentoDeMaos(maos))
+
+	t.Run("retorna o histórico em texto", func(t *testing.T) {
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, httptest.NewRequest(http.MethodGet, "/jogos/abcd/maos", nil))
+
+		verificaStatus(t, resposta, http.StatusOK)
+		verificaTipoDoConteudo(t, resposta, "text/plain; charset=utf-8")
+
+		if resposta.Body.String() != maoAteOShowdown+"\n" {
+			t.Errorf("obtido\n%s", resposta.Body.String())
+		}
+	})
+
+	casos := map[string]struct {
+		metodo  string
+		caminho string
+		status  int
+	}{
+		"jogo sem mãos":      {http.MethodGet, "/jogos/WXYZ/maos", http.StatusNotFound},
+		"rota desconhecida":  {http.MethodGet, "/jogos/ABCD", http.StatusNotFound},
+		"método não aceito":  {http.MethodPost, "/jogos/ABCD/maos", http.StatusMethodNotAllowed},
+		"caminho mais longo": {http.MethodGet, "/jogos/ABCD/maos/1", http.StatusNotFound},
+	}
+
+	for nome, caso := range casos {
+		t.Run(nome, func(t *testing.T) {
+			resposta := httptest.NewRecorder()
+			servidor.ServeHTTP(resposta, httptest.NewRequest(caso.metodo, caso.caminho, nil))
+			verificaStatus(t, resposta, caso.status)
+		})
+	}
+}
+
+func deveLerHistorico(t *testing.T, texto string) []poquer.HistoricoDaMao {
+	t.Helper()
+
+	maos, err := poquer.LerHistorico(strings.NewReader(texto))
+	verificaSemErro(t, err)
+
+	return maos
+}
@@ -0,0 +1,143 @@
+package poquer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const cabecalhoIdempotencia = "Idempotency-Key"
+
+// RespostaGravada é a resposta enviada na primeira requisição feita com uma chave de idempotência
+type RespostaGravada struct {
+	Caminho   string
+	Status    int
+	Corpo     string
+	GravadaEm time.Time
+}
+
+// ArmazenamentoIdempotencia guarda as respostas já enviadas para cada chave de idempotência
+type ArmazenamentoIdempotencia interface {
+	ObterResposta(chave string) (RespostaGravada, bool)
+	GravarResposta(chave string, resposta RespostaGravada)
+}
+
+// SistemaArquivoArmazenamentoIdempotencia guarda respostas no sistema de arquivos durante uma janela de tempo
+type SistemaArquivoArmazenamentoIdempotencia struct {
+	mu          sync.Mutex
+	baseDeDados *json.Encoder
+	respostas   map[string]RespostaGravada
+	janela      time.Duration
+	relogio     Relogio
+}
+
+// NovoSistemaArquivoArmazenamentoIdempotencia cria um SistemaArquivoArmazenamentoIdempotencia
+// carregando as respostas já gravadas no arquivo
+func NovoSistemaArquivoArmazenamentoIdempotencia(arquivo *os.File, janela time.Duration, relogio Relogio) (*SistemaArquivoArmazenamentoIdempotencia, error) {
+	arquivo.Seek(0, 0)
+
+	info, err := arquivo.Stat()
+
+	if err != nil {
+		return nil, fmt.Errorf("problema ao obter informações do arquivo %s, %v", arquivo.Name(), err)
+	}
+
+	if info.Size() == 0 {
+		arquivo.Write([]byte("{}"))
+		arquivo.Seek(0, 0)
+	}
+
+	respostas := map[string]RespostaGravada{}
+
+	if err := json.NewDecoder(arquivo).Decode(&respostas); err != nil {
+		return nil, fmt.Errorf("problema ao carregar as chaves de idempotência do arquivo %s, %v", arquivo.Name(), err)
+	}
+
+	return &SistemaArquivoArmazenamentoIdempotencia{
+		baseDeDados: json.NewEncoder(&Tape{arquivo}),
+		respostas:   respostas,
+		janela:      janela,
+		relogio:     relogio,
+	}, nil
+}
+
+// SistemaArquivoArmazenamentoIdempotenciaDoArquivo cria um SistemaArquivoArmazenamentoIdempotencia
+// a partir do arquivo encontrado em um caminho
+func SistemaArquivoArmazenamentoIdempotenciaDoArquivo(path string, janela time.Duration, relogio Relogio) (*SistemaArquivoArmazenamentoIdempotencia, func(), error) {
+	db, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("problema ao abrir %s %v", path, err)
+	}
+
+	closeFunc := func() {
+		db.Close()
+	}
+
+	armazenamento, err := NovoSistemaArquivoArmazenamentoIdempotencia(db, janela, relogio)
+
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("problema ao criar sistema de arquivo de armazenamento de idempotência, %v ", err)
+	}
+
+	return armazenamento, closeFunc, nil
+}
+
+// ObterResposta retorna a resposta gravada para a chave, caso ainda esteja dentro da janela
+func (s *SistemaArquivoArmazenamentoIdempotencia) ObterResposta(chave string) (RespostaGravada, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resposta, existe := s.respostas[chave]
+
+	if !existe || s.expirou(resposta) {
+		return RespostaGravada{}, false
+	}
+
+	return resposta, true
+}
+
+// GravarResposta grava a resposta da chave com o horário atual e descarta as que já saíram da janela
+func (s *SistemaArquivoArmazenamentoIdempotencia) GravarResposta(chave string, resposta RespostaGravada) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c, r := range s.respostas {
+		if s.expirou(r) {
+			delete(s.respostas, c)
+		}
+	}
+
+	resposta.GravadaEm = s.relogio.Agora()
+	s.respostas[chave] = resposta
+	s.baseDeDados.Encode(s.respostas)
+}
+
+func (s *SistemaArquivoArmazenamentoIdempotencia) expirou(resposta RespostaGravada) bool {
+	return s.relogio.Agora().Sub(resposta.GravadaEm) >= s.janela
+}
+
+// gravadorDeResposta repassa a resposta para o ResponseWriter guardando uma cópia dela
+type gravadorDeResposta struct {
+	http.ResponseWriter
+	status int
+	corpo  bytes.Buffer
+}
+
+func (g *gravadorDeResposta) WriteHeader(status int) {
+	g.status = status
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gravadorDeResposta) Write(p []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	g.corpo.Write(p)
+	return g.ResponseWriter.Write(p)
+}
@@ -0,0 +1,119 @@
+package poquer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestArmazenamentoIdempotencia(t *testing.T) {
+	t.Run("retorna a resposta gravada dentro da janela", func(t *testing.T) {
+		baseDeDados, limparBaseDeDados := criarArquivoTemporario(t, "")
+		defer limparBaseDeDados()
+
+		relogio := poquer.NovoRelogioFalso()
+		armazenamento, err := poquer.NovoSistemaArquivoArmazenamentoIdempotencia(baseDeDados, time.Hour, relogio)
+		verificaSemErro(t, err)
+
+		armazenamento.GravarResposta("abc", poquer.RespostaGravada{Caminho: "/jogadores/Chris", Status: http.StatusAccepted})
+
+		relogio.Avancar(59 * time.Minute)
+		obtido, existe := armazenamento.ObterResposta("abc")
+
+		if !existe {
+			t.Fatal("esperava encontrar a resposta gravada")
+		}
+
+		if obtido.Status != http.StatusAccepted || obtido.Caminho != "/jogadores/Chris" {
+			t.Errorf("obtido %+v, esperado status %d em /jogadores/Chris", obtido, http.StatusAccepted)
+		}
+	})
+
+	t.Run("esquece respostas fora da janela", func(t *testing.T) {
+		baseDeDados, limparBaseDeDados := criarArquivoTemporario(t, "")
+		defer limparBaseDeDados()
+
+		relogio := poquer.NovoRelogioFalso()
+		armazenamento, err := poquer.NovoSistemaArquivoArmazenamentoIdempotencia(baseDeDados, time.Hour, relogio)
+		verificaSemErro(t, err)
+
+		armazenamento.GravarResposta("abc", poquer.RespostaGravada{Status: http.StatusAccepted})
+		relogio.Avancar(time.Hour)
+
+		if _, existe := armazenamento.ObterResposta("abc"); existe {
+			t.Error("não esperava encontrar uma resposta expirada")
+		}
+	})
+
+	t.Run("mantém as respostas ao reabrir o arquivo", func(t *testing.T) {
+		baseDeDados, limparBaseDeDados := criarArquivoTemporario(t, "")
+		defer limparBaseDeDados()
+
+		relogio := poquer.NovoRelogioFalso()
+		armazenamento, err := poquer.NovoSistemaArquivoArmazenamentoIdempotencia(baseDeDados, time.Hour, relogio)
+		verificaSemErro(t, err)
+
+		armazenamento.GravarResposta("abc", poquer.RespostaGravada{Status: http.StatusAccepted})
+
+		reaberto, err := poquer.NovoSistemaArquivoArmazenamentoIdempotencia(baseDeDados, time.Hour, relogio)
+		verificaSemErro(t, err)
+
+		if _, existe := reaberto.ObterResposta("abc"); !existe {
+			t.Error("esperava encontrar a resposta depois de reabrir o arquivo")
+		}
+	})
+}
+
+func TestVitoriasIdempotentes(t *testing.T) {
+	baseDeDados, limparBaseDeDados := criarArquivoTemporario(t, "")
+	defer limparBaseDeDados()
+
+	idempotencia, err := poquer.NovoSistemaArquivoArmazenamentoIdempotencia(baseDeDados, time.Hour, poquer.NovoRelogioFalso())
+	verificaSemErro(t, err)
+
+	armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+	servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco, poquer.ComIdempotencia(idempotencia))
+
+	t.Run("grava a vitória uma única vez para requisições repetidas", func(t *testing.T) {
+		primeira := httptest.NewRecorder()
+		servidor.ServeHTTP(primeira, novaRequisiçãoPostDeVitoriaIdempotente("Chris", "chave-1"))
+
+		repetida := httptest.NewRecorder()
+		servidor.ServeHTTP(repetida, novaRequisiçãoPostDeVitoriaIdempotente("Chris", "chave-1"))
+
+		verificaStatus(t, primeira, http.StatusAccepted)
+		verificaStatus(t, repetida, http.StatusAccepted)
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris")
+
+		if repetida.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("esperava que a resposta repetida fosse marcada com Idempotent-Replayed")
+		}
+	})
+
+	t.Run("recusa uma chave reutilizada em outro jogador", func(t *testing.T) {
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisiçãoPostDeVitoriaIdempotente("Cleo", "chave-1"))
+
+		verificaStatus(t, resposta, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("requisições sem a chave continuam sendo gravadas", func(t *testing.T) {
+		armazenamento.ChamadasDeVitoria = nil
+
+		servidor.ServeHTTP(httptest.NewRecorder(), novaRequisiçãoPostDeVitoria("Cleo"))
+		servidor.ServeHTTP(httptest.NewRecorder(), novaRequisiçãoPostDeVitoria("Cleo"))
+
+		if len(armazenamento.ChamadasDeVitoria) != 2 {
+			t.Errorf("obtido %d vitórias gravadas, esperado 2", len(armazenamento.ChamadasDeVitoria))
+		}
+	})
+}
+
+func novaRequisiçãoPostDeVitoriaIdempotente(nome, chave string) *http.Request {
+	req := novaRequisiçãoPostDeVitoria(nome)
+	req.Header.Set("Idempotency-Key", chave)
+	return req
+}
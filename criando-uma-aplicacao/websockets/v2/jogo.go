@@ -1,9 +1,51 @@
 package poquer
 
-import "io"
+import (
+	"fmt"
+	"io"
+)
 
-// Jogo gerencia o estado de uma jogo
+// Jogo começa partidas de pôquer. Cada partida tem seus próprios alertas e é
+// terminada pelo valor devolvido por Começar, sem interferir nas outras
 type Jogo interface {
-	Começar(numeroDeJogadores int, destinoDosAlertas io.Writer)
+	Começar(configuracao ConfiguracaoDaPartida, destinoDosAlertas io.Writer) (Partida, error)
+	Partida(id string) (Partida, bool)
+}
+
+// ConfiguracaoDaPartida descreve como uma partida deve ser jogada
+type ConfiguracaoDaPartida struct {
+	NumeroDeJogadores int
+	// Estrutura é o nome da estrutura de blinds; vazio usa a EstruturaPadrao
+	Estrutura string
+	// Torneio liga o modo torneio; NumeroDeJogadores deve ser o número de inscritos
+	Torneio *ConfiguracaoDoTorneio
+}
+
+// Validar confere se a partida pode começar com essa configuração
+func (c ConfiguracaoDaPartida) Validar() error {
+	if c.NumeroDeJogadores < 1 {
+		return fmt.Errorf("o número de jogadores deve ser positivo, obtido %d", c.NumeroDeJogadores)
+	}
+
+	if c.Torneio == nil {
+		return nil
+	}
+
+	if err := c.Torneio.Validar(); err != nil {
+		return err
+	}
+
+	if c.NumeroDeJogadores != len(c.Torneio.Jogadores) {
+		return fmt.Errorf("o número de jogadores, %d, deve ser o de inscritos no torneio, %d", c.NumeroDeJogadores, len(c.Torneio.Jogadores))
+	}
+
+	return nil
+}
+
+// Partida é um jogo em andamento
+type Partida interface {
+	ControleDeBlinds
+	ID() string
 	Terminar(vencedor string)
+	Abandonar()
 }
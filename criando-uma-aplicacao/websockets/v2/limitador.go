@@ -0,0 +1,110 @@
+package poquer
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// limiteDeBaldes é a quantidade máxima de baldes guardados. Quando uma chave nova chega com
+// o limite atingido, o balde usado há mais tempo é descartado
+const limiteDeBaldes = 1024
+
+// LimitadorDeTaxa limita requisições por chave usando um token bucket para cada chave
+type LimitadorDeTaxa struct {
+	capacidade float64
+	reposicao  time.Duration
+	relogio    Relogio
+
+	mu     sync.Mutex
+	baldes map[string]*list.Element
+	usos   *list.List
+}
+
+type balde struct {
+	chave        string
+	fichas       float64
+	atualizadoEm time.Time
+}
+
+// NovoLimitadorDeTaxa cria um LimitadorDeTaxa que permite rajadas de até capacidade requisições
+// e devolve uma ficha ao balde a cada intervalo de reposicao
+func NovoLimitadorDeTaxa(capacidade int, reposicao time.Duration, relogio Relogio) *LimitadorDeTaxa {
+	return &LimitadorDeTaxa{
+		capacidade: float64(capacidade),
+		reposicao:  reposicao,
+		relogio:    relogio,
+		baldes:     map[string]*list.Element{},
+		usos:       list.New(),
+	}
+}
+
+// Permitir consome uma ficha do balde da chave. Quando não há fichas, retorna false e
+// quanto tempo falta para a próxima ficha ficar disponível
+func (l *LimitadorDeTaxa) Permitir(chave string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	agora := l.relogio.Agora()
+	b := l.baldeDa(chave, agora)
+	l.reabastecer(b, agora)
+
+	if b.fichas < 1 {
+		faltando := time.Duration((1 - b.fichas) * float64(l.reposicao))
+		return false, faltando
+	}
+
+	b.fichas--
+	return true, 0
+}
+
+func (l *LimitadorDeTaxa) reabastecer(b *balde, agora time.Time) {
+	decorrido := agora.Sub(b.atualizadoEm)
+	if decorrido <= 0 {
+		return
+	}
+
+	b.fichas += float64(decorrido) / float64(l.reposicao)
+	if b.fichas > l.capacidade {
+		b.fichas = l.capacidade
+	}
+	b.atualizadoEm = agora
+}
+
+// Devolver põe de volta no balde da chave a ficha de uma requisição que acabou não sendo
+// feita, como quando outro limite a recusou
+func (l *LimitadorDeTaxa) Devolver(chave string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elemento, existe := l.baldes[chave]
+	if !existe {
+		return
+	}
+
+	b := elemento.Value.(*balde)
+	b.fichas++
+	if b.fichas > l.capacidade {
+		b.fichas = l.capacidade
+	}
+}
+
+// baldeDa retorna o balde da chave, marcando-o como o usado mais recentemente. Uma chave
+// nova recebe um balde cheio e, se o limite foi atingido, toma o lugar do balde usado há
+// mais tempo
+func (l *LimitadorDeTaxa) baldeDa(chave string, agora time.Time) *balde {
+	if elemento, existe := l.baldes[chave]; existe {
+		l.usos.MoveToFront(elemento)
+		return elemento.Value.(*balde)
+	}
+
+	if l.usos.Len() >= limiteDeBaldes {
+		antigo := l.usos.Back()
+		l.usos.Remove(antigo)
+		delete(l.baldes, antigo.Value.(*balde).chave)
+	}
+
+	b := &balde{chave: chave, fichas: l.capacidade, atualizadoEm: agora}
+	l.baldes[chave] = l.usos.PushFront(b)
+	return b
+}
@@ -0,0 +1,111 @@
+package poquer_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestLimitadorDeTaxa(t *testing.T) {
+	t.Run("permite rajadas até a capacidade e então recusa", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		limitador := poquer.NovoLimitadorDeTaxa(3, time.Second, relogio)
+
+		for i := 0; i < 3; i++ {
+			verificaPermitido(t, limitador, "Chris")
+		}
+
+		permitido, espera := limitador.Permitir("Chris")
+
+		if permitido {
+			t.Fatal("não deveria permitir a quarta requisição")
+		}
+
+		if espera != time.Second {
+			t.Errorf("obtido espera de %v, esperado %v", espera, time.Second)
+		}
+	})
+
+	t.Run("repõe fichas com o passar do tempo", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		limitador := poquer.NovoLimitadorDeTaxa(1, time.Second, relogio)
+
+		verificaPermitido(t, limitador, "Chris")
+
+		relogio.Avancar(400 * time.Millisecond)
+		permitido, espera := limitador.Permitir("Chris")
+
+		if permitido {
+			t.Fatal("não deveria permitir antes da reposição")
+		}
+
+		if espera != 600*time.Millisecond {
+			t.Errorf("obtido espera de %v, esperado %v", espera, 600*time.Millisecond)
+		}
+
+		relogio.Avancar(600 * time.Millisecond)
+		verificaPermitido(t, limitador, "Chris")
+	})
+
+	t.Run("não acumula mais fichas que a capacidade", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		limitador := poquer.NovoLimitadorDeTaxa(2, time.Second, relogio)
+
+		relogio.Avancar(time.Hour)
+
+		verificaPermitido(t, limitador, "Chris")
+		verificaPermitido(t, limitador, "Chris")
+
+		if permitido, _ := limitador.Permitir("Chris"); permitido {
+			t.Error("não deveria permitir mais que a capacidade")
+		}
+	})
+
+	t.Run("chaves diferentes têm baldes independentes", func(t *testing.T) {
+		limitador := poquer.NovoLimitadorDeTaxa(1, time.Second, poquer.NovoRelogioFalso())
+
+		verificaPermitido(t, limitador, "Chris")
+		verificaPermitido(t, limitador, "Cleo")
+	})
+
+	t.Run("guarda no máximo 1024 baldes, descartando o usado há mais tempo", func(t *testing.T) {
+		limitador := poquer.NovoLimitadorDeTaxa(1, time.Hour, poquer.NovoRelogioFalso())
+
+		verificaPermitido(t, limitador, "Chris")
+		verificaPermitido(t, limitador, "Cleo")
+
+		for i := 0; i < 1022; i++ {
+			verificaPermitido(t, limitador, fmt.Sprintf("cliente %d", i))
+		}
+		limitador.Permitir("Chris")
+		verificaPermitido(t, limitador, "Ruth")
+
+		if permitido, _ := limitador.Permitir("Chris"); permitido {
+			t.Error("o balde usado recentemente deveria ter sido mantido")
+		}
+
+		verificaPermitido(t, limitador, "Cleo")
+	})
+
+	t.Run("devolve a ficha de uma requisição que não foi feita", func(t *testing.T) {
+		limitador := poquer.NovoLimitadorDeTaxa(1, time.Hour, poquer.NovoRelogioFalso())
+
+		verificaPermitido(t, limitador, "Chris")
+		limitador.Devolver("Chris")
+		limitador.Devolver("Chris")
+		verificaPermitido(t, limitador, "Chris")
+
+		if permitido, _ := limitador.Permitir("Chris"); permitido {
+			t.Error("devolver não deveria passar da capacidade")
+		}
+	})
+}
+
+func verificaPermitido(t *testing.T, limitador *poquer.LimitadorDeTaxa, chave string) {
+	t.Helper()
+	if permitido, _ := limitador.Permitir(chave); !permitido {
+		t.Fatalf("esperava que a requisição para %s fosse permitida", chave)
+	}
+}
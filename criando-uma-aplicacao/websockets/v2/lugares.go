@@ -0,0 +1,335 @@
+package poquer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Motivos de uma MudancaDeLugar
+const (
+	// MotivoRecompra senta quem voltou ao torneio
+	MotivoRecompra = "recompra"
+	// MotivoEquilibrio leva um jogador da mesa mais cheia para a mais vazia
+	MotivoEquilibrio = "equilibrio"
+	// MotivoMesaDesfeita leva os jogadores de uma mesa desfeita para as outras
+	MotivoMesaDesfeita = "mesa_desfeita"
+)
+
+// ErrMesaDesconhecida indica que o torneio não tem uma mesa com o número informado
+var ErrMesaDesconhecida = errors.New("mesa não encontrada no torneio")
+
+// JogadorSentado é quem ocupa um lugar de uma mesa do torneio. Os lugares são numerados a
+// partir de 1
+type JogadorSentado struct {
+	Lugar int    `json:"lugar"`
+	Nome  string `json:"nome"`
+}
+
+// MesaDoTorneio descreve uma das mesas do torneio: o seu número, a partir de 1, o lugar do
+// botão e os jogadores sentados, pela ordem dos lugares
+type MesaDoTorneio struct {
+	Numero    int              `json:"numero"`
+	Botao     int              `json:"botao"`
+	Jogadores []JogadorSentado `json:"jogadores"`
+}
+
+// MudancaDeLugar é um jogador levado para outro lugar. Quem senta ao recomprar não tem mesa
+// de origem
+type MudancaDeLugar struct {
+	Jogador   string `json:"jogador"`
+	Motivo    string `json:"motivo"`
+	DeMesa    int    `json:"deMesa,omitempty"`
+	DeLugar   int    `json:"deLugar,omitempty"`
+	ParaMesa  int    `json:"paraMesa"`
+	ParaLugar int    `json:"paraLugar"`
+}
+
+func (m MudancaDeLugar) String() string {
+	if m.DeMesa == 0 {
+		return fmt.Sprintf("%s senta na mesa %d, lugar %d", m.Jogador, m.ParaMesa, m.ParaLugar)
+	}
+	return fmt.Sprintf("%s muda da mesa %d, lugar %d, para a mesa %d, lugar %d", m.Jogador, m.DeMesa, m.DeLugar, m.ParaMesa, m.ParaLugar)
+}
+
+// DistribuicaoDosLugares diz onde cada jogador do torneio está sentado. Mudancas são os
+// jogadores que trocaram de lugar na última eliminação ou recompra
+type DistribuicaoDosLugares struct {
+	LugaresPorMesa int              `json:"lugaresPorMesa"`
+	Mesas          []MesaDoTorneio  `json:"mesas"`
+	Mudancas       []MudancaDeLugar `json:"mudancas,omitempty"`
+}
+
+// LugarDe retorna a mesa e o lugar do jogador
+func (d DistribuicaoDosLugares) LugarDe(nome string) (mesa, lugar int, sentado bool) {
+	for _, m := range d.Mesas {
+		for _, jogador := range m.Jogadores {
+			if jogador.Nome == nome {
+				return m.Numero, jogador.Lugar, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func (d DistribuicaoDosLugares) String() string {
+	linhas := make([]string, len(d.Mesas))
+
+	for i, mesa := range d.Mesas {
+		jogadores := make([]string, len(mesa.Jogadores))
+		for j, jogador := range mesa.Jogadores {
+			jogadores[j] = fmt.Sprintf("%d %s", jogador.Lugar, jogador.Nome)
+		}
+
+		linhas[i] = fmt.Sprintf("Mesa %d, botão no lugar %d: %s", mesa.Numero, mesa.Botao, strings.Join(jogadores, ", "))
+	}
+
+	return strings.Join(linhas, "\n")
+}
+
+// ControleDeLugares pode ser implementado por uma Partida que distribui os jogadores do
+// torneio pelas mesas. Todas as mesas jogam com o mesmo relógio de blinds, o da partida
+type ControleDeLugares interface {
+	Lugares() DistribuicaoDosLugares
+	// AvancarBotao passa o botão da mesa para o próximo lugar ocupado, depois de cada mão
+	AvancarBotao(mesa int) error
+}
+
+// mesaComLugares guarda quem ocupa cada lugar; lugares[0] é o lugar 1 e "" é um lugar vazio
+type mesaComLugares struct {
+	numero  int
+	lugares []string
+	botao   int
+}
+
+func (m *mesaComLugares) ocupados() int {
+	ocupados := 0
+	for _, nome := range m.lugares {
+		if nome != "" {
+			ocupados++
+		}
+	}
+	return ocupados
+}
+
+// proximoOcupado é o primeiro lugar ocupado depois do lugar, dando a volta na mesa
+func (m *mesaComLugares) proximoOcupado(lugar int) int {
+	for i := 1; i <= len(m.lugares); i++ {
+		proximo := (lugar-1+i+len(m.lugares))%len(m.lugares) + 1
+		if m.lugares[proximo-1] != "" {
+			return proximo
+		}
+	}
+	return 0
+}
+
+// proximoBigBlind é o lugar de quem paga o big blind na próxima mão, o segundo depois do botão
+func (m *mesaComLugares) proximoBigBlind() int {
+	return m.proximoOcupado(m.proximoOcupado(m.botao))
+}
+
+// sentarAoAcaso coloca o jogador em um dos lugares vazios sorteado. A primeira pessoa a
+// sentar em uma mesa nova fica com o botão
+func (m *mesaComLugares) sentarAoAcaso(nome string, aleatorio *rand.Rand) int {
+	var vazios []int
+	for i, ocupante := range m.lugares {
+		if ocupante == "" {
+			vazios = append(vazios, i+1)
+		}
+	}
+
+	lugar := vazios[aleatorio.Intn(len(vazios))]
+	m.lugares[lugar-1] = nome
+
+	if m.botao == 0 {
+		m.botao = lugar
+	}
+
+	return lugar
+}
+
+// salao distribui os jogadores de um torneio pelas mesas. Quando alguém sai, ele equilibra
+// as mesas, que nunca diferem em mais de um jogador, e desfaz uma mesa assim que os
+// jogadores cabem nas outras. As mesas ficam na ordem dos números
+type salao struct {
+	lugaresPorMesa int
+	mesas          []*mesaComLugares
+	aleatorio      *rand.Rand
+	mudancas       []MudancaDeLugar
+}
+
+// novoSalao sorteia os lugares: os jogadores são repartidos por igual entre o menor número
+// de mesas em que cabem, cada um em um lugar sorteado, e o botão de cada mesa também é sorteado
+func novoSalao(jogadores []string, lugaresPorMesa int, aleatorio *rand.Rand) *salao {
+	s := &salao{lugaresPorMesa: lugaresPorMesa, aleatorio: aleatorio}
+
+	numeroDeMesas := (len(jogadores) + lugaresPorMesa - 1) / lugaresPorMesa
+	for i := 1; i <= numeroDeMesas; i++ {
+		s.mesas = append(s.mesas, &mesaComLugares{numero: i, lugares: make([]string, lugaresPorMesa)})
+	}
+
+	for i, j := range aleatorio.Perm(len(jogadores)) {
+		s.mesas[i%numeroDeMesas].sentarAoAcaso(jogadores[j], aleatorio)
+	}
+
+	for _, mesa := range s.mesas {
+		var ocupados []int
+		for i, nome := range mesa.lugares {
+			if nome != "" {
+				ocupados = append(ocupados, i+1)
+			}
+		}
+		mesa.botao = ocupados[aleatorio.Intn(len(ocupados))]
+	}
+
+	return s
+}
+
+// sentar coloca quem recomprou na mesa mais vazia, abrindo uma mesa nova se todas estão cheias
+func (s *salao) sentar(nome string) {
+	s.mudancas = nil
+	destino := s.maisVazia()
+
+	if destino.ocupados() == s.lugaresPorMesa {
+		destino = &mesaComLugares{numero: s.mesas[len(s.mesas)-1].numero + 1, lugares: make([]string, s.lugaresPorMesa)}
+		s.mesas = append(s.mesas, destino)
+	}
+
+	lugar := destino.sentarAoAcaso(nome, s.aleatorio)
+	s.mudancas = append(s.mudancas, MudancaDeLugar{Jogador: nome, Motivo: MotivoRecompra, ParaMesa: destino.numero, ParaLugar: lugar})
+	s.equilibrar()
+}
+
+// levantar libera o lugar de quem foi eliminado. O botão continua no lugar até a próxima mão
+func (s *salao) levantar(nome string) {
+	s.mudancas = nil
+
+	for _, mesa := range s.mesas {
+		for i, ocupante := range mesa.lugares {
+			if ocupante == nome {
+				mesa.lugares[i] = ""
+			}
+		}
+	}
+
+	s.equilibrar()
+}
+
+// equilibrar desfaz as mesas que sobram, a de menos jogadores primeiro, e depois leva quem
+// pagaria o próximo big blind da mesa mais cheia para a mais vazia até que elas difiram em
+// no máximo um jogador
+func (s *salao) equilibrar() {
+	for len(s.mesas) > 1 && s.jogadores() <= (len(s.mesas)-1)*s.lugaresPorMesa {
+		s.desfazer(s.aDesfazer())
+	}
+
+	for {
+		origem, destino := s.maisCheia(), s.maisVazia()
+
+		if origem.ocupados()-destino.ocupados() <= 1 {
+			return
+		}
+
+		s.mover(origem, origem.proximoBigBlind(), destino, MotivoEquilibrio)
+	}
+}
+
+// desfazer tira a mesa do salão e senta os seus jogadores, a partir do que está depois do
+// botão, nas mesas mais vazias
+func (s *salao) desfazer(mesa *mesaComLugares) {
+	for i, outra := range s.mesas {
+		if outra == mesa {
+			s.mesas = append(s.mesas[:i], s.mesas[i+1:]...)
+			break
+		}
+	}
+
+	for lugar := mesa.proximoOcupado(mesa.botao); lugar != 0; lugar = mesa.proximoOcupado(mesa.botao) {
+		s.mover(mesa, lugar, s.maisVazia(), MotivoMesaDesfeita)
+	}
+}
+
+func (s *salao) mover(origem *mesaComLugares, lugar int, destino *mesaComLugares, motivo string) {
+	nome := origem.lugares[lugar-1]
+	origem.lugares[lugar-1] = ""
+
+	s.mudancas = append(s.mudancas, MudancaDeLugar{
+		Jogador:   nome,
+		Motivo:    motivo,
+		DeMesa:    origem.numero,
+		DeLugar:   lugar,
+		ParaMesa:  destino.numero,
+		ParaLugar: destino.sentarAoAcaso(nome, s.aleatorio),
+	})
+}
+
+func (s *salao) jogadores() int {
+	jogadores := 0
+	for _, mesa := range s.mesas {
+		jogadores += mesa.ocupados()
+	}
+	return jogadores
+}
+
+// maisVazia é a mesa com menos jogadores; no empate, a de menor número
+func (s *salao) maisVazia() *mesaComLugares {
+	escolhida := s.mesas[0]
+	for _, mesa := range s.mesas[1:] {
+		if mesa.ocupados() < escolhida.ocupados() {
+			escolhida = mesa
+		}
+	}
+	return escolhida
+}
+
+// maisCheia é a mesa com mais jogadores; no empate, a de menor número
+func (s *salao) maisCheia() *mesaComLugares {
+	escolhida := s.mesas[0]
+	for _, mesa := range s.mesas[1:] {
+		if mesa.ocupados() > escolhida.ocupados() {
+			escolhida = mesa
+		}
+	}
+	return escolhida
+}
+
+// aDesfazer é a mesa com menos jogadores; no empate, a de maior número
+func (s *salao) aDesfazer() *mesaComLugares {
+	escolhida := s.mesas[0]
+	for _, mesa := range s.mesas[1:] {
+		if mesa.ocupados() <= escolhida.ocupados() {
+			escolhida = mesa
+		}
+	}
+	return escolhida
+}
+
+func (s *salao) avancarBotao(numero int) error {
+	for _, mesa := range s.mesas {
+		if mesa.numero == numero {
+			mesa.botao = mesa.proximoOcupado(mesa.botao)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d", ErrMesaDesconhecida, numero)
+}
+
+func (s *salao) distribuicao() DistribuicaoDosLugares {
+	distribuicao := DistribuicaoDosLugares{
+		LugaresPorMesa: s.lugaresPorMesa,
+		Mudancas:       append([]MudancaDeLugar(nil), s.mudancas...),
+	}
+
+	for _, mesa := range s.mesas {
+		descricao := MesaDoTorneio{Numero: mesa.numero, Botao: mesa.botao, Jogadores: []JogadorSentado{}}
+		for i, nome := range mesa.lugares {
+			if nome != "" {
+				descricao.Jogadores = append(descricao.Jogadores, JogadorSentado{Lugar: i + 1, Nome: nome})
+			}
+		}
+		distribuicao.Mesas = append(distribuicao.Mesas, descricao)
+	}
+
+	return distribuicao
+}
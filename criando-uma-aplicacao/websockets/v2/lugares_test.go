@@ -0,0 +1,251 @@
+package poquer_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestLugares(t *testing.T) {
+	t.Run("sorteia os inscritos em mesas equilibradas", func(t *testing.T) {
+		torneio := começarTorneioComLugares(t, 23, 0, 1)
+		lugares := torneio.(poquer.ControleDeLugares).Lugares()
+
+		verificaMesasEquilibradas(t, lugares, 23, 3)
+
+		for _, mesa := range lugares.Mesas {
+			if !lugarOcupado(mesa, mesa.Botao) {
+				t.Errorf("o botão da mesa %d foi sorteado para o lugar vazio %d", mesa.Numero, mesa.Botao)
+			}
+		}
+
+		if lugares.LugaresPorMesa != poquer.MaximoDeLugares {
+			t.Errorf("esperava mesas de %d lugares, obtido %d", poquer.MaximoDeLugares, lugares.LugaresPorMesa)
+		}
+
+		repetido := começarTorneioComLugares(t, 23, 0, 1).(poquer.ControleDeLugares).Lugares()
+		if !reflect.DeepEqual(lugares, repetido) {
+			t.Errorf("a mesma semente deveria repetir os lugares, obtido\n%v\ne\n%v", lugares, repetido)
+		}
+
+		outro := começarTorneioComLugares(t, 23, 0, 2).(poquer.ControleDeLugares).Lugares()
+		if reflect.DeepEqual(lugares, outro) {
+			t.Error("outra semente deveria sortear outros lugares")
+		}
+	})
+
+	t.Run("equilibra as mesas a cada eliminação", func(t *testing.T) {
+		torneio := começarTorneioComLugares(t, 23, 0, 3)
+		controle := torneio.(poquer.ControleDeLugares)
+
+		for restantes := 22; restantes > 10; restantes-- {
+			antes := controle.Lugares()
+			verificaSemErro(t, torneio.Eliminar(antes.Mesas[0].Jogadores[0].Nome))
+
+			depois := controle.Lugares()
+			verificaMesasEquilibradas(t, depois, restantes, (restantes+9)/10)
+			verificaMudancas(t, antes, depois)
+		}
+	})
+
+	t.Run("desfaz a mesa de número mais alto quando os jogadores cabem nas outras", func(t *testing.T) {
+		torneio := começarTorneioComLugares(t, 12, 0, 4)
+		controle := torneio.(poquer.ControleDeLugares)
+
+		verificaSemErro(t, torneio.Eliminar(controle.Lugares().Mesas[0].Jogadores[0].Nome))
+		if mudancas := controle.Lugares().Mudancas; len(mudancas) != 0 {
+			t.Errorf("11 jogadores ainda não cabem em uma mesa, obtido %v", mudancas)
+		}
+
+		antes := controle.Lugares()
+		verificaSemErro(t, torneio.Eliminar(antes.Mesas[1].Jogadores[0].Nome))
+
+		depois := controle.Lugares()
+		verificaMesasEquilibradas(t, depois, 10, 1)
+		verificaMudancas(t, antes, depois)
+
+		if depois.Mesas[0].Numero != 1 || len(depois.Mudancas) != 5 {
+			t.Fatalf("esperava os 5 jogadores da mesa 2 na mesa 1, obtido %v", depois.Mudancas)
+		}
+
+		for _, mudanca := range depois.Mudancas {
+			if mudanca.Motivo != poquer.MotivoMesaDesfeita || mudanca.DeMesa != 2 {
+				t.Errorf("esperava uma mudança da mesa 2 desfeita, obtido %+v", mudanca)
+			}
+		}
+	})
+
+	t.Run("quem recompra senta na mesa mais vazia ou em uma mesa nova", func(t *testing.T) {
+		torneio := começarTorneioComLugares(t, 9, 4, 5)
+		controle := torneio.(poquer.ControleDeLugares)
+
+		eliminado := controle.Lugares().Mesas[0].Jogadores[0].Nome
+		verificaSemErro(t, torneio.Eliminar(eliminado))
+
+		antes := controle.Lugares()
+		verificaMesasEquilibradas(t, antes, 8, 2)
+		mesaNova := antes.Mesas[1].Numero + 1
+
+		verificaSemErro(t, torneio.Recomprar(eliminado))
+
+		depois := controle.Lugares()
+		verificaMesasEquilibradas(t, depois, 9, 3)
+		verificaMudancas(t, antes, depois)
+
+		recompra := depois.Mudancas[0]
+		if recompra.Motivo != poquer.MotivoRecompra || recompra.Jogador != eliminado || recompra.ParaMesa != mesaNova {
+			t.Errorf("esperava %s sentado na mesa nova, obtido %+v", eliminado, recompra)
+		}
+
+		for _, mudanca := range depois.Mudancas[1:] {
+			if mudanca.Motivo != poquer.MotivoEquilibrio || mudanca.ParaMesa != mesaNova {
+				t.Errorf("esperava um jogador levado para a mesa nova, obtido %+v", mudanca)
+			}
+		}
+	})
+
+	t.Run("o botão avança para o próximo lugar ocupado", func(t *testing.T) {
+		torneio := começarTorneioComLugares(t, 3, 0, 6)
+		controle := torneio.(poquer.ControleDeLugares)
+		mesa := controle.Lugares().Mesas[0]
+
+		var botoes []int
+		for i := 0; i < len(mesa.Jogadores); i++ {
+			verificaSemErro(t, controle.AvancarBotao(1))
+			botoes = append(botoes, controle.Lugares().Mesas[0].Botao)
+		}
+
+		if botoes[len(botoes)-1] != mesa.Botao {
+			t.Errorf("o botão deveria voltar ao lugar %d depois de uma volta, obtido %v", mesa.Botao, botoes)
+		}
+
+		for _, botao := range botoes {
+			if !lugarOcupado(mesa, botao) {
+				t.Errorf("o botão deveria ficar em lugares ocupados, obtido %v em %+v", botoes, mesa)
+			}
+		}
+
+		verificaErro(t, controle.AvancarBotao(2), poquer.ErrMesaDesconhecida)
+	})
+
+	t.Run("partidas fora do modo torneio não têm lugares", func(t *testing.T) {
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+		controle := começarPartida(t, jogo, 3, nil).(poquer.ControleDeLugares)
+
+		if lugares := controle.Lugares(); len(lugares.Mesas) != 0 {
+			t.Errorf("esperava nenhuma mesa, obtido %+v", lugares)
+		}
+
+		verificaErro(t, controle.AvancarBotao(1), poquer.ErrSemTorneio)
+	})
+
+	t.Run("escreve as mesas e as mudanças", func(t *testing.T) {
+		lugares := poquer.DistribuicaoDosLugares{Mesas: []poquer.MesaDoTorneio{
+			{Numero: 1, Botao: 3, Jogadores: []poquer.JogadorSentado{{Lugar: 1, Nome: "Cleo"}, {Lugar: 3, Nome: "Ruth"}}},
+			{Numero: 2, Botao: 2, Jogadores: []poquer.JogadorSentado{{Lugar: 2, Nome: "Chris"}, {Lugar: 7, Nome: "Tiest"}}},
+		}}
+
+		if obtido, esperado := lugares.String(), "Mesa 1, botão no lugar 3: 1 Cleo, 3 Ruth\nMesa 2, botão no lugar 2: 2 Chris, 7 Tiest"; obtido != esperado {
+			t.Errorf("obtido\n%s\nesperado\n%s", obtido, esperado)
+		}
+
+		mudancas := []poquer.MudancaDeLugar{
+			{Jogador: "Ruth", Motivo: poquer.MotivoRecompra, ParaMesa: 1, ParaLugar: 3},
+			{Jogador: "Tiest", Motivo: poquer.MotivoEquilibrio, DeMesa: 1, DeLugar: 5, ParaMesa: 2, ParaLugar: 7},
+		}
+		esperadas := []string{"Ruth senta na mesa 1, lugar 3", "Tiest muda da mesa 1, lugar 5, para a mesa 2, lugar 7"}
+
+		for i, mudanca := range mudancas {
+			if mudanca.String() != esperadas[i] {
+				t.Errorf("obtido %q, esperado %q", mudanca.String(), esperadas[i])
+			}
+		}
+	})
+}
+
+func começarTorneioComLugares(t *testing.T, inscritos, lugaresPorMesa int, semente int64) poquer.ControleDeTorneio {
+	t.Helper()
+
+	configuracao := poquer.ConfiguracaoDoTorneio{
+		FichasIniciais:   1000,
+		NiveisDeRecompra: 1,
+		LugaresPorMesa:   lugaresPorMesa,
+		Aleatorio:        rand.New(rand.NewSource(semente)),
+	}
+
+	for i := 1; i <= inscritos; i++ {
+		configuracao.Jogadores = append(configuracao.Jogadores, fmt.Sprintf("Jogador %d", i))
+	}
+
+	jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+	return começarTorneio(t, jogo, configuracao)
+}
+
+// verificaMesasEquilibradas confere que cada jogador ocupa um único lugar válido, que os
+// botões estão em lugares da mesa e que as mesas diferem em no máximo um jogador. O botão
+// pode ficar em um lugar vazio depois que o jogador sai
+func verificaMesasEquilibradas(t *testing.T, lugares poquer.DistribuicaoDosLugares, jogadores, mesas int) {
+	t.Helper()
+
+	if len(lugares.Mesas) != mesas {
+		t.Fatalf("esperava %d mesas, obtido\n%v", mesas, lugares)
+	}
+
+	sentados := map[string]bool{}
+	menor, maior := poquer.MaximoDeLugares, 0
+
+	for _, mesa := range lugares.Mesas {
+		for _, jogador := range mesa.Jogadores {
+			if sentados[jogador.Nome] || jogador.Lugar < 1 || jogador.Lugar > lugares.LugaresPorMesa {
+				t.Errorf("%s está em um lugar inválido ou repetido, obtido\n%v", jogador.Nome, lugares)
+			}
+			sentados[jogador.Nome] = true
+		}
+
+		if mesa.Botao < 1 || mesa.Botao > lugares.LugaresPorMesa {
+			t.Errorf("o botão da mesa %d está no lugar inválido %d", mesa.Numero, mesa.Botao)
+		}
+
+		if len(mesa.Jogadores) < menor {
+			menor = len(mesa.Jogadores)
+		}
+		if len(mesa.Jogadores) > maior {
+			maior = len(mesa.Jogadores)
+		}
+	}
+
+	if len(sentados) != jogadores {
+		t.Errorf("esperava %d jogadores sentados, obtido %d", jogadores, len(sentados))
+	}
+
+	if maior-menor > 1 {
+		t.Errorf("as mesas deveriam diferir em no máximo um jogador, obtido\n%v", lugares)
+	}
+}
+
+// verificaMudancas confere que as mudanças levam cada jogador do lugar em que estava antes
+// para o lugar em que está depois
+func verificaMudancas(t *testing.T, antes, depois poquer.DistribuicaoDosLugares) {
+	t.Helper()
+
+	for _, mudanca := range depois.Mudancas {
+		deMesa, deLugar, _ := antes.LugarDe(mudanca.Jogador)
+		paraMesa, paraLugar, _ := depois.LugarDe(mudanca.Jogador)
+
+		if mudanca.DeMesa != deMesa || mudanca.DeLugar != deLugar || mudanca.ParaMesa != paraMesa || mudanca.ParaLugar != paraLugar {
+			t.Errorf("a mudança %+v não corresponde aos lugares de %s, de %d/%d para %d/%d", mudanca, mudanca.Jogador, deMesa, deLugar, paraMesa, paraLugar)
+		}
+	}
+}
+
+func lugarOcupado(mesa poquer.MesaDoTorneio, lugar int) bool {
+	for _, jogador := range mesa.Jogadores {
+		if jogador.Lugar == lugar {
+			return true
+		}
+	}
+	return false
+}
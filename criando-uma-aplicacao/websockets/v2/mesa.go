@@ -0,0 +1,610 @@
+package poquer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaximoDeLugares é quantos jogadores cabem em uma mesa
+const MaximoDeLugares = 10
+
+// esperaDoIntervalo é de quanto em quanto tempo a mesa tenta começar a próxima mão durante
+// um intervalo da estrutura de blinds
+const esperaDoIntervalo = 10 * time.Second
+
+var (
+	// ErrMesaCheia indica que todos os lugares da mesa já estão ocupados
+	ErrMesaCheia = errors.New("a mesa está cheia")
+	// ErrMesaEncerrada indica que o jogo da mesa já terminou
+	ErrMesaEncerrada = errors.New("o jogo da mesa já terminou")
+	// ErrSemMao indica que nenhuma mão está sendo jogada na mesa
+	ErrSemMao = errors.New("nenhuma mão em andamento")
+	// ErrLugarOcupado indica que o lugar do jogador já é de um bot ou de outra conexão
+	ErrLugarOcupado = errors.New("o lugar já está ocupado")
+	// ErrNomeDeBot indica que uma pessoa tentou sentar com um nome reservado aos bots
+	ErrNomeDeBot = errors.New("o nome é reservado aos bots")
+)
+
+// PrefixoDeBot começa o nome de todo bot sentado por NomeDeBot. Pessoas não podem sentar
+// com esses nomes, para que o vencedor de uma mesa nunca seja confundido com um bot
+const PrefixoDeBot = "Bot "
+
+// NomeDeBot é o nome do n-ésimo bot de uma mesa, como "Bot 1"
+func NomeDeBot(n int) string {
+	return fmt.Sprintf("%s%d", PrefixoDeBot, n)
+}
+
+// EhNomeDeBot diz se o nome é reservado aos bots
+func EhNomeDeBot(nome string) bool {
+	return strings.HasPrefix(nome, PrefixoDeBot)
+}
+
+// ConfiguracaoDaMesa define uma mesa de hold'em jogada pela rede
+type ConfiguracaoDaMesa struct {
+	Lugares        int
+	FichasIniciais int
+	// Blinds informa o nível de blind de cada mão, normalmente a partida que tem o relógio;
+	// NovaMesa não abre uma mesa sem ele
+	Blinds ControleDeBlinds
+	// PrazoDaVez é quanto tempo um jogador tem para agir antes de desistir da mão; zero
+	// espera para sempre
+	PrazoDaVez time.Duration
+	// Relogio mede o PrazoDaVez; sem relógio, usa o RelogioDoSistema
+	Relogio Relogio
+	// Aleatorio embaralha as cartas; sem ele, as cartas são embaralhadas com o horário atual
+	Aleatorio *rand.Rand
+}
+
+// Validar confere se a mesa pode ser aberta com essa configuração
+func (c ConfiguracaoDaMesa) Validar() error {
+	if c.Lugares < 2 || c.Lugares > MaximoDeLugares {
+		return fmt.Errorf("a mesa deve ter de 2 a %d lugares, obtido %d", MaximoDeLugares, c.Lugares)
+	}
+
+	if c.FichasIniciais < 1 {
+		return fmt.Errorf("as fichas iniciais devem ser positivas, obtido %d", c.FichasIniciais)
+	}
+
+	if c.PrazoDaVez < 0 {
+		return fmt.Errorf("o prazo da vez não pode ser negativo, obtido %v", c.PrazoDaVez)
+	}
+
+	return nil
+}
+
+// DestinoDaMesa recebe o que acontece na mesa. As cartas de EnviarCartas só devem chegar ao
+// jogador sentado no lugar; o estado e o resultado são de todos. A mesa chama o destino
+// depois de soltar a sua trava, uma chamada por vez e na ordem dos acontecimentos
+type DestinoDaMesa interface {
+	EnviarCartas(lugar int, cartas []Carta)
+	AtualizarMesa(estado EstadoDaMesa)
+	MostrarResultado(resultado ResultadoDaMao)
+}
+
+// JogadorNaMesa descreve um lugar ocupado da mesa
+type JogadorNaMesa struct {
+	Nome      string `json:"nome"`
+	Fichas    int    `json:"fichas"`
+	Aposta    int    `json:"aposta,omitempty"`
+	Desistiu  bool   `json:"desistiu,omitempty"`
+	AllIn     bool   `json:"allIn,omitempty"`
+	Eliminado bool   `json:"eliminado,omitempty"`
+	Bot       bool   `json:"bot,omitempty"`
+}
+
+// EstadoDaMesa é o que todos os jogadores veem da mesa. Vez e Botao são lugares da mesa e
+// Vez é -1 quando ninguém precisa agir
+type EstadoDaMesa struct {
+	Mao       int             `json:"mao"`
+	Rodada    string          `json:"rodada,omitempty"`
+	Cartas    []Carta         `json:"cartas"`
+	Pote      int             `json:"pote"`
+	Botao     int             `json:"botao"`
+	Vez       int             `json:"vez"`
+	Opcoes    *Opcoes         `json:"opcoes,omitempty"`
+	Jogadores []JogadorNaMesa `json:"jogadores"`
+}
+
+// MaoMostrada é o que um jogador ganhou na mão e, no showdown, as cartas que ele mostrou
+type MaoMostrada struct {
+	Lugar      int     `json:"lugar"`
+	Nome       string  `json:"nome"`
+	Cartas     []Carta `json:"cartas,omitempty"`
+	Combinacao string  `json:"combinacao,omitempty"`
+	Ganhos     int     `json:"ganhos"`
+}
+
+// ResultadoDaMao conta como terminou uma mão. Sem showdown, só o vencedor aparece e as suas
+// cartas não são mostradas. Vencedor só é preenchido quando a mão termina o jogo
+type ResultadoDaMao struct {
+	Mao        int           `json:"mao"`
+	Cartas     []Carta       `json:"cartas"`
+	Jogadores  []MaoMostrada `json:"jogadores"`
+	Eliminados []string      `json:"eliminados,omitempty"`
+	Vencedor   string        `json:"vencedor,omitempty"`
+}
+
+type jogadorDaMesa struct {
+	nome       string
+	fichas     int
+	cartas     []Carta
+	eliminado  bool
+	estrategia Estrategia
+}
+
+// Mesa conduz as mãos de hold'em entre os jogadores sentados: dá as cartas, recebe as ações,
+// abre a mesa a cada rodada e paga os potes. Quando um jogador fica com todas as fichas, a
+// mesa termina e chama aoTerminar com o nome dele
+type Mesa struct {
+	configuracao ConfiguracaoDaMesa
+	destino      DestinoDaMesa
+	aoTerminar   func(vencedor string)
+
+	// muDaEntrega mantém as entregas ao destino em ordem sem segurar mu enquanto elas
+	// esperam pelas conexões
+	muDaEntrega sync.Mutex
+
+	mu          sync.Mutex
+	pendentes   []func()
+	jogadores   []*jogadorDaMesa
+	numeroDaMao int
+	botao       int
+	baralho     *Baralho
+	cartas      []Carta
+	apostas     *Apostas
+	naMao       []int
+	historico   HistoricoDaMao
+	prazo       Timer
+	turno       int
+	começou     bool
+	encerrada   bool
+}
+
+// NovaMesa abre uma mesa vazia
+func NovaMesa(configuracao ConfiguracaoDaMesa, destino DestinoDaMesa, aoTerminar func(vencedor string)) (*Mesa, error) {
+	if err := configuracao.Validar(); err != nil {
+		return nil, err
+	}
+
+	if configuracao.Blinds == nil {
+		return nil, errors.New("a mesa precisa de um relógio de blinds")
+	}
+
+	if configuracao.Relogio == nil {
+		configuracao.Relogio = RelogioDoSistema{}
+	}
+
+	if configuracao.Aleatorio == nil {
+		configuracao.Aleatorio = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return &Mesa{configuracao: configuracao, destino: destino, aoTerminar: aoTerminar, botao: -1}, nil
+}
+
+// Sentar coloca o jogador no próximo lugar livre e retorna o lugar. Quem já está sentado
+// recebe o seu lugar de volta, para poder voltar à mesa depois de uma queda de conexão.
+// Nomes de bot são recusados
+func (m *Mesa) Sentar(nome string) (int, error) {
+	if EhNomeDeBot(nome) {
+		return 0, fmt.Errorf("%w: %s", ErrNomeDeBot, nome)
+	}
+	return m.sentar(nome, nil)
+}
+
+// SentarBot ocupa o próximo lugar livre com um bot que decide as suas ações pela estratégia
+func (m *Mesa) SentarBot(nome string, estrategia Estrategia) (int, error) {
+	if estrategia == nil {
+		return 0, errors.New("o bot precisa de uma estratégia")
+	}
+	return m.sentar(nome, estrategia)
+}
+
+func (m *Mesa) sentar(nome string, estrategia Estrategia) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.encerrada {
+		return 0, ErrMesaEncerrada
+	}
+
+	if strings.TrimSpace(nome) == "" {
+		return 0, errors.New("o jogador precisa de um nome para sentar")
+	}
+
+	for lugar, jogador := range m.jogadores {
+		if jogador.nome != nome {
+			continue
+		}
+
+		if jogador.estrategia != nil || estrategia != nil {
+			return 0, fmt.Errorf("%w: %s já está sentado no lugar %d", ErrLugarOcupado, nome, lugar)
+		}
+
+		return lugar, nil
+	}
+
+	if len(m.jogadores) == m.configuracao.Lugares {
+		return 0, ErrMesaCheia
+	}
+
+	m.jogadores = append(m.jogadores, &jogadorDaMesa{nome: nome, fichas: m.configuracao.FichasIniciais, estrategia: estrategia})
+	return len(m.jogadores) - 1, nil
+}
+
+// Começar dá a primeira mão quando todos os lugares estão ocupados. Retorna false se a mesa
+// ainda espera jogadores ou se as mãos já começaram
+func (m *Mesa) Começar() bool {
+	m.mu.Lock()
+	defer m.liberar()
+
+	if m.encerrada || m.começou || len(m.jogadores) < m.configuracao.Lugares {
+		return false
+	}
+
+	m.começou = true
+	m.jogar()
+	return true
+}
+
+// Cartas retorna as cartas fechadas do jogador na mão atual
+func (m *Mesa) Cartas(lugar int) []Carta {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lugar < 0 || lugar >= len(m.jogadores) {
+		return nil
+	}
+
+	return append([]Carta(nil), m.jogadores[lugar].cartas...)
+}
+
+// Estado retorna o que todos os jogadores veem da mesa
+func (m *Mesa) Estado() EstadoDaMesa {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.estado()
+}
+
+// Agir aplica a ação do jogador sentado no lugar
+func (m *Mesa) Agir(lugar int, acao Acao) error {
+	m.mu.Lock()
+	defer m.liberar()
+
+	if m.encerrada {
+		return ErrMesaEncerrada
+	}
+
+	if m.apostas == nil {
+		return ErrSemMao
+	}
+
+	indice := m.indiceNaMao(lugar)
+
+	if indice < 0 {
+		return fmt.Errorf("%w: o lugar %d não está na mão", ErrForaDaVez, lugar)
+	}
+
+	if err := m.apostas.Agir(indice, acao); err != nil {
+		return err
+	}
+
+	m.depoisDaAcao()
+	return nil
+}
+
+// Encerrada informa se a mesa já tem um vencedor ou foi encerrada
+func (m *Mesa) Encerrada() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.encerrada
+}
+
+// Encerrar termina a mesa sem vencedor, como quando o jogo da sala é abandonado
+func (m *Mesa) Encerrar() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.encerrada = true
+	if m.prazo != nil {
+		m.prazo.Parar()
+		m.prazo = nil
+	}
+}
+
+// enviar guarda uma entrega ao destino para depois que a trava da mesa for solta
+func (m *Mesa) enviar(entrega func()) {
+	m.pendentes = append(m.pendentes, entrega)
+}
+
+// atualizarMesa guarda o estado atual da mesa para o destino
+func (m *Mesa) atualizarMesa() {
+	estado := m.estado()
+	m.enviar(func() { m.destino.AtualizarMesa(estado) })
+}
+
+// liberar solta a trava da mesa e só então faz as entregas guardadas enquanto ela estava
+// presa: as mensagens, que podem esperar por conexões lentas, e o fim do jogo. muDaEntrega
+// é pego antes de soltar mu para que as entregas de chamadas diferentes não se misturem
+func (m *Mesa) liberar() {
+	pendentes := m.pendentes
+	m.pendentes = nil
+
+	m.muDaEntrega.Lock()
+	defer m.muDaEntrega.Unlock()
+	m.mu.Unlock()
+
+	for _, entregar := range pendentes {
+		entregar()
+	}
+}
+
+// jogar conduz as mãos até que um jogador que não é um bot precise agir, até um intervalo
+// ou até o fim do jogo. Os bots agem na hora, sem esperar pelo prazo da vez
+func (m *Mesa) jogar() {
+	for !m.encerrada {
+		if m.apostas == nil && !m.começarMao() {
+			return
+		}
+
+		m.abrirCartas()
+
+		if m.apostas.Encerrada() {
+			m.terminarMao()
+			continue
+		}
+
+		m.atualizarMesa()
+
+		lugar := m.naMao[m.apostas.Vez()]
+		jogador := m.jogadores[lugar]
+
+		if jogador.estrategia == nil {
+			m.agendarPrazo()
+			return
+		}
+
+		m.agirPeloBot(lugar, jogador)
+	}
+}
+
+// começarMao embaralha e dá as cartas fechadas. Sem blinds, como em um intervalo, a mesa
+// espera e tenta de novo mais tarde
+func (m *Mesa) começarMao() bool {
+	m.naMao = nil
+	for lugar, jogador := range m.jogadores {
+		jogador.cartas = nil
+		if !jogador.eliminado {
+			m.naMao = append(m.naMao, lugar)
+		}
+	}
+
+	botao := m.proximoNaMao(m.botao)
+
+	lugares := make([]Lugar, len(m.naMao))
+	for i, lugar := range m.naMao {
+		lugares[i] = Lugar{Nome: m.jogadores[lugar].nome, Fichas: m.jogadores[lugar].fichas}
+	}
+
+	apostas, err := NovasApostasNoNivel(m.configuracao.Blinds, lugares, m.indiceNaMao(botao))
+
+	if err != nil {
+		m.prazo = m.configuracao.Relogio.NovoTimer(esperaDoIntervalo, m.tentarComeçarMao)
+		m.atualizarMesa()
+		return false
+	}
+
+	m.numeroDaMao++
+	m.botao = botao
+	m.apostas = apostas
+	m.baralho = NovoBaralhoEmbaralhado(m.configuracao.Aleatorio)
+	m.cartas = nil
+	m.historico = HistoricoDaMao{Blinds: apostas.Blinds(), Botao: botao}
+
+	for _, lugar := range m.naMao {
+		m.historico.Lugares = append(m.historico.Lugares, LugarNoHistorico{Lugar: lugar, Nome: m.jogadores[lugar].nome, Fichas: m.jogadores[lugar].fichas})
+	}
+
+	fechadas, _ := m.baralho.DarCartasFechadas(len(m.naMao))
+	for i, lugar := range m.naMao {
+		lugar, cartas := lugar, fechadas[i]
+		m.jogadores[lugar].cartas = cartas
+		m.enviar(func() { m.destino.EnviarCartas(lugar, cartas) })
+	}
+
+	return true
+}
+
+func (m *Mesa) tentarComeçarMao() {
+	m.mu.Lock()
+	defer m.liberar()
+
+	if !m.encerrada && m.apostas == nil {
+		m.prazo = nil
+		m.jogar()
+	}
+}
+
+func (m *Mesa) depoisDaAcao() {
+	if m.prazo != nil {
+		m.prazo.Parar()
+		m.prazo = nil
+	}
+
+	m.jogar()
+}
+
+// abrirCartas abre as cartas comunitárias que faltam para a rodada atual
+func (m *Mesa) abrirCartas() {
+	abertas := map[Rodada]int{PreFlop: 0, Flop: 3, Turn: 4, River: 5, Showdown: 5}
+
+	for len(m.cartas) < abertas[m.apostas.Rodada()] {
+		n := 1
+		if len(m.cartas) == 0 {
+			n = 3
+		}
+
+		cartas, _ := m.baralho.DarMesa(n)
+		m.cartas = append(m.cartas, cartas...)
+	}
+}
+
+// agirPeloBot aplica a decisão da estratégia do bot; uma ação recusada vira passar ou desistir
+func (m *Mesa) agirPeloBot(lugar int, jogador *jogadorDaMesa) {
+	vez := m.apostas.Vez()
+	situacao := Situacao{Lugar: lugar, Cartas: append([]Carta(nil), jogador.cartas...), Mesa: m.estado()}
+
+	for _, acao := range []Acao{jogador.estrategia.Decidir(situacao), {Tipo: Passar}, {Tipo: Desistir}} {
+		if m.apostas.Agir(vez, acao) == nil {
+			return
+		}
+	}
+}
+
+func (m *Mesa) agendarPrazo() {
+	m.turno++
+
+	if m.configuracao.PrazoDaVez == 0 {
+		return
+	}
+
+	turno := m.turno
+	m.prazo = m.configuracao.Relogio.NovoTimer(m.configuracao.PrazoDaVez, func() {
+		m.esgotarPrazo(turno)
+	})
+}
+
+// esgotarPrazo faz o jogador da vez desistir se ele não agiu no prazo
+func (m *Mesa) esgotarPrazo(turno int) {
+	m.mu.Lock()
+	defer m.liberar()
+
+	if m.encerrada || m.apostas == nil || turno != m.turno {
+		return
+	}
+
+	m.prazo = nil
+	m.apostas.Agir(m.apostas.Vez(), Acao{Tipo: Desistir})
+	m.jogar()
+}
+
+// terminarMao paga os potes, elimina quem ficou sem fichas, registra o histórico da mão se
+// o destino o guarda e, se resta um jogador, termina o jogo. O destino e aoTerminar só são
+// chamados depois que a trava da mesa é solta
+func (m *Mesa) terminarMao() {
+	fechadas := make([][]Carta, len(m.naMao))
+	for i, lugar := range m.naMao {
+		fechadas[i] = m.jogadores[lugar].cartas
+	}
+
+	var combinacoes []Combinacao
+	if m.apostas.Rodada() == Showdown {
+		combinacoes, _ = AvaliarJogadores(fechadas, m.cartas)
+	}
+
+	ganhos, _ := m.apostas.Distribuir(combinacoes)
+	resultado := resultadoDasApostas(m.numeroDaMao, m.cartas, m.apostas, m.naMao, fechadas, combinacoes, ganhos)
+
+	for i, estado := range m.apostas.Jogadores() {
+		jogador := m.jogadores[m.naMao[i]]
+		jogador.fichas = estado.Fichas
+		jogador.eliminado = jogador.fichas == 0
+	}
+
+	m.historico.Acoes = acoesNoHistorico(m.apostas.Acoes(), m.naMao)
+	m.apostas = nil
+	restantes := m.restantes()
+
+	if len(restantes) == 1 {
+		m.encerrada = true
+		resultado.Vencedor = restantes[0].nome
+	}
+
+	m.atualizarMesa()
+	m.enviar(func() { m.destino.MostrarResultado(resultado) })
+
+	if registro, ok := m.destino.(RegistroDeMaos); ok {
+		m.historico.Resultado = resultado
+		historico := m.historico
+		m.enviar(func() { registro.RegistrarMao(historico) })
+	}
+
+	if m.encerrada && m.aoTerminar != nil {
+		m.enviar(func() { m.aoTerminar(resultado.Vencedor) })
+	}
+}
+
+func (m *Mesa) restantes() []*jogadorDaMesa {
+	var restantes []*jogadorDaMesa
+	for _, jogador := range m.jogadores {
+		if !jogador.eliminado {
+			restantes = append(restantes, jogador)
+		}
+	}
+	return restantes
+}
+
+// proximoNaMao retorna o primeiro lugar depois do recebido que joga a mão
+func (m *Mesa) proximoNaMao(lugar int) int {
+	for i := 1; i <= len(m.jogadores); i++ {
+		proximo := (lugar + i + len(m.jogadores)) % len(m.jogadores)
+		if m.indiceNaMao(proximo) >= 0 {
+			return proximo
+		}
+	}
+	return lugar
+}
+
+func (m *Mesa) indiceNaMao(lugar int) int {
+	for i, naMao := range m.naMao {
+		if naMao == lugar {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Mesa) estado() EstadoDaMesa {
+	estado := EstadoDaMesa{
+		Mao:    m.numeroDaMao,
+		Cartas: append([]Carta{}, m.cartas...),
+		Botao:  m.botao,
+		Vez:    -1,
+	}
+
+	for _, jogador := range m.jogadores {
+		estado.Jogadores = append(estado.Jogadores, JogadorNaMesa{
+			Nome:      jogador.nome,
+			Fichas:    jogador.fichas,
+			Eliminado: jogador.eliminado,
+			Bot:       jogador.estrategia != nil,
+		})
+	}
+
+	if m.apostas == nil {
+		return estado
+	}
+
+	estado.Rodada = m.apostas.Rodada().String()
+	estado.Pote = m.apostas.Pote()
+
+	for i, apostador := range m.apostas.Jogadores() {
+		jogador := &estado.Jogadores[m.naMao[i]]
+		jogador.Fichas = apostador.Fichas
+		jogador.Aposta = apostador.Aposta
+		jogador.Desistiu = apostador.Desistiu
+		jogador.AllIn = apostador.AllIn
+	}
+
+	if vez := m.apostas.Vez(); vez >= 0 {
+		opcoes := m.apostas.Opcoes()
+		opcoes.Jogador = m.naMao[vez]
+		estado.Vez = opcoes.Jogador
+		estado.Opcoes = &opcoes
+	}
+
+	return estado
+}
@@ -0,0 +1,355 @@
+package poquer_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+// DestinoDaMesaEspiao guarda tudo o que a mesa enviou
+type DestinoDaMesaEspiao struct {
+	Cartas     map[int][][]poquer.Carta
+	Estados    []poquer.EstadoDaMesa
+	Resultados []poquer.ResultadoDaMao
+	Historicos []poquer.HistoricoDaMao
+}
+
+func (d *DestinoDaMesaEspiao) EnviarCartas(lugar int, cartas []poquer.Carta) {
+	if d.Cartas == nil {
+		d.Cartas = map[int][][]poquer.Carta{}
+	}
+	d.Cartas[lugar] = append(d.Cartas[lugar], cartas)
+}
+
+func (d *DestinoDaMesaEspiao) AtualizarMesa(estado poquer.EstadoDaMesa) {
+	d.Estados = append(d.Estados, estado)
+}
+
+func (d *DestinoDaMesaEspiao) MostrarResultado(resultado poquer.ResultadoDaMao) {
+	d.Resultados = append(d.Resultados, resultado)
+}
+
+func (d *DestinoDaMesaEspiao) RegistrarMao(historico poquer.HistoricoDaMao) {
+	d.Historicos = append(d.Historicos, historico)
+}
+
+func (d *DestinoDaMesaEspiao) ultimoEstado() poquer.EstadoDaMesa {
+	return d.Estados[len(d.Estados)-1]
+}
+
+const prazoDaVez = 30 * time.Second
+
+func TestMesa(t *testing.T) {
+	t.Run("espera todos os lugares serem ocupados e dá duas cartas só para cada lugar", func(t *testing.T) {
+		mesa, destino, _ := novaMesaDeTeste(t, 3, 1000, nil)
+
+		sentar(t, mesa, "Cleo", 0)
+		sentar(t, mesa, "Chris", 1)
+
+		if mesa.Começar() {
+			t.Fatal("a mesa não deveria começar com lugares vazios")
+		}
+
+		sentar(t, mesa, "Ruth", 2)
+		sentar(t, mesa, "Cleo", 0)
+
+		if _, err := mesa.Sentar("Tiest"); err != poquer.ErrMesaCheia {
+			t.Errorf("obtido %v, esperado %v", err, poquer.ErrMesaCheia)
+		}
+
+		if !mesa.Começar() || mesa.Começar() {
+			t.Fatal("a mesa deveria começar uma única vez")
+		}
+
+		vistas := map[poquer.Carta]bool{}
+		for lugar := 0; lugar < 3; lugar++ {
+			if len(destino.Cartas[lugar]) != 1 || len(destino.Cartas[lugar][0]) != 2 {
+				t.Fatalf("o lugar %d deveria receber duas cartas, obtido %v", lugar, destino.Cartas[lugar])
+			}
+			for _, carta := range mesa.Cartas(lugar) {
+				vistas[carta] = true
+			}
+		}
+
+		if len(vistas) != 6 {
+			t.Errorf("as cartas fechadas deveriam ser distintas, obtido %v", destino.Cartas)
+		}
+
+		estado := destino.ultimoEstado()
+		verificaEstadoDaMesa(t, estado, 1, "pré-flop", 0, 15)
+
+		if estado.Botao != 0 || estado.Opcoes == nil || estado.Opcoes.Jogador != 0 || estado.Opcoes.ParaPagar != 10 {
+			t.Errorf("esperava o botão no lugar 0 pagando 10, obtido %+v", estado)
+		}
+
+		if len(estado.Cartas) != 0 {
+			t.Errorf("a mesa não deveria ter cartas no pré-flop, obtido %v", estado.Cartas)
+		}
+	})
+
+	t.Run("recusa ações fora da vez e abre as cartas a cada rodada", func(t *testing.T) {
+		mesa, destino, _ := novaMesaCheia(t, 1000, nil, "Cleo", "Chris", "Ruth")
+
+		verificaErro(t, mesa.Agir(1, poquer.Acao{Tipo: poquer.Pagar}), poquer.ErrForaDaVez)
+
+		agirNaMesa(t, mesa, 0, poquer.Pagar)
+		agirNaMesa(t, mesa, 1, poquer.Pagar)
+		agirNaMesa(t, mesa, 2, poquer.Passar)
+
+		verificaEstadoDaMesa(t, destino.ultimoEstado(), 1, "flop", 1, 30)
+		verificaCartasNaMesa(t, destino.ultimoEstado(), 3)
+
+		for _, rodada := range []struct {
+			nome   string
+			cartas int
+		}{{"turn", 4}, {"river", 5}} {
+			agirNaMesa(t, mesa, 1, poquer.Passar)
+			agirNaMesa(t, mesa, 2, poquer.Passar)
+			agirNaMesa(t, mesa, 0, poquer.Passar)
+
+			verificaEstadoDaMesa(t, destino.ultimoEstado(), 1, rodada.nome, 1, 30)
+			verificaCartasNaMesa(t, destino.ultimoEstado(), rodada.cartas)
+		}
+
+		agirNaMesa(t, mesa, 1, poquer.Passar)
+		agirNaMesa(t, mesa, 2, poquer.Passar)
+		agirNaMesa(t, mesa, 0, poquer.Passar)
+
+		if len(destino.Resultados) != 1 {
+			t.Fatalf("esperava o resultado da primeira mão, obtido %v", destino.Resultados)
+		}
+
+		resultado := destino.Resultados[0]
+		if len(resultado.Cartas) != 5 || len(resultado.Jogadores) != 3 {
+			t.Errorf("no showdown, todos mostram as cartas, obtido %+v", resultado)
+		}
+
+		ganhos := 0
+		for _, jogador := range resultado.Jogadores {
+			ganhos += jogador.Ganhos
+			if len(jogador.Cartas) != 2 || jogador.Combinacao == "" {
+				t.Errorf("o jogador %s deveria mostrar as cartas, obtido %+v", jogador.Nome, jogador)
+			}
+		}
+
+		if ganhos != 30 {
+			t.Errorf("os ganhos deveriam somar o pote de 30, obtido %d", ganhos)
+		}
+
+		estado := destino.ultimoEstado()
+		verificaEstadoDaMesa(t, estado, 2, "pré-flop", 1, 15)
+
+		if estado.Botao != 1 {
+			t.Errorf("o botão deveria passar para o lugar 1, obtido %d", estado.Botao)
+		}
+
+		verificaFichasDaMesa(t, estado, 3000)
+	})
+
+	t.Run("quem não age no prazo desiste da mão", func(t *testing.T) {
+		mesa, destino, relogio := novaMesaCheia(t, 1000, nil, "Cleo", "Chris")
+
+		relogio.Avancar(prazoDaVez - time.Second)
+		if len(destino.Resultados) != 0 {
+			t.Fatal("o jogador ainda estava no prazo")
+		}
+
+		relogio.Avancar(time.Second)
+
+		if len(destino.Resultados) != 1 {
+			t.Fatalf("esperava que o botão desistisse, obtido %v", destino.Resultados)
+		}
+
+		resultado := destino.Resultados[0]
+		if len(resultado.Jogadores) != 1 || resultado.Jogadores[0].Nome != "Chris" || resultado.Jogadores[0].Ganhos != 15 {
+			t.Errorf("Chris deveria levar o pote de 15 sem mostrar as cartas, obtido %+v", resultado.Jogadores)
+		}
+
+		if resultado.Jogadores[0].Cartas != nil {
+			t.Errorf("as cartas não deveriam ser mostradas sem showdown, obtido %v", resultado.Jogadores[0].Cartas)
+		}
+
+		agirNaMesa(t, mesa, 1, poquer.Pagar)
+		relogio.Avancar(prazoDaVez / 2)
+		agirNaMesa(t, mesa, 0, poquer.Passar)
+		relogio.Avancar(prazoDaVez / 2)
+
+		if len(destino.Resultados) != 1 {
+			t.Error("o prazo deveria recomeçar a cada ação")
+		}
+	})
+
+	t.Run("termina quando um jogador fica com todas as fichas", func(t *testing.T) {
+		var vencedor string
+		mesa, destino, _ := novaMesaCheia(t, 100, func(nome string) { vencedor = nome }, "Cleo", "Chris", "Ruth")
+
+		for maos := 0; !mesa.Encerrada(); maos++ {
+			if maos > 200 {
+				t.Fatal("a mesa deveria terminar")
+			}
+
+			estado := mesa.Estado()
+			agirNaMesa(t, mesa, estado.Vez, poquer.AllIn)
+		}
+
+		ultimo := destino.Resultados[len(destino.Resultados)-1]
+
+		if vencedor == "" || ultimo.Vencedor != vencedor {
+			t.Errorf("aoTerminar deveria receber o vencedor %q, obtido %q", ultimo.Vencedor, vencedor)
+		}
+
+		estado := destino.ultimoEstado()
+		verificaFichasDaMesa(t, estado, 300)
+
+		for _, jogador := range estado.Jogadores {
+			if (jogador.Nome == vencedor) == jogador.Eliminado {
+				t.Errorf("apenas o vencedor deveria continuar na mesa, obtido %+v", jogador)
+			}
+		}
+
+		verificaErro(t, mesa.Agir(0, poquer.Acao{Tipo: poquer.Passar}), poquer.ErrMesaEncerrada)
+	})
+
+	t.Run("avisa o destino e chama aoTerminar depois de soltar a mesa", func(t *testing.T) {
+		var mesa *poquer.Mesa
+		estados := make(chan poquer.EstadoDaMesa, 1)
+		aoTerminar := func(string) { estados <- mesa.Estado() }
+		mesa, _, _ = novaMesaCheia(t, 100, aoTerminar, "Cleo", "Chris")
+
+		within(t, time.Second, func() {
+			for maos := 0; !mesa.Encerrada() && maos <= 200; maos++ {
+				mesa.Agir(mesa.Estado().Vez, poquer.Acao{Tipo: poquer.AllIn})
+			}
+		})
+
+		select {
+		case estado := <-estados:
+			verificaFichasDaMesa(t, estado, 200)
+		default:
+			t.Error("aoTerminar deveria ter sido chamado")
+		}
+	})
+
+	t.Run("espera o intervalo terminar para dar a próxima mão", func(t *testing.T) {
+		blinds := &poquer.ControleDeBlindsFixo{Atual: poquer.EstadoDosBlinds{Intervalo: true}}
+		relogio := poquer.NovoRelogioFalso()
+		destino := &DestinoDaMesaEspiao{}
+
+		mesa, err := poquer.NovaMesa(poquer.ConfiguracaoDaMesa{
+			Lugares:        2,
+			FichasIniciais: 1000,
+			Blinds:         blinds,
+			Relogio:        relogio,
+			Aleatorio:      rand.New(rand.NewSource(1)),
+		}, destino, nil)
+		verificaSemErro(t, err)
+
+		sentar(t, mesa, "Cleo", 0)
+		sentar(t, mesa, "Chris", 1)
+		mesa.Começar()
+
+		verificaErro(t, mesa.Agir(0, poquer.Acao{Tipo: poquer.Pagar}), poquer.ErrSemMao)
+
+		blinds.Atual = poquer.EstadoDosBlinds{Nivel: 2, Blinds: blindsDe5e10}
+		relogio.Avancar(10 * time.Second)
+
+		verificaEstadoDaMesa(t, destino.ultimoEstado(), 1, "pré-flop", 0, 15)
+	})
+
+	t.Run("recusa configurações inválidas", func(t *testing.T) {
+		blinds := &poquer.ControleDeBlindsFixo{}
+
+		for nome, configuracao := range map[string]poquer.ConfiguracaoDaMesa{
+			"um lugar":               {Lugares: 1, FichasIniciais: 100, Blinds: blinds},
+			"lugares demais":         {Lugares: poquer.MaximoDeLugares + 1, FichasIniciais: 100, Blinds: blinds},
+			"sem fichas":             {Lugares: 2, Blinds: blinds},
+			"prazo negativo":         {Lugares: 2, FichasIniciais: 100, Blinds: blinds, PrazoDaVez: -time.Second},
+			"sem controle de blinds": {Lugares: 2, FichasIniciais: 100},
+		} {
+			if _, err := poquer.NovaMesa(configuracao, &DestinoDaMesaEspiao{}, nil); err == nil {
+				t.Errorf("esperava um erro para a mesa com %s", nome)
+			}
+		}
+	})
+}
+
+func novaMesaDeTeste(t *testing.T, lugares, fichas int, aoTerminar func(string)) (*poquer.Mesa, *DestinoDaMesaEspiao, *poquer.RelogioFalso) {
+	t.Helper()
+
+	relogio := poquer.NovoRelogioFalso()
+	destino := &DestinoDaMesaEspiao{}
+
+	mesa, err := poquer.NovaMesa(poquer.ConfiguracaoDaMesa{
+		Lugares:        lugares,
+		FichasIniciais: fichas,
+		Blinds:         &poquer.ControleDeBlindsFixo{Atual: poquer.EstadoDosBlinds{Nivel: 1, Blinds: blindsDe5e10}},
+		PrazoDaVez:     prazoDaVez,
+		Relogio:        relogio,
+		Aleatorio:      rand.New(rand.NewSource(1)),
+	}, destino, aoTerminar)
+
+	verificaSemErro(t, err)
+	return mesa, destino, relogio
+}
+
+func novaMesaCheia(t *testing.T, fichas int, aoTerminar func(string), nomes ...string) (*poquer.Mesa, *DestinoDaMesaEspiao, *poquer.RelogioFalso) {
+	t.Helper()
+
+	mesa, destino, relogio := novaMesaDeTeste(t, len(nomes), fichas, aoTerminar)
+	for lugar, nome := range nomes {
+		sentar(t, mesa, nome, lugar)
+	}
+	mesa.Começar()
+
+	return mesa, destino, relogio
+}
+
+func sentar(t *testing.T, mesa *poquer.Mesa, nome string, esperado int) {
+	t.Helper()
+
+	lugar, err := mesa.Sentar(nome)
+	verificaSemErro(t, err)
+
+	if lugar != esperado {
+		t.Errorf("%s sentou no lugar %d, esperado %d", nome, lugar, esperado)
+	}
+}
+
+func agirNaMesa(t testing.TB, mesa *poquer.Mesa, lugar int, tipo poquer.TipoDeAcao) {
+	t.Helper()
+
+	if err := mesa.Agir(lugar, poquer.Acao{Tipo: tipo}); err != nil {
+		t.Fatalf("o lugar %d não conseguiu %s, %v", lugar, tipo, err)
+	}
+}
+
+func verificaEstadoDaMesa(t testing.TB, estado poquer.EstadoDaMesa, mao int, rodada string, vez, pote int) {
+	t.Helper()
+
+	if estado.Mao != mao || estado.Rodada != rodada || estado.Vez != vez || estado.Pote != pote {
+		t.Errorf("esperava a mão %d no %s com a vez do lugar %d e pote %d, obtido %+v", mao, rodada, vez, pote, estado)
+	}
+}
+
+func verificaCartasNaMesa(t testing.TB, estado poquer.EstadoDaMesa, esperado int) {
+	t.Helper()
+
+	if len(estado.Cartas) != esperado {
+		t.Errorf("esperava %d cartas abertas, obtido %v", esperado, estado.Cartas)
+	}
+}
+
+func verificaFichasDaMesa(t testing.TB, estado poquer.EstadoDaMesa, esperado int) {
+	t.Helper()
+
+	total := estado.Pote
+	for _, jogador := range estado.Jogadores {
+		total += jogador.Fichas
+	}
+
+	if total != esperado {
+		t.Errorf("as fichas deveriam somar %d, obtido %d em %+v", esperado, total, estado)
+	}
+}
@@ -0,0 +1,268 @@
+package poquer
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// EspecificacaoOpenAPI descreve as rotas do ServidorJogador no formato OpenAPI 3
+const EspecificacaoOpenAPI = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "Servidor de pôquer",
+    "description": "Liga de pôquer com placar de vitórias, jogo com alertas de blind e websocket.",
+    "version": "1.0.0"
+  },
+  "paths": {
+    "/liga": {
+      "get": {
+        "operationId": "obterLiga",
+        "summary": "Retorna os jogadores ordenados por vitórias",
+        "responses": {
+          "200": {
+            "description": "Tabela da liga",
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/Liga"}
+              }
+            }
+          }
+        }
+      }
+    },
+    "/jogadores/{nome}": {
+      "parameters": [
+        {"name": "nome", "in": "path", "required": true, "schema": {"type": "string"}}
+      ],
+      "get": {
+        "operationId": "obtemPontuacao",
+        "summary": "Retorna o número de vitórias de um jogador",
+        "responses": {
+          "200": {
+            "description": "Número de vitórias",
+            "content": {"text/plain": {"schema": {"type": "integer"}}}
+          },
+          "404": {
+            "description": "Jogador sem vitórias",
+            "content": {"text/plain": {"schema": {"type": "integer"}}}
+          }
+        }
+      },
+      "post": {
+        "operationId": "gravarVitoria",
+        "summary": "Grava uma vitória para o jogador",
+        "parameters": [
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "required": false,
+            "description": "Requisições repetidas com a mesma chave recebem a resposta original",
+            "schema": {"type": "string"}
+          }
+        ],
+        "responses": {
+          "202": {"description": "Vitória gravada"},
+          "422": {"description": "Chave de idempotência já usada para outro jogador"},
+          "429": {
+            "description": "Limite de requisições atingido",
+            "headers": {
+              "Retry-After": {"description": "Segundos até a próxima tentativa", "schema": {"type": "integer"}}
+            }
+          }
+        }
+      }
+    },
+    "/jogo": {
+      "get": {
+        "operationId": "jogarJogo",
+        "summary": "Página para conduzir um jogo pelo navegador",
+        "responses": {
+          "200": {"description": "Página HTML", "content": {"text/html": {}}}
+        }
+      }
+    },
+    "/jogos/{id}/maos": {
+      "parameters": [
+        {"name": "id", "in": "path", "required": true, "description": "Código da sala em que o jogo com mesa foi jogado", "schema": {"type": "string"}}
+      ],
+      "get": {
+        "operationId": "obterMaos",
+        "summary": "Histórico em texto das mãos jogadas na mesa do jogo: lugares, blinds, botão, ações de cada rodada, cartas da mesa, showdown e ganhos",
+        "responses": {
+          "200": {"description": "Mãos na ordem em que foram jogadas, separadas por uma linha em branco", "content": {"text/plain": {}}},
+          "404": {"description": "Nenhuma mão jogada no jogo", "content": {"text/plain": {}}},
+          "405": {"description": "Método HTTP diferente de GET"}
+        }
+      }
+    },
+    "/equidade": {
+      "post": {
+        "operationId": "calcularEquidade",
+        "summary": "Calcula a chance de cada jogador ganhar o pote com as suas cartas fechadas e as cartas da mesa: avalia todas as mesas possíveis quando são até 50000, o que cobre qualquer mão a partir do flop, ou sorteia simulacoes mesas (50000 se ausente, no máximo 50000). Cada cliente está sujeito ao limite de requisições e poucos cálculos rodam ao mesmo tempo",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {"$ref": "#/components/schemas/PedidoDeEquidade"}
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "Equidade de cada jogador, na ordem do pedido",
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/ResultadoDaEquidade"}
+              }
+            }
+          },
+          "400": {"description": "Cartas inválidas ou repetidas, número de jogadores ou de cartas na mesa inválido, ou simulações demais", "content": {"text/plain": {}}},
+          "405": {"description": "Método HTTP diferente de POST"},
+          "429": {
+            "description": "Limite de requisições do cliente atingido",
+            "headers": {
+              "Retry-After": {"description": "Segundos até a próxima tentativa", "schema": {"type": "integer"}}
+            }
+          },
+          "503": {
+            "description": "Muitos cálculos em andamento",
+            "headers": {
+              "Retry-After": {"description": "Segundos até a próxima tentativa", "schema": {"type": "integer"}}
+            }
+          }
+        }
+      }
+    },
+    "/ws": {
+      "get": {
+        "operationId": "webSocket",
+        "parameters": [
+          {"name": "sala", "in": "query", "required": false, "description": "Código de uma sala aberta para acompanhar", "schema": {"type": "string"}}
+        ],
+        "summary": "Websocket do jogo com mensagens JSON {versao, tipo, dados}: o cliente envia iniciar (com numeroDeJogadores e, opcionalmente, o nome de uma estrutura de /estruturas; com jogadores e fichasIniciais, o jogo é um torneio, que com buyIn paga prêmios pelos percentuais de premiacao e sorteia os inscritos em mesas de lugaresPorMesa lugares, 10 se ausente) ou retomar, depois controle (comando pausar, continuar, avancar ou voltar), torneio (comando eliminar, recomprar, addon ou fichas para um jogador, ou botao para uma mesa) e declarar_vencedor, o servidor envia sala_criada, retomado, alerta_blind, relogio, classificacao, lugares (as mesas com o botão e os jogadores em cada lugar, e quem mudou de lugar para equilibrar as mesas, desfazer uma mesa ou depois de uma recompra), entrou, saiu, erro e fim. Um torneio termina sozinho quando resta um jogador. Com mesa e fichasIniciais, o servidor dá as cartas: cada conexão envia sentar (nome, e o segredo do lugar para voltar a ele depois de uma queda de conexão) e acao (tipo desistir, passar, pagar, apostar, aumentar ou allin, com a quantia total da aposta), recebe sentado (com o segredo do lugar) e só as suas cartas, e todos recebem mesa e resultado; quem não age em prazoDaVez segundos desiste, bots lugares são ocupados por bots (chamados Bot 1, Bot 2..., nomes que ninguém mais pode usar, e cujas vitórias não entram na liga), e o jogo termina quando um jogador fica com todas as fichas. Com ?sala=CODIGO a conexão acompanha o jogo daquela sala e pode sentar à mesa",
+        "responses": {
+          "101": {"description": "Conexão atualizada para websocket"},
+          "400": {"description": "A requisição não é um handshake de websocket"},
+          "403": {"description": "A origem da página não está entre as origens permitidas"}
+        }
+      }
+    },
+    "/rpc": {
+      "post": {
+        "operationId": "rpc",
+        "summary": "JSON-RPC 2.0 com os métodos jogadores.pontuacao, jogadores.gravarVitoria, liga.obter, jogo.comecar (recebe numeroDeJogadores e estrutura, devolve o id do jogo e um token) e jogo.terminar (recebe o id, o token devolvido por jogo.comecar e o vencedor; só termina jogos começados pelo RPC). jogo.comecar segue o limite de taxa do cliente, no máximo 100 jogos ficam abertos e um jogo não terminado em uma hora é abandonado; aceita lotes de até 20 chamadas, com o corpo de até 64 KiB",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {}}
+        },
+        "responses": {
+          "200": {"description": "Resposta ou lote de respostas JSON-RPC", "content": {"application/json": {}}},
+          "204": {"description": "Apenas notificações foram enviadas"},
+          "405": {"description": "Método HTTP diferente de POST"}
+        }
+      }
+    },
+    "/estruturas": {
+      "get": {
+        "operationId": "obterEstruturas",
+        "summary": "Lista as estruturas de blinds que podem ser escolhidas ao começar um jogo",
+        "responses": {
+          "200": {
+            "description": "Estruturas ordenadas pelo nome",
+            "content": {
+              "application/json": {
+                "schema": {"type": "array", "items": {"$ref": "#/components/schemas/EstruturaDeBlinds"}}
+              }
+            }
+          }
+        }
+      }
+    },
+    "/openapi.json": {
+      "get": {
+        "operationId": "obterEspecificacao",
+        "summary": "Este documento",
+        "responses": {
+          "200": {"description": "Especificação OpenAPI", "content": {"application/json": {}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Jogador": {
+        "type": "object",
+        "required": ["Nome", "Vitorias", "Ganhos"],
+        "properties": {
+          "Nome": {"type": "string"},
+          "Vitorias": {"type": "integer"},
+          "Colocacoes": {"type": "array", "items": {"type": "integer"}, "description": "Colocação do jogador em cada torneio que terminou"},
+          "Ganhos": {"type": "integer", "description": "Soma dos prêmios que o jogador ganhou nos torneios"}
+        }
+      },
+      "Liga": {
+        "type": "array",
+        "items": {"$ref": "#/components/schemas/Jogador"}
+      },
+      "NivelDeBlind": {
+        "type": "object",
+        "properties": {
+          "smallBlind": {"type": "integer", "description": "Ausente quando o nível tem um único blind"},
+          "bigBlind": {"type": "integer"},
+          "ante": {"type": "integer"},
+          "duracao": {"type": "string", "description": "Duração própria do nível, como \"15m\""},
+          "intervalo": {"type": "boolean"}
+        }
+      },
+      "Carta": {
+        "type": "string",
+        "description": "Valor (2-9, T, J, Q, K, A) e naipe (c, d, h, s), como \"Ah\" ou \"Td\""
+      },
+      "PedidoDeEquidade": {
+        "type": "object",
+        "required": ["jogadores"],
+        "properties": {
+          "jogadores": {"type": "array", "minItems": 2, "maxItems": 10, "items": {"type": "array", "minItems": 2, "maxItems": 2, "items": {"$ref": "#/components/schemas/Carta"}}},
+          "mesa": {"type": "array", "description": "0, 3, 4 ou 5 cartas", "items": {"$ref": "#/components/schemas/Carta"}},
+          "simulacoes": {"type": "integer"}
+        }
+      },
+      "ResultadoDaEquidade": {
+        "type": "object",
+        "required": ["exaustivo", "mesas", "jogadores"],
+        "properties": {
+          "exaustivo": {"type": "boolean", "description": "Todas as mesas possíveis foram avaliadas e o resultado é exato"},
+          "mesas": {"type": "integer"},
+          "jogadores": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "cartas": {"type": "array", "items": {"$ref": "#/components/schemas/Carta"}},
+                "vitorias": {"type": "integer", "description": "Mesas em que o jogador vence sozinho"},
+                "empates": {"type": "integer", "description": "Mesas em que o jogador divide o pote"},
+                "equidade": {"type": "number", "description": "Percentual do pote que cabe ao jogador na média das mesas"}
+              }
+            }
+          }
+        }
+      },
+      "EstruturaDeBlinds": {
+        "type": "object",
+        "required": ["nome", "niveis"],
+        "properties": {
+          "nome": {"type": "string"},
+          "descricao": {"type": "string"},
+          "duracaoDoNivel": {"type": "string", "description": "Duração dos níveis sem duração própria; ausente faz cada nível durar 5 minutos mais um por jogador"},
+          "niveis": {"type": "array", "items": {"$ref": "#/components/schemas/NivelDeBlind"}}
+        }
+      }
+    }
+  }
+}
+`
+
+func (p *ServidorJogador) manipulaOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", tipoConteudoJSON)
+	fmt.Fprint(w, EspecificacaoOpenAPI)
+}
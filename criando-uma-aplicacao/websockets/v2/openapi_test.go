@@ -0,0 +1,141 @@
+package poquer_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+type especificacao struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+type operacao struct {
+	OperationID string                         `json:"operationId"`
+	Responses   map[string]respostaDocumentada `json:"responses"`
+}
+
+type respostaDocumentada struct {
+	Content map[string]json.RawMessage `json:"content"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	armazenamento := &poquer.EsbocoDeArmazenamentoJogador{
+		Pontuações: map[string]int{"Pepper": 3},
+		Liga:       []poquer.Jogador{{Nome: "Pepper", Vitorias: 3}},
+	}
+	servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco)
+
+	resposta := httptest.NewRecorder()
+	servidor.ServeHTTP(resposta, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	verificaStatus(t, resposta, http.StatusOK)
+	verificaTipoDoConteudo(t, resposta, "application/json")
+
+	var spec especificacao
+	if err := json.NewDecoder(resposta.Body).Decode(&spec); err != nil {
+		t.Fatalf("a especificação não é um JSON válido, %v", err)
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("esperava uma especificação OpenAPI 3, obtido %q", spec.OpenAPI)
+	}
+
+	t.Run("documenta todas as rotas do servidor", func(t *testing.T) {
+		var obtidas []string
+		for caminho := range spec.Paths {
+			obtidas = append(obtidas, caminho)
+		}
+		sort.Strings(obtidas)
+
+		esperadas := []string{"/equidade", "/estruturas", "/jogadores/{nome}", "/jogo", "/jogos/{id}/maos", "/liga", "/openapi.json", "/rpc", "/ws"}
+		if strings.Join(obtidas, " ") != strings.Join(esperadas, " ") {
+			t.Errorf("obtido %v, esperado %v", obtidas, esperadas)
+		}
+	})
+
+	casos := []struct {
+		metodo  string
+		caminho string
+	}{
+		{http.MethodGet, "/liga"},
+		{http.MethodGet, "/jogadores/Pepper"},
+		{http.MethodGet, "/jogadores/Apollo"},
+		{http.MethodPost, "/jogadores/Pepper"},
+		{http.MethodGet, "/jogo"},
+		{http.MethodGet, "/jogos/ABCD/maos"},
+		{http.MethodGet, "/ws"},
+		{http.MethodGet, "/openapi.json"},
+		{http.MethodGet, "/estruturas"},
+		{http.MethodPost, "/rpc"},
+		{http.MethodPost, "/equidade"},
+	}
+
+	for _, c := range casos {
+		t.Run(c.metodo+" "+c.caminho+" responde como documentado", func(t *testing.T) {
+			op := operacaoDocumentada(t, spec, c.metodo, c.caminho)
+
+			resposta := httptest.NewRecorder()
+			servidor.ServeHTTP(resposta, httptest.NewRequest(c.metodo, c.caminho, nil))
+
+			documentada, existe := op.Responses[strconv.Itoa(resposta.Code)]
+			if !existe {
+				t.Fatalf("status %d não está documentado para %s", resposta.Code, op.OperationID)
+			}
+
+			if _, ehJSON := documentada.Content["application/json"]; ehJSON {
+				var corpo interface{}
+				if err := json.Unmarshal(resposta.Body.Bytes(), &corpo); err != nil {
+					t.Errorf("esperava um corpo JSON, %v", err)
+				}
+			}
+		})
+	}
+}
+
+func operacaoDocumentada(t *testing.T, spec especificacao, metodo, caminho string) operacao {
+	t.Helper()
+
+	for modelo, operacoes := range spec.Paths {
+		if !caminhoCorresponde(modelo, caminho) {
+			continue
+		}
+		if bruta, existe := operacoes[strings.ToLower(metodo)]; existe {
+			var op operacao
+			if err := json.Unmarshal(bruta, &op); err != nil {
+				t.Fatalf("operação %s %s inválida, %v", metodo, modelo, err)
+			}
+			return op
+		}
+	}
+
+	t.Fatalf("%s %s não está documentado", metodo, caminho)
+	return operacao{}
+}
+
+func caminhoCorresponde(modelo, caminho string) bool {
+	partesModelo := strings.Split(modelo, "/")
+	partesCaminho := strings.Split(caminho, "/")
+
+	if len(partesModelo) != len(partesCaminho) {
+		return false
+	}
+
+	for i, parte := range partesModelo {
+		if strings.HasPrefix(parte, "{") {
+			continue
+		}
+		if parte != partesCaminho[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,95 @@
+package poquer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PremiacaoPara retorna os percentuais do total de prêmios pagos a cada colocação em um
+// torneio com o número de inscritos: quanto mais inscritos, mais colocações são pagas
+func PremiacaoPara(inscritos int) []float64 {
+	switch {
+	case inscritos <= 4:
+		return []float64{100}
+	case inscritos <= 7:
+		return []float64{65, 35}
+	case inscritos <= 10:
+		return []float64{50, 30, 20}
+	case inscritos <= 20:
+		return []float64{45, 27, 18, 10}
+	}
+	return []float64{40, 25, 15, 12, 8}
+}
+
+// ValidarPremiacao confere se os percentuais são positivos e somam 100
+func ValidarPremiacao(percentuais []float64) error {
+	if len(percentuais) == 0 {
+		return errors.New("a premiação precisa de pelo menos uma colocação")
+	}
+
+	soma := 0.0
+	for i, percentual := range percentuais {
+		if percentual <= 0 {
+			return fmt.Errorf("o percentual do %dº lugar deve ser positivo, obtido %v", i+1, percentual)
+		}
+		soma += percentual
+	}
+
+	if math.Abs(soma-100) > 0.01 {
+		return fmt.Errorf("os percentuais da premiação devem somar 100, obtido %v", soma)
+	}
+
+	return nil
+}
+
+// LerPremiacao converte percentuais separados por vírgula, como "50,30,20", em uma premiação
+// válida. Um texto vazio retorna nenhuma premiação
+func LerPremiacao(texto string) ([]float64, error) {
+	if strings.TrimSpace(texto) == "" {
+		return nil, nil
+	}
+
+	var percentuais []float64
+
+	for _, parte := range strings.Split(texto, ",") {
+		percentual, err := strconv.ParseFloat(strings.TrimSpace(parte), 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("problema ao ler o percentual %q da premiação, %v", parte, err)
+		}
+
+		percentuais = append(percentuais, percentual)
+	}
+
+	if err := ValidarPremiacao(percentuais); err != nil {
+		return nil, err
+	}
+
+	return percentuais, nil
+}
+
+// CalcularPremios divide o total de prêmios entre as colocações pelos percentuais. Os
+// prêmios são arredondados para baixo e o que sobra do arredondamento vai para o primeiro lugar
+func CalcularPremios(total int, percentuais []float64) ([]int, error) {
+	if total < 0 {
+		return nil, fmt.Errorf("o total de prêmios não pode ser negativo, obtido %d", total)
+	}
+
+	if err := ValidarPremiacao(percentuais); err != nil {
+		return nil, err
+	}
+
+	premios := make([]int, len(percentuais))
+	distribuido := 0
+
+	for i, percentual := range percentuais {
+		premios[i] = int(math.Floor(float64(total)*percentual/100 + 1e-9))
+		distribuido += premios[i]
+	}
+
+	premios[0] += total - distribuido
+	return premios, nil
+}
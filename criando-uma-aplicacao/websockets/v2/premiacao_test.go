@@ -0,0 +1,89 @@
+package poquer_test
+
+import (
+	"reflect"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestPremiacaoPara(t *testing.T) {
+	colocacoesPagas := map[int]int{2: 1, 4: 1, 5: 2, 8: 3, 12: 4, 50: 5}
+
+	for inscritos, esperado := range colocacoesPagas {
+		percentuais := poquer.PremiacaoPara(inscritos)
+
+		if len(percentuais) != esperado {
+			t.Errorf("com %d inscritos esperava pagar %d colocações, obtido %v", inscritos, esperado, percentuais)
+		}
+
+		verificaSemErro(t, poquer.ValidarPremiacao(percentuais))
+	}
+}
+
+func TestCalcularPremios(t *testing.T) {
+	t.Run("divide o total pelos percentuais", func(t *testing.T) {
+		premios, err := poquer.CalcularPremios(1000, []float64{50, 30, 20})
+
+		verificaSemErro(t, err)
+		verificaPremios(t, premios, []int{500, 300, 200})
+	})
+
+	t.Run("dá o que sobra do arredondamento ao primeiro lugar", func(t *testing.T) {
+		premios, err := poquer.CalcularPremios(100, []float64{33.34, 33.33, 33.33})
+
+		verificaSemErro(t, err)
+		verificaPremios(t, premios, []int{34, 33, 33})
+	})
+
+	invalidas := map[string][]float64{
+		"vazia":               nil,
+		"percentual negativo": {110, -10},
+		"percentual zero":     {100, 0},
+		"soma menor que 100":  {50, 30},
+	}
+
+	for nome, percentuais := range invalidas {
+		t.Run("recusa premiação "+nome, func(t *testing.T) {
+			if _, err := poquer.CalcularPremios(1000, percentuais); err == nil {
+				t.Errorf("esperava um erro para %v", percentuais)
+			}
+		})
+	}
+}
+
+func TestLerPremiacao(t *testing.T) {
+	t.Run("lê percentuais separados por vírgula", func(t *testing.T) {
+		percentuais, err := poquer.LerPremiacao("50, 30,20")
+
+		verificaSemErro(t, err)
+
+		if !reflect.DeepEqual(percentuais, []float64{50, 30, 20}) {
+			t.Errorf("obtido %v", percentuais)
+		}
+	})
+
+	t.Run("texto vazio não tem premiação", func(t *testing.T) {
+		percentuais, err := poquer.LerPremiacao("")
+
+		verificaSemErro(t, err)
+
+		if percentuais != nil {
+			t.Errorf("esperava nenhuma premiação, obtido %v", percentuais)
+		}
+	})
+
+	for _, texto := range []string{"50,trinta,20", "50,30"} {
+		if _, err := poquer.LerPremiacao(texto); err == nil {
+			t.Errorf("esperava um erro ao ler %q", texto)
+		}
+	}
+}
+
+func verificaPremios(t *testing.T, obtido, esperado []int) {
+	t.Helper()
+
+	if !reflect.DeepEqual(obtido, esperado) {
+		t.Errorf("obtido %v, esperado %v", obtido, esperado)
+	}
+}
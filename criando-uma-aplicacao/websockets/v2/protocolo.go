@@ -0,0 +1,371 @@
+package poquer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VersaoProtocolo é a versão das mensagens trocadas no websocket /ws
+const VersaoProtocolo = 1
+
+// Tipos das mensagens trocadas no websocket
+const (
+	MensagemIniciar          = "iniciar"
+	MensagemAlertaBlind      = "alerta_blind"
+	MensagemDeclararVencedor = "declarar_vencedor"
+	MensagemErro             = "erro"
+	MensagemFim              = "fim"
+	MensagemSalaCriada       = "sala_criada"
+	MensagemEntrou           = "entrou"
+	MensagemSaiu             = "saiu"
+	MensagemRetomar          = "retomar"
+	MensagemRetomado         = "retomado"
+	MensagemControle         = "controle"
+	MensagemRelogio          = "relogio"
+	MensagemTorneio          = "torneio"
+	MensagemClassificacao    = "classificacao"
+	MensagemLugares          = "lugares"
+	MensagemSentar           = "sentar"
+	MensagemSentado          = "sentado"
+	MensagemCartas           = "cartas"
+	MensagemMesa             = "mesa"
+	MensagemAcao             = "acao"
+	MensagemResultado        = "resultado"
+)
+
+// PrazoDaVezPadrao é quanto tempo um jogador da mesa tem para agir quando prazoDaVez é omitido
+const PrazoDaVezPadrao = 30 * time.Second
+
+// MensagemWebsocket é o envelope de todas as mensagens trocadas no websocket
+type MensagemWebsocket struct {
+	Versao int             `json:"versao"`
+	Tipo   string          `json:"tipo"`
+	Dados  json.RawMessage `json:"dados,omitempty"`
+}
+
+// DadosIniciar começa um jogo. Sem estrutura, a partida usa a EstruturaPadrao de blinds.
+// Com jogadores, a partida é um torneio entre eles e numeroDeJogadores pode ser omitido.
+// Sem premiacao, um torneio com buyIn paga as colocações de PremiacaoPara os inscritos.
+// Os inscritos são sorteados em mesas de lugaresPorMesa lugares, 10 se omitido.
+// Com mesa, o servidor dá as cartas para numeroDeJogadores jogadores que se sentam pelo
+// websocket, cada um com fichasIniciais e prazoDaVez segundos para agir; bots desses
+// lugares são ocupados por bots que jogam pela força das cartas
+type DadosIniciar struct {
+	NumeroDeJogadores int       `json:"numeroDeJogadores"`
+	Estrutura         string    `json:"estrutura,omitempty"`
+	Jogadores         []string  `json:"jogadores,omitempty"`
+	FichasIniciais    int       `json:"fichasIniciais,omitempty"`
+	NiveisDeRecompra  int       `json:"niveisDeRecompra,omitempty"`
+	FichasDoAddOn     int       `json:"fichasDoAddOn,omitempty"`
+	BuyIn             int       `json:"buyIn,omitempty"`
+	Premiacao         []float64 `json:"premiacao,omitempty"`
+	LugaresPorMesa    int       `json:"lugaresPorMesa,omitempty"`
+	Mesa              bool      `json:"mesa,omitempty"`
+	PrazoDaVez        int       `json:"prazoDaVez,omitempty"`
+	Bots              int       `json:"bots,omitempty"`
+}
+
+// prazoDaVez converte os segundos de PrazoDaVez, usando o PrazoDaVezPadrao se omitido
+func (d DadosIniciar) prazoDaVez() time.Duration {
+	if d.PrazoDaVez == 0 {
+		return PrazoDaVezPadrao
+	}
+	return time.Duration(d.PrazoDaVez) * time.Second
+}
+
+// configuracaoDaPartida converte os dados para a configuração passada a Jogo.Começar
+func (d DadosIniciar) configuracaoDaPartida() ConfiguracaoDaPartida {
+	configuracao := ConfiguracaoDaPartida{NumeroDeJogadores: d.NumeroDeJogadores, Estrutura: d.Estrutura}
+
+	if len(d.Jogadores) > 0 {
+		configuracao.NumeroDeJogadores = len(d.Jogadores)
+		configuracao.Torneio = &ConfiguracaoDoTorneio{
+			Jogadores:        d.Jogadores,
+			FichasIniciais:   d.FichasIniciais,
+			NiveisDeRecompra: d.NiveisDeRecompra,
+			FichasDoAddOn:    d.FichasDoAddOn,
+			BuyIn:            d.BuyIn,
+			Premiacao:        d.Premiacao,
+			LugaresPorMesa:   d.LugaresPorMesa,
+		}
+	}
+
+	return configuracao
+}
+
+// configuracaoDaMesa converte os dados para a configuração da mesa jogada com os blinds da
+// partida e medida no relógio do servidor
+func (d DadosIniciar) configuracaoDaMesa(blinds ControleDeBlinds, relogio Relogio) ConfiguracaoDaMesa {
+	return ConfiguracaoDaMesa{
+		Lugares:        d.NumeroDeJogadores,
+		FichasIniciais: d.FichasIniciais,
+		Blinds:         blinds,
+		PrazoDaVez:     d.prazoDaVez(),
+		Relogio:        relogio,
+	}
+}
+
+// DadosAlertaBlind avisa que os blinds mudaram. Quantia é o big blind, com o nome que
+// tinha quando o nível tinha um único blind
+type DadosAlertaBlind struct {
+	Quantia    int    `json:"quantia,omitempty"`
+	SmallBlind int    `json:"smallBlind,omitempty"`
+	Ante       int    `json:"ante,omitempty"`
+	Mensagem   string `json:"mensagem"`
+}
+
+// NovosDadosAlertaBlind converte os blinds para a mensagem alerta_blind
+func NovosDadosAlertaBlind(blinds Blinds) DadosAlertaBlind {
+	return DadosAlertaBlind{
+		Quantia:    blinds.BigBlind,
+		SmallBlind: blinds.SmallBlind,
+		Ante:       blinds.Ante,
+		Mensagem:   mensagemDeBlind(blinds),
+	}
+}
+
+// DadosDeclararVencedor termina o jogo com um vencedor
+type DadosDeclararVencedor struct {
+	Vencedor string `json:"vencedor"`
+}
+
+// DadosErro explica por que uma mensagem foi recusada
+type DadosErro struct {
+	Mensagem string `json:"mensagem"`
+}
+
+// DadosFim confirma que o jogo terminou. Um jogo abandonado termina sem vencedor
+type DadosFim struct {
+	Vencedor   string `json:"vencedor"`
+	Abandonado bool   `json:"abandonado,omitempty"`
+}
+
+// DadosSala informa o código da sala criada para o jogo, usado em /ws?sala=CODIGO,
+// e o token que permite retomar o jogo caso a conexão caia
+type DadosSala struct {
+	Sala  string `json:"sala"`
+	Token string `json:"token,omitempty"`
+}
+
+// DadosRetomar pede para conduzir de novo um jogo cuja conexão caiu
+type DadosRetomar struct {
+	Sala  string `json:"sala"`
+	Token string `json:"token"`
+}
+
+// DadosRetomado confirma que a conexão voltou a conduzir o jogo
+type DadosRetomado struct {
+	Sala              string `json:"sala"`
+	NumeroDeJogadores int    `json:"numeroDeJogadores"`
+}
+
+// DadosPresenca informa quantas conexões estão na sala depois que alguém entrou ou saiu
+type DadosPresenca struct {
+	Conexoes int `json:"conexoes"`
+}
+
+// DadosControle pede que o relógio de blinds seja pausado, continuado, avançado ou voltado
+type DadosControle struct {
+	Comando string `json:"comando"`
+}
+
+// DadosRelogio informa o nível de blind atual e quantos segundos faltam para o próximo.
+// Quantia e ProximaQuantia são os big blinds
+type DadosRelogio struct {
+	Nivel             int  `json:"nivel"`
+	Quantia           int  `json:"quantia"`
+	SmallBlind        int  `json:"smallBlind,omitempty"`
+	Ante              int  `json:"ante,omitempty"`
+	ProximaQuantia    int  `json:"proximaQuantia,omitempty"`
+	ProximoSmallBlind int  `json:"proximoSmallBlind,omitempty"`
+	ProximoAnte       int  `json:"proximoAnte,omitempty"`
+	SegundosRestantes int  `json:"segundosRestantes"`
+	Pausado           bool `json:"pausado"`
+	Intervalo         bool `json:"intervalo,omitempty"`
+}
+
+// DadosTorneio pede que um jogador do torneio seja eliminado, recompre, faça o add-on ou
+// tenha as fichas atualizadas, ou que o botão de uma mesa avance. Fichas só é usado pelo
+// comando fichas e Mesa só pelo comando botao, que não tem jogador
+type DadosTorneio struct {
+	Comando string `json:"comando"`
+	Jogador string `json:"jogador,omitempty"`
+	Fichas  int    `json:"fichas,omitempty"`
+	Mesa    int    `json:"mesa,omitempty"`
+}
+
+// DadosSentar pede um lugar na mesa da sala. Quem volta com o mesmo nome recebe o seu lugar,
+// desde que envie o segredo recebido em DadosSentado
+type DadosSentar struct {
+	Nome    string `json:"nome"`
+	Segredo string `json:"segredo,omitempty"`
+}
+
+// DadosSentado confirma o lugar do jogador na mesa. O segredo é exigido para recuperar o
+// lugar depois de uma queda de conexão
+type DadosSentado struct {
+	Lugar   int    `json:"lugar"`
+	Nome    string `json:"nome"`
+	Segredo string `json:"segredo"`
+}
+
+// DadosCartas são as cartas fechadas do jogador, enviadas apenas para a conexão dele
+type DadosCartas struct {
+	Lugar  int     `json:"lugar"`
+	Cartas []Carta `json:"cartas"`
+}
+
+// NovosDadosRelogio converte o estado dos blinds para a mensagem relogio
+func NovosDadosRelogio(estado EstadoDosBlinds) DadosRelogio {
+	return DadosRelogio{
+		Nivel:             estado.Nivel,
+		Quantia:           estado.Blinds.BigBlind,
+		SmallBlind:        estado.Blinds.SmallBlind,
+		Ante:              estado.Blinds.Ante,
+		ProximaQuantia:    estado.ProximosBlinds.BigBlind,
+		ProximoSmallBlind: estado.ProximosBlinds.SmallBlind,
+		ProximoAnte:       estado.ProximosBlinds.Ante,
+		SegundosRestantes: int(estado.Restante.Round(time.Second) / time.Second),
+		Pausado:           estado.Pausado,
+		Intervalo:         estado.Intervalo,
+	}
+}
+
+// NovaMensagemWebsocket cria uma mensagem na versão atual do protocolo
+func NovaMensagemWebsocket(tipo string, dados interface{}) (MensagemWebsocket, error) {
+	mensagem := MensagemWebsocket{Versao: VersaoProtocolo, Tipo: tipo}
+
+	if dados == nil {
+		return mensagem, nil
+	}
+
+	bruto, err := json.Marshal(dados)
+
+	if err != nil {
+		return mensagem, fmt.Errorf("problema ao codificar os dados da mensagem %s, %v", tipo, err)
+	}
+
+	mensagem.Dados = bruto
+	return mensagem, nil
+}
+
+// LerDados decodifica os dados da mensagem, verificando a versão e o tipo esperado
+func (m MensagemWebsocket) LerDados(tipo string, dados interface{}) error {
+	if m.Versao != VersaoProtocolo {
+		return fmt.Errorf("versão %d do protocolo não suportada, use a versão %d", m.Versao, VersaoProtocolo)
+	}
+
+	if m.Tipo != tipo {
+		return fmt.Errorf("esperava uma mensagem %s, obtido %q", tipo, m.Tipo)
+	}
+
+	if len(m.Dados) == 0 {
+		return errors.New("a mensagem " + tipo + " precisa de dados")
+	}
+
+	if err := json.Unmarshal(m.Dados, dados); err != nil {
+		return fmt.Errorf("dados inválidos para a mensagem %s, %v", tipo, err)
+	}
+
+	return nil
+}
+
+func (d DadosIniciar) validar() error {
+	if len(d.Jogadores) == 0 && d.NumeroDeJogadores < 1 {
+		return fmt.Errorf("numeroDeJogadores deve ser positivo, obtido %d", d.NumeroDeJogadores)
+	}
+
+	if !d.Mesa {
+		return nil
+	}
+
+	if len(d.Jogadores) > 0 {
+		return errors.New("a mesa não pode ser jogada como torneio")
+	}
+
+	if d.PrazoDaVez < 0 {
+		return fmt.Errorf("prazoDaVez não pode ser negativo, obtido %d", d.PrazoDaVez)
+	}
+
+	if d.Bots < 0 || d.Bots >= d.NumeroDeJogadores {
+		return fmt.Errorf("bots deve deixar ao menos um lugar livre, obtido %d bots para %d lugares", d.Bots, d.NumeroDeJogadores)
+	}
+
+	return d.configuracaoDaMesa(nil, nil).Validar()
+}
+
+func (d DadosSentar) validar() error {
+	if strings.TrimSpace(d.Nome) == "" {
+		return errors.New("nome é obrigatório")
+	}
+	return nil
+}
+
+func (a Acao) validar() error {
+	switch a.Tipo {
+	case Desistir, Passar, Pagar, Apostar, Aumentar, AllIn:
+	default:
+		return fmt.Errorf("%w: %q", ErrAcaoInvalida, a.Tipo)
+	}
+
+	if a.Quantia < 0 {
+		return fmt.Errorf("quantia não pode ser negativa, obtido %d", a.Quantia)
+	}
+
+	return nil
+}
+
+func (d DadosRetomar) validar() error {
+	if d.Sala == "" || d.Token == "" {
+		return errors.New("sala e token são obrigatórios")
+	}
+	return nil
+}
+
+func (d DadosDeclararVencedor) validar() error {
+	if d.Vencedor == "" {
+		return errors.New("vencedor é obrigatório")
+	}
+	return nil
+}
+
+func (d DadosControle) validar() error {
+	if !comandoDeBlinds(d.Comando) {
+		return errComandoDesconhecido(d.Comando)
+	}
+	return nil
+}
+
+func (d DadosTorneio) validar() error {
+	switch d.Comando {
+	case ComandoBotao:
+		if d.Mesa < 1 {
+			return fmt.Errorf("mesa deve ser positiva, obtido %d", d.Mesa)
+		}
+		return nil
+	case ComandoEliminar, ComandoRecomprar, ComandoAddOn, ComandoFichas:
+	default:
+		return errComandoDeTorneioDesconhecido(d.Comando)
+	}
+
+	if d.Jogador == "" {
+		return errors.New("jogador é obrigatório")
+	}
+
+	return nil
+}
+
+type validavel interface {
+	validar() error
+}
+
+// lerDadosValidos decodifica os dados da mensagem e os valida
+func (m MensagemWebsocket) lerDadosValidos(tipo string, dados validavel) error {
+	if err := m.LerDados(tipo, dados); err != nil {
+		return err
+	}
+	return dados.validar()
+}
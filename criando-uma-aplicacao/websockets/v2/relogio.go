@@ -0,0 +1,67 @@
+package poquer
+
+import "time"
+
+// Relogio informa o horário atual e agenda eventos no tempo, permitindo que o tempo seja
+// controlado nos testes com um RelogioFalso
+type Relogio interface {
+	Agora() time.Time
+	Apos(duracao time.Duration) <-chan time.Time
+	NovoTimer(duracao time.Duration, f func()) Timer
+	NovoTicker(intervalo time.Duration) Ticker
+}
+
+// Timer chama uma função depois de uma duração, a não ser que seja parado antes
+type Timer interface {
+	// Parar retorna false se a função já foi chamada ou o timer já tinha sido parado
+	Parar() bool
+}
+
+// Ticker envia o horário em C a cada intervalo até ser parado
+type Ticker interface {
+	C() <-chan time.Time
+	Parar()
+}
+
+// RelogioDoSistema é um Relogio que usa o horário real da máquina
+type RelogioDoSistema struct{}
+
+// Agora retorna time.Now()
+func (RelogioDoSistema) Agora() time.Time {
+	return time.Now()
+}
+
+// Apos retorna time.After(duracao)
+func (RelogioDoSistema) Apos(duracao time.Duration) <-chan time.Time {
+	return time.After(duracao)
+}
+
+// NovoTimer retorna um Timer baseado em time.AfterFunc
+func (RelogioDoSistema) NovoTimer(duracao time.Duration, f func()) Timer {
+	return timerDoSistema{time.AfterFunc(duracao, f)}
+}
+
+// NovoTicker retorna um Ticker baseado em time.NewTicker
+func (RelogioDoSistema) NovoTicker(intervalo time.Duration) Ticker {
+	return tickerDoSistema{time.NewTicker(intervalo)}
+}
+
+type timerDoSistema struct {
+	timer *time.Timer
+}
+
+func (t timerDoSistema) Parar() bool {
+	return t.timer.Stop()
+}
+
+type tickerDoSistema struct {
+	ticker *time.Ticker
+}
+
+func (t tickerDoSistema) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t tickerDoSistema) Parar() {
+	t.ticker.Stop()
+}
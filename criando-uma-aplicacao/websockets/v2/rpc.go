@@ -0,0 +1,441 @@
+package poquer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const versaoJSONRPC = "2.0"
+
+// Códigos de erro definidos pela especificação JSON-RPC 2.0, mais os do servidor de pôquer
+const (
+	ErroRPCParse               = -32700
+	ErroRPCRequisicaoInvalida  = -32600
+	ErroRPCMetodoInexistente   = -32601
+	ErroRPCParametrosInvalidos = -32602
+	ErroRPCInterno             = -32603
+	ErroRPCLimiteDeTaxa        = -32000
+	ErroRPCJogoNaoEncontrado   = -32001
+	ErroRPCTokenInvalido       = -32002
+	ErroRPCJogosDemais         = -32003
+)
+
+const (
+	// MaximoDePartidasRPC é quantos jogos começados por jogo.comecar podem estar abertos ao
+	// mesmo tempo
+	MaximoDePartidasRPC = 100
+	// TempoOciosoDasPartidasRPC é por quanto tempo um jogo começado por jogo.comecar espera
+	// por jogo.terminar antes de ser abandonado
+	TempoOciosoDasPartidasRPC = time.Hour
+
+	// MaximoDeRequisicoesPorLote é quantas chamadas um lote JSON-RPC pode ter
+	MaximoDeRequisicoesPorLote = 20
+	// tamanhoMaximoDoRPC limita o corpo de um POST /rpc, com folga para um lote cheio
+	tamanhoMaximoDoRPC = 64 << 10
+)
+
+// RequisicaoRPC é uma chamada JSON-RPC 2.0. Sem ID, a chamada é uma notificação e não tem resposta
+type RequisicaoRPC struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Metodo  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// RespostaRPC é a resposta a uma RequisicaoRPC, com Resultado ou Erro
+type RespostaRPC struct {
+	JSONRPC   string
+	Resultado interface{}
+	Erro      *ErroRPC
+	ID        json.RawMessage
+}
+
+// MarshalJSON inclui "result" mesmo quando ele é zero, mas nunca junto de "error"
+func (r RespostaRPC) MarshalJSON() ([]byte, error) {
+	if r.Erro != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Erro    *ErroRPC        `json:"error"`
+			ID      json.RawMessage `json:"id"`
+		}{r.JSONRPC, r.Erro, r.ID})
+	}
+
+	return json.Marshal(struct {
+		JSONRPC   string          `json:"jsonrpc"`
+		Resultado interface{}     `json:"result"`
+		ID        json.RawMessage `json:"id"`
+	}{r.JSONRPC, r.Resultado, r.ID})
+}
+
+// ErroRPC descreve uma chamada que falhou
+type ErroRPC struct {
+	Codigo   int         `json:"code"`
+	Mensagem string      `json:"message"`
+	Dados    interface{} `json:"data,omitempty"`
+}
+
+type metodoRPC func(r *http.Request, params json.RawMessage) (interface{}, *ErroRPC)
+
+type paramsJogador struct {
+	Nome string `json:"nome"`
+}
+
+type paramsComeçar struct {
+	NumeroDeJogadores int    `json:"numeroDeJogadores"`
+	Estrutura         string `json:"estrutura"`
+}
+
+type resultadoComeçar struct {
+	Jogo  string `json:"jogo"`
+	Token string `json:"token"`
+}
+
+type paramsTerminar struct {
+	Jogo     string `json:"jogo"`
+	Token    string `json:"token"`
+	Vencedor string `json:"vencedor"`
+}
+
+// partidaRPC é uma partida começada por jogo.comecar, que só quem recebeu o token termina
+type partidaRPC struct {
+	partida   Partida
+	token     string
+	expiracao Timer
+}
+
+// ComPartidasRPC limita quantos jogos começados por jogo.comecar podem estar abertos e por
+// quanto tempo, medido no relógio, cada um espera por jogo.terminar antes de ser abandonado
+func ComPartidasRPC(maximo int, tempoOcioso time.Duration, relogio Relogio) OpcaoServidor {
+	return func(p *ServidorJogador) {
+		p.maximoDePartidasRPC = maximo
+		p.tempoOciosoRPC = tempoOcioso
+		p.relogioRPC = relogio
+	}
+}
+
+func (p *ServidorJogador) metodosRPC() map[string]metodoRPC {
+	return map[string]metodoRPC{
+		"jogadores.pontuacao":     p.rpcObtemPontuacao,
+		"jogadores.gravarVitoria": p.rpcGravarVitoria,
+		"liga.obter":              p.rpcObterLiga,
+		"jogo.comecar":            p.rpcComeçar,
+		"jogo.terminar":           p.rpcTerminar,
+	}
+}
+
+func (p *ServidorJogador) manipulaRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	corpo, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, tamanhoMaximoDoRPC))
+
+	if err != nil {
+		escreverRPC(w, respostaDeErro(nil, ErroRPCRequisicaoInvalida, fmt.Sprintf("não foi possível ler a requisição, que pode ter até %d bytes", tamanhoMaximoDoRPC)))
+		return
+	}
+
+	corpo = bytes.TrimSpace(corpo)
+
+	if len(corpo) > 0 && corpo[0] == '[' {
+		p.manipulaLoteRPC(w, r, corpo)
+		return
+	}
+
+	var requisicao RequisicaoRPC
+	if err := json.Unmarshal(corpo, &requisicao); err != nil {
+		escreverRPC(w, respostaDeErro(nil, ErroRPCParse, "JSON inválido"))
+		return
+	}
+
+	resposta := p.executarRPC(r, requisicao)
+
+	if resposta == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	escreverRPC(w, resposta)
+}
+
+func (p *ServidorJogador) manipulaLoteRPC(w http.ResponseWriter, r *http.Request, corpo []byte) {
+	var lote []json.RawMessage
+
+	if err := json.Unmarshal(corpo, &lote); err != nil {
+		escreverRPC(w, respostaDeErro(nil, ErroRPCParse, "JSON inválido"))
+		return
+	}
+
+	if len(lote) == 0 {
+		escreverRPC(w, respostaDeErro(nil, ErroRPCRequisicaoInvalida, "lote vazio"))
+		return
+	}
+
+	if len(lote) > MaximoDeRequisicoesPorLote {
+		escreverRPC(w, respostaDeErro(nil, ErroRPCRequisicaoInvalida, fmt.Sprintf("o lote pode ter até %d requisições", MaximoDeRequisicoesPorLote)))
+		return
+	}
+
+	respostas := []*RespostaRPC{}
+
+	for _, bruta := range lote {
+		var requisicao RequisicaoRPC
+
+		if err := json.Unmarshal(bruta, &requisicao); err != nil {
+			respostas = append(respostas, respostaDeErro(nil, ErroRPCRequisicaoInvalida, "requisição inválida"))
+			continue
+		}
+
+		if resposta := p.executarRPC(r, requisicao); resposta != nil {
+			respostas = append(respostas, resposta)
+		}
+	}
+
+	if len(respostas) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	escreverRPC(w, respostas)
+}
+
+// executarRPC chama o método pedido, retornando nil para notificações
+func (p *ServidorJogador) executarRPC(r *http.Request, requisicao RequisicaoRPC) *RespostaRPC {
+	if requisicao.JSONRPC != versaoJSONRPC || requisicao.Metodo == "" {
+		return respostaDeErro(requisicao.ID, ErroRPCRequisicaoInvalida, "requisição inválida")
+	}
+
+	metodo, existe := p.metodosRPC()[requisicao.Metodo]
+
+	var resultado interface{}
+	var erro *ErroRPC
+
+	if existe {
+		resultado, erro = metodo(r, requisicao.Params)
+	} else {
+		erro = &ErroRPC{Codigo: ErroRPCMetodoInexistente, Mensagem: "método não encontrado: " + requisicao.Metodo}
+	}
+
+	if requisicao.ID == nil {
+		return nil
+	}
+
+	if erro != nil {
+		return &RespostaRPC{JSONRPC: versaoJSONRPC, Erro: erro, ID: requisicao.ID}
+	}
+
+	return &RespostaRPC{JSONRPC: versaoJSONRPC, Resultado: resultado, ID: requisicao.ID}
+}
+
+func (p *ServidorJogador) rpcObtemPontuacao(r *http.Request, params json.RawMessage) (interface{}, *ErroRPC) {
+	var parametros paramsJogador
+
+	if erro := lerParametros(params, &parametros); erro != nil {
+		return nil, erro
+	}
+
+	if parametros.Nome == "" {
+		return nil, parametroInvalido("nome é obrigatório")
+	}
+
+	return p.armazenamento.ObtemPontuacaoDoJogador(parametros.Nome), nil
+}
+
+func (p *ServidorJogador) rpcGravarVitoria(r *http.Request, params json.RawMessage) (interface{}, *ErroRPC) {
+	var parametros paramsJogador
+
+	if erro := lerParametros(params, &parametros); erro != nil {
+		return nil, erro
+	}
+
+	if parametros.Nome == "" {
+		return nil, parametroInvalido("nome é obrigatório")
+	}
+
+	if erro := p.limitarAlteracaoRPC(r, parametros.Nome); erro != nil {
+		return nil, erro
+	}
+
+	p.armazenamento.GravarVitoria(parametros.Nome)
+	return true, nil
+}
+
+func (p *ServidorJogador) rpcObterLiga(r *http.Request, params json.RawMessage) (interface{}, *ErroRPC) {
+	return p.armazenamento.ObterLiga(), nil
+}
+
+// rpcComeçar começa uma partida e devolve seu id e o token exigido por jogo.terminar. Os
+// alertas de blind são descartados, já que uma chamada RPC não tem para onde enviá-los. A
+// chamada gasta o limite de taxa do cliente, e a partida é abandonada se ninguém a terminar
+// em tempoOciosoRPC
+func (p *ServidorJogador) rpcComeçar(r *http.Request, params json.RawMessage) (interface{}, *ErroRPC) {
+	var parametros paramsComeçar
+
+	if erro := lerParametros(params, &parametros); erro != nil {
+		return nil, erro
+	}
+
+	if parametros.NumeroDeJogadores < 1 {
+		return nil, parametroInvalido("numeroDeJogadores deve ser positivo")
+	}
+
+	if permitido, espera := p.limitadorPorCliente.Permitir(enderecoDoCliente(r)); !permitido {
+		return nil, erroDeLimiteRPC(segundosDeEspera(espera))
+	}
+
+	p.muRPC.Lock()
+	defer p.muRPC.Unlock()
+
+	if len(p.partidasRPC) >= p.maximoDePartidasRPC {
+		return nil, &ErroRPC{Codigo: ErroRPCJogosDemais, Mensagem: "jogos demais em andamento, termine um antes de começar outro"}
+	}
+
+	configuracao := ConfiguracaoDaPartida{NumeroDeJogadores: parametros.NumeroDeJogadores, Estrutura: parametros.Estrutura}
+	partida, err := p.jogo.Começar(configuracao, ioutil.Discard)
+
+	if err != nil {
+		return nil, parametroInvalido(err.Error())
+	}
+
+	id, token := partida.ID(), novoToken()
+	p.partidasRPC[id] = partidaRPC{
+		partida:   partida,
+		token:     token,
+		expiracao: p.relogioRPC.NovoTimer(p.tempoOciosoRPC, func() { p.abandonarPartidaDoRPC(id, token) }),
+	}
+
+	return resultadoComeçar{Jogo: id, Token: token}, nil
+}
+
+func (p *ServidorJogador) rpcTerminar(r *http.Request, params json.RawMessage) (interface{}, *ErroRPC) {
+	var parametros paramsTerminar
+
+	if erro := lerParametros(params, &parametros); erro != nil {
+		return nil, erro
+	}
+
+	if parametros.Jogo == "" || parametros.Vencedor == "" {
+		return nil, parametroInvalido("jogo e vencedor são obrigatórios")
+	}
+
+	partida, erro := p.partidaDoRPC(parametros.Jogo, parametros.Token)
+
+	if erro != nil {
+		return nil, erro
+	}
+
+	if err := VerificarVencedor(partida, parametros.Vencedor); err != nil {
+		return nil, parametroInvalido(err.Error())
+	}
+
+	if erro := p.limitarAlteracaoRPC(r, parametros.Vencedor); erro != nil {
+		return nil, erro
+	}
+
+	p.removerPartidaDoRPC(parametros.Jogo)
+	partida.Terminar(parametros.Vencedor)
+	return true, nil
+}
+
+// partidaDoRPC retorna a partida em andamento começada por jogo.comecar com o id, desde que
+// o token confira. As partidas das salas do websocket não podem ser terminadas pelo RPC
+func (p *ServidorJogador) partidaDoRPC(id, token string) (Partida, *ErroRPC) {
+	p.muRPC.Lock()
+	registrada, existe := p.partidasRPC[id]
+	p.muRPC.Unlock()
+
+	if _, emAndamento := p.jogo.Partida(id); !existe || !emAndamento {
+		p.removerPartidaDoRPC(id)
+		return nil, &ErroRPC{Codigo: ErroRPCJogoNaoEncontrado, Mensagem: "jogo " + id + " não está em andamento"}
+	}
+
+	if registrada.token != token {
+		return nil, &ErroRPC{Codigo: ErroRPCTokenInvalido, Mensagem: "token inválido para o jogo " + id}
+	}
+
+	return registrada.partida, nil
+}
+
+func (p *ServidorJogador) removerPartidaDoRPC(id string) {
+	p.muRPC.Lock()
+	defer p.muRPC.Unlock()
+
+	if registrada, existe := p.partidasRPC[id]; existe {
+		registrada.expiracao.Parar()
+		delete(p.partidasRPC, id)
+	}
+}
+
+// abandonarPartidaDoRPC abandona a partida que ninguém terminou a tempo. O token confere que
+// o timer é o da partida registrada com o id
+func (p *ServidorJogador) abandonarPartidaDoRPC(id, token string) {
+	p.muRPC.Lock()
+	registrada, existe := p.partidasRPC[id]
+	if existe && registrada.token == token {
+		delete(p.partidasRPC, id)
+	}
+	p.muRPC.Unlock()
+
+	if existe && registrada.token == token {
+		registrada.partida.Abandonar()
+	}
+}
+
+// limitarAlteracaoRPC aplica às chamadas que gravam vitórias os mesmos limites de taxa das
+// rotas HTTP, por cliente e pelo jogador que ganha
+func (p *ServidorJogador) limitarAlteracaoRPC(r *http.Request, jogador string) *ErroRPC {
+	segundos := p.esperaParaAlterar(r, jogador)
+
+	if segundos == 0 {
+		return nil
+	}
+
+	return erroDeLimiteRPC(segundos)
+}
+
+func erroDeLimiteRPC(segundos int) *ErroRPC {
+	return &ErroRPC{
+		Codigo:   ErroRPCLimiteDeTaxa,
+		Mensagem: "limite de requisições atingido",
+		Dados:    map[string]int{"retryAfter": segundos},
+	}
+}
+
+func lerParametros(params json.RawMessage, destino interface{}) *ErroRPC {
+	if len(params) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(params, destino); err != nil {
+		return parametroInvalido("os parâmetros devem ser um objeto com os campos do método")
+	}
+
+	return nil
+}
+
+func parametroInvalido(mensagem string) *ErroRPC {
+	return &ErroRPC{Codigo: ErroRPCParametrosInvalidos, Mensagem: mensagem}
+}
+
+func respostaDeErro(id json.RawMessage, codigo int, mensagem string) *RespostaRPC {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	return &RespostaRPC{
+		JSONRPC: versaoJSONRPC,
+		Erro:    &ErroRPC{Codigo: codigo, Mensagem: mensagem},
+		ID:      id,
+	}
+}
+
+func escreverRPC(w http.ResponseWriter, resposta interface{}) {
+	w.Header().Set("content-type", tipoConteudoJSON)
+	json.NewEncoder(w).Encode(resposta)
+}
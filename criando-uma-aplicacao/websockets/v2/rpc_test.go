@@ -0,0 +1,391 @@
+package poquer_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+type respostaRPC struct {
+	JSONRPC   string          `json:"jsonrpc"`
+	Resultado json.RawMessage `json:"result"`
+	Erro      *poquer.ErroRPC `json:"error"`
+	ID        json.RawMessage `json:"id"`
+}
+
+func TestRPC(t *testing.T) {
+	t.Run("obtém a pontuação de um jogador", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{Pontuações: map[string]int{"Pepper": 20}}
+		servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogadores.pontuacao", "params": {"nome": "Pepper"}, "id": 1}`)
+
+		obtido := decodificarRespostaRPC(t, resposta)
+		verificaResultadoRPC(t, obtido, "20")
+		verificaIDRPC(t, obtido, "1")
+	})
+
+	t.Run("retorna pontuação zero em vez de omitir o resultado", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogadores.pontuacao", "params": {"nome": "Apollo"}, "id": "a"}`)
+
+		verificaResultadoRPC(t, decodificarRespostaRPC(t, resposta), "0")
+	})
+
+	t.Run("grava uma vitória", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco)
+
+		chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogadores.gravarVitoria", "params": {"nome": "Chris"}, "id": 1}`)
+
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris")
+	})
+
+	t.Run("aplica o limite de taxa às vitórias", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco, poquer.ComLimitesDeTaxa(
+			poquer.NovoLimitadorDeTaxa(1, 5*time.Second, relogio),
+			poquer.NovoLimitadorDeTaxa(1, 5*time.Second, relogio),
+		))
+
+		chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogadores.gravarVitoria", "params": {"nome": "Chris"}, "id": 1}`)
+		resposta := chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogadores.gravarVitoria", "params": {"nome": "Chris"}, "id": 2}`)
+
+		verificaErroRPC(t, decodificarRespostaRPC(t, resposta), poquer.ErroRPCLimiteDeTaxa)
+	})
+
+	t.Run("retorna a liga", func(t *testing.T) {
+		liga := []poquer.Jogador{{Nome: "Cleo", Vitorias: 32}}
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{Liga: liga}, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "liga.obter", "id": 1}`)
+
+		obtido := obterLigaDaResposta(t, strings.NewReader(string(decodificarRespostaRPC(t, resposta).Resultado)))
+		verificaLiga(t, obtido, liga)
+	})
+
+	t.Run("começa e termina o jogo", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogo)
+
+		começado := começarJogoPeloRPC(t, servidor)
+		if começado.Jogo != "espiao" || começado.Token == "" {
+			t.Fatalf("esperava o jogo espiao com um token, obtido %+v", começado)
+		}
+
+		resposta := terminarJogoPeloRPC(t, servidor, começado.Jogo, começado.Token, "Ruth")
+		verificaResultadoRPC(t, resposta, "true")
+
+		verificaJogoComeçadoCom(t, jogo, 4)
+		verificaTerminosChamadosCom(t, jogo, "Ruth")
+	})
+
+	t.Run("aplica o limite de taxa às vitórias de jogo.terminar", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+		servidor := deveFazerServidorJogador(t, armazenamento, jogo, poquer.ComLimitesDeTaxa(
+			poquer.NovoLimitadorDeTaxa(2, 5*time.Second, relogio),
+			poquer.NovoLimitadorDeTaxa(10, time.Second, relogio),
+		))
+
+		começado := começarJogoPeloRPC(t, servidor)
+		chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogadores.gravarVitoria", "params": {"nome": "Chris"}, "id": 1}`)
+
+		resposta := terminarJogoPeloRPC(t, servidor, começado.Jogo, começado.Token, "Chris")
+
+		verificaErroRPC(t, resposta, poquer.ErroRPCLimiteDeTaxa)
+		if len(armazenamento.ChamadasDeVitoria) != 1 {
+			t.Errorf("esperava só a primeira vitória gravada, obtido %v", armazenamento.ChamadasDeVitoria)
+		}
+
+		relogio.Avancar(5 * time.Second)
+		verificaResultadoRPC(t, terminarJogoPeloRPC(t, servidor, começado.Jogo, começado.Token, "Chris"), "true")
+		if len(armazenamento.ChamadasDeVitoria) != 2 {
+			t.Errorf("esperava a vitória de jogo.terminar gravada depois da espera, obtido %v", armazenamento.ChamadasDeVitoria)
+		}
+	})
+
+	t.Run("aplica o limite de taxa do cliente a jogo.comecar", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+		servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogo, poquer.ComLimitesDeTaxa(
+			poquer.NovoLimitadorDeTaxa(1, 5*time.Second, relogio),
+			poquer.NovoLimitadorDeTaxa(10, time.Second, relogio),
+		))
+
+		começarJogoPeloRPC(t, servidor)
+		resposta := decodificarRespostaRPC(t, chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogo.comecar", "params": {"numeroDeJogadores": 4}, "id": 1}`))
+
+		verificaErroRPC(t, resposta, poquer.ErroRPCLimiteDeTaxa)
+		if jogo.Partidas() != 1 {
+			t.Errorf("esperava 1 partida em andamento, obtido %d", jogo.Partidas())
+		}
+	})
+
+	t.Run("recusa começar mais jogos que o máximo", func(t *testing.T) {
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+		servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogo, poquer.ComPartidasRPC(1, time.Hour, poquer.NovoRelogioFalso()))
+
+		começado := começarJogoPeloRPC(t, servidor)
+		resposta := decodificarRespostaRPC(t, chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogo.comecar", "params": {"numeroDeJogadores": 4}, "id": 1}`))
+		verificaErroRPC(t, resposta, poquer.ErroRPCJogosDemais)
+
+		verificaResultadoRPC(t, terminarJogoPeloRPC(t, servidor, começado.Jogo, começado.Token, "Ruth"), "true")
+		começarJogoPeloRPC(t, servidor)
+	})
+
+	t.Run("abandona os jogos que ninguém termina a tempo", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+		servidor := deveFazerServidorJogador(t, armazenamento, jogo, poquer.ComPartidasRPC(1, time.Minute, relogio))
+
+		começado := começarJogoPeloRPC(t, servidor)
+		relogio.Avancar(time.Minute)
+
+		if jogo.Partidas() != 0 {
+			t.Errorf("a partida deveria ter sido abandonada, obtido %d em andamento", jogo.Partidas())
+		}
+
+		verificaErroRPC(t, terminarJogoPeloRPC(t, servidor, começado.Jogo, começado.Token, "Ruth"), poquer.ErroRPCJogoNaoEncontrado)
+		if len(armazenamento.ChamadasDeVitoria) != 0 {
+			t.Errorf("um jogo abandonado não deveria gravar vitórias, obtido %v", armazenamento.ChamadasDeVitoria)
+		}
+
+		começarJogoPeloRPC(t, servidor)
+	})
+
+	t.Run("recusa terminar um jogo que não está em andamento", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogo)
+
+		resposta := terminarJogoPeloRPC(t, servidor, "42", "", "Ruth")
+
+		verificaErroRPC(t, resposta, poquer.ErroRPCJogoNaoEncontrado)
+		verificaPartidaNaoFinalizada(t, jogo)
+	})
+
+	t.Run("recusa terminar um jogo sem o token de quem o começou", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogo)
+
+		começado := começarJogoPeloRPC(t, servidor)
+
+		for _, token := range []string{"", "adivinhado"} {
+			resposta := terminarJogoPeloRPC(t, servidor, começado.Jogo, token, "Ruth")
+			verificaErroRPC(t, resposta, poquer.ErroRPCTokenInvalido)
+		}
+
+		verificaPartidaNaoFinalizada(t, jogo)
+	})
+
+	t.Run("não termina jogos começados fora do RPC", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogo)
+
+		partida, err := jogo.Começar(poquer.ConfiguracaoDaPartida{NumeroDeJogadores: 4}, ioutil.Discard)
+		verificaSemErro(t, err)
+
+		resposta := terminarJogoPeloRPC(t, servidor, partida.ID(), "", "Ruth")
+
+		verificaErroRPC(t, resposta, poquer.ErroRPCJogoNaoEncontrado)
+		verificaPartidaNaoFinalizada(t, jogo)
+	})
+
+	t.Run("recusa parâmetros inválidos", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogo)
+
+		resposta := chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogo.comecar", "params": {"numeroDeJogadores": "tortas"}, "id": 1}`)
+
+		verificaErroRPC(t, decodificarRespostaRPC(t, resposta), poquer.ErroRPCParametrosInvalidos)
+		verificaPartidaNaoIniciada(t, jogo)
+	})
+
+	t.Run("retorna erro para métodos desconhecidos", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogo.trapacear", "id": 1}`)
+
+		verificaErroRPC(t, decodificarRespostaRPC(t, resposta), poquer.ErroRPCMetodoInexistente)
+	})
+
+	t.Run("retorna erro de parse para JSON inválido", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method"`)
+
+		obtido := decodificarRespostaRPC(t, resposta)
+		verificaErroRPC(t, obtido, poquer.ErroRPCParse)
+		verificaIDRPC(t, obtido, "null")
+	})
+
+	t.Run("recusa requisições sem a versão 2.0", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `{"method": "liga.obter", "id": 1}`)
+
+		verificaErroRPC(t, decodificarRespostaRPC(t, resposta), poquer.ErroRPCRequisicaoInvalida)
+	})
+
+	t.Run("não responde a notificações", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogadores.gravarVitoria", "params": {"nome": "Chris"}}`)
+
+		verificaStatus(t, resposta, http.StatusNoContent)
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris")
+	})
+
+	t.Run("executa lotes respondendo apenas às chamadas com id", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{Pontuações: map[string]int{"Pepper": 20}}
+		servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `[
+			{"jsonrpc": "2.0", "method": "jogadores.gravarVitoria", "params": {"nome": "Chris"}},
+			{"jsonrpc": "2.0", "method": "jogadores.pontuacao", "params": {"nome": "Pepper"}, "id": 1},
+			1,
+			{"jsonrpc": "2.0", "method": "jogo.trapacear", "id": 2}
+		]`)
+
+		var lote []respostaRPC
+		if err := json.NewDecoder(resposta.Body).Decode(&lote); err != nil {
+			t.Fatalf("não foi possível ler o lote de respostas %q, %v", resposta.Body.String(), err)
+		}
+
+		if len(lote) != 3 {
+			t.Fatalf("obtido %d respostas, esperado 3: %s", len(lote), resposta.Body.String())
+		}
+
+		verificaResultadoRPC(t, lote[0], "20")
+		verificaErroRPC(t, lote[1], poquer.ErroRPCRequisicaoInvalida)
+		verificaErroRPC(t, lote[2], poquer.ErroRPCMetodoInexistente)
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris")
+	})
+
+	t.Run("recusa lotes com requisições demais", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco)
+
+		chamadas := make([]string, poquer.MaximoDeRequisicoesPorLote+1)
+		for i := range chamadas {
+			chamadas[i] = `{"jsonrpc": "2.0", "method": "jogadores.gravarVitoria", "params": {"nome": "Chris"}}`
+		}
+
+		resposta := chamarRPC(t, servidor, "["+strings.Join(chamadas, ",")+"]")
+
+		verificaErroRPC(t, decodificarRespostaRPC(t, resposta), poquer.ErroRPCRequisicaoInvalida)
+		if len(armazenamento.ChamadasDeVitoria) != 0 {
+			t.Errorf("nenhuma chamada do lote deveria ter sido executada, obtido %v", armazenamento.ChamadasDeVitoria)
+		}
+	})
+
+	t.Run("recusa corpos grandes demais", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco)
+
+		corpo := `{"jsonrpc": "2.0", "method": "liga.obter", "params": {"nome": "` + strings.Repeat("a", 1<<20) + `"}, "id": 1}`
+		resposta := chamarRPC(t, servidor, corpo)
+
+		verificaErroRPC(t, decodificarRespostaRPC(t, resposta), poquer.ErroRPCRequisicaoInvalida)
+	})
+
+	t.Run("recusa lotes vazios", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco)
+
+		resposta := chamarRPC(t, servidor, `[]`)
+
+		verificaErroRPC(t, decodificarRespostaRPC(t, resposta), poquer.ErroRPCRequisicaoInvalida)
+	})
+}
+
+type jogoComeçadoPeloRPC struct {
+	Jogo  string `json:"jogo"`
+	Token string `json:"token"`
+}
+
+func começarJogoPeloRPC(t *testing.T, servidor http.Handler) jogoComeçadoPeloRPC {
+	t.Helper()
+
+	resposta := decodificarRespostaRPC(t, chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogo.comecar", "params": {"numeroDeJogadores": 4}, "id": 1}`))
+	if resposta.Erro != nil {
+		t.Fatalf("não esperava um erro ao começar o jogo, obtido %+v", resposta.Erro)
+	}
+
+	var começado jogoComeçadoPeloRPC
+	if err := json.Unmarshal(resposta.Resultado, &começado); err != nil {
+		t.Fatalf("não foi possível ler o jogo começado %s, %v", resposta.Resultado, err)
+	}
+
+	return começado
+}
+
+func terminarJogoPeloRPC(t *testing.T, servidor http.Handler, jogo, token, vencedor string) respostaRPC {
+	t.Helper()
+
+	params, _ := json.Marshal(map[string]string{"jogo": jogo, "token": token, "vencedor": vencedor})
+	return decodificarRespostaRPC(t, chamarRPC(t, servidor, `{"jsonrpc": "2.0", "method": "jogo.terminar", "params": `+string(params)+`, "id": 2}`))
+}
+
+func chamarRPC(t *testing.T, servidor http.Handler, corpo string) *httptest.ResponseRecorder {
+	t.Helper()
+	resposta := httptest.NewRecorder()
+	servidor.ServeHTTP(resposta, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(corpo)))
+	return resposta
+}
+
+func decodificarRespostaRPC(t *testing.T, resposta *httptest.ResponseRecorder) respostaRPC {
+	t.Helper()
+
+	verificaStatus(t, resposta, http.StatusOK)
+	verificaTipoDoConteudo(t, resposta, "application/json")
+
+	var obtido respostaRPC
+	if err := json.NewDecoder(resposta.Body).Decode(&obtido); err != nil {
+		t.Fatalf("não foi possível ler a resposta RPC %q, %v", resposta.Body.String(), err)
+	}
+
+	if obtido.JSONRPC != "2.0" {
+		t.Errorf("obtido jsonrpc %q, esperado 2.0", obtido.JSONRPC)
+	}
+
+	return obtido
+}
+
+func verificaResultadoRPC(t *testing.T, obtido respostaRPC, esperado string) {
+	t.Helper()
+	if obtido.Erro != nil {
+		t.Fatalf("não esperava um erro mas obteve %+v", obtido.Erro)
+	}
+	if string(obtido.Resultado) != esperado {
+		t.Errorf("obtido resultado %s, esperado %s", obtido.Resultado, esperado)
+	}
+}
+
+func verificaErroRPC(t *testing.T, obtido respostaRPC, codigo int) {
+	t.Helper()
+	if obtido.Erro == nil {
+		t.Fatalf("esperava o erro %d mas obteve o resultado %s", codigo, obtido.Resultado)
+	}
+	if obtido.Erro.Codigo != codigo {
+		t.Errorf("obtido código %d, esperado %d", obtido.Erro.Codigo, codigo)
+	}
+	if obtido.Resultado != nil {
+		t.Errorf("respostas de erro não devem ter resultado, obtido %s", obtido.Resultado)
+	}
+}
+
+func verificaIDRPC(t *testing.T, obtido respostaRPC, esperado string) {
+	t.Helper()
+	if string(obtido.ID) != esperado {
+		t.Errorf("obtido id %s, esperado %s", obtido.ID, esperado)
+	}
+}
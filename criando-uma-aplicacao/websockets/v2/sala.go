@@ -0,0 +1,540 @@
+package poquer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	letrasDoCodigo  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	tamanhoDoCodigo = 4
+)
+
+// JanelaDeRetomadaPadrao é quanto tempo um jogo espera pela volta de quem o conduz
+const JanelaDeRetomadaPadrao = 5 * time.Minute
+
+var (
+	// ErrSalaNaoEncontrada indica que não há jogo em andamento com o código informado
+	ErrSalaNaoEncontrada = errors.New("sala não encontrada")
+	// ErrTokenInvalido indica que o token não é o de quem começou o jogo
+	ErrTokenInvalido = errors.New("token inválido para retomar o jogo")
+	// ErrSemMesa indica que o jogo da sala não tem uma mesa para sentar
+	ErrSemMesa = errors.New("este jogo não tem uma mesa")
+	// ErrSegredoDoLugar indica que o lugar já é de um jogador e o segredo dele não confere
+	ErrSegredoDoLugar = errors.New("segredo inválido para voltar ao lugar")
+)
+
+// Sala reúne todas as conexões que acompanham um mesmo jogo. Ela é o destino dos
+// alertas do jogo e repassa cada alerta para todas as conexões. O código da sala
+// identifica o jogo e sobrevive às quedas de conexão de quem o conduz
+type Sala struct {
+	Codigo            string
+	NumeroDeJogadores int
+	token             string
+
+	mu           sync.Mutex
+	conexoes     map[*websocketServidorJogador]bool
+	anfitriao    *websocketServidorJogador
+	ultimoAlerta *DadosAlertaBlind
+	encerrada    bool
+	expiracao    Timer
+	partida      Partida
+	mesa         *Mesa
+	assentos     map[int]*websocketServidorJogador
+	segredos     map[int]string
+}
+
+// Write repassa o texto como alerta de blind para todas as conexões
+func (s *Sala) Write(p []byte) (int, error) {
+	texto := strings.TrimSpace(string(p))
+
+	if texto != "" {
+		s.alertar(DadosAlertaBlind{Mensagem: texto})
+	}
+
+	return len(p), nil
+}
+
+// AlertarBlind repassa o alerta de blind para todas as conexões
+func (s *Sala) AlertarBlind(blinds Blinds) error {
+	s.alertar(NovosDadosAlertaBlind(blinds))
+	return nil
+}
+
+// AtualizarRelogio repassa o estado dos blinds para todas as conexões
+func (s *Sala) AtualizarRelogio(estado EstadoDosBlinds) error {
+	s.transmitir(MensagemRelogio, NovosDadosRelogio(estado))
+	return nil
+}
+
+// Conexoes retorna quantas conexões estão na sala
+func (s *Sala) Conexoes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conexoes)
+}
+
+// Partida retorna a partida jogada na sala
+func (s *Sala) Partida() Partida {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.partida
+}
+
+func (s *Sala) jogar(partida Partida) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.partida = partida
+}
+
+// Lugares retorna onde estão sentados os jogadores do torneio jogado na sala; falso quando
+// a partida não distribui lugares
+func (s *Sala) Lugares() (DistribuicaoDosLugares, bool) {
+	controle, ok := s.Partida().(ControleDeLugares)
+
+	if !ok {
+		return DistribuicaoDosLugares{}, false
+	}
+
+	lugares := controle.Lugares()
+	return lugares, len(lugares.Mesas) > 0
+}
+
+// Mesa retorna a mesa jogada na sala ou nil se o jogo não tem mesa
+func (s *Sala) Mesa() *Mesa {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mesa
+}
+
+func (s *Sala) sentarNaMesa(mesa *Mesa) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mesa = mesa
+	s.assentos = map[int]*websocketServidorJogador{}
+	s.segredos = map[int]string{}
+}
+
+// EnviarCartas envia as cartas fechadas apenas para a conexão sentada no lugar
+func (s *Sala) EnviarCartas(lugar int, cartas []Carta) {
+	s.mu.Lock()
+	conexão := s.assentos[lugar]
+	s.mu.Unlock()
+
+	if conexão == nil {
+		return
+	}
+
+	if err := conexão.EnviarMensagem(MensagemCartas, DadosCartas{Lugar: lugar, Cartas: cartas}); err != nil {
+		log.Printf("erro ao enviar as cartas do lugar %d da sala %s %v\n", lugar, s.Codigo, err)
+	}
+}
+
+// AtualizarMesa repassa o estado da mesa para todas as conexões
+func (s *Sala) AtualizarMesa(estado EstadoDaMesa) {
+	s.transmitir(MensagemMesa, estado)
+}
+
+// MostrarResultado repassa o resultado da mão para todas as conexões
+func (s *Sala) MostrarResultado(resultado ResultadoDaMao) {
+	s.transmitir(MensagemResultado, resultado)
+}
+
+// sentar coloca a conexão no lugar do jogador e retorna o segredo do lugar. Quem volta
+// depois de uma queda de conexão recupera o lugar com o segredo recebido ao sentar, desde que
+// nenhuma outra conexão esteja sentada nele. A mesa é chamada fora do cadeado da sala,
+// porque ela avisa a sala enquanto segura a ordem das entregas
+func (s *Sala) sentar(conexão *websocketServidorJogador, nome, segredo string) (int, string, error) {
+	mesa := s.Mesa()
+
+	if mesa == nil {
+		return 0, "", ErrSemMesa
+	}
+
+	if lugar, sentado := s.lugarDa(conexão); sentado {
+		return 0, "", fmt.Errorf("a conexão já está sentada no lugar %d", lugar)
+	}
+
+	lugar, err := mesa.Sentar(nome)
+
+	if err != nil {
+		return 0, "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ocupante := s.assentos[lugar]; ocupante != nil && ocupante != conexão {
+		return 0, "", ErrLugarOcupado
+	}
+
+	if s.segredos[lugar] == "" {
+		s.segredos[lugar] = novoToken()
+	} else if s.segredos[lugar] != segredo {
+		return 0, "", ErrSegredoDoLugar
+	}
+
+	s.assentos[lugar] = conexão
+	return lugar, s.segredos[lugar], nil
+}
+
+// lugarDa retorna o lugar da mesa em que a conexão está sentada
+func (s *Sala) lugarDa(conexão *websocketServidorJogador) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for lugar, sentada := range s.assentos {
+		if sentada == conexão {
+			return lugar, true
+		}
+	}
+
+	return 0, false
+}
+
+func (s *Sala) alertar(alerta DadosAlertaBlind) {
+	s.mu.Lock()
+	s.ultimoAlerta = &alerta
+	s.mu.Unlock()
+
+	s.transmitir(MensagemAlertaBlind, alerta)
+}
+
+// transmitir envia a mensagem para todas as conexões da sala
+func (s *Sala) transmitir(tipo string, dados interface{}) {
+	for _, conexão := range s.copiarConexoes() {
+		if err := conexão.EnviarMensagem(tipo, dados); err != nil {
+			log.Printf("erro ao enviar %s para a sala %s %v\n", tipo, s.Codigo, err)
+		}
+	}
+}
+
+func (s *Sala) copiarConexoes() []*websocketServidorJogador {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conexoes := make([]*websocketServidorJogador, 0, len(s.conexoes))
+	for conexão := range s.conexoes {
+		conexoes = append(conexoes, conexão)
+	}
+	return conexoes
+}
+
+// entrar adiciona a conexão, avisa a sala e repete para ela o blind atual e os lugares do
+// torneio
+func (s *Sala) entrar(conexão *websocketServidorJogador) {
+	s.mu.Lock()
+	s.conexoes[conexão] = true
+	total := len(s.conexoes)
+	ultimoAlerta := s.ultimoAlerta
+	s.mu.Unlock()
+
+	s.transmitir(MensagemEntrou, DadosPresenca{Conexoes: total})
+	s.repetirEstado(conexão, ultimoAlerta)
+}
+
+// abrir coloca o anfitrião entre as conexões e repete para ele o blind atual e os lugares
+// sorteados para o torneio
+func (s *Sala) abrir() {
+	s.mu.Lock()
+	anfitriao := s.anfitriao
+	s.conexoes[anfitriao] = true
+	ultimoAlerta := s.ultimoAlerta
+	s.mu.Unlock()
+
+	s.repetirEstado(anfitriao, ultimoAlerta)
+}
+
+func (s *Sala) repetirEstado(conexão *websocketServidorJogador, ultimoAlerta *DadosAlertaBlind) {
+	if ultimoAlerta != nil {
+		conexão.EnviarMensagem(MensagemAlertaBlind, *ultimoAlerta)
+	}
+
+	if lugares, ok := s.Lugares(); ok {
+		conexão.EnviarMensagem(MensagemLugares, lugares)
+	}
+}
+
+// sair remove a conexão, avisa quem ficou e informa se quem saiu conduzia o jogo
+func (s *Sala) sair(conexão *websocketServidorJogador) (eraAnfitriao bool) {
+	s.mu.Lock()
+	delete(s.conexoes, conexão)
+	for lugar, sentada := range s.assentos {
+		if sentada == conexão {
+			delete(s.assentos, lugar)
+		}
+	}
+	total := len(s.conexoes)
+	eraAnfitriao = s.anfitriao == conexão && !s.encerrada
+	if eraAnfitriao {
+		s.anfitriao = nil
+	}
+	s.mu.Unlock()
+
+	if total > 0 {
+		s.transmitir(MensagemSaiu, DadosPresenca{Conexoes: total})
+	}
+
+	return eraAnfitriao
+}
+
+// assumir faz da conexão a nova condutora do jogo e retorna a anterior, que quem chama deve
+// derrubar depois de soltar os seus cadeados
+func (s *Sala) assumir(conexão *websocketServidorJogador) (anterior *websocketServidorJogador) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	anterior = s.anfitriao
+	s.anfitriao = conexão
+	if s.expiracao != nil {
+		s.expiracao.Parar()
+		s.expiracao = nil
+	}
+
+	if anterior == conexão {
+		return nil
+	}
+	return anterior
+}
+
+// encerrar marca o jogo como terminado se a conexão ainda o conduz
+func (s *Sala) encerrar(conexão *websocketServidorJogador) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.encerrada || s.anfitriao != conexão {
+		return false
+	}
+
+	s.encerrada = true
+	return true
+}
+
+// terminarMesa marca o jogo como terminado pela mesa, que não depende de quem o conduz
+func (s *Sala) terminarMesa() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.encerrada {
+		return false
+	}
+
+	s.encerrada = true
+	return true
+}
+
+// abandonar encerra o jogo sem vencedor se ninguém o retomou
+func (s *Sala) abandonar() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.encerrada || s.anfitriao != nil {
+		return false
+	}
+
+	s.encerrada = true
+	return true
+}
+
+func (s *Sala) fecharConexoes() {
+	for _, conexão := range s.copiarConexoes() {
+		conexão.Close()
+	}
+}
+
+// CentralDeSalas guarda os jogos em andamento. Quando quem conduz um jogo cai, a sala
+// espera pela janela de retomada antes de ser abandonada
+type CentralDeSalas struct {
+	janelaDeRetomada time.Duration
+	relogio          Relogio
+
+	mu    sync.Mutex
+	salas map[string]*Sala
+}
+
+// NovaCentralDeSalas cria uma CentralDeSalas vazia que mede a janela de retomada no relógio
+func NovaCentralDeSalas(janelaDeRetomada time.Duration, relogio Relogio) *CentralDeSalas {
+	return &CentralDeSalas{
+		janelaDeRetomada: janelaDeRetomada,
+		relogio:          relogio,
+		salas:            map[string]*Sala{},
+	}
+}
+
+// criarSala abre uma sala com um código ainda não usado para o anfitrião. Ele só passa a
+// receber as mensagens da sala depois de abrir, para que saiba o código antes do primeiro alerta
+func (c *CentralDeSalas) criarSala(anfitriao *websocketServidorJogador, numeroDeJogadores int) *Sala {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	codigo := novoCodigoDeSala()
+	for c.salas[codigo] != nil {
+		codigo = novoCodigoDeSala()
+	}
+
+	sala := &Sala{
+		Codigo:            codigo,
+		NumeroDeJogadores: numeroDeJogadores,
+		token:             novoToken(),
+		conexoes:          map[*websocketServidorJogador]bool{},
+		anfitriao:         anfitriao,
+	}
+	c.salas[codigo] = sala
+	return sala
+}
+
+// descartar remove uma sala cujo jogo não pôde começar
+func (c *CentralDeSalas) descartar(sala *Sala) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.salas, sala.Codigo)
+}
+
+// entrar coloca a conexão na sala com o código, se ela estiver aberta. A sala avisa as
+// conexões fora do cadeado da central, para que uma conexão lenta não segure as outras salas
+func (c *CentralDeSalas) entrar(codigo string, conexão *websocketServidorJogador) (*Sala, bool) {
+	sala, existe := c.Sala(codigo)
+
+	if existe {
+		sala.entrar(conexão)
+	}
+
+	return sala, existe
+}
+
+// retomar devolve o jogo para a conexão de quem o começou, desde que o token confira
+func (c *CentralDeSalas) retomar(codigo, token string, conexão *websocketServidorJogador) (*Sala, error) {
+	c.mu.Lock()
+	sala, existe := c.salas[strings.ToUpper(codigo)]
+
+	if !existe {
+		c.mu.Unlock()
+		return nil, ErrSalaNaoEncontrada
+	}
+
+	if sala.token != token {
+		c.mu.Unlock()
+		return nil, ErrTokenInvalido
+	}
+
+	anterior := sala.assumir(conexão)
+	c.mu.Unlock()
+
+	// a conexão anterior é derrubada fora do cadeado, para que um cliente lento não
+	// trave as outras salas
+	if anterior != nil {
+		anterior.Close()
+	}
+
+	return sala, nil
+}
+
+// Sala retorna a sala aberta com o código
+func (c *CentralDeSalas) Sala(codigo string) (*Sala, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sala, existe := c.salas[strings.ToUpper(codigo)]
+	return sala, existe
+}
+
+// Salas retorna quantas salas estão abertas
+func (c *CentralDeSalas) Salas() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.salas)
+}
+
+// sair remove a conexão da sala. Se ela conduzia o jogo, começa a contar a janela de retomada
+func (c *CentralDeSalas) sair(sala *Sala, conexão *websocketServidorJogador) {
+	if !sala.sair(conexão) {
+		return
+	}
+
+	sala.mu.Lock()
+	defer sala.mu.Unlock()
+
+	if sala.anfitriao == nil && sala.expiracao == nil {
+		sala.expiracao = c.relogio.NovoTimer(c.janelaDeRetomada, func() {
+			c.abandonar(sala)
+		})
+	}
+}
+
+// encerrar termina o jogo da sala, se a conexão ainda o conduz, e fecha a sala
+func (c *CentralDeSalas) encerrar(sala *Sala, conexão *websocketServidorJogador) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !sala.encerrar(conexão) {
+		return false
+	}
+
+	delete(c.salas, sala.Codigo)
+	return true
+}
+
+// terminarMesa termina o jogo da sala quando a mesa tem um vencedor e fecha a sala
+func (c *CentralDeSalas) terminarMesa(sala *Sala) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !sala.terminarMesa() {
+		return false
+	}
+
+	delete(c.salas, sala.Codigo)
+	return true
+}
+
+func (c *CentralDeSalas) abandonar(sala *Sala) {
+	c.mu.Lock()
+	abandonada := sala.abandonar()
+	if abandonada {
+		delete(c.salas, sala.Codigo)
+	}
+	c.mu.Unlock()
+
+	if !abandonada {
+		return
+	}
+
+	if partida := sala.Partida(); partida != nil {
+		partida.Abandonar()
+	}
+
+	if mesa := sala.Mesa(); mesa != nil {
+		mesa.Encerrar()
+	}
+
+	sala.transmitir(MensagemFim, DadosFim{Abandonado: true})
+	sala.fecharConexoes()
+}
+
+func novoCodigoDeSala() string {
+	codigo := make([]byte, tamanhoDoCodigo)
+	limite := big.NewInt(int64(len(letrasDoCodigo)))
+
+	for i := range codigo {
+		n, err := rand.Int(rand.Reader, limite)
+		if err != nil {
+			panic(err)
+		}
+		codigo[i] = letrasDoCodigo[n.Int64()]
+	}
+
+	return string(codigo)
+}
+
+func novoToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
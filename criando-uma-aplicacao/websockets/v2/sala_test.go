@@ -0,0 +1,743 @@
+package poquer_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+// JogoComAlertas guarda o destino dos alertas para que o teste dispare alertas quando quiser
+type JogoComAlertas struct {
+	JogoEspiao
+	destino chan io.Writer
+}
+
+func (j *JogoComAlertas) Começar(configuracao poquer.ConfiguracaoDaPartida, destino io.Writer) (poquer.Partida, error) {
+	partida, err := j.JogoEspiao.Começar(configuracao, destino)
+	j.destino <- destino
+	return partida, err
+}
+
+func novoJogoComAlertas() *JogoComAlertas {
+	return &JogoComAlertas{destino: make(chan io.Writer, 1)}
+}
+
+func TestSalas(t *testing.T) {
+	t.Run("transmite os alertas de blind para todas as conexões da sala", func(t *testing.T) {
+		jogo := novoJogoComAlertas()
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		sala := começarJogoNaSala(t, anfitriao, 4)
+		destino := receberDestino(t, jogo)
+
+		convidado := deveConectarAoWebSocket(t, url+"?sala="+strings.ToLower(sala.Sala))
+		defer convidado.Close()
+
+		verificaPresenca(t, convidado, poquer.MensagemEntrou, 2)
+		verificaPresenca(t, anfitriao, poquer.MensagemEntrou, 2)
+
+		destino.(poquer.DestinoDeBlind).AlertarBlind(poquer.Blinds{SmallBlind: 100, BigBlind: 200, Ante: 25})
+
+		alerta := poquer.DadosAlertaBlind{Quantia: 200, SmallBlind: 100, Ante: 25, Mensagem: "Blinds agora são 100/200, ante 25"}
+		within(t, tenMS, func() { verificaSeWebSocketObteveMensagem(t, anfitriao, poquer.MensagemAlertaBlind, alerta) })
+		within(t, tenMS, func() { verificaSeWebSocketObteveMensagem(t, convidado, poquer.MensagemAlertaBlind, alerta) })
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemDeclararVencedor, poquer.DadosDeclararVencedor{Vencedor: "Ruth"})
+
+		within(t, tenMS, func() {
+			verificaSeWebSocketObteveMensagem(t, convidado, poquer.MensagemFim, poquer.DadosFim{Vencedor: "Ruth"})
+		})
+	})
+
+	t.Run("transmite o relógio quando quem conduz controla os blinds", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		sala := começarJogoNaSala(t, anfitriao, 4)
+
+		convidado := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		defer convidado.Close()
+		verificaPresenca(t, anfitriao, poquer.MensagemEntrou, 2)
+		verificaPresenca(t, convidado, poquer.MensagemEntrou, 2)
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemControle, poquer.DadosControle{Comando: poquer.ComandoPausar})
+
+		relogio := poquer.DadosRelogio{Nivel: 1, Quantia: 100, ProximaQuantia: 200, SegundosRestantes: 600, Pausado: true}
+		within(t, tenMS, func() { verificaSeWebSocketObteveMensagem(t, anfitriao, poquer.MensagemRelogio, relogio) })
+		within(t, tenMS, func() { verificaSeWebSocketObteveMensagem(t, convidado, poquer.MensagemRelogio, relogio) })
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemControle, poquer.DadosControle{Comando: "pizza"})
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, anfitriao) })
+
+		verificaComandos(t, jogo, poquer.ComandoPausar)
+	})
+
+	t.Run("transmite a contagem regressiva até o próximo blind", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, &poquer.EsbocoDeArmazenamentoJogador{},
+			poquer.ComRelogio(relogio), poquer.ComIntervaloDoRelogio(time.Second))
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		começarJogoNaSala(t, anfitriao, 3)
+
+		tentarNovamenteAte(500*time.Millisecond, func() bool { return relogio.TickersAtivos() == 1 })
+		relogio.Avancar(time.Second)
+
+		within(t, tenMS, func() {
+			verificaSeWebSocketObteveMensagem(t, anfitriao, poquer.MensagemRelogio, poquer.DadosRelogio{
+				Nivel: 1, Quantia: 100, ProximaQuantia: 200, SegundosRestantes: 479,
+			})
+		})
+	})
+
+	t.Run("transmite a classificação do torneio e termina quando resta um jogador", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+		salas := poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{})
+		url := novoServidorDeSalas(t, jogo, salas)
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemIniciar, poquer.DadosIniciar{
+			Jogadores:      []string{"Cleo", "Chris", "Ruth"},
+			FichasIniciais: 1000,
+		})
+		sala := lerAte(t, anfitriao, poquer.MensagemSalaCriada)
+		verificaLugaresNoWebsocket(t, anfitriao, "Cleo", "Chris", "Ruth")
+
+		var dadosDaSala poquer.DadosSala
+		sala.LerDados(poquer.MensagemSalaCriada, &dadosDaSala)
+		convidado := deveConectarAoWebSocket(t, url+"?sala="+dadosDaSala.Sala)
+		defer convidado.Close()
+		verificaPresenca(t, anfitriao, poquer.MensagemEntrou, 2)
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemTorneio, poquer.DadosTorneio{Comando: poquer.ComandoEliminar, Jogador: "Tiest"})
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, anfitriao) })
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemTorneio, poquer.DadosTorneio{Comando: poquer.ComandoEliminar, Jogador: "Chris"})
+		verificaClassificacaoNoWebsocket(t, convidado, poquer.Classificacao{
+			Jogadores: []poquer.PosicaoNoTorneio{
+				{Nome: "Cleo", Fichas: 1000},
+				{Nome: "Ruth", Fichas: 1000},
+				{Nome: "Chris", Posicao: 3},
+			},
+			FichasEmJogo: 3000,
+		})
+		verificaLugaresNoWebsocket(t, convidado, "Cleo", "Ruth")
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemDeclararVencedor, poquer.DadosDeclararVencedor{Vencedor: "Chris"})
+		verificaErroEntreAsMensagens(t, anfitriao)
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemTorneio, poquer.DadosTorneio{Comando: poquer.ComandoEliminar, Jogador: "Ruth"})
+		verificaClassificacaoNoWebsocket(t, convidado, poquer.Classificacao{
+			Jogadores: []poquer.PosicaoNoTorneio{
+				{Nome: "Cleo", Fichas: 3000, Posicao: 1},
+				{Nome: "Ruth", Posicao: 2},
+				{Nome: "Chris", Posicao: 3},
+			},
+			FichasEmJogo: 3000,
+			Encerrado:    true,
+		})
+		within(t, tenMS, func() {
+			verificaSeWebSocketObteveMensagem(t, convidado, poquer.MensagemFim, poquer.DadosFim{Vencedor: "Cleo"})
+		})
+
+		verificaSalasAbertas(t, salas, 0)
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Cleo")
+	})
+
+	t.Run("espalha o torneio por várias mesas e transmite as mudanças de lugar", func(t *testing.T) {
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, &poquer.EsbocoDeArmazenamentoJogador{})
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemIniciar, poquer.DadosIniciar{
+			Jogadores:      []string{"Cleo", "Chris", "Ruth", "Tiest"},
+			FichasIniciais: 1000,
+			LugaresPorMesa: 2,
+		})
+		lerAte(t, anfitriao, poquer.MensagemSalaCriada)
+		lugares := verificaLugaresNoWebsocket(t, anfitriao, "Cleo", "Chris", "Ruth", "Tiest")
+
+		if len(lugares.Mesas) != 2 {
+			t.Fatalf("esperava duas mesas de dois lugares, obtido %+v", lugares.Mesas)
+		}
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemTorneio, poquer.DadosTorneio{Comando: poquer.ComandoBotao, Mesa: 2})
+		depoisDoBotao := verificaLugaresNoWebsocket(t, anfitriao, "Cleo", "Chris", "Ruth", "Tiest")
+
+		if depoisDoBotao.Mesas[1].Botao == lugares.Mesas[1].Botao {
+			t.Errorf("o botão da mesa 2 deveria ter avançado, obtido %+v", depoisDoBotao.Mesas[1])
+		}
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemTorneio, poquer.DadosTorneio{Comando: poquer.ComandoBotao, Mesa: 3})
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, anfitriao) })
+
+		primeiro, segundo := lugares.Mesas[0].Jogadores[0].Nome, lugares.Mesas[1].Jogadores[0].Nome
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemTorneio, poquer.DadosTorneio{Comando: poquer.ComandoEliminar, Jogador: primeiro})
+		verificaLugaresNoWebsocket(t, anfitriao, restantes(lugares, primeiro)...)
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemTorneio, poquer.DadosTorneio{Comando: poquer.ComandoEliminar, Jogador: segundo})
+		desfeita := verificaLugaresNoWebsocket(t, anfitriao, restantes(lugares, primeiro, segundo)...)
+
+		if len(desfeita.Mesas) != 1 || len(desfeita.Mudancas) != 1 || desfeita.Mudancas[0].Motivo != poquer.MotivoMesaDesfeita {
+			t.Errorf("esperava uma mesa desfeita, obtido %+v", desfeita)
+		}
+	})
+
+	t.Run("recusa comandos de torneio em partidas comuns", func(t *testing.T) {
+		url := novoServidorDeSalas(t, &JogoEspiao{}, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		começarJogoNaSala(t, anfitriao, 3)
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemTorneio, poquer.DadosTorneio{Comando: poquer.ComandoEliminar, Jogador: "Cleo"})
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, anfitriao) })
+	})
+
+	t.Run("quem entra depois recebe o blind atual", func(t *testing.T) {
+		jogo := novoJogoComAlertas()
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		sala := começarJogoNaSala(t, anfitriao, 4)
+		receberDestino(t, jogo).(poquer.DestinoDeBlind).AlertarBlind(blindUnico(300))
+
+		convidado := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		defer convidado.Close()
+
+		verificaPresenca(t, convidado, poquer.MensagemEntrou, 2)
+		within(t, tenMS, func() {
+			verificaSeWebSocketObteveMensagem(t, convidado, poquer.MensagemAlertaBlind, poquer.DadosAlertaBlind{Quantia: 300, Mensagem: "Blind agora é 300"})
+		})
+	})
+
+	t.Run("avisa a sala quando alguém sai", func(t *testing.T) {
+		url := novoServidorDeSalas(t, jogoTosco, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		sala := começarJogoNaSala(t, anfitriao, 4)
+
+		convidado := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		verificaPresenca(t, anfitriao, poquer.MensagemEntrou, 2)
+
+		convidado.Close()
+
+		verificaPresenca(t, anfitriao, poquer.MensagemSaiu, 1)
+	})
+
+	t.Run("convidados não controlam o jogo", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		sala := começarJogoNaSala(t, anfitriao, 4)
+
+		convidado := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		defer convidado.Close()
+		verificaPresenca(t, convidado, poquer.MensagemEntrou, 2)
+
+		escreverMensagemNoWebsocket(t, convidado, poquer.MensagemDeclararVencedor, poquer.DadosDeclararVencedor{Vencedor: "Eu"})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, convidado) })
+		verificaPartidaNaoFinalizada(t, jogo)
+	})
+
+	t.Run("responde com erro a salas inexistentes", func(t *testing.T) {
+		url := novoServidorDeSalas(t, jogoTosco, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		convidado := deveConectarAoWebSocket(t, url+"?sala=NADA")
+		defer convidado.Close()
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, convidado) })
+	})
+
+	t.Run("fecha a sala quando o jogo termina", func(t *testing.T) {
+		salas := poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{})
+		url := novoServidorDeSalas(t, jogoTosco, salas)
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		começarJogoNaSala(t, anfitriao, 4)
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemDeclararVencedor, poquer.DadosDeclararVencedor{Vencedor: "Ruth"})
+
+		verificaSalasAbertas(t, salas, 0)
+	})
+}
+
+func TestRetomarJogo(t *testing.T) {
+	t.Run("uma nova conexão retoma o jogo, recebe o blind atual e declara o vencedor", func(t *testing.T) {
+		jogo := novoJogoComAlertas()
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		primeira := deveConectarAoWebSocket(t, url)
+		sala := começarJogoNaSala(t, primeira, 6)
+		receberDestino(t, jogo).(poquer.DestinoDeBlind).AlertarBlind(blindUnico(400))
+		primeira.Close()
+
+		segunda := deveConectarAoWebSocket(t, url)
+		defer segunda.Close()
+
+		escreverMensagemNoWebsocket(t, segunda, poquer.MensagemRetomar, poquer.DadosRetomar{Sala: sala.Sala, Token: sala.Token})
+
+		within(t, tenMS, func() {
+			verificaSeWebSocketObteveMensagem(t, segunda, poquer.MensagemRetomado, poquer.DadosRetomado{Sala: sala.Sala, NumeroDeJogadores: 6})
+		})
+		lerAte(t, segunda, poquer.MensagemAlertaBlind)
+
+		escreverMensagemNoWebsocket(t, segunda, poquer.MensagemDeclararVencedor, poquer.DadosDeclararVencedor{Vencedor: "Ruth"})
+
+		verificaTerminosChamadosCom(t, &jogo.JogoEspiao, "Ruth")
+	})
+
+	t.Run("não retoma com um token errado", func(t *testing.T) {
+		url := novoServidorDeSalas(t, jogoTosco, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		primeira := deveConectarAoWebSocket(t, url)
+		defer primeira.Close()
+		sala := começarJogoNaSala(t, primeira, 6)
+
+		intrusa := deveConectarAoWebSocket(t, url)
+		defer intrusa.Close()
+
+		escreverMensagemNoWebsocket(t, intrusa, poquer.MensagemRetomar, poquer.DadosRetomar{Sala: sala.Sala, Token: "chute"})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, intrusa) })
+	})
+
+	t.Run("abandona o jogo sem gravar vencedor quando ninguém o retoma", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		relogio := poquer.NovoRelogioFalso()
+		salas := poquer.NovaCentralDeSalas(time.Minute, relogio)
+		url := novoServidorDeSalas(t, jogo, salas)
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		sala := começarJogoNaSala(t, anfitriao, 4)
+
+		convidado := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		defer convidado.Close()
+		verificaPresenca(t, convidado, poquer.MensagemEntrou, 2)
+
+		anfitriao.Close()
+		verificaPresenca(t, convidado, poquer.MensagemSaiu, 1)
+
+		tentarNovamenteAte(500*time.Millisecond, func() bool { return relogio.TimersPendentes() == 1 })
+		relogio.Avancar(59 * time.Second)
+		verificaSalasAbertas(t, salas, 1)
+		relogio.Avancar(time.Second)
+
+		within(t, tenMS, func() {
+			verificaSeWebSocketObteveMensagem(t, convidado, poquer.MensagemFim, poquer.DadosFim{Abandonado: true})
+		})
+		verificaSalasAbertas(t, salas, 0)
+		verificaPartidaNaoFinalizada(t, jogo)
+		verificaPartidaAbandonada(t, jogo)
+
+		retomada := deveConectarAoWebSocket(t, url)
+		defer retomada.Close()
+		escreverMensagemNoWebsocket(t, retomada, poquer.MensagemRetomar, poquer.DadosRetomar{Sala: sala.Sala, Token: sala.Token})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, retomada) })
+	})
+}
+
+func novoServidorDeSalas(t *testing.T, jogo poquer.Jogo, salas *poquer.CentralDeSalas, opcoes ...poquer.OpcaoServidor) string {
+	t.Helper()
+	opcoes = append(opcoes, poquer.ComCentralDeSalas(salas))
+	servidor := httptest.NewServer(deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogo, opcoes...))
+	t.Cleanup(servidor.Close)
+	return "ws" + strings.TrimPrefix(servidor.URL, "http") + "/ws"
+}
+
+// enderecoHTTP volta do endereço do websocket para o do servidor
+func enderecoHTTP(url string) string {
+	return "http" + strings.TrimSuffix(strings.TrimPrefix(url, "ws"), "/ws")
+}
+
+func receberDestino(t *testing.T, jogo *JogoComAlertas) io.Writer {
+	t.Helper()
+	select {
+	case destino := <-jogo.destino:
+		return destino
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("o jogo não começou")
+		return nil
+	}
+}
+
+func verificaPresenca(t *testing.T, ws *websocket.Conn, tipo string, conexoes int) {
+	t.Helper()
+	within(t, tenMS, func() { verificaSeWebSocketObteveMensagem(t, ws, tipo, poquer.DadosPresenca{Conexoes: conexoes}) })
+}
+
+func verificaSalasAbertas(t *testing.T, salas *poquer.CentralDeSalas, esperado int) {
+	t.Helper()
+	passou := tentarNovamenteAte(500*time.Millisecond, func() bool { return salas.Salas() == esperado })
+	if !passou {
+		t.Errorf("obtido %d salas abertas, esperado %d", salas.Salas(), esperado)
+	}
+}
+
+func verificaClassificacaoNoWebsocket(t *testing.T, ws *websocket.Conn, esperada poquer.Classificacao) {
+	t.Helper()
+
+	var obtida poquer.Classificacao
+	if err := lerAte(t, ws, poquer.MensagemClassificacao).LerDados(poquer.MensagemClassificacao, &obtida); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(obtida, esperada) {
+		t.Errorf("obtida %+v, esperada %+v", obtida, esperada)
+	}
+}
+
+// lerAte descarta mensagens até encontrar uma do tipo esperado
+// verificaLugaresNoWebsocket confere que os jogadores, e só eles, estão sentados e retorna
+// os lugares recebidos
+func verificaLugaresNoWebsocket(t *testing.T, ws *websocket.Conn, jogadores ...string) poquer.DistribuicaoDosLugares {
+	t.Helper()
+
+	var lugares poquer.DistribuicaoDosLugares
+	if err := lerAte(t, ws, poquer.MensagemLugares).LerDados(poquer.MensagemLugares, &lugares); err != nil {
+		t.Fatal(err)
+	}
+
+	sentados := 0
+	for _, mesa := range lugares.Mesas {
+		sentados += len(mesa.Jogadores)
+	}
+
+	for _, jogador := range jogadores {
+		if _, _, sentado := lugares.LugarDe(jogador); !sentado {
+			t.Errorf("%s deveria estar sentado, obtido %+v", jogador, lugares.Mesas)
+		}
+	}
+
+	if sentados != len(jogadores) {
+		t.Errorf("esperava %d jogadores sentados, obtido %+v", len(jogadores), lugares.Mesas)
+	}
+
+	return lugares
+}
+
+// restantes são os jogadores sentados, menos os eliminados
+func restantes(lugares poquer.DistribuicaoDosLugares, eliminados ...string) []string {
+	var nomes []string
+
+	for _, mesa := range lugares.Mesas {
+		for _, jogador := range mesa.Jogadores {
+			nomes = append(nomes, jogador.Nome)
+		}
+	}
+
+	for _, eliminado := range eliminados {
+		for i, nome := range nomes {
+			if nome == eliminado {
+				nomes = append(nomes[:i], nomes[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return nomes
+}
+
+func lerAte(t *testing.T, ws *websocket.Conn, tipo string) poquer.MensagemWebsocket {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	defer ws.SetReadDeadline(time.Time{})
+
+	for {
+		var mensagem poquer.MensagemWebsocket
+		if err := ws.ReadJSON(&mensagem); err != nil {
+			t.Fatalf("não recebeu uma mensagem %s, %v", tipo, err)
+		}
+		if mensagem.Tipo == tipo {
+			return mensagem
+		}
+	}
+}
+
+func TestMesaNaSala(t *testing.T) {
+	t.Run("cada jogador vê só as suas cartas e o vencedor da mesa é gravado", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 2, Mesa: true, FichasIniciais: 1000})
+
+		var sala poquer.DadosSala
+		lerAte(t, anfitriao, poquer.MensagemSalaCriada).LerDados(poquer.MensagemSalaCriada, &sala)
+
+		cleo := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		defer cleo.Close()
+		escreverMensagemNoWebsocket(t, cleo, poquer.MensagemAcao, poquer.Acao{Tipo: poquer.Pagar})
+		verificaErroEntreAsMensagens(t, cleo)
+
+		escreverMensagemNoWebsocket(t, cleo, poquer.MensagemSentar, poquer.DadosSentar{Nome: "Cleo"})
+		verificaSentado(t, cleo, poquer.DadosSentado{Lugar: 0, Nome: "Cleo"})
+
+		impostor := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		escreverMensagemNoWebsocket(t, impostor, poquer.MensagemSentar, poquer.DadosSentar{Nome: "Cleo"})
+		verificaErroEntreAsMensagens(t, impostor)
+		impostor.Close()
+
+		chris := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		defer chris.Close()
+		escreverMensagemNoWebsocket(t, chris, poquer.MensagemSentar, poquer.DadosSentar{Nome: "Chris"})
+		verificaSentado(t, chris, poquer.DadosSentado{Lugar: 1, Nome: "Chris"})
+
+		jogadas := make(chan jogadaNaMesa, 2)
+		go jogarAllInAteOFim(cleo, 0, jogadas)
+		go jogarAllInAteOFim(chris, 1, jogadas)
+
+		vencedores := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			jogada := <-jogadas
+			if jogada.err != nil {
+				t.Fatal(jogada.err)
+			}
+			vencedores[jogada.vencedor] = true
+		}
+
+		if len(vencedores) != 1 || (!vencedores["Cleo"] && !vencedores["Chris"]) {
+			t.Fatalf("os dois jogadores deveriam ver o mesmo vencedor, obtido %v", vencedores)
+		}
+
+		for {
+			var mensagem poquer.MensagemWebsocket
+			if err := anfitriao.ReadJSON(&mensagem); err != nil {
+				break
+			}
+			if mensagem.Tipo == poquer.MensagemCartas {
+				t.Fatalf("quem não está sentado não deveria receber cartas, obtido %s", mensagem.Dados)
+			}
+		}
+
+		passou := tentarNovamenteAte(500*time.Millisecond, func() bool { return len(armazenamento.ChamadasDeVitoria) == 1 })
+		if !passou || !vencedores[armazenamento.ChamadasDeVitoria[0]] {
+			t.Errorf("o vencedor da mesa deveria ser gravado, obtido %v", armazenamento.ChamadasDeVitoria)
+		}
+	})
+
+	t.Run("os bots ocupam os primeiros lugares e jogam contra quem senta, e as mãos ficam no histórico", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+		maos := poquer.NovoArmazenamentoDeMaosEmMemoria()
+		url := novoServidorDeSalas(t, jogo, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}), poquer.ComArmazenamentoDeMaos(maos))
+
+		cleo := deveConectarAoWebSocket(t, url)
+		defer cleo.Close()
+		escreverMensagemNoWebsocket(t, cleo, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 2, Mesa: true, FichasIniciais: 1000, Bots: 1})
+
+		var sala poquer.DadosSala
+		lerAte(t, cleo, poquer.MensagemSalaCriada).LerDados(poquer.MensagemSalaCriada, &sala)
+
+		for _, nome := range []string{"Bot 1", "Bot 2"} {
+			escreverMensagemNoWebsocket(t, cleo, poquer.MensagemSentar, poquer.DadosSentar{Nome: nome})
+			verificaErroEntreAsMensagens(t, cleo)
+		}
+
+		escreverMensagemNoWebsocket(t, cleo, poquer.MensagemSentar, poquer.DadosSentar{Nome: "Cleo"})
+		verificaSentado(t, cleo, poquer.DadosSentado{Lugar: 1, Nome: "Cleo"})
+
+		jogadas := make(chan jogadaNaMesa, 1)
+		go jogarAllInAteOFim(cleo, 1, jogadas)
+
+		jogada := <-jogadas
+		if jogada.err != nil {
+			t.Fatal(jogada.err)
+		}
+
+		if jogada.vencedor != "Cleo" && jogada.vencedor != "Bot 1" {
+			t.Fatalf("o vencedor deveria ser Cleo ou o bot, obtido %q", jogada.vencedor)
+		}
+
+		if jogada.vencedor == "Cleo" {
+			passou := tentarNovamenteAte(500*time.Millisecond, func() bool { return len(armazenamento.ChamadasDeVitoria) == 1 })
+			if !passou || armazenamento.ChamadasDeVitoria[0] != "Cleo" {
+				t.Errorf("a vitória de Cleo deveria ser gravada, obtido %v", armazenamento.ChamadasDeVitoria)
+			}
+		} else if len(armazenamento.ChamadasDeVitoria) != 0 {
+			t.Errorf("a vitória de um bot não deveria ser gravada, obtido %v", armazenamento.ChamadasDeVitoria)
+		}
+
+		resposta, err := http.Get(enderecoHTTP(url) + "/jogos/" + sala.Sala + "/maos")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resposta.Body.Close()
+
+		historico, err := poquer.LerHistorico(resposta.Body)
+		verificaSemErro(t, err)
+
+		if len(historico) == 0 || historico[len(historico)-1].Resultado.Vencedor != jogada.vencedor {
+			t.Fatalf("o histórico deveria terminar com a vitória de %s, obtido %+v", jogada.vencedor, historico)
+		}
+
+		for _, mao := range historico {
+			if err := poquer.Reproduzir(mao); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("só quem tem o segredo volta ao lugar depois de cair", func(t *testing.T) {
+		url := novoServidorDeSalas(t, &JogoEspiao{}, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 2, Mesa: true, FichasIniciais: 1000})
+
+		var sala poquer.DadosSala
+		lerAte(t, anfitriao, poquer.MensagemSalaCriada).LerDados(poquer.MensagemSalaCriada, &sala)
+
+		cleo := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		escreverMensagemNoWebsocket(t, cleo, poquer.MensagemSentar, poquer.DadosSentar{Nome: "Cleo"})
+		segredo := verificaSentado(t, cleo, poquer.DadosSentado{Lugar: 0, Nome: "Cleo"})
+		cleo.Close()
+
+		impostor := deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		defer impostor.Close()
+
+		recusado := tentarNovamenteAte(500*time.Millisecond, func() bool {
+			escreverMensagemNoWebsocket(t, impostor, poquer.MensagemSentar, poquer.DadosSentar{Nome: "Cleo", Segredo: "chute"})
+
+			var erro poquer.DadosErro
+			lerAte(t, impostor, poquer.MensagemErro).LerDados(poquer.MensagemErro, &erro)
+			return erro.Mensagem == poquer.ErrSegredoDoLugar.Error()
+		})
+
+		if !recusado {
+			t.Fatal("o lugar livre de Cleo deveria ser recusado a quem não tem o segredo")
+		}
+
+		cleo = deveConectarAoWebSocket(t, url+"?sala="+sala.Sala)
+		defer cleo.Close()
+		escreverMensagemNoWebsocket(t, cleo, poquer.MensagemSentar, poquer.DadosSentar{Nome: "Cleo", Segredo: segredo})
+		if novo := verificaSentado(t, cleo, poquer.DadosSentado{Lugar: 0, Nome: "Cleo"}); novo != segredo {
+			t.Errorf("o segredo do lugar não deveria mudar, obtido %q, esperado %q", novo, segredo)
+		}
+	})
+
+	t.Run("recusa uma mesa só de bots", func(t *testing.T) {
+		url := novoServidorDeSalas(t, &JogoEspiao{}, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 2, Mesa: true, FichasIniciais: 1000, Bots: 2})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, anfitriao) })
+	})
+
+	t.Run("recusa sentar em um jogo sem mesa", func(t *testing.T) {
+		url := novoServidorDeSalas(t, &JogoEspiao{}, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		começarJogoNaSala(t, anfitriao, 2)
+
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemSentar, poquer.DadosSentar{Nome: "Cleo"})
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, anfitriao) })
+	})
+
+	t.Run("recusa uma mesa sem fichas iniciais", func(t *testing.T) {
+		url := novoServidorDeSalas(t, &JogoEspiao{}, poquer.NovaCentralDeSalas(time.Minute, poquer.RelogioDoSistema{}))
+
+		anfitriao := deveConectarAoWebSocket(t, url)
+		defer anfitriao.Close()
+		escreverMensagemNoWebsocket(t, anfitriao, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 2, Mesa: true})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, anfitriao) })
+	})
+}
+
+type jogadaNaMesa struct {
+	vencedor string
+	err      error
+}
+
+// jogarAllInAteOFim vai all-in sempre que é a vez do lugar e confere que as cartas recebidas
+// são as do próprio lugar, até o jogo terminar
+func jogarAllInAteOFim(ws *websocket.Conn, lugar int, jogadas chan<- jogadaNaMesa) {
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	for {
+		var mensagem poquer.MensagemWebsocket
+		if err := ws.ReadJSON(&mensagem); err != nil {
+			jogadas <- jogadaNaMesa{err: fmt.Errorf("o lugar %d não viu o fim do jogo, %v", lugar, err)}
+			return
+		}
+
+		switch mensagem.Tipo {
+		case poquer.MensagemCartas:
+			var cartas poquer.DadosCartas
+			mensagem.LerDados(poquer.MensagemCartas, &cartas)
+			if cartas.Lugar != lugar || len(cartas.Cartas) != 2 {
+				jogadas <- jogadaNaMesa{err: fmt.Errorf("o lugar %d recebeu as cartas %+v", lugar, cartas)}
+				return
+			}
+
+		case poquer.MensagemMesa:
+			var estado poquer.EstadoDaMesa
+			mensagem.LerDados(poquer.MensagemMesa, &estado)
+			if estado.Vez == lugar {
+				acao, _ := poquer.NovaMensagemWebsocket(poquer.MensagemAcao, poquer.Acao{Tipo: poquer.AllIn})
+				ws.WriteJSON(acao)
+			}
+
+		case poquer.MensagemFim:
+			var fim poquer.DadosFim
+			mensagem.LerDados(poquer.MensagemFim, &fim)
+			jogadas <- jogadaNaMesa{vencedor: fim.Vencedor}
+			return
+		}
+	}
+}
+
+// verificaSentado confere o lugar e o nome confirmados e retorna o segredo do lugar
+func verificaSentado(t *testing.T, ws *websocket.Conn, esperado poquer.DadosSentado) string {
+	t.Helper()
+
+	var sentado poquer.DadosSentado
+	if err := lerAte(t, ws, poquer.MensagemSentado).LerDados(poquer.MensagemSentado, &sentado); err != nil {
+		t.Fatal(err)
+	}
+
+	if sentado.Lugar != esperado.Lugar || sentado.Nome != esperado.Nome {
+		t.Errorf("obtido %+v, esperado %+v", sentado, esperado)
+	}
+
+	if sentado.Segredo == "" {
+		t.Error("esperava receber o segredo do lugar")
+	}
+
+	return sentado.Segredo
+}
+
+func verificaErroEntreAsMensagens(t *testing.T, ws *websocket.Conn) {
+	t.Helper()
+	lerAte(t, ws, poquer.MensagemErro)
+}
@@ -2,10 +2,17 @@ package poquer
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"log"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -17,10 +24,13 @@ type ArmazenamentoJogador interface {
 	ObterLiga() Liga
 }
 
-// Jogador armazena um nome com um número de vitórias
+// Jogador armazena um nome com um número de vitórias, as colocações nos torneios que jogou
+// e o total de prêmios que ganhou neles
 type Jogador struct {
-	Nome     string
-	Vitorias int
+	Nome       string
+	Vitorias   int
+	Colocacoes []int `json:",omitempty"`
+	Ganhos     int
 }
 
 // ServidorJogador é uma interface HTTP para informações de jogador
@@ -29,13 +39,94 @@ type ServidorJogador struct {
 	http.Handler
 	template *template.Template
 	jogo     Jogo
+	salas    *CentralDeSalas
+	maos     ArmazenamentoDeMaos
+
+	configuracaoWebsocket  ConfiguracaoWebsocket
+	atualizadorDeWebsocket *websocket.Upgrader
+
+	limitadorPorCliente *LimitadorDeTaxa
+	limitadorPorJogador *LimitadorDeTaxa
+
+	maximoDePartidasRPC int
+	tempoOciosoRPC      time.Duration
+	relogioRPC          Relogio
+	muRPC               sync.Mutex
+	partidasRPC         map[string]partidaRPC
+
+	// calculosDeEquidade tem uma vaga para cada POST /equidade que pode calcular ao mesmo tempo
+	calculosDeEquidade chan struct{}
+
+	muIdempotencia sync.Mutex
+	idempotencia   ArmazenamentoIdempotencia
+}
+
+// OpcaoServidor altera a configuração padrão de um ServidorJogador
+type OpcaoServidor func(*ServidorJogador)
+
+// ComLimitesDeTaxa substitui os limitadores usados nas rotas que alteram dados,
+// um aplicado por cliente e outro por jogador
+func ComLimitesDeTaxa(porCliente, porJogador *LimitadorDeTaxa) OpcaoServidor {
+	return func(p *ServidorJogador) {
+		p.limitadorPorCliente = porCliente
+		p.limitadorPorJogador = porJogador
+	}
+}
+
+// ComCalculosDeEquidadeSimultaneos define quantos POST /equidade podem calcular ao mesmo
+// tempo; os que chegam com todas as vagas ocupadas recebem 503
+func ComCalculosDeEquidadeSimultaneos(calculos int) OpcaoServidor {
+	return func(p *ServidorJogador) {
+		p.calculosDeEquidade = make(chan struct{}, calculos)
+	}
+}
+
+// ComCentralDeSalas usa a central informada para guardar as salas dos jogos
+func ComCentralDeSalas(salas *CentralDeSalas) OpcaoServidor {
+	return func(p *ServidorJogador) {
+		p.salas = salas
+	}
+}
+
+// ComArmazenamentoDeMaos guarda no armazenamento informado o histórico das mãos jogadas nas
+// mesas, em vez de mantê-lo só na memória
+func ComArmazenamentoDeMaos(maos ArmazenamentoDeMaos) OpcaoServidor {
+	return func(p *ServidorJogador) {
+		p.maos = maos
+	}
+}
+
+// ComConfiguracaoWebsocket substitui os limites padrão das conexões do /ws. Os campos
+// zerados continuam com o valor padrão e NovoServidorJogador recusa limites inválidos
+func ComConfiguracaoWebsocket(configuracao ConfiguracaoWebsocket) OpcaoServidor {
+	return func(p *ServidorJogador) {
+		p.configuracaoWebsocket = configuracao.completar()
+	}
+}
+
+// ComIdempotencia faz com que requisições repetidas com o mesmo cabeçalho Idempotency-Key
+// recebam a resposta original em vez de serem processadas de novo
+func ComIdempotencia(armazenamento ArmazenamentoIdempotencia) OpcaoServidor {
+	return func(p *ServidorJogador) {
+		p.idempotencia = armazenamento
+	}
 }
 
 const tipoConteudoJSON = "application/json"
+const tipoConteudoHistorico = "text/plain; charset=utf-8"
 const caminhoTemplateHTML = "jogo.html"
 
+const (
+	capacidadePorCliente = 10
+	reposicaoPorCliente  = time.Second
+	capacidadePorJogador = 5
+	reposicaoPorJogador  = 2 * time.Second
+
+	calculosDeEquidadeSimultaneos = 2
+)
+
 // NovoServidorJogador cria um ServidorJogador com rotas configuradas
-func NovoServidorJogador(armazenamento ArmazenamentoJogador, jogo Jogo) (*ServidorJogador, error) {
+func NovoServidorJogador(armazenamento ArmazenamentoJogador, jogo Jogo, opcoes ...OpcaoServidor) (*ServidorJogador, error) {
 	p := new(ServidorJogador)
 
 	tmpl, err := template.ParseFiles("jogo.html")
@@ -47,38 +138,527 @@ func NovoServidorJogador(armazenamento ArmazenamentoJogador, jogo Jogo) (*Servid
 	p.jogo = jogo
 	p.template = tmpl
 	p.armazenamento = armazenamento
+	p.salas = NovaCentralDeSalas(JanelaDeRetomadaPadrao, RelogioDoSistema{})
+	p.maos = NovoArmazenamentoDeMaosEmMemoria()
+	p.configuracaoWebsocket = ConfiguracaoWebsocketPadrao()
+	p.limitadorPorCliente = NovoLimitadorDeTaxa(capacidadePorCliente, reposicaoPorCliente, RelogioDoSistema{})
+	p.limitadorPorJogador = NovoLimitadorDeTaxa(capacidadePorJogador, reposicaoPorJogador, RelogioDoSistema{})
+	p.calculosDeEquidade = make(chan struct{}, calculosDeEquidadeSimultaneos)
+	p.partidasRPC = map[string]partidaRPC{}
+	p.maximoDePartidasRPC = MaximoDePartidasRPC
+	p.tempoOciosoRPC = TempoOciosoDasPartidasRPC
+	p.relogioRPC = RelogioDoSistema{}
+
+	for _, opcao := range opcoes {
+		opcao(p)
+	}
+
+	if err := p.configuracaoWebsocket.validar(); err != nil {
+		return nil, fmt.Errorf("problema com a configuração do websocket, %v", err)
+	}
+
+	p.atualizadorDeWebsocket = novoAtualizadorDeWebsocket(p.configuracaoWebsocket.OrigensPermitidas)
 
 	roteador := http.NewServeMux()
 	roteador.Handle("/liga", http.HandlerFunc(p.manipulaLiga))
 	roteador.Handle("/jogadores/", http.HandlerFunc(p.manipulaJogadores))
 	roteador.Handle("/jogo", http.HandlerFunc(p.jogarJogo))
+	roteador.Handle("/jogos/", http.HandlerFunc(p.manipulaJogos))
+	roteador.Handle("/equidade", http.HandlerFunc(p.manipulaEquidade))
 	roteador.Handle("/ws", http.HandlerFunc(p.webSocket))
+	roteador.Handle("/rpc", http.HandlerFunc(p.manipulaRPC))
+	roteador.Handle("/openapi.json", http.HandlerFunc(p.manipulaOpenAPI))
+	roteador.Handle("/estruturas", http.HandlerFunc(p.manipulaEstruturas))
 
 	p.Handler = roteador
 
 	return p, nil
 }
 
-var atualizadorDeWebsocket = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
+func (p *ServidorJogador) webSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := novoWebsocketServidorJogador(w, r, p.atualizadorDeWebsocket, p.configuracaoWebsocket)
+
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	if codigo := r.URL.Query().Get("sala"); codigo != "" {
+		p.acompanharSala(ws, codigo)
+		return
+	}
+
+	p.conduzirJogo(ws)
 }
 
-func (p *ServidorJogador) webSocket(w http.ResponseWriter, r *http.Request) {
-	ws := novoWebsocketServidorJogador(w, r)
+// conduzirJogo recebe os comandos de quem começa ou retoma um jogo
+func (p *ServidorJogador) conduzirJogo(ws *websocketServidorJogador) {
+	sala, err := p.abrirOuRetomarSala(ws)
+
+	if err != nil {
+		return
+	}
+	defer p.salas.sair(sala, ws)
+
+	for {
+		mensagem, err := ws.EsperarPelaMensagem()
+
+		if err != nil {
+			return
+		}
+
+		switch mensagem.Tipo {
+		case MensagemControle:
+			p.controlarBlinds(ws, sala, mensagem)
+
+		case MensagemTorneio:
+			if p.controlarTorneio(ws, sala, mensagem) {
+				return
+			}
+
+		case MensagemSentar, MensagemAcao:
+			p.jogarNaMesa(ws, sala, mensagem)
+
+		case MensagemDeclararVencedor:
+			var vencedor DadosDeclararVencedor
+			if err := mensagem.lerDadosValidos(MensagemDeclararVencedor, &vencedor); err != nil {
+				ws.EnviarErro(err)
+				continue
+			}
+
+			if err := VerificarVencedor(sala.Partida(), vencedor.Vencedor); err != nil {
+				ws.EnviarErro(err)
+				continue
+			}
+
+			if !p.salas.encerrar(sala, ws) {
+				ws.EnviarErro(errors.New("este jogo já terminou ou é conduzido por outra conexão"))
+				return
+			}
+
+			if mesa := sala.Mesa(); mesa != nil {
+				mesa.Encerrar()
+			}
+
+			partida := sala.Partida()
+			partida.Terminar(vencedor.Vencedor)
+
+			if controle, ok := partida.(ControleDeTorneio); ok {
+				if classificacao := controle.Classificacao(); classificacao.Encerrado {
+					sala.transmitir(MensagemClassificacao, classificacao)
+				}
+			}
+
+			sala.transmitir(MensagemFim, DadosFim{Vencedor: vencedor.Vencedor})
+			sala.fecharConexoes()
+			return
+
+		default:
+			ws.EnviarErro(fmt.Errorf("esperava uma mensagem %s, %s, %s, %s ou %s, obtido %q", MensagemControle, MensagemTorneio, MensagemSentar, MensagemAcao, MensagemDeclararVencedor, mensagem.Tipo))
+		}
+	}
+}
+
+// controlarBlinds aplica o comando ao relógio de blinds e transmite o novo estado para a sala
+func (p *ServidorJogador) controlarBlinds(ws *websocketServidorJogador, sala *Sala, mensagem MensagemWebsocket) {
+	var controle DadosControle
+	if err := mensagem.lerDadosValidos(MensagemControle, &controle); err != nil {
+		ws.EnviarErro(err)
+		return
+	}
+
+	partida := sala.Partida()
+
+	if err := executarComando(partida, controle.Comando); err != nil {
+		ws.EnviarErro(err)
+		return
+	}
+
+	sala.AtualizarRelogio(partida.Estado())
+}
+
+// controlarTorneio aplica o comando ao torneio e transmite a classificação para a sala, com
+// os novos lugares depois de uma eliminação ou recompra. Quando resta um jogador, o jogo
+// termina com ele como vencedor e controlarTorneio retorna true
+func (p *ServidorJogador) controlarTorneio(ws *websocketServidorJogador, sala *Sala, mensagem MensagemWebsocket) (encerrado bool) {
+	var dados DadosTorneio
+	if err := mensagem.lerDadosValidos(MensagemTorneio, &dados); err != nil {
+		ws.EnviarErro(err)
+		return false
+	}
+
+	if dados.Comando == ComandoBotao {
+		p.avancarBotao(ws, sala, dados.Mesa)
+		return false
+	}
+
+	controle, ok := sala.Partida().(ControleDeTorneio)
+
+	if !ok {
+		ws.EnviarErro(ErrSemTorneio)
+		return false
+	}
+
+	if err := executarComandoDeTorneio(controle, dados.Comando, dados.Jogador, dados.Fichas); err != nil {
+		ws.EnviarErro(err)
+		return false
+	}
+
+	classificacao := controle.Classificacao()
+	sala.transmitir(MensagemClassificacao, classificacao)
+
+	if !classificacao.Encerrado {
+		if lugares, ok := sala.Lugares(); ok && (dados.Comando == ComandoEliminar || dados.Comando == ComandoRecomprar) {
+			sala.transmitir(MensagemLugares, lugares)
+		}
+		return false
+	}
+
+	if !p.salas.encerrar(sala, ws) {
+		ws.EnviarErro(errors.New("este jogo já terminou ou é conduzido por outra conexão"))
+		return true
+	}
+
+	sala.transmitir(MensagemFim, DadosFim{Vencedor: classificacao.Vencedor()})
+	sala.fecharConexoes()
+	return true
+}
+
+// avancarBotao passa o botão de uma das mesas do torneio e transmite os lugares para a sala
+func (p *ServidorJogador) avancarBotao(ws *websocketServidorJogador, sala *Sala, mesa int) {
+	controle, ok := sala.Partida().(ControleDeLugares)
+
+	if !ok {
+		ws.EnviarErro(ErrSemTorneio)
+		return
+	}
+
+	if err := controle.AvancarBotao(mesa); err != nil {
+		ws.EnviarErro(err)
+		return
+	}
+
+	sala.transmitir(MensagemLugares, controle.Lugares())
+}
+
+// abrirOuRetomarSala espera por uma mensagem iniciar, que cria uma sala e começa o jogo,
+// ou retomar, que devolve à conexão um jogo em andamento
+func (p *ServidorJogador) abrirOuRetomarSala(ws *websocketServidorJogador) (*Sala, error) {
+	for {
+		mensagem, err := ws.EsperarPelaMensagem()
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch mensagem.Tipo {
+		case MensagemIniciar:
+			var iniciar DadosIniciar
+			if err := mensagem.lerDadosValidos(MensagemIniciar, &iniciar); err != nil {
+				ws.EnviarErro(err)
+				continue
+			}
+
+			configuracao := iniciar.configuracaoDaPartida()
+			sala := p.salas.criarSala(ws, configuracao.NumeroDeJogadores)
+			partida, err := p.jogo.Começar(configuracao, sala)
+
+			if err != nil {
+				p.salas.descartar(sala)
+				ws.EnviarErro(err)
+				continue
+			}
+
+			sala.jogar(partida)
+
+			if iniciar.Mesa {
+				if err := p.abrirMesa(sala, iniciar); err != nil {
+					partida.Abandonar()
+					p.salas.descartar(sala)
+					ws.EnviarErro(err)
+					continue
+				}
+			}
+
+			ws.EnviarMensagem(MensagemSalaCriada, DadosSala{Sala: sala.Codigo, Token: sala.token})
+			sala.abrir()
+			return sala, nil
+
+		case MensagemRetomar:
+			var retomar DadosRetomar
+			if err := mensagem.lerDadosValidos(MensagemRetomar, &retomar); err != nil {
+				ws.EnviarErro(err)
+				continue
+			}
+
+			sala, err := p.salas.retomar(retomar.Sala, retomar.Token, ws)
+			if err != nil {
+				ws.EnviarErro(err)
+				continue
+			}
+
+			ws.EnviarMensagem(MensagemRetomado, DadosRetomado{Sala: sala.Codigo, NumeroDeJogadores: sala.NumeroDeJogadores})
+			sala.entrar(ws)
+			return sala, nil
+
+		default:
+			ws.EnviarErro(fmt.Errorf("esperava uma mensagem %s ou %s, obtido %q", MensagemIniciar, MensagemRetomar, mensagem.Tipo))
+		}
+	}
+}
+
+// abrirMesa coloca na sala uma mesa jogada com os blinds da partida, com os bots pedidos já
+// sentados, e guarda o histórico de cada mão com o código da sala. Quando a mesa tem um
+// vencedor, o jogo termina com ele como se quem o conduz o tivesse declarado; se quem vence
+// é um bot, a partida é abandonada para que a liga não grave a vitória
+func (p *ServidorJogador) abrirMesa(sala *Sala, iniciar DadosIniciar) error {
+	partida := sala.Partida()
+	configuracao := iniciar.configuracaoDaMesa(partida, p.salas.relogio)
+	destino := &destinoComHistorico{Sala: sala, maos: p.maos}
+
+	mesa, err := NovaMesa(configuracao, destino, func(vencedor string) {
+		if !p.salas.terminarMesa(sala) {
+			return
+		}
+
+		if EhNomeDeBot(vencedor) {
+			partida.Abandonar()
+		} else {
+			partida.Terminar(vencedor)
+		}
+
+		sala.transmitir(MensagemFim, DadosFim{Vencedor: vencedor})
+		sala.fecharConexoes()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= iniciar.Bots; i++ {
+		if _, err := mesa.SentarBot(NomeDeBot(i), EstrategiaDeForca{}); err != nil {
+			return err
+		}
+	}
+
+	sala.sentarNaMesa(mesa)
+	return nil
+}
 
-	mensagemNumeroDeJogadores := ws.EsperarPelaMensagem()
-	numeroDeJogadores, _ := strconv.Atoi(mensagemNumeroDeJogadores)
-	p.jogo.Começar(numeroDeJogadores, ws)
+// destinoComHistorico repassa a mesa para as conexões da sala e grava o histórico das mãos
+type destinoComHistorico struct {
+	*Sala
+	maos ArmazenamentoDeMaos
+}
+
+func (d *destinoComHistorico) RegistrarMao(historico HistoricoDaMao) {
+	historico.Jogo = d.Codigo
 
-	vencedor := ws.EsperarPelaMensagem()
-	p.jogo.Terminar(vencedor)
+	if err := d.maos.GravarMao(historico); err != nil {
+		log.Printf("erro ao gravar a mão #%d da sala %s %v\n", historico.Resultado.Mao, d.Codigo, err)
+	}
+}
+
+// jogarNaMesa senta a conexão na mesa da sala ou aplica a ação do jogador sentado nela
+func (p *ServidorJogador) jogarNaMesa(ws *websocketServidorJogador, sala *Sala, mensagem MensagemWebsocket) {
+	if mensagem.Tipo == MensagemSentar {
+		p.sentarNaMesa(ws, sala, mensagem)
+		return
+	}
+
+	lugar, sentado := sala.lugarDa(ws)
+
+	if !sentado {
+		ws.EnviarErro(errors.New("sente-se à mesa antes de agir"))
+		return
+	}
+
+	var acao Acao
+	if err := mensagem.lerDadosValidos(MensagemAcao, &acao); err != nil {
+		ws.EnviarErro(err)
+		return
+	}
+
+	if err := sala.Mesa().Agir(lugar, acao); err != nil {
+		ws.EnviarErro(err)
+	}
+}
+
+// sentarNaMesa confirma o lugar do jogador e devolve as cartas dele se voltou no meio de
+// uma mão. A primeira mão é dada quando o último lugar é ocupado
+func (p *ServidorJogador) sentarNaMesa(ws *websocketServidorJogador, sala *Sala, mensagem MensagemWebsocket) {
+	var sentar DadosSentar
+	if err := mensagem.lerDadosValidos(MensagemSentar, &sentar); err != nil {
+		ws.EnviarErro(err)
+		return
+	}
+
+	lugar, segredo, err := sala.sentar(ws, sentar.Nome, sentar.Segredo)
+
+	if err != nil {
+		ws.EnviarErro(err)
+		return
+	}
+
+	ws.EnviarMensagem(MensagemSentado, DadosSentado{Lugar: lugar, Nome: sentar.Nome, Segredo: segredo})
+
+	mesa := sala.Mesa()
+
+	if cartas := mesa.Cartas(lugar); len(cartas) > 0 {
+		ws.EnviarMensagem(MensagemCartas, DadosCartas{Lugar: lugar, Cartas: cartas})
+	}
+
+	if !mesa.Começar() {
+		sala.AtualizarMesa(mesa.Estado())
+	}
+}
+
+// acompanharSala coloca a conexão em uma sala existente para receber os alertas do jogo e,
+// se o jogo tem uma mesa, sentar e jogar nela
+func (p *ServidorJogador) acompanharSala(ws *websocketServidorJogador, codigo string) {
+	sala, existe := p.salas.entrar(codigo, ws)
+
+	if !existe {
+		ws.EnviarErro(fmt.Errorf("sala %s não encontrada", codigo))
+		return
+	}
+	defer p.salas.sair(sala, ws)
+
+	for {
+		mensagem, err := ws.EsperarPelaMensagem()
+
+		if err != nil {
+			return
+		}
+
+		switch mensagem.Tipo {
+		case MensagemSentar, MensagemAcao:
+			p.jogarNaMesa(ws, sala, mensagem)
+		default:
+			ws.EnviarErro(errors.New("apenas quem criou a sala controla o jogo"))
+		}
+	}
 }
 
 func (p *ServidorJogador) jogarJogo(w http.ResponseWriter, r *http.Request) {
 	p.template.Execute(w, nil)
 }
 
+// manipulaJogos responde em /jogos/{id}/maos com o histórico das mãos do jogo em texto
+func (p *ServidorJogador) manipulaJogos(w http.ResponseWriter, r *http.Request) {
+	partes := strings.Split(strings.TrimPrefix(r.URL.Path, "/jogos/"), "/")
+
+	if len(partes) != 2 || partes[0] == "" || partes[1] != "maos" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "use GET para obter o histórico", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jogo := strings.ToUpper(partes[0])
+	maos, err := p.maos.ObterMaos(jogo)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("problema ao obter as mãos do jogo %s, %v", jogo, err), http.StatusInternalServerError)
+		return
+	}
+
+	if len(maos) == 0 {
+		http.Error(w, fmt.Sprintf("nenhuma mão jogada no jogo %s", jogo), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("content-type", tipoConteudoHistorico)
+	EscreverHistorico(w, maos)
+}
+
+// MaximoDeSimulacoesPorRequisicao limita as mesas sorteadas em cada POST /equidade e é
+// quantas são sorteadas quando o pedido não diz
+const MaximoDeSimulacoesPorRequisicao = 50000
+
+// tamanhoMaximoDaEquidade limita o corpo de um POST /equidade, que só tem algumas cartas
+const tamanhoMaximoDaEquidade = 4096
+
+// PedidoDeEquidade é o corpo de um POST /equidade: as cartas fechadas de cada jogador, as
+// cartas da mesa e, opcionalmente, quantas mesas sortear quando o cálculo não é exaustivo
+type PedidoDeEquidade struct {
+	Jogadores  [][]Carta `json:"jogadores"`
+	Mesa       []Carta   `json:"mesa,omitempty"`
+	Simulacoes int       `json:"simulacoes,omitempty"`
+}
+
+// manipulaEquidade calcula a equidade das cartas recebidas em um POST /equidade. O cálculo
+// ocupa todas as CPUs, então cada cliente passa pelo seu limite de taxa, poucos cálculos
+// rodam ao mesmo tempo e o cálculo para quando o cliente desiste da requisição
+func (p *ServidorJogador) manipulaEquidade(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST com as cartas dos jogadores", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if permitido, espera := p.limitadorPorCliente.Permitir(enderecoDoCliente(r)); !permitido {
+		w.Header().Set("Retry-After", strconv.Itoa(segundosDeEspera(espera)))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	select {
+	case p.calculosDeEquidade <- struct{}{}:
+		defer func() { <-p.calculosDeEquidade }()
+	default:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "muitos cálculos de equidade em andamento, tente novamente", http.StatusServiceUnavailable)
+		return
+	}
+
+	var pedido PedidoDeEquidade
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, tamanhoMaximoDaEquidade)).Decode(&pedido); err != nil {
+		http.Error(w, fmt.Sprintf("problema ao ler o pedido de equidade, %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if pedido.Simulacoes == 0 {
+		pedido.Simulacoes = MaximoDeSimulacoesPorRequisicao
+	}
+
+	if pedido.Simulacoes > MaximoDeSimulacoesPorRequisicao {
+		http.Error(w, fmt.Sprintf("no máximo %d simulações por pedido, obtido %d", MaximoDeSimulacoesPorRequisicao, pedido.Simulacoes), http.StatusBadRequest)
+		return
+	}
+
+	resultado, err := CalcularEquidade(pedido.Jogadores, pedido.Mesa, ComSimulacoes(pedido.Simulacoes), ComContexto(r.Context()))
+
+	if r.Context().Err() != nil {
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("content-type", tipoConteudoJSON)
+	json.NewEncoder(w).Encode(resultado)
+}
+
+// manipulaEstruturas lista as estruturas de blinds que o jogo aceita
+func (p *ServidorJogador) manipulaEstruturas(w http.ResponseWriter, r *http.Request) {
+	estruturas := EstruturasPredefinidas()
+
+	if fonte, ok := p.jogo.(FonteDeEstruturas); ok {
+		estruturas = fonte.Estruturas()
+	}
+
+	w.Header().Set("content-type", tipoConteudoJSON)
+	json.NewEncoder(w).Encode(estruturas)
+}
+
 func (p *ServidorJogador) manipulaLiga(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("content-type", tipoConteudoJSON)
 	json.NewEncoder(w).Encode(p.armazenamento.ObterLiga())
@@ -89,7 +669,7 @@ func (p *ServidorJogador) manipulaJogadores(w http.ResponseWriter, r *http.Reque
 
 	switch r.Method {
 	case http.MethodPost:
-		p.processarVitoria(w, jogador)
+		p.manipulaVitoria(w, r, jogador)
 	case http.MethodGet:
 		p.mostrarPontuacao(w, jogador)
 	}
@@ -105,7 +685,97 @@ func (p *ServidorJogador) mostrarPontuacao(w http.ResponseWriter, jogador string
 	fmt.Fprint(w, pontuação)
 }
 
+func (p *ServidorJogador) manipulaVitoria(w http.ResponseWriter, r *http.Request, jogador string) {
+	chave := r.Header.Get(cabecalhoIdempotencia)
+
+	if chave == "" || p.idempotencia == nil {
+		if p.permitirAlteracao(w, r, jogador) {
+			p.processarVitoria(w, jogador)
+		}
+		return
+	}
+
+	p.muIdempotencia.Lock()
+	defer p.muIdempotencia.Unlock()
+
+	if gravada, existe := p.idempotencia.ObterResposta(chave); existe {
+		p.repetirResposta(w, r, gravada)
+		return
+	}
+
+	if !p.permitirAlteracao(w, r, jogador) {
+		return
+	}
+
+	gravador := &gravadorDeResposta{ResponseWriter: w}
+	p.processarVitoria(gravador, jogador)
+
+	p.idempotencia.GravarResposta(chave, RespostaGravada{
+		Caminho: r.URL.Path,
+		Status:  gravador.status,
+		Corpo:   gravador.corpo.String(),
+	})
+}
+
+func (p *ServidorJogador) repetirResposta(w http.ResponseWriter, r *http.Request, gravada RespostaGravada) {
+	if gravada.Caminho != r.URL.Path {
+		http.Error(w, fmt.Sprintf("a chave de idempotência já foi usada em %s", gravada.Caminho), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(gravada.Status)
+	fmt.Fprint(w, gravada.Corpo)
+}
+
 func (p *ServidorJogador) processarVitoria(w http.ResponseWriter, jogador string) {
 	p.armazenamento.GravarVitoria(jogador)
 	w.WriteHeader(http.StatusAccepted)
 }
+
+func (p *ServidorJogador) permitirAlteracao(w http.ResponseWriter, r *http.Request, jogador string) bool {
+	segundos := p.esperaParaAlterar(r, jogador)
+
+	if segundos > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(segundos))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return false
+	}
+
+	return true
+}
+
+// esperaParaAlterar consome os limites de taxa do cliente e do jogador, retornando quantos
+// segundos faltam para a alteração ser permitida ou zero se ela pode ser feita agora. Se o
+// jogador recusa a alteração, a ficha do cliente é devolvida, já que nada foi alterado
+func (p *ServidorJogador) esperaParaAlterar(r *http.Request, jogador string) int {
+	cliente := enderecoDoCliente(r)
+
+	if permitido, espera := p.limitadorPorCliente.Permitir(cliente); !permitido {
+		return segundosDeEspera(espera)
+	}
+
+	if permitido, espera := p.limitadorPorJogador.Permitir(jogador); !permitido {
+		p.limitadorPorCliente.Devolver(cliente)
+		return segundosDeEspera(espera)
+	}
+
+	return 0
+}
+
+// segundosDeEspera arredonda a espera para cima, em segundos inteiros como o Retry-After pede
+func segundosDeEspera(espera time.Duration) int {
+	segundos := int(math.Ceil(espera.Seconds()))
+	if segundos < 1 {
+		segundos = 1
+	}
+	return segundos
+}
+
+func enderecoDoCliente(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
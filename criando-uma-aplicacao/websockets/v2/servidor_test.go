@@ -1,6 +1,7 @@
 package poquer_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -19,8 +20,8 @@ var (
 	tenMS     = 10 * time.Millisecond
 )
 
-func deveFazerServidorJogador(t *testing.T, armazenamento poquer.ArmazenamentoJogador, jogo poquer.Jogo) *poquer.ServidorJogador {
-	servidor, err := poquer.NovoServidorJogador(armazenamento, jogo)
+func deveFazerServidorJogador(t *testing.T, armazenamento poquer.ArmazenamentoJogador, jogo poquer.Jogo, opcoes ...poquer.OpcaoServidor) *poquer.ServidorJogador {
+	servidor, err := poquer.NovoServidorJogador(armazenamento, jogo, opcoes...)
 	if err != nil {
 		t.Fatal("problema ao criar o servidor do jogador", err)
 	}
@@ -85,6 +86,89 @@ func TestArmazenarVitórias(t *testing.T) {
 	})
 }
 
+func TestLimiteDeTaxa(t *testing.T) {
+	t.Run("retorna 429 com Retry-After quando um cliente envia vitórias demais", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco, poquer.ComLimitesDeTaxa(
+			poquer.NovoLimitadorDeTaxa(2, 30*time.Second, relogio),
+			poquer.NovoLimitadorDeTaxa(10, time.Second, relogio),
+		))
+
+		servidor.ServeHTTP(httptest.NewRecorder(), novaRequisiçãoPostDeVitoria("Chris"))
+		servidor.ServeHTTP(httptest.NewRecorder(), novaRequisiçãoPostDeVitoria("Cleo"))
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisiçãoPostDeVitoria("Pepper"))
+
+		verificaStatus(t, resposta, http.StatusTooManyRequests)
+		verificaRetryAfter(t, resposta, "30")
+
+		if len(armazenamento.ChamadasDeVitoria) != 2 {
+			t.Errorf("obtido %d vitórias gravadas, esperado 2", len(armazenamento.ChamadasDeVitoria))
+		}
+
+		relogio.Avancar(30 * time.Second)
+		resposta = httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisiçãoPostDeVitoria("Pepper"))
+
+		verificaStatus(t, resposta, http.StatusAccepted)
+	})
+
+	t.Run("retorna 429 quando o mesmo jogador recebe vitórias demais", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco, poquer.ComLimitesDeTaxa(
+			poquer.NovoLimitadorDeTaxa(10, time.Second, relogio),
+			poquer.NovoLimitadorDeTaxa(1, 1500*time.Millisecond, relogio),
+		))
+
+		servidor.ServeHTTP(httptest.NewRecorder(), novaRequisiçãoPostDeVitoria("Chris"))
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisiçãoPostDeVitoria("Chris"))
+
+		verificaStatus(t, resposta, http.StatusTooManyRequests)
+		verificaRetryAfter(t, resposta, "2")
+
+		resposta = httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisiçãoPostDeVitoria("Cleo"))
+
+		verificaStatus(t, resposta, http.StatusAccepted)
+	})
+
+	t.Run("não gasta o limite do cliente quando o jogador recusa a vitória", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		servidor := deveFazerServidorJogador(t, &poquer.EsbocoDeArmazenamentoJogador{}, jogoTosco, poquer.ComLimitesDeTaxa(
+			poquer.NovoLimitadorDeTaxa(2, time.Minute, relogio),
+			poquer.NovoLimitadorDeTaxa(1, time.Minute, relogio),
+		))
+
+		for i := 0; i < 3; i++ {
+			servidor.ServeHTTP(httptest.NewRecorder(), novaRequisiçãoPostDeVitoria("Chris"))
+		}
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisiçãoPostDeVitoria("Cleo"))
+
+		verificaStatus(t, resposta, http.StatusAccepted)
+	})
+
+	t.Run("não limita as rotas de leitura", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{Pontuações: map[string]int{"Chris": 1}}
+		servidor := deveFazerServidorJogador(t, armazenamento, jogoTosco, poquer.ComLimitesDeTaxa(
+			poquer.NovoLimitadorDeTaxa(1, time.Minute, relogio),
+			poquer.NovoLimitadorDeTaxa(1, time.Minute, relogio),
+		))
+
+		for i := 0; i < 3; i++ {
+			resposta := httptest.NewRecorder()
+			servidor.ServeHTTP(resposta, novaRequisicaoObterPontuacao("Chris"))
+			verificaStatus(t, resposta, http.StatusOK)
+		}
+	})
+}
+
 func TestLiga(t *testing.T) {
 
 	t.Run("retorna a tabela da Liga como JSON", func(t *testing.T) {
@@ -109,6 +193,57 @@ func TestLiga(t *testing.T) {
 		verificaTipoDoConteudo(t, resposta, "application/json")
 
 	})
+
+	t.Run("inclui as colocações e os ganhos dos torneios", func(t *testing.T) {
+		ligaEsperada := []poquer.Jogador{
+			{Nome: "Cleo", Vitorias: 3, Colocacoes: []int{1, 2}, Ganhos: 1500},
+			{Nome: "Chris", Vitorias: 1},
+		}
+
+		armazenamento := poquer.EsbocoDeArmazenamentoJogador{Liga: ligaEsperada}
+		servidor := deveFazerServidorJogador(t, &armazenamento, jogoTosco)
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisicaoDeLiga())
+
+		verificaStatus(t, resposta, http.StatusOK)
+
+		var campos []map[string]interface{}
+		if err := json.Unmarshal(resposta.Body.Bytes(), &campos); err != nil {
+			t.Fatalf("não foi possível ler a liga, %v", err)
+		}
+		if ganhos, existe := campos[1]["Ganhos"]; !existe || ganhos != 0.0 {
+			t.Errorf("esperava Ganhos 0 para quem não ganhou prêmios, obtido %v", campos[1])
+		}
+
+		verificaLiga(t, obterLigaDaResposta(t, resposta.Body), ligaEsperada)
+	})
+}
+
+func TestEstruturas(t *testing.T) {
+	t.Run("lista as estruturas do catálogo do jogo", func(t *testing.T) {
+		caseira, _ := poquer.LerEstrutura(strings.NewReader(estruturaCaseira))
+		catalogo, _ := poquer.NovoCatalogoDeEstruturas(caseira)
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco, poquer.ComEstruturas(catalogo))
+		servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogo)
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisicaoDeEstruturas())
+
+		verificaStatus(t, resposta, http.StatusOK)
+		verificaTipoDoConteudo(t, resposta, "application/json")
+		verificaNomesDasEstruturas(t, obterEstruturasDaResposta(t, resposta.Body), "caseira", "padrao", "torneio", "turbo")
+	})
+
+	t.Run("lista as estruturas predefinidas quando o jogo não tem catálogo", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, &JogoEspiao{})
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, novaRequisicaoDeEstruturas())
+
+		verificaStatus(t, resposta, http.StatusOK)
+		verificaNomesDasEstruturas(t, obterEstruturasDaResposta(t, resposta.Body), "padrao", "turbo", "torneio")
+	})
 }
 
 func TestJogo(t *testing.T) {
@@ -134,19 +269,166 @@ func TestJogo(t *testing.T) {
 		defer servidor.Close()
 		defer ws.Close()
 
-		escreverMensagemNoWebsocket(t, ws, "3")
-		escreverMensagemNoWebsocket(t, ws, vencedor)
+		começarJogoNaSala(t, ws, 3)
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemDeclararVencedor, poquer.DadosDeclararVencedor{Vencedor: vencedor})
 
 		verificaJogoComeçadoCom(t, jogo, 3)
 		verificaTerminosChamadosCom(t, jogo, vencedor)
-		within(t, tenMS, func() { verificaSeWebSocketObteveMensagem(t, ws, alertaDeBlindEsperado) })
+		within(t, tenMS, func() {
+			verificaSeWebSocketObteveMensagem(t, ws, poquer.MensagemAlertaBlind, poquer.DadosAlertaBlind{Mensagem: alertaDeBlindEsperado})
+		})
+		within(t, tenMS, func() {
+			verificaSeWebSocketObteveMensagem(t, ws, poquer.MensagemFim, poquer.DadosFim{Vencedor: vencedor})
+		})
+	})
+}
+
+func TestProtocoloWebsocket(t *testing.T) {
+	conectar := func(t *testing.T, jogo poquer.Jogo) (*websocket.Conn, func()) {
+		servidor := httptest.NewServer(deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogo))
+		ws := deveConectarAoWebSocket(t, "ws"+strings.TrimPrefix(servidor.URL, "http")+"/ws")
+
+		return ws, func() {
+			ws.Close()
+			servidor.Close()
+		}
+	}
+
+	t.Run("responde com erro a um número de jogadores inválido e não começa o jogo", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		ws, fechar := conectar(t, jogo)
+		defer fechar()
+
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 0})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, ws) })
+		verificaPartidaNaoIniciada(t, jogo)
+	})
+
+	t.Run("responde com erro a mensagens que não são JSON", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		ws, fechar := conectar(t, jogo)
+		defer fechar()
+
+		if err := ws.WriteMessage(websocket.TextMessage, []byte("3")); err != nil {
+			t.Fatal(err)
+		}
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, ws) })
+		verificaPartidaNaoIniciada(t, jogo)
+	})
+
+	t.Run("responde com erro a versões desconhecidas do protocolo", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		ws, fechar := conectar(t, jogo)
+		defer fechar()
+
+		ws.WriteJSON(map[string]interface{}{"versao": 2, "tipo": "iniciar", "dados": map[string]int{"numeroDeJogadores": 3}})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, ws) })
+		verificaPartidaNaoIniciada(t, jogo)
+	})
+
+	t.Run("responde com erro a mensagens fora de ordem e continua esperando", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		ws, fechar := conectar(t, jogo)
+		defer fechar()
+
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemDeclararVencedor, poquer.DadosDeclararVencedor{Vencedor: "Ruth"})
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, ws) })
+
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 5})
+		verificaJogoComeçadoCom(t, jogo, 5)
+	})
+
+	t.Run("não aceita um vencedor vazio", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		ws, fechar := conectar(t, jogo)
+		defer fechar()
+
+		começarJogoNaSala(t, ws, 3)
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemDeclararVencedor, poquer.DadosDeclararVencedor{Vencedor: ""})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, ws) })
+		verificaPartidaNaoFinalizada(t, jogo)
+	})
+
+	t.Run("responde com erro a uma estrutura de blinds desconhecida e não abre a sala", func(t *testing.T) {
+		salas := poquer.NovaCentralDeSalas(poquer.JanelaDeRetomadaPadrao, poquer.NovoRelogioFalso())
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+		servidor := httptest.NewServer(deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogo, poquer.ComCentralDeSalas(salas)))
+		ws := deveConectarAoWebSocket(t, "ws"+strings.TrimPrefix(servidor.URL, "http")+"/ws")
+		defer servidor.Close()
+		defer ws.Close()
+
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 3, Estrutura: "maratona"})
+
+		within(t, tenMS, func() { verificaErroNoWebsocket(t, ws) })
+
+		if salas.Salas() != 0 || jogo.Partidas() != 0 {
+			t.Errorf("nenhuma sala deveria estar aberta, obtido %d salas e %d partidas", salas.Salas(), jogo.Partidas())
+		}
+	})
+
+	t.Run("não grava vencedor quando a conexão é fechada", func(t *testing.T) {
+		jogo := &JogoEspiao{}
+		ws, fechar := conectar(t, jogo)
+		defer fechar()
+
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 3})
+		verificaJogoComeçadoCom(t, jogo, 3)
+		ws.Close()
+
+		time.Sleep(tenMS)
+		verificaPartidaNaoFinalizada(t, jogo)
 	})
 }
 
-func verificaSeWebSocketObteveMensagem(t *testing.T, ws *websocket.Conn, esperado string) {
-	_, msg, _ := ws.ReadMessage()
-	if string(msg) != esperado {
-		t.Errorf(`obtido "%s", esperado "%s"`, string(msg), esperado)
+func começarJogoNaSala(t *testing.T, ws *websocket.Conn, numeroDeJogadores int) poquer.DadosSala {
+	t.Helper()
+	escreverMensagemNoWebsocket(t, ws, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: numeroDeJogadores})
+
+	mensagem := lerMensagemDoWebsocket(t, ws)
+
+	var sala poquer.DadosSala
+	if err := mensagem.LerDados(poquer.MensagemSalaCriada, &sala); err != nil {
+		t.Fatalf("esperava o código da sala, %v", err)
+	}
+
+	return sala
+}
+
+func verificaErroNoWebsocket(t *testing.T, ws *websocket.Conn) {
+	t.Helper()
+	mensagem := lerMensagemDoWebsocket(t, ws)
+
+	if mensagem.Tipo != poquer.MensagemErro {
+		t.Errorf("esperava uma mensagem de erro, obtido %+v", mensagem)
+	}
+}
+
+func lerMensagemDoWebsocket(t *testing.T, ws *websocket.Conn) poquer.MensagemWebsocket {
+	t.Helper()
+	var mensagem poquer.MensagemWebsocket
+
+	if err := ws.ReadJSON(&mensagem); err != nil {
+		t.Errorf("não foi possível ler a mensagem do websocket %v", err)
+	}
+
+	if mensagem.Versao != poquer.VersaoProtocolo {
+		t.Errorf("obtido versão %d, esperado %d", mensagem.Versao, poquer.VersaoProtocolo)
+	}
+
+	return mensagem
+}
+
+func verificaSeWebSocketObteveMensagem(t *testing.T, ws *websocket.Conn, tipo string, dados interface{}) {
+	t.Helper()
+	obtido := lerMensagemDoWebsocket(t, ws)
+	esperado, _ := poquer.NovaMensagemWebsocket(tipo, dados)
+
+	if !reflect.DeepEqual(obtido, esperado) {
+		t.Errorf(`obtido "%s %s", esperado "%s %s"`, obtido.Tipo, obtido.Dados, esperado.Tipo, esperado.Dados)
 	}
 }
 
@@ -177,13 +459,26 @@ func within(t *testing.T, d time.Duration, assert func()) {
 	}
 }
 
-func escreverMensagemNoWebsocket(t *testing.T, conexão *websocket.Conn, mensagem string) {
+func escreverMensagemNoWebsocket(t *testing.T, conexão *websocket.Conn, tipo string, dados interface{}) {
 	t.Helper()
-	if err := conexão.WriteMessage(websocket.TextMessage, []byte(mensagem)); err != nil {
+	mensagem, err := poquer.NovaMensagemWebsocket(tipo, dados)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conexão.WriteJSON(mensagem); err != nil {
 		t.Fatalf("não foi possível enviar mensagem na conexão websocket %v", err)
 	}
 }
 
+func verificaRetryAfter(t *testing.T, resposta *httptest.ResponseRecorder, esperado string) {
+	t.Helper()
+	if obtido := resposta.Header().Get("Retry-After"); obtido != esperado {
+		t.Errorf("obtido Retry-After '%s', esperado '%s'", obtido, esperado)
+	}
+}
+
 func verificaTipoDoConteudo(t *testing.T, resposta *httptest.ResponseRecorder, esperado string) {
 	t.Helper()
 	if resposta.Header().Get("content-type") != esperado {
@@ -216,6 +511,22 @@ func verificaStatus(t *testing.T, obtido *httptest.ResponseRecorder, esperado in
 	}
 }
 
+func novaRequisicaoDeEstruturas() *http.Request {
+	requisicao, _ := http.NewRequest(http.MethodGet, "/estruturas", nil)
+	return requisicao
+}
+
+func obterEstruturasDaResposta(t *testing.T, corpo io.Reader) []poquer.EstruturaDeBlinds {
+	t.Helper()
+
+	var estruturas []poquer.EstruturaDeBlinds
+	if err := json.NewDecoder(corpo).Decode(&estruturas); err != nil {
+		t.Fatalf("não foi possível analisar as estruturas %v", err)
+	}
+
+	return estruturas
+}
+
 func novaRequisicaoJogo() *http.Request {
 	req, _ := http.NewRequest(http.MethodGet, "/jogo", nil)
 	return req
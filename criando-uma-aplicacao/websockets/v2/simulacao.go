@@ -0,0 +1,242 @@
+package poquer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"text/tabwriter"
+)
+
+// MaosPorNivelPadrao é de quantas em quantas mãos os blinds sobem em uma simulação
+const MaosPorNivelPadrao = 10
+
+// Bot é um jogador da simulação com a estratégia que decide as suas ações
+type Bot struct {
+	Nome       string
+	Estrategia Estrategia
+}
+
+// ConfiguracaoDaSimulacao define partidas jogadas só entre bots, sem relógio nem conexões.
+// Os blinds seguem os níveis da Estrutura, ignorando os intervalos, e sobem a cada
+// MaosPorNivel mãos; depois do último nível, dobram a cada subida para que toda partida
+// termine. A mesma Semente dá as mesmas cartas e os mesmos lugares
+type ConfiguracaoDaSimulacao struct {
+	Bots           []Bot
+	Partidas       int
+	FichasIniciais int
+	Estrutura      EstruturaDeBlinds
+	MaosPorNivel   int
+	Semente        int64
+	// Historico, quando presente, recebe o histórico de todas as mãos; cada partida é um
+	// jogo com o código SIM seguido do seu número
+	Historico io.Writer
+}
+
+// Validar confere se a simulação pode ser jogada
+func (c ConfiguracaoDaSimulacao) Validar() error {
+	if len(c.Bots) < 2 || len(c.Bots) > MaximoDeLugares {
+		return fmt.Errorf("a simulação precisa de 2 a %d bots, obtido %d", MaximoDeLugares, len(c.Bots))
+	}
+
+	nomes := map[string]bool{}
+	for _, bot := range c.Bots {
+		if bot.Nome == "" || bot.Estrategia == nil {
+			return errors.New("todo bot precisa de um nome e de uma estratégia")
+		}
+
+		if nomes[bot.Nome] {
+			return fmt.Errorf("o bot %s aparece mais de uma vez", bot.Nome)
+		}
+		nomes[bot.Nome] = true
+	}
+
+	if c.Partidas < 1 {
+		return fmt.Errorf("o número de partidas deve ser positivo, obtido %d", c.Partidas)
+	}
+
+	if c.FichasIniciais < 1 {
+		return fmt.Errorf("as fichas iniciais devem ser positivas, obtido %d", c.FichasIniciais)
+	}
+
+	if c.MaosPorNivel < 0 {
+		return fmt.Errorf("as mãos por nível não podem ser negativas, obtido %d", c.MaosPorNivel)
+	}
+
+	if len(niveisSemIntervalo(c.Estrutura)) == 0 {
+		return fmt.Errorf("a estrutura %q não tem níveis de blind", c.Estrutura.Nome)
+	}
+
+	return nil
+}
+
+// DesempenhoDoBot é quantas partidas da simulação o bot venceu
+type DesempenhoDoBot struct {
+	Nome       string
+	Vitorias   int
+	Percentual float64
+}
+
+// ResultadoDaSimulacao tem o desempenho de cada bot, na ordem da configuração, e quantas
+// mãos foram jogadas no total
+type ResultadoDaSimulacao struct {
+	Partidas int
+	Maos     int
+	Bots     []DesempenhoDoBot
+}
+
+// Escrever mostra o resultado como uma tabela com o percentual de vitórias de cada bot
+func (r ResultadoDaSimulacao) Escrever(saida io.Writer) error {
+	tabela := tabwriter.NewWriter(saida, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tabela, "%d partidas, %d mãos\n", r.Partidas, r.Maos)
+	fmt.Fprintln(tabela, "bot\tvitórias\t%")
+	for _, bot := range r.Bots {
+		fmt.Fprintf(tabela, "%s\t%d\t%.1f\n", bot.Nome, bot.Vitorias, bot.Percentual)
+	}
+
+	return tabela.Flush()
+}
+
+// Simular joga as partidas entre os bots e conta as vitórias de cada um. Os lugares são
+// sorteados a cada partida
+func Simular(configuracao ConfiguracaoDaSimulacao) (ResultadoDaSimulacao, error) {
+	if err := configuracao.Validar(); err != nil {
+		return ResultadoDaSimulacao{}, err
+	}
+
+	if configuracao.MaosPorNivel == 0 {
+		configuracao.MaosPorNivel = MaosPorNivelPadrao
+	}
+
+	aleatorio := rand.New(rand.NewSource(configuracao.Semente))
+	vitorias := map[string]int{}
+	resultado := ResultadoDaSimulacao{Partidas: configuracao.Partidas}
+
+	for partida := 0; partida < configuracao.Partidas; partida++ {
+		vencedor, maos, err := simularPartida(configuracao, fmt.Sprintf("SIM%d", partida+1), aleatorio)
+
+		if err != nil {
+			return ResultadoDaSimulacao{}, fmt.Errorf("problema ao simular a partida %d, %v", partida+1, err)
+		}
+
+		vitorias[vencedor]++
+		resultado.Maos += maos
+	}
+
+	for _, bot := range configuracao.Bots {
+		resultado.Bots = append(resultado.Bots, DesempenhoDoBot{
+			Nome:       bot.Nome,
+			Vitorias:   vitorias[bot.Nome],
+			Percentual: 100 * float64(vitorias[bot.Nome]) / float64(configuracao.Partidas),
+		})
+	}
+
+	return resultado, nil
+}
+
+func simularPartida(configuracao ConfiguracaoDaSimulacao, jogo string, aleatorio *rand.Rand) (string, int, error) {
+	blinds := &blindsDaSimulacao{niveis: niveisSemIntervalo(configuracao.Estrutura)}
+	destino := &destinoDaSimulacao{blinds: blinds, maosPorNivel: configuracao.MaosPorNivel, jogo: jogo, historico: configuracao.Historico}
+
+	var vencedor string
+	mesa, err := NovaMesa(ConfiguracaoDaMesa{
+		Lugares:        len(configuracao.Bots),
+		FichasIniciais: configuracao.FichasIniciais,
+		Blinds:         blinds,
+		Aleatorio:      aleatorio,
+	}, destino, func(nome string) { vencedor = nome })
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	for _, i := range aleatorio.Perm(len(configuracao.Bots)) {
+		bot := configuracao.Bots[i]
+		if _, err := mesa.SentarBot(bot.Nome, bot.Estrategia); err != nil {
+			return "", 0, err
+		}
+	}
+
+	mesa.Começar()
+
+	if vencedor == "" {
+		return "", 0, errors.New("a partida terminou sem vencedor")
+	}
+
+	return vencedor, destino.maos, nil
+}
+
+func niveisSemIntervalo(estrutura EstruturaDeBlinds) []Blinds {
+	var niveis []Blinds
+	for _, nivel := range estrutura.Niveis {
+		if !nivel.Intervalo {
+			niveis = append(niveis, nivel.Blinds)
+		}
+	}
+	return niveis
+}
+
+// blindsDaSimulacao é o relógio de blinds de uma simulação, que avança por mãos e não por tempo
+type blindsDaSimulacao struct {
+	niveis []Blinds
+	nivel  int
+}
+
+func (b *blindsDaSimulacao) Pausar() error    { return nil }
+func (b *blindsDaSimulacao) Continuar() error { return nil }
+
+func (b *blindsDaSimulacao) AvancarNivel() error {
+	b.nivel++
+	return nil
+}
+
+func (b *blindsDaSimulacao) VoltarNivel() error {
+	if b.nivel > 0 {
+		b.nivel--
+	}
+	return nil
+}
+
+// Estado retorna os blinds do nível; depois do último, os blinds dobram a cada nível
+func (b *blindsDaSimulacao) Estado() EstadoDosBlinds {
+	ultimo := len(b.niveis) - 1
+
+	if b.nivel <= ultimo {
+		return EstadoDosBlinds{Nivel: b.nivel + 1, Blinds: b.niveis[b.nivel]}
+	}
+
+	blinds := b.niveis[ultimo]
+	for i := ultimo; i < b.nivel; i++ {
+		blinds = Blinds{SmallBlind: blinds.SmallBlind * 2, BigBlind: blinds.BigBlind * 2, Ante: blinds.Ante * 2}
+	}
+
+	return EstadoDosBlinds{Nivel: b.nivel + 1, Blinds: blinds}
+}
+
+// destinoDaSimulacao conta as mãos e sobe os blinds; ninguém olha a mesa, mas as mãos podem
+// ir para o histórico
+type destinoDaSimulacao struct {
+	blinds       *blindsDaSimulacao
+	maosPorNivel int
+	maos         int
+	jogo         string
+	historico    io.Writer
+}
+
+func (d *destinoDaSimulacao) EnviarCartas(lugar int, cartas []Carta) {}
+func (d *destinoDaSimulacao) AtualizarMesa(estado EstadoDaMesa)      {}
+
+func (d *destinoDaSimulacao) MostrarResultado(resultado ResultadoDaMao) {
+	d.maos++
+	if d.maos%d.maosPorNivel == 0 {
+		d.blinds.AvancarNivel()
+	}
+}
+
+func (d *destinoDaSimulacao) RegistrarMao(historico HistoricoDaMao) {
+	if d.historico != nil {
+		historico.Jogo = d.jogo
+		historico.Escrever(d.historico)
+	}
+}
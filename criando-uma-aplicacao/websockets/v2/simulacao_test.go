@@ -0,0 +1,135 @@
+package poquer_test
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestSimular(t *testing.T) {
+	t.Run("a mesma semente repete a simulação", func(t *testing.T) {
+		primeira := deveSimular(t, 42, "forca", "pagar", "aleatorio")
+		segunda := deveSimular(t, 42, "forca", "pagar", "aleatorio")
+
+		if !reflect.DeepEqual(primeira, segunda) {
+			t.Errorf("obtido %+v e %+v", primeira, segunda)
+		}
+
+		vitorias := 0
+		for _, bot := range primeira.Bots {
+			vitorias += bot.Vitorias
+		}
+
+		if vitorias != primeira.Partidas || primeira.Maos < primeira.Partidas {
+			t.Errorf("cada partida deveria ter um vencedor e ao menos uma mão, obtido %+v", primeira)
+		}
+	})
+
+	t.Run("jogar pela força vence quem só paga", func(t *testing.T) {
+		resultado := deveSimular(t, 1, "forca", "pagar")
+
+		if resultado.Bots[0].Vitorias <= resultado.Bots[1].Vitorias {
+			t.Errorf("esperava mais vitórias para a força, obtido %+v", resultado.Bots)
+		}
+	})
+
+	t.Run("escreve o histórico das mãos de todas as partidas", func(t *testing.T) {
+		historico := &bytes.Buffer{}
+		configuracao := configuracaoDeSimulacao(t, 5, "forca", "aleatorio")
+		configuracao.Partidas = 3
+		configuracao.Historico = historico
+
+		resultado, err := poquer.Simular(configuracao)
+		verificaSemErro(t, err)
+
+		maos := deveLerHistorico(t, historico.String())
+
+		if len(maos) != resultado.Maos || maos[len(maos)-1].Jogo != "SIM3" {
+			t.Fatalf("esperava %d mãos até a partida SIM3, obtido %d", resultado.Maos, len(maos))
+		}
+
+		for _, mao := range maos {
+			if err := poquer.Reproduzir(mao); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("escreve o percentual de vitórias de cada bot", func(t *testing.T) {
+		resultado := poquer.ResultadoDaSimulacao{Partidas: 4, Maos: 37, Bots: []poquer.DesempenhoDoBot{
+			{Nome: "forca", Vitorias: 3, Percentual: 75},
+			{Nome: "pagar", Vitorias: 1, Percentual: 25},
+		}}
+
+		saida := &bytes.Buffer{}
+		resultado.Escrever(saida)
+
+		esperado := strings.Join([]string{
+			"4 partidas, 37 mãos",
+			"bot    vitórias  %",
+			"forca  3         75.0",
+			"pagar  1         25.0",
+			"",
+		}, "\n")
+
+		if saida.String() != esperado {
+			t.Errorf("obtido\n%s\nesperado\n%s", saida.String(), esperado)
+		}
+	})
+
+	t.Run("recusa configurações inválidas", func(t *testing.T) {
+		valida := configuracaoDeSimulacao(t, 1, "forca", "pagar")
+
+		invalidas := map[string]func(*poquer.ConfiguracaoDaSimulacao){
+			"um bot":         func(c *poquer.ConfiguracaoDaSimulacao) { c.Bots = c.Bots[:1] },
+			"nome repetido":  func(c *poquer.ConfiguracaoDaSimulacao) { c.Bots[1].Nome = c.Bots[0].Nome },
+			"sem partidas":   func(c *poquer.ConfiguracaoDaSimulacao) { c.Partidas = 0 },
+			"sem fichas":     func(c *poquer.ConfiguracaoDaSimulacao) { c.FichasIniciais = 0 },
+			"sem estrutura":  func(c *poquer.ConfiguracaoDaSimulacao) { c.Estrutura = poquer.EstruturaDeBlinds{} },
+			"sem estratégia": func(c *poquer.ConfiguracaoDaSimulacao) { c.Bots[0].Estrategia = nil },
+		}
+
+		for nome, invalidar := range invalidas {
+			configuracao := valida
+			configuracao.Bots = append([]poquer.Bot(nil), valida.Bots...)
+			invalidar(&configuracao)
+
+			if _, err := poquer.Simular(configuracao); err == nil {
+				t.Errorf("esperava um erro para a simulação com %s", nome)
+			}
+		}
+	})
+}
+
+func configuracaoDeSimulacao(t *testing.T, semente int64, estrategias ...string) poquer.ConfiguracaoDaSimulacao {
+	t.Helper()
+
+	aleatorio := rand.New(rand.NewSource(semente))
+	configuracao := poquer.ConfiguracaoDaSimulacao{
+		Partidas:       50,
+		FichasIniciais: 1000,
+		Estrutura:      poquer.EstruturasPredefinidas()[1],
+		Semente:        semente,
+	}
+
+	for _, nome := range estrategias {
+		estrategia, err := poquer.NovaEstrategia(nome, aleatorio)
+		verificaSemErro(t, err)
+		configuracao.Bots = append(configuracao.Bots, poquer.Bot{Nome: nome, Estrategia: estrategia})
+	}
+
+	return configuracao
+}
+
+func deveSimular(t *testing.T, semente int64, estrategias ...string) poquer.ResultadoDaSimulacao {
+	t.Helper()
+
+	resultado, err := poquer.Simular(configuracaoDeSimulacao(t, semente, estrategias...))
+	verificaSemErro(t, err)
+
+	return resultado
+}
@@ -3,15 +3,18 @@ package poquer
 import (
 	"fmt"
 	"io"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 )
 
 // EsbocoDeArmazenamentoJogador implementa ArmazenamentoJogador para propósitos de teste
 type EsbocoDeArmazenamentoJogador struct {
-	Pontuações        map[string]int
-	ChamadasDeVitoria []string
-	Liga              []Jogador
+	Pontuações           map[string]int
+	ChamadasDeVitoria    []string
+	ChamadasDeColocacoes [][]Colocacao
+	Liga                 []Jogador
 }
 
 // ObtemPontuacaoDoJogador retorna uma pontuação de Pontuações
@@ -25,6 +28,11 @@ func (s *EsbocoDeArmazenamentoJogador) GravarVitoria(nome string) {
 	s.ChamadasDeVitoria = append(s.ChamadasDeVitoria, nome)
 }
 
+// GravarColocacoes grava as colocações de um torneio para ChamadasDeColocacoes
+func (s *EsbocoDeArmazenamentoJogador) GravarColocacoes(colocacoes []Colocacao) {
+	s.ChamadasDeColocacoes = append(s.ChamadasDeColocacoes, colocacoes)
+}
+
 // ObterLiga retorna Liga
 func (s *EsbocoDeArmazenamentoJogador) ObterLiga() Liga {
 	return s.Liga
@@ -45,20 +53,218 @@ func VerificaVitoriaDoVencedor(t *testing.T, armazenamento *EsbocoDeArmazenament
 
 // AlertaAgendado contém informações sobre quando um alerta é agendado
 type AlertaAgendado struct {
-	Em      time.Duration
-	Quantia int
+	Em        time.Duration
+	Blinds    Blinds
+	Cancelado bool
 }
 
 func (s AlertaAgendado) String() string {
-	return fmt.Sprintf("%d chips em %v", s.Quantia, s.Em)
+	return fmt.Sprintf("%v em %v", s.Blinds, s.Em)
 }
 
 // AlertadorDeBlindEspiao te permite espionar em chamadas AgendarAlertaPara
 type AlertadorDeBlindEspiao struct {
 	Alertas []AlertaAgendado
+	mu      sync.Mutex
+}
+
+// AgendarAlertaPara grava alertas que foram agendados, marcando-os quando são cancelados
+func (s *AlertadorDeBlindEspiao) AgendarAlertaPara(em time.Duration, blinds Blinds, para io.Writer) CancelarAlerta {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Alertas = append(s.Alertas, AlertaAgendado{Em: em, Blinds: blinds})
+	i := len(s.Alertas) - 1
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.Alertas[i].Cancelado = true
+	}
+}
+
+// ControleDeBlindsFixo é um ControleDeBlinds que fica sempre no estado Atual
+type ControleDeBlindsFixo struct {
+	Atual EstadoDosBlinds
+}
+
+// Pausar não faz nada
+func (c *ControleDeBlindsFixo) Pausar() error { return nil }
+
+// Continuar não faz nada
+func (c *ControleDeBlindsFixo) Continuar() error { return nil }
+
+// AvancarNivel não faz nada
+func (c *ControleDeBlindsFixo) AvancarNivel() error { return nil }
+
+// VoltarNivel não faz nada
+func (c *ControleDeBlindsFixo) VoltarNivel() error { return nil }
+
+// Estado retorna Atual
+func (c *ControleDeBlindsFixo) Estado() EstadoDosBlinds { return c.Atual }
+
+// RelogioFalso é um Relogio para testes em que o tempo só passa quando Avancar é chamado
+type RelogioFalso struct {
+	mu      sync.Mutex
+	agora   time.Time
+	timers  []*timerFalso
+	tickers []*tickerFalso
 }
 
-// AgendarAlertaPara grava alertas que foram agendados
-func (s *AlertadorDeBlindEspiao) AgendarAlertaPara(em time.Duration, quantia int, para io.Writer) {
-	s.Alertas = append(s.Alertas, AlertaAgendado{em, quantia})
+// NovoRelogioFalso cria um RelogioFalso parado em um horário fixo
+func NovoRelogioFalso() *RelogioFalso {
+	return &RelogioFalso{agora: time.Date(2020, 1, 1, 20, 0, 0, 0, time.UTC)}
+}
+
+// Agora retorna o horário do relógio
+func (r *RelogioFalso) Agora() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.agora
+}
+
+// Apos retorna um canal que recebe o horário quando o relógio avançar a duração
+func (r *RelogioFalso) Apos(duracao time.Duration) <-chan time.Time {
+	c := make(chan time.Time, 1)
+	r.NovoTimer(duracao, func() {
+		c <- r.Agora()
+	})
+	return c
+}
+
+// NovoTimer agenda f para quando o relógio avançar a duração
+func (r *RelogioFalso) NovoTimer(duracao time.Duration, f func()) Timer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timer := &timerFalso{relogio: r, quando: r.agora.Add(duracao), f: f}
+	r.timers = append(r.timers, timer)
+	return timer
+}
+
+// NovoTicker cria um ticker que dispara a cada intervalo avançado no relógio
+func (r *RelogioFalso) NovoTicker(intervalo time.Duration) Ticker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ticker := &tickerFalso{relogio: r, c: make(chan time.Time, 1), intervalo: intervalo, proximo: r.agora.Add(intervalo)}
+	r.tickers = append(r.tickers, ticker)
+	return ticker
+}
+
+// Avancar move o relógio. Os timers vencidos são chamados em ordem, antes de Avancar
+// retornar e com Agora no horário de cada um. Como no time.Ticker, ticks que ninguém
+// leu a tempo são descartados
+func (r *RelogioFalso) Avancar(duracao time.Duration) {
+	r.mu.Lock()
+	destino := r.agora.Add(duracao)
+
+	for {
+		timer := r.proximoTimerAte(destino)
+		if timer == nil {
+			break
+		}
+
+		r.agora = timer.quando
+		r.mu.Unlock()
+		timer.f()
+		r.mu.Lock()
+	}
+
+	r.agora = destino
+	for _, ticker := range r.tickers {
+		ticker.disparar(destino)
+	}
+	r.mu.Unlock()
+}
+
+// TimersPendentes retorna quantos timers ainda não foram chamados nem parados
+func (r *RelogioFalso) TimersPendentes() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.timers)
+}
+
+// TickersAtivos retorna quantos tickers ainda não foram parados
+func (r *RelogioFalso) TickersAtivos() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.tickers)
+}
+
+// proximoTimerAte tira da lista o timer que vence primeiro, se ele vencer até o horário
+func (r *RelogioFalso) proximoTimerAte(horario time.Time) *timerFalso {
+	sort.SliceStable(r.timers, func(i, j int) bool {
+		return r.timers[i].quando.Before(r.timers[j].quando)
+	})
+
+	if len(r.timers) == 0 || r.timers[0].quando.After(horario) {
+		return nil
+	}
+
+	timer := r.timers[0]
+	r.timers = r.timers[1:]
+	return timer
+}
+
+func (r *RelogioFalso) remover(timer *timerFalso) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, pendente := range r.timers {
+		if pendente == timer {
+			r.timers = append(r.timers[:i], r.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RelogioFalso) removerTicker(ticker *tickerFalso) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, ativo := range r.tickers {
+		if ativo == ticker {
+			r.tickers = append(r.tickers[:i], r.tickers[i+1:]...)
+			return
+		}
+	}
+}
+
+type timerFalso struct {
+	relogio *RelogioFalso
+	quando  time.Time
+	f       func()
+}
+
+func (t *timerFalso) Parar() bool {
+	return t.relogio.remover(t)
+}
+
+// tickerFalso só é disparado por Avancar, com o cadeado do relógio, e deixa de ser
+// disparado quando Parar o tira da lista do relógio
+type tickerFalso struct {
+	relogio   *RelogioFalso
+	c         chan time.Time
+	intervalo time.Duration
+	proximo   time.Time
+}
+
+func (t *tickerFalso) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *tickerFalso) Parar() {
+	t.relogio.removerTicker(t)
+}
+
+func (t *tickerFalso) disparar(agora time.Time) {
+	for !t.proximo.After(agora) {
+		select {
+		case t.c <- t.proximo:
+		default:
+		}
+		t.proximo = t.proximo.Add(t.intervalo)
+	}
 }
@@ -2,36 +2,510 @@ package poquer
 
 import (
 	"io"
+	"sync"
 	"time"
 )
 
-// TexasHoldem gerencia um jogo de pôquer
+// TexasHoldem gerencia as partidas de pôquer em andamento
 type TexasHoldem struct {
 	alertador     AlertadorDeBlind
 	armazenamento ArmazenamentoJogador
+	relogio       Relogio
+	// intervaloDoRelogio é de quanto em quanto tempo o estado dos blinds é publicado; zero desliga
+	intervaloDoRelogio time.Duration
+	estruturas         *CatalogoDeEstruturas
+
+	mu       sync.Mutex
+	partidas map[string]*PartidaTexasHoldem
+}
+
+// OpcaoTexasHoldem configura um TexasHoldem criado por NovoTexasHoldem
+type OpcaoTexasHoldem func(*TexasHoldem)
+
+// ComRelogio troca o relógio usado para medir o tempo de cada nível de blind
+func ComRelogio(relogio Relogio) OpcaoTexasHoldem {
+	return func(p *TexasHoldem) {
+		p.relogio = relogio
+	}
+}
+
+// ComIntervaloDoRelogio faz cada partida publicar, a cada intervalo, quanto falta para o
+// próximo blind no destino dos alertas
+func ComIntervaloDoRelogio(intervalo time.Duration) OpcaoTexasHoldem {
+	return func(p *TexasHoldem) {
+		p.intervaloDoRelogio = intervalo
+	}
+}
+
+// ComEstruturas troca as estruturas de blinds que podem ser escolhidas nas partidas
+func ComEstruturas(estruturas *CatalogoDeEstruturas) OpcaoTexasHoldem {
+	return func(p *TexasHoldem) {
+		p.estruturas = estruturas
+	}
 }
 
 // NovoTexasHoldem retorna um novo jogo
-func NovoTexasHoldem(alertador AlertadorDeBlind, armazenamento ArmazenamentoJogador) *TexasHoldem {
-	return &TexasHoldem{
+func NovoTexasHoldem(alertador AlertadorDeBlind, armazenamento ArmazenamentoJogador, opcoes ...OpcaoTexasHoldem) *TexasHoldem {
+	jogo := &TexasHoldem{
 		alertador:     alertador,
 		armazenamento: armazenamento,
+		relogio:       RelogioDoSistema{},
+		partidas:      map[string]*PartidaTexasHoldem{},
+	}
+
+	for _, opcao := range opcoes {
+		opcao(jogo)
+	}
+
+	if jogo.estruturas == nil {
+		jogo.estruturas, _ = NovoCatalogoDeEstruturas()
+	}
+
+	return jogo
+}
+
+// Começar cria uma partida com a estrutura de blinds escolhida e agenda seus alertas
+func (p *TexasHoldem) Começar(configuracao ConfiguracaoDaPartida, destinoDosAlertas io.Writer) (Partida, error) {
+	if err := configuracao.Validar(); err != nil {
+		return nil, err
+	}
+
+	estrutura, err := p.estruturas.Estrutura(configuracao.Estrutura)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	id := novoToken()
+	for p.partidas[id] != nil {
+		id = novoToken()
+	}
+	partida := &PartidaTexasHoldem{
+		id:                id,
+		NumeroDeJogadores: configuracao.NumeroDeJogadores,
+		Estrutura:         estrutura,
+		jogo:              p,
+		destino:           destinoDosAlertas,
+		fimDoRelogio:      make(chan struct{}),
+	}
+	if configuracao.Torneio != nil {
+		partida.torneio = novoTorneio(*configuracao.Torneio)
+	}
+	p.partidas[partida.id] = partida
+	p.mu.Unlock()
+
+	partida.começar()
+
+	if p.intervaloDoRelogio > 0 {
+		go partida.publicarRelogio(p.relogio.NovoTicker(p.intervaloDoRelogio))
+	}
+
+	return partida, nil
+}
+
+// Estruturas retorna as estruturas de blinds que podem ser escolhidas
+func (p *TexasHoldem) Estruturas() []EstruturaDeBlinds {
+	return p.estruturas.Estruturas()
+}
+
+// Partida retorna a partida em andamento com o id
+func (p *TexasHoldem) Partida(id string) (Partida, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	partida, existe := p.partidas[id]
+	if !existe {
+		return nil, false
+	}
+	return partida, true
+}
+
+// Partidas retorna quantas partidas estão em andamento
+func (p *TexasHoldem) Partidas() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.partidas)
+}
+
+func (p *TexasHoldem) remover(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.partidas, id)
+}
+
+// PartidaTexasHoldem é uma partida começada por TexasHoldem. Seu relógio de blinds pode
+// ser pausado e ter o nível trocado; os alertas que faltam são reagendados a cada mudança.
+// No modo torneio, ela também acompanha as fichas, os lugares e as eliminações dos inscritos
+type PartidaTexasHoldem struct {
+	id                string
+	NumeroDeJogadores int
+	Estrutura         EstruturaDeBlinds
+	jogo              *TexasHoldem
+	destino           io.Writer
+	fimDoRelogio      chan struct{}
+
+	mu               sync.Mutex
+	nivel            int
+	inicioDoNivel    time.Time
+	restanteNoNivel  time.Duration
+	pausada          bool
+	alertasPendentes []CancelarAlerta
+	finalizada       bool
+	torneio          *torneio
+}
+
+// ID identifica a partida em TexasHoldem.Partida. Ele é sorteado, para que não possa ser
+// adivinhado a partir dos ids de outras partidas
+func (p *PartidaTexasHoldem) ID() string {
+	return p.id
+}
+
+func (p *PartidaTexasHoldem) começar() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.inicioDoNivel = p.jogo.relogio.Agora()
+	p.agendar(0, p.nivel)
+	p.agendarProximosNiveis(p.duracao(p.nivel))
+}
+
+// publicarRelogio envia o estado dos blinds ao destino a cada tick até a partida terminar.
+// Nada é publicado com os blinds pausados ou no último nível, quando o tempo não corre
+func (p *PartidaTexasHoldem) publicarRelogio(ticker Ticker) {
+	defer ticker.Parar()
+
+	for {
+		select {
+		case <-ticker.C():
+			estado := p.Estado()
+			if !estado.Pausado && estado.Restante > 0 {
+				atualizarRelogio(p.destino, estado)
+			}
+		case <-p.fimDoRelogio:
+			return
+		}
+	}
+}
+
+// Pausar congela o relógio de blinds, guardando quanto falta para o próximo nível
+func (p *PartidaTexasHoldem) Pausar() error {
+	var pendentes []CancelarAlerta
+	defer func() { cancelarAlertas(pendentes) }()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.finalizada {
+		return ErrPartidaFinalizada
+	}
+
+	if p.pausada {
+		return ErrBlindsPausados
+	}
+
+	p.atualizarNivel()
+	p.restanteNoNivel = p.restante()
+	p.pausada = true
+	pendentes = p.tirarAlertasPendentes()
+	return nil
+}
+
+// Continuar volta a contar o tempo de onde ele parou em Pausar
+func (p *PartidaTexasHoldem) Continuar() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.finalizada {
+		return ErrPartidaFinalizada
+	}
+
+	if !p.pausada {
+		return ErrBlindsEmAndamento
+	}
+
+	p.pausada = false
+	p.inicioDoNivel = p.jogo.relogio.Agora().Add(p.restanteNoNivel - p.duracao(p.nivel))
+	p.agendarProximosNiveis(p.restanteNoNivel)
+	return nil
+}
+
+// AvancarNivel pula para o próximo nível de blind, que começa com o tempo cheio
+func (p *PartidaTexasHoldem) AvancarNivel() error {
+	return p.mudarNivel(1)
+}
+
+// VoltarNivel retorna ao nível de blind anterior, que recomeça com o tempo cheio
+func (p *PartidaTexasHoldem) VoltarNivel() error {
+	return p.mudarNivel(-1)
+}
+
+func (p *PartidaTexasHoldem) mudarNivel(delta int) error {
+	var pendentes []CancelarAlerta
+	defer func() { cancelarAlertas(pendentes) }()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.finalizada {
+		return ErrPartidaFinalizada
+	}
+
+	p.atualizarNivel()
+	novoNivel := p.nivel + delta
+
+	if novoNivel >= len(p.Estrutura.Niveis) {
+		return ErrUltimoNivel
+	}
+
+	if novoNivel < 0 {
+		return ErrPrimeiroNivel
 	}
+
+	pendentes = p.tirarAlertasPendentes()
+	p.nivel = novoNivel
+	p.inicioDoNivel = p.jogo.relogio.Agora()
+	p.restanteNoNivel = p.duracao(p.nivel)
+	p.agendar(0, p.nivel)
+
+	if !p.pausada {
+		p.agendarProximosNiveis(p.restanteNoNivel)
+	}
+
+	return nil
 }
 
-// Começar armazena alertas de blind dependendo do número de jogadores
-func (p *TexasHoldem) Começar(numeroDeJogadores int, destinoDosAlertas io.Writer) {
-	incrementoDeBlind := time.Duration(5+numeroDeJogadores) * time.Minute
+// Estado informa o nível atual e quanto falta para o próximo
+func (p *PartidaTexasHoldem) Estado() EstadoDosBlinds {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.atualizarNivel()
+
+	estado := EstadoDosBlinds{
+		Nivel:     p.nivel + 1,
+		Blinds:    p.Estrutura.blindsEm(p.nivel),
+		Pausado:   p.pausada,
+		Intervalo: p.Estrutura.Niveis[p.nivel].Intervalo,
+	}
+
+	if p.nivel+1 < len(p.Estrutura.Niveis) {
+		estado.ProximosBlinds = p.Estrutura.Niveis[p.nivel+1].Blinds
+		estado.Restante = p.restante()
+	}
+
+	return estado
+}
+
+// atualizarNivel avança os níveis cujo tempo já passou desde a última mudança
+func (p *PartidaTexasHoldem) atualizarNivel() {
+	if p.pausada {
+		return
+	}
+
+	agora := p.jogo.relogio.Agora()
+	for p.nivel+1 < len(p.Estrutura.Niveis) && agora.Sub(p.inicioDoNivel) >= p.duracao(p.nivel) {
+		p.inicioDoNivel = p.inicioDoNivel.Add(p.duracao(p.nivel))
+		p.nivel++
+	}
+}
+
+func (p *PartidaTexasHoldem) restante() time.Duration {
+	if p.pausada {
+		return p.restanteNoNivel
+	}
+
+	restante := p.duracao(p.nivel) - p.jogo.relogio.Agora().Sub(p.inicioDoNivel)
+	if restante < 0 {
+		return 0
+	}
+	return restante
+}
 
-	blinds := []int{100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000}
-	horarioDoBlind := 0 * time.Second
-	for _, blind := range blinds {
-		p.alertador.AgendarAlertaPara(horarioDoBlind, blind, destinoDosAlertas)
-		horarioDoBlind = horarioDoBlind + incrementoDeBlind
+// agendarProximosNiveis agenda os alertas dos níveis depois do atual, o primeiro deles
+// daqui a restante
+func (p *PartidaTexasHoldem) agendarProximosNiveis(restante time.Duration) {
+	horarioDoBlind := restante
+	for nivel := p.nivel + 1; nivel < len(p.Estrutura.Niveis); nivel++ {
+		p.agendar(horarioDoBlind, nivel)
+		horarioDoBlind = horarioDoBlind + p.duracao(nivel)
 	}
 }
 
-// Terminar finaliza o jogo, gravando o vencedor
-func (p *TexasHoldem) Terminar(vencedor string) {
-	p.armazenamento.GravarVitoria(vencedor)
+// agendar agenda o alerta do nível; intervalos não têm alerta
+func (p *PartidaTexasHoldem) agendar(duracao time.Duration, nivel int) {
+	if p.Estrutura.Niveis[nivel].Intervalo {
+		return
+	}
+
+	cancelar := p.jogo.alertador.AgendarAlertaPara(duracao, p.Estrutura.Niveis[nivel].Blinds, p.destino)
+	p.alertasPendentes = append(p.alertasPendentes, cancelar)
+}
+
+func (p *PartidaTexasHoldem) duracao(nivel int) time.Duration {
+	return p.Estrutura.duracaoDoNivel(nivel, p.NumeroDeJogadores)
+}
+
+// tirarAlertasPendentes esvazia a lista de alertas agendados e a retorna para que eles sejam
+// cancelados depois de soltar o cadeado: cancelar espera um alerta que já está sendo escrito,
+// e um cliente lento não pode travar os outros métodos da partida
+func (p *PartidaTexasHoldem) tirarAlertasPendentes() []CancelarAlerta {
+	pendentes := p.alertasPendentes
+	p.alertasPendentes = nil
+	return pendentes
+}
+
+func cancelarAlertas(alertas []CancelarAlerta) {
+	for _, cancelar := range alertas {
+		if cancelar != nil {
+			cancelar()
+		}
+	}
+}
+
+// Terminar finaliza a partida, cancelando os alertas que faltam e gravando o vencedor.
+// Em um torneio, quem ainda não foi eliminado fica atrás do vencedor, pelas fichas, e a
+// vitória vai para o primeiro colocado: um vencedor eliminado ou não inscrito perde o lugar
+// para quem tem mais fichas. Use VerificarVencedor para recusá-lo antes.
+// Só a primeira chamada a Terminar ou Abandonar tem efeito
+func (p *PartidaTexasHoldem) Terminar(vencedor string) {
+	p.mu.Lock()
+	if p.torneio != nil && !p.finalizada && !p.torneio.encerrado {
+		p.torneio.encerrar(vencedor)
+	}
+	p.mu.Unlock()
+
+	if p.torneio != nil {
+		vencedor = p.Classificacao().Vencedor()
+	}
+
+	if p.finalizar() {
+		p.gravarResultado(vencedor)
+	}
+}
+
+// gravarResultado grava a vitória e, em um torneio, as colocações se o armazenamento aceitar
+func (p *PartidaTexasHoldem) gravarResultado(vencedor string) {
+	p.jogo.armazenamento.GravarVitoria(vencedor)
+
+	if p.torneio == nil {
+		return
+	}
+
+	if armazenamento, ok := p.jogo.armazenamento.(ArmazenamentoDeColocacoes); ok {
+		p.mu.Lock()
+		colocacoes := p.torneio.colocacoes()
+		p.mu.Unlock()
+
+		armazenamento.GravarColocacoes(colocacoes)
+	}
+}
+
+// Eliminar tira o jogador do torneio. Quando resta um jogador, a partida termina com ele
+// como vencedor
+func (p *PartidaTexasHoldem) Eliminar(jogador string) error {
+	return p.mudarTorneio(func(t *torneio) error {
+		return t.eliminar(jogador)
+	})
+}
+
+// Recomprar devolve um jogador eliminado ao torneio com as fichas iniciais
+func (p *PartidaTexasHoldem) Recomprar(jogador string) error {
+	return p.mudarTorneio(func(t *torneio) error {
+		p.atualizarNivel()
+		return t.recomprar(jogador, p.nivel+1)
+	})
+}
+
+// AddOn dá ao jogador as fichas do add-on, uma vez por torneio
+func (p *PartidaTexasHoldem) AddOn(jogador string) error {
+	return p.mudarTorneio(func(t *torneio) error {
+		p.atualizarNivel()
+		return t.addOn(jogador, p.nivel+1)
+	})
+}
+
+// AtualizarFichas registra quantas fichas o jogador tem
+func (p *PartidaTexasHoldem) AtualizarFichas(jogador string, fichas int) error {
+	return p.mudarTorneio(func(t *torneio) error {
+		return t.atualizarFichas(jogador, fichas)
+	})
+}
+
+// Classificacao retorna a situação dos inscritos no torneio; vazia fora do modo torneio
+func (p *PartidaTexasHoldem) Classificacao() Classificacao {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.torneio == nil {
+		return Classificacao{}
+	}
+
+	return p.torneio.classificacao()
+}
+
+// Lugares retorna onde cada jogador do torneio está sentado; vazia fora do modo torneio
+func (p *PartidaTexasHoldem) Lugares() DistribuicaoDosLugares {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.torneio == nil {
+		return DistribuicaoDosLugares{}
+	}
+
+	return p.torneio.lugares.distribuicao()
+}
+
+// AvancarBotao passa o botão da mesa do torneio para o próximo jogador
+func (p *PartidaTexasHoldem) AvancarBotao(mesa int) error {
+	return p.mudarTorneio(func(t *torneio) error {
+		return t.lugares.avancarBotao(mesa)
+	})
+}
+
+// mudarTorneio aplica a mudança ao torneio e finaliza a partida se o torneio acabou com ela
+func (p *PartidaTexasHoldem) mudarTorneio(mudanca func(t *torneio) error) error {
+	p.mu.Lock()
+
+	if p.finalizada {
+		p.mu.Unlock()
+		return ErrPartidaFinalizada
+	}
+
+	if p.torneio == nil {
+		p.mu.Unlock()
+		return ErrSemTorneio
+	}
+
+	err := mudanca(p.torneio)
+	encerrado := p.torneio.encerrado
+	p.mu.Unlock()
+
+	if err == nil && encerrado && p.finalizar() {
+		p.gravarResultado(p.Classificacao().Vencedor())
+	}
+
+	return err
+}
+
+// Abandonar finaliza a partida sem vencedor, cancelando os alertas que faltam
+func (p *PartidaTexasHoldem) Abandonar() {
+	p.finalizar()
+}
+
+func (p *PartidaTexasHoldem) finalizar() bool {
+	p.mu.Lock()
+	if p.finalizada {
+		p.mu.Unlock()
+		return false
+	}
+	p.finalizada = true
+	pendentes := p.tirarAlertasPendentes()
+	close(p.fimDoRelogio)
+	p.mu.Unlock()
+
+	cancelarAlertas(pendentes)
+
+	p.jogo.remover(p.id)
+	return true
 }
@@ -1,8 +1,14 @@
 package poquer_test
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,20 +20,20 @@ func TestJogo_Começar(t *testing.T) {
 		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
 		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, ArmazenamentoJogadorTosco)
 
-		jogo.Começar(5, ioutil.Discard)
+		começarPartida(t, jogo, 5, ioutil.Discard)
 
 		cases := []poquer.AlertaAgendado{
-			{Em: 0 * time.Second, Quantia: 100},
-			{Em: 10 * time.Minute, Quantia: 200},
-			{Em: 20 * time.Minute, Quantia: 300},
-			{Em: 30 * time.Minute, Quantia: 400},
-			{Em: 40 * time.Minute, Quantia: 500},
-			{Em: 50 * time.Minute, Quantia: 600},
-			{Em: 60 * time.Minute, Quantia: 800},
-			{Em: 70 * time.Minute, Quantia: 1000},
-			{Em: 80 * time.Minute, Quantia: 2000},
-			{Em: 90 * time.Minute, Quantia: 4000},
-			{Em: 100 * time.Minute, Quantia: 8000},
+			{Em: 0 * time.Second, Blinds: blindUnico(100)},
+			{Em: 10 * time.Minute, Blinds: blindUnico(200)},
+			{Em: 20 * time.Minute, Blinds: blindUnico(300)},
+			{Em: 30 * time.Minute, Blinds: blindUnico(400)},
+			{Em: 40 * time.Minute, Blinds: blindUnico(500)},
+			{Em: 50 * time.Minute, Blinds: blindUnico(600)},
+			{Em: 60 * time.Minute, Blinds: blindUnico(800)},
+			{Em: 70 * time.Minute, Blinds: blindUnico(1000)},
+			{Em: 80 * time.Minute, Blinds: blindUnico(2000)},
+			{Em: 90 * time.Minute, Blinds: blindUnico(4000)},
+			{Em: 100 * time.Minute, Blinds: blindUnico(8000)},
 		}
 
 		verificaCasosAgendados(cases, t, alertadorDeBlind)
@@ -37,13 +43,13 @@ func TestJogo_Começar(t *testing.T) {
 		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
 		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, ArmazenamentoJogadorTosco)
 
-		jogo.Começar(7, ioutil.Discard)
+		começarPartida(t, jogo, 7, ioutil.Discard)
 
 		cases := []poquer.AlertaAgendado{
-			{Em: 0 * time.Second, Quantia: 100},
-			{Em: 12 * time.Minute, Quantia: 200},
-			{Em: 24 * time.Minute, Quantia: 300},
-			{Em: 36 * time.Minute, Quantia: 400},
+			{Em: 0 * time.Second, Blinds: blindUnico(100)},
+			{Em: 12 * time.Minute, Blinds: blindUnico(200)},
+			{Em: 24 * time.Minute, Blinds: blindUnico(300)},
+			{Em: 36 * time.Minute, Blinds: blindUnico(400)},
 		}
 
 		verificaCasosAgendados(cases, t, alertadorDeBlind)
@@ -53,11 +59,263 @@ func TestJogo_Começar(t *testing.T) {
 
 func TestJogo_Terminar(t *testing.T) {
 	armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
-	jogo := poquer.NovoTexasHoldem(AlertadorDeBlindTosco, armazenamento)
+	jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
 	vencedor := "Ruth"
 
-	jogo.Terminar(vencedor)
+	partida := começarPartida(t, jogo, 5, ioutil.Discard)
+	partida.Terminar(vencedor)
+	partida.Terminar("Chris")
+
 	poquer.VerificaVitoriaDoVencedor(t, armazenamento, vencedor)
+
+	if len(armazenamento.ChamadasDeVitoria) != 1 {
+		t.Errorf("uma partida só deveria gravar uma vitória, obtido %v", armazenamento.ChamadasDeVitoria)
+	}
+
+	if _, existe := jogo.Partida(partida.ID()); existe {
+		t.Errorf("a partida %s não deveria estar mais em andamento", partida.ID())
+	}
+}
+
+func TestJogo_PartidasIndependentes(t *testing.T) {
+	t.Run("terminar uma partida não cancela os alertas da outra", func(t *testing.T) {
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, &poquer.EsbocoDeArmazenamentoJogador{})
+
+		primeira := começarPartida(t, jogo, 5, ioutil.Discard)
+		segunda := começarPartida(t, jogo, 7, ioutil.Discard)
+
+		if primeira.ID() == segunda.ID() {
+			t.Fatalf("as partidas deveriam ter ids diferentes, ambas têm %q", primeira.ID())
+		}
+
+		primeira.Terminar("Ruth")
+
+		alertasPorPartida := len(alertadorDeBlind.Alertas) / 2
+		for i, alerta := range alertadorDeBlind.Alertas {
+			cancelado := i < alertasPorPartida
+			if alerta.Cancelado != cancelado {
+				t.Errorf("alerta %d (%v): cancelado %v, esperado %v", i, alerta, alerta.Cancelado, cancelado)
+			}
+		}
+
+		if _, existe := jogo.Partida(segunda.ID()); !existe {
+			t.Errorf("a partida %s deveria continuar em andamento", segunda.ID())
+		}
+	})
+
+	t.Run("muitas partidas em paralelo", func(t *testing.T) {
+		armazenamento := &armazenamentoSincronizado{}
+		jogo := poquer.NovoTexasHoldem(poquer.AlertadorDeBlindFunc(alertarImediatamente), armazenamento)
+
+		const numeroDePartidas = 50
+		destinos := make([]*bytes.Buffer, numeroDePartidas)
+		ids := make(chan string, numeroDePartidas)
+
+		var wg sync.WaitGroup
+		wg.Add(numeroDePartidas)
+
+		for i := 0; i < numeroDePartidas; i++ {
+			destinos[i] = &bytes.Buffer{}
+
+			go func(i int) {
+				defer wg.Done()
+
+				partida, err := jogo.Começar(poquer.ConfiguracaoDaPartida{NumeroDeJogadores: i%8 + 2}, destinos[i])
+				if err != nil {
+					t.Errorf("não foi possível começar a partida %d, %v", i, err)
+					return
+				}
+
+				ids <- partida.ID()
+				partida.Terminar(fmt.Sprintf("jogador%d", i))
+			}(i)
+		}
+
+		wg.Wait()
+		close(ids)
+
+		vistos := map[string]bool{}
+		for id := range ids {
+			if vistos[id] {
+				t.Errorf("id %s usado por mais de uma partida", id)
+			}
+			vistos[id] = true
+		}
+
+		for i, destino := range destinos {
+			if alertas := strings.Count(destino.String(), "Blind agora é"); alertas != 11 {
+				t.Errorf("a partida %d recebeu %d alertas, esperado 11", i, alertas)
+			}
+		}
+
+		if vitorias := armazenamento.vitorias(); vitorias != numeroDePartidas {
+			t.Errorf("esperava %d vitórias gravadas, obtido %d", numeroDePartidas, vitorias)
+		}
+
+		if jogo.Partidas() != 0 {
+			t.Errorf("nenhuma partida deveria estar em andamento, obtido %d", jogo.Partidas())
+		}
+	})
+}
+
+func alertarImediatamente(duracao time.Duration, blinds poquer.Blinds, para io.Writer) poquer.CancelarAlerta {
+	fmt.Fprintf(para, "Blind agora é %d\n", blinds.BigBlind)
+	return func() {}
+}
+
+type armazenamentoSincronizado struct {
+	poquer.EsbocoDeArmazenamentoJogador
+	mu sync.Mutex
+}
+
+func (a *armazenamentoSincronizado) GravarVitoria(nome string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.EsbocoDeArmazenamentoJogador.GravarVitoria(nome)
+}
+
+func (a *armazenamentoSincronizado) vitorias() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.ChamadasDeVitoria)
+}
+
+func TestJogo_CancelarAlertas(t *testing.T) {
+	t.Run("cancela os alertas que faltam quando o jogo termina", func(t *testing.T) {
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, &poquer.EsbocoDeArmazenamentoJogador{})
+
+		começarPartida(t, jogo, 5, ioutil.Discard).Terminar("Ruth")
+
+		verificaAlertasCancelados(t, alertadorDeBlind)
+	})
+
+	t.Run("cancela os alertas que faltam quando o jogo é abandonado", func(t *testing.T) {
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, armazenamento)
+
+		começarPartida(t, jogo, 5, ioutil.Discard).Abandonar()
+
+		verificaAlertasCancelados(t, alertadorDeBlind)
+
+		if len(armazenamento.ChamadasDeVitoria) != 0 {
+			t.Errorf("um jogo abandonado não deveria gravar vitórias, obtido %v", armazenamento.ChamadasDeVitoria)
+		}
+	})
+}
+
+func TestAlertador(t *testing.T) {
+	t.Run("escreve o alerta quando chega a hora", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		destino := &bytes.Buffer{}
+
+		poquer.NovoAlertador(relogio)(10*time.Minute, blindUnico(100), destino)
+
+		relogio.Avancar(9 * time.Minute)
+		verificaAlertasEscritos(t, destino)
+
+		relogio.Avancar(time.Minute)
+		verificaAlertasEscritos(t, destino, 100)
+	})
+
+	t.Run("escreve small blind, big blind e ante", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		destino := &bytes.Buffer{}
+
+		poquer.NovoAlertador(relogio)(0, poquer.Blinds{SmallBlind: 50, BigBlind: 100, Ante: 10}, destino)
+		relogio.Avancar(0)
+
+		if destino.String() != "Blinds agora são 50/100, ante 10\n" {
+			t.Errorf("alerta inesperado %q", destino.String())
+		}
+	})
+
+	t.Run("não escreve o alerta depois de cancelado", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		destino := &bytes.Buffer{}
+
+		cancelar := poquer.NovoAlertador(relogio)(10*time.Minute, blindUnico(100), destino)
+		cancelar()
+
+		relogio.Avancar(time.Hour)
+		verificaAlertasEscritos(t, destino)
+	})
+
+	t.Run("entrega os alertas da partida no horário, respeitando a pausa", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		destino := &bytes.Buffer{}
+		jogo := poquer.NovoTexasHoldem(poquer.NovoAlertador(relogio), ArmazenamentoJogadorTosco, poquer.ComRelogio(relogio))
+
+		partida := começarPartida(t, jogo, 5, destino)
+		verificaAlertasEscritos(t, destino)
+
+		relogio.Avancar(0)
+		verificaAlertasEscritos(t, destino, 100)
+
+		relogio.Avancar(15 * time.Minute)
+		verificaAlertasEscritos(t, destino, 100, 200)
+
+		verificaSemErro(t, partida.Pausar())
+		relogio.Avancar(time.Hour)
+		verificaAlertasEscritos(t, destino, 100, 200)
+
+		verificaSemErro(t, partida.Continuar())
+		relogio.Avancar(5 * time.Minute)
+		verificaAlertasEscritos(t, destino, 100, 200, 300)
+
+		partida.Terminar("Ruth")
+		relogio.Avancar(24 * time.Hour)
+		verificaAlertasEscritos(t, destino, 100, 200, 300)
+
+		if pendentes := relogio.TimersPendentes(); pendentes != 0 {
+			t.Errorf("nenhum timer deveria continuar agendado, obtido %d", pendentes)
+		}
+	})
+}
+
+// destinoLento segura a escrita do alerta até ser liberado, como um cliente lento
+type destinoLento struct {
+	escrevendo chan struct{}
+	liberar    chan struct{}
+}
+
+func (d *destinoLento) Write(p []byte) (int, error) {
+	close(d.escrevendo)
+	<-d.liberar
+	return len(p), nil
+}
+
+func verificaAlertasEscritos(t *testing.T, destino *bytes.Buffer, quantias ...int) {
+	t.Helper()
+
+	esperado := ""
+	for _, quantia := range quantias {
+		esperado += fmt.Sprintf("Blind agora é %d\n", quantia)
+	}
+
+	if destino.String() != esperado {
+		t.Errorf("obtido %q, esperado %q", destino.String(), esperado)
+	}
+}
+
+func verificaAlertasCancelados(t *testing.T, alertadorDeBlind *poquer.AlertadorDeBlindEspiao) {
+	t.Helper()
+
+	if len(alertadorDeBlind.Alertas) == 0 {
+		t.Fatal("nenhum alerta foi agendado")
+	}
+
+	for _, alerta := range alertadorDeBlind.Alertas {
+		if !alerta.Cancelado {
+			t.Errorf("o alerta %v deveria ter sido cancelado", alerta)
+		}
+	}
+}
+
+func blindUnico(quantia int) poquer.Blinds {
+	return poquer.Blinds{BigBlind: quantia}
 }
 
 func verificaCasosAgendados(cases []poquer.AlertaAgendado, t *testing.T, alertadorDeBlind *poquer.AlertadorDeBlindEspiao) {
@@ -73,3 +331,236 @@ func verificaCasosAgendados(cases []poquer.AlertaAgendado, t *testing.T, alertad
 		})
 	}
 }
+
+func TestJogo_ControleDeBlinds(t *testing.T) {
+	t.Run("pausar congela o tempo que falta e continuar reagenda os alertas a partir dele", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, ArmazenamentoJogadorTosco, poquer.ComRelogio(relogio))
+
+		partida := começarPartida(t, jogo, 5, ioutil.Discard)
+		relogio.Avancar(13 * time.Minute)
+
+		verificaSemErro(t, partida.Pausar())
+		verificaAlertasCancelados(t, alertadorDeBlind)
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 2, Blinds: blindUnico(200), ProximosBlinds: blindUnico(300), Restante: 7 * time.Minute, Pausado: true})
+
+		relogio.Avancar(30 * time.Minute)
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 2, Blinds: blindUnico(200), ProximosBlinds: blindUnico(300), Restante: 7 * time.Minute, Pausado: true})
+
+		verificaSemErro(t, partida.Continuar())
+		verificaCasosAgendados([]poquer.AlertaAgendado{
+			{Em: 7 * time.Minute, Blinds: blindUnico(300)},
+			{Em: 17 * time.Minute, Blinds: blindUnico(400)},
+			{Em: 27 * time.Minute, Blinds: blindUnico(500)},
+		}, t, alertasPendentes(alertadorDeBlind))
+
+		relogio.Avancar(8 * time.Minute)
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 3, Blinds: blindUnico(300), ProximosBlinds: blindUnico(400), Restante: 9 * time.Minute})
+	})
+
+	t.Run("avançar e voltar de nível recomeçam o nível com o tempo cheio", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, ArmazenamentoJogadorTosco, poquer.ComRelogio(relogio))
+
+		partida := começarPartida(t, jogo, 5, ioutil.Discard)
+		relogio.Avancar(4 * time.Minute)
+
+		verificaSemErro(t, partida.AvancarNivel())
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 2, Blinds: blindUnico(200), ProximosBlinds: blindUnico(300), Restante: 10 * time.Minute})
+		verificaCasosAgendados([]poquer.AlertaAgendado{
+			{Em: 0, Blinds: blindUnico(200)},
+			{Em: 10 * time.Minute, Blinds: blindUnico(300)},
+		}, t, alertasPendentes(alertadorDeBlind))
+
+		relogio.Avancar(2 * time.Minute)
+		verificaSemErro(t, partida.VoltarNivel())
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 1, Blinds: blindUnico(100), ProximosBlinds: blindUnico(200), Restante: 10 * time.Minute})
+		verificaCasosAgendados([]poquer.AlertaAgendado{
+			{Em: 0, Blinds: blindUnico(100)},
+			{Em: 10 * time.Minute, Blinds: blindUnico(200)},
+		}, t, alertasPendentes(alertadorDeBlind))
+
+		verificaErro(t, partida.VoltarNivel(), poquer.ErrPrimeiroNivel)
+	})
+
+	t.Run("trocar de nível com os blinds pausados só anuncia o novo nível", func(t *testing.T) {
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, ArmazenamentoJogadorTosco, poquer.ComRelogio(poquer.NovoRelogioFalso()))
+
+		partida := começarPartida(t, jogo, 5, ioutil.Discard)
+		verificaSemErro(t, partida.Pausar())
+		verificaSemErro(t, partida.AvancarNivel())
+
+		verificaCasosAgendados([]poquer.AlertaAgendado{{Em: 0, Blinds: blindUnico(200)}}, t, alertasPendentes(alertadorDeBlind))
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 2, Blinds: blindUnico(200), ProximosBlinds: blindUnico(300), Restante: 10 * time.Minute, Pausado: true})
+	})
+
+	t.Run("um destino lento não trava a partida enquanto os alertas são cancelados", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		destino := &destinoLento{escrevendo: make(chan struct{}), liberar: make(chan struct{})}
+		jogo := poquer.NovoTexasHoldem(poquer.NovoAlertador(relogio), ArmazenamentoJogadorTosco, poquer.ComRelogio(relogio))
+
+		partida := começarPartida(t, jogo, 5, destino)
+		go relogio.Avancar(0)
+		<-destino.escrevendo
+
+		pausou := make(chan error, 1)
+		go func() { pausou <- partida.Pausar() }()
+		time.Sleep(tenMS)
+
+		within(t, 100*time.Millisecond, func() { partida.Estado() })
+
+		close(destino.liberar)
+		verificaSemErro(t, <-pausou)
+	})
+
+	t.Run("recusa comandos fora de hora", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, &poquer.EsbocoDeArmazenamentoJogador{}, poquer.ComRelogio(relogio))
+
+		partida := começarPartida(t, jogo, 5, ioutil.Discard)
+		verificaErro(t, partida.Continuar(), poquer.ErrBlindsEmAndamento)
+
+		verificaSemErro(t, partida.Pausar())
+		verificaErro(t, partida.Pausar(), poquer.ErrBlindsPausados)
+
+		relogio.Avancar(time.Hour)
+		for i := 0; i < 10; i++ {
+			verificaSemErro(t, partida.AvancarNivel())
+		}
+		verificaErro(t, partida.AvancarNivel(), poquer.ErrUltimoNivel)
+		verificaEstadoDosBlinds(t, partida, poquer.EstadoDosBlinds{Nivel: 11, Blinds: blindUnico(8000), Pausado: true})
+
+		partida.Terminar("Ruth")
+		verificaErro(t, partida.Continuar(), poquer.ErrPartidaFinalizada)
+	})
+}
+
+func começarPartida(t *testing.T, jogo *poquer.TexasHoldem, numeroDeJogadores int, destino io.Writer) poquer.Partida {
+	t.Helper()
+
+	partida, err := jogo.Começar(poquer.ConfiguracaoDaPartida{NumeroDeJogadores: numeroDeJogadores}, destino)
+
+	if err != nil {
+		t.Fatalf("não foi possível começar a partida, %v", err)
+	}
+
+	return partida
+}
+
+func alertasPendentes(alertadorDeBlind *poquer.AlertadorDeBlindEspiao) *poquer.AlertadorDeBlindEspiao {
+	pendentes := &poquer.AlertadorDeBlindEspiao{}
+	for _, alerta := range alertadorDeBlind.Alertas {
+		if !alerta.Cancelado {
+			pendentes.Alertas = append(pendentes.Alertas, alerta)
+		}
+	}
+	return pendentes
+}
+
+func verificaEstadoDosBlinds(t *testing.T, partida poquer.Partida, esperado poquer.EstadoDosBlinds) {
+	t.Helper()
+	if obtido := partida.Estado(); obtido != esperado {
+		t.Errorf("obtido %+v, esperado %+v", obtido, esperado)
+	}
+}
+
+func verificaErro(t *testing.T, obtido, esperado error) {
+	t.Helper()
+	if !errors.Is(obtido, esperado) {
+		t.Errorf("obtido o erro %v, esperado %v", obtido, esperado)
+	}
+}
+
+func TestJogo_Relogio(t *testing.T) {
+	t.Run("publica o tempo até o próximo blind a cada intervalo", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		destino := &destinoSincronizado{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, &poquer.EsbocoDeArmazenamentoJogador{},
+			poquer.ComRelogio(relogio), poquer.ComIntervaloDoRelogio(30*time.Second))
+
+		partida := começarPartida(t, jogo, 5, destino)
+
+		relogio.Avancar(30 * time.Second)
+		verificaDestinoRecebeu(t, destino, "Nível 1: blind 100, próximo blind 200 em 9m30s\n")
+
+		relogio.Avancar(30 * time.Second)
+		verificaDestinoRecebeu(t, destino, "Nível 1: blind 100, próximo blind 200 em 9m30s\n", "Nível 1: blind 100, próximo blind 200 em 9m0s\n")
+
+		partida.Terminar("Ruth")
+		verificaTickerParado(t, relogio)
+	})
+
+	t.Run("não publica nada com os blinds pausados", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		destino := &destinoSincronizado{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, &poquer.EsbocoDeArmazenamentoJogador{},
+			poquer.ComRelogio(relogio), poquer.ComIntervaloDoRelogio(time.Minute))
+
+		partida := começarPartida(t, jogo, 5, destino)
+		defer partida.Abandonar()
+
+		verificaSemErro(t, partida.Pausar())
+		relogio.Avancar(time.Minute)
+		time.Sleep(tenMS)
+
+		if recebido := destino.recebido(); len(recebido) != 0 {
+			t.Errorf("nada deveria ser publicado com os blinds pausados, obtido %q", recebido)
+		}
+	})
+
+	t.Run("sem intervalo nenhum ticker é criado", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, &poquer.EsbocoDeArmazenamentoJogador{}, poquer.ComRelogio(relogio))
+
+		começarPartida(t, jogo, 5, ioutil.Discard).Abandonar()
+
+		if tickers := relogio.TickersAtivos(); tickers != 0 {
+			t.Errorf("nenhum ticker deveria ter sido criado, obtido %d", tickers)
+		}
+	})
+}
+
+type destinoSincronizado struct {
+	mu     sync.Mutex
+	linhas []string
+}
+
+func (d *destinoSincronizado) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.linhas = append(d.linhas, string(p))
+	return len(p), nil
+}
+
+func (d *destinoSincronizado) recebido() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.linhas...)
+}
+
+func verificaDestinoRecebeu(t *testing.T, destino *destinoSincronizado, esperado ...string) {
+	t.Helper()
+
+	passou := tentarNovamenteAte(500*time.Millisecond, func() bool {
+		return reflect.DeepEqual(destino.recebido(), esperado)
+	})
+
+	if !passou {
+		t.Errorf("obtido %q, esperado %q", destino.recebido(), esperado)
+	}
+}
+
+func verificaTickerParado(t *testing.T, relogio *poquer.RelogioFalso) {
+	t.Helper()
+
+	passou := tentarNovamenteAte(500*time.Millisecond, func() bool {
+		return relogio.TickersAtivos() == 0
+	})
+
+	if !passou {
+		t.Error("o ticker deveria ter parado quando a partida terminou")
+	}
+}
@@ -0,0 +1,519 @@
+package poquer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Comandos que mudam as fichas e os inscritos de um torneio
+const (
+	ComandoEliminar  = "eliminar"
+	ComandoRecomprar = "recomprar"
+	ComandoAddOn     = "addon"
+	ComandoFichas    = "fichas"
+	ComandoBotao     = "botao"
+)
+
+var (
+	// ErrSemTorneio indica que a partida não foi começada no modo torneio
+	ErrSemTorneio = errors.New("a partida não é um torneio")
+	// ErrJogadorDesconhecido indica que o jogador não está inscrito no torneio
+	ErrJogadorDesconhecido = errors.New("jogador não inscrito no torneio")
+	// ErrJogadorEliminado indica que o jogador já foi eliminado
+	ErrJogadorEliminado = errors.New("o jogador já foi eliminado")
+	// ErrJogadorEmJogo indica que só um jogador eliminado pode recomprar
+	ErrJogadorEmJogo = errors.New("o jogador ainda está no torneio")
+	// ErrRecomprasEncerradas indica que o período de recompras e add-ons já acabou
+	ErrRecomprasEncerradas = errors.New("o período de recompras já acabou")
+	// ErrAddOnRepetido indica que o jogador já fez o seu add-on
+	ErrAddOnRepetido = errors.New("o jogador já fez o add-on")
+	// ErrSemAddOn indica que o torneio não tem add-on
+	ErrSemAddOn = errors.New("o torneio não tem add-on")
+)
+
+// ConfiguracaoDoTorneio liga o modo torneio de uma partida
+type ConfiguracaoDoTorneio struct {
+	Jogadores      []string
+	FichasIniciais int
+	// NiveisDeRecompra é por quantos níveis de blind os eliminados podem recomprar e os
+	// jogadores podem fazer o add-on; zero não permite recompras
+	NiveisDeRecompra int
+	// FichasDoAddOn são as fichas de um add-on, que cada jogador pode fazer uma vez; zero
+	// não permite add-ons
+	FichasDoAddOn int
+	// BuyIn é quanto custa cada inscrição, recompra e add-on; tudo vai para os prêmios.
+	// Zero joga o torneio sem prêmios
+	BuyIn int
+	// Premiacao são os percentuais dos prêmios pagos a cada colocação, do primeiro lugar em
+	// diante; vazia usa PremiacaoPara o número de inscritos
+	Premiacao []float64
+	// LugaresPorMesa é quantos jogadores cabem em cada mesa; zero usa MaximoDeLugares. Com
+	// mais inscritos, o torneio é jogado em várias mesas
+	LugaresPorMesa int
+	// Aleatorio sorteia os lugares; sem ele, os lugares são sorteados com o horário atual
+	Aleatorio *rand.Rand
+}
+
+// Validar confere se o torneio pode começar com essa configuração
+func (c ConfiguracaoDoTorneio) Validar() error {
+	if len(c.Jogadores) < 2 {
+		return fmt.Errorf("o torneio precisa de pelo menos 2 jogadores, obtido %d", len(c.Jogadores))
+	}
+
+	inscritos := map[string]bool{}
+	for _, nome := range c.Jogadores {
+		if strings.TrimSpace(nome) == "" {
+			return errors.New("todo jogador do torneio precisa de um nome")
+		}
+
+		if inscritos[nome] {
+			return fmt.Errorf("o jogador %s foi inscrito mais de uma vez", nome)
+		}
+		inscritos[nome] = true
+	}
+
+	if c.FichasIniciais < 1 {
+		return fmt.Errorf("as fichas iniciais devem ser positivas, obtido %d", c.FichasIniciais)
+	}
+
+	if c.NiveisDeRecompra < 0 || c.FichasDoAddOn < 0 || c.BuyIn < 0 {
+		return errors.New("os níveis de recompra, as fichas do add-on e o buy-in não podem ser negativos")
+	}
+
+	if c.LugaresPorMesa != 0 && (c.LugaresPorMesa < 2 || c.LugaresPorMesa > MaximoDeLugares) {
+		return fmt.Errorf("as mesas devem ter de 2 a %d lugares, obtido %d", MaximoDeLugares, c.LugaresPorMesa)
+	}
+
+	if len(c.Premiacao) == 0 {
+		return nil
+	}
+
+	if c.BuyIn == 0 {
+		return errors.New("a premiação precisa de um buy-in")
+	}
+
+	if len(c.Premiacao) > len(c.Jogadores) {
+		return fmt.Errorf("a premiação paga %d colocações, mais que os %d inscritos", len(c.Premiacao), len(c.Jogadores))
+	}
+
+	return ValidarPremiacao(c.Premiacao)
+}
+
+// percentuais retorna a premiação do torneio, ou nenhuma se ele não tem buy-in
+func (c ConfiguracaoDoTorneio) percentuais() []float64 {
+	if c.BuyIn == 0 {
+		return nil
+	}
+
+	if len(c.Premiacao) > 0 {
+		return c.Premiacao
+	}
+
+	return PremiacaoPara(len(c.Jogadores))
+}
+
+// lugaresPorMesa retorna LugaresPorMesa, ou MaximoDeLugares se omitido
+func (c ConfiguracaoDoTorneio) lugaresPorMesa() int {
+	if c.LugaresPorMesa == 0 {
+		return MaximoDeLugares
+	}
+	return c.LugaresPorMesa
+}
+
+// ControleDeTorneio registra o que acontece com os jogadores de uma partida no modo torneio.
+// O torneio termina sozinho quando resta um jogador
+type ControleDeTorneio interface {
+	Eliminar(jogador string) error
+	Recomprar(jogador string) error
+	AddOn(jogador string) error
+	AtualizarFichas(jogador string, fichas int) error
+	Classificacao() Classificacao
+}
+
+// VerificarVencedor confere que o vencedor declarado de um torneio ainda está em jogo. Partidas
+// fora do modo torneio aceitam qualquer vencedor
+func VerificarVencedor(partida Partida, vencedor string) error {
+	controle, ok := partida.(ControleDeTorneio)
+
+	if !ok {
+		return nil
+	}
+
+	classificacao := controle.Classificacao()
+
+	if len(classificacao.Jogadores) == 0 {
+		return nil
+	}
+
+	for _, jogador := range classificacao.Jogadores {
+		if jogador.Nome != vencedor {
+			continue
+		}
+
+		if jogador.Posicao != 0 {
+			return fmt.Errorf("%w: %s", ErrJogadorEliminado, vencedor)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrJogadorDesconhecido, vencedor)
+}
+
+// PosicaoNoTorneio descreve um inscrito do torneio
+type PosicaoNoTorneio struct {
+	Nome   string `json:"nome"`
+	Fichas int    `json:"fichas"`
+	// Posicao é a colocação final; zero enquanto o jogador continua no torneio
+	Posicao   int  `json:"posicao,omitempty"`
+	Recompras int  `json:"recompras,omitempty"`
+	AddOn     bool `json:"addOn,omitempty"`
+	// Premio é quanto o jogador ganha pela colocação
+	Premio int `json:"premio,omitempty"`
+}
+
+// Classificacao lista quem continua no torneio, do maior para o menor número de fichas,
+// seguido dos eliminados pela colocação
+type Classificacao struct {
+	Jogadores      []PosicaoNoTorneio `json:"jogadores"`
+	FichasEmJogo   int                `json:"fichasEmJogo"`
+	TotalDePremios int                `json:"totalDePremios,omitempty"`
+	Encerrado      bool               `json:"encerrado,omitempty"`
+}
+
+// Vencedor retorna quem terminou em primeiro, ou vazio se o torneio não acabou
+func (c Classificacao) Vencedor() string {
+	if !c.Encerrado || len(c.Jogadores) == 0 {
+		return ""
+	}
+	return c.Jogadores[0].Nome
+}
+
+func (c Classificacao) String() string {
+	linhas := make([]string, len(c.Jogadores))
+
+	for i, jogador := range c.Jogadores {
+		if jogador.Posicao == 0 {
+			linhas[i] = fmt.Sprintf("%s: %d fichas", jogador.Nome, jogador.Fichas)
+		} else {
+			linhas[i] = fmt.Sprintf("%dº lugar: %s", jogador.Posicao, jogador.Nome)
+		}
+
+		if jogador.Premio > 0 {
+			linhas[i] += fmt.Sprintf(", prêmio %d", jogador.Premio)
+		}
+	}
+
+	return strings.Join(linhas, "\n")
+}
+
+// Colocacao é a posição final de um jogador em um torneio e o prêmio que ela pagou
+type Colocacao struct {
+	Nome    string
+	Posicao int
+	Premio  int
+}
+
+// ArmazenamentoDeColocacoes pode ser implementado por um ArmazenamentoJogador para guardar
+// as colocações e os prêmios de todos os jogadores de um torneio, e não só a vitória
+type ArmazenamentoDeColocacoes interface {
+	GravarColocacoes(colocacoes []Colocacao)
+}
+
+// executarComandoDeTorneio aplica um dos comandos de torneio ao jogador
+func executarComandoDeTorneio(controle ControleDeTorneio, comando, jogador string, fichas int) error {
+	switch comando {
+	case ComandoEliminar:
+		return controle.Eliminar(jogador)
+	case ComandoRecomprar:
+		return controle.Recomprar(jogador)
+	case ComandoAddOn:
+		return controle.AddOn(jogador)
+	case ComandoFichas:
+		return controle.AtualizarFichas(jogador, fichas)
+	}
+	return errComandoDeTorneioDesconhecido(comando)
+}
+
+func errComandoDeTorneioDesconhecido(comando string) error {
+	return fmt.Errorf("comando %q desconhecido, use %s, %s, %s, %s ou %s", comando, ComandoEliminar, ComandoRecomprar, ComandoAddOn, ComandoFichas, ComandoBotao)
+}
+
+type inscrito struct {
+	nome      string
+	fichas    int
+	recompras int
+	addOn     bool
+	eliminado bool
+}
+
+// torneio guarda as fichas, os lugares e a ordem das eliminações. A colocação de cada
+// eliminado só é calculada a partir dessa ordem, para que uma recompra não deixe buracos na
+// classificação
+type torneio struct {
+	configuracao ConfiguracaoDoTorneio
+	inscritos    []*inscrito
+	eliminados   []*inscrito
+	lugares      *salao
+	fichasEmJogo int
+	// entradas conta as inscrições, recompras e add-ons, cada um pagando um buy-in
+	entradas  int
+	encerrado bool
+}
+
+func novoTorneio(configuracao ConfiguracaoDoTorneio) *torneio {
+	t := &torneio{configuracao: configuracao}
+
+	for _, nome := range configuracao.Jogadores {
+		t.inscritos = append(t.inscritos, &inscrito{nome: nome, fichas: configuracao.FichasIniciais})
+		t.fichasEmJogo += configuracao.FichasIniciais
+		t.entradas++
+	}
+
+	aleatorio := configuracao.Aleatorio
+	if aleatorio == nil {
+		aleatorio = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	t.lugares = novoSalao(configuracao.Jogadores, configuracao.lugaresPorMesa(), aleatorio)
+
+	return t
+}
+
+func (t *torneio) inscrito(nome string) (*inscrito, error) {
+	for _, jogador := range t.inscritos {
+		if jogador.nome == nome {
+			return jogador, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrJogadorDesconhecido, nome)
+}
+
+// jogadorEmJogo encontra um inscrito que ainda não foi eliminado
+func (t *torneio) jogadorEmJogo(nome string) (*inscrito, error) {
+	jogador, err := t.inscrito(nome)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if jogador.eliminado {
+		return nil, fmt.Errorf("%w: %s", ErrJogadorEliminado, nome)
+	}
+
+	return jogador, nil
+}
+
+// eliminar tira o jogador do torneio e da sua mesa e o encerra se restar apenas um
+func (t *torneio) eliminar(nome string) error {
+	jogador, err := t.jogadorEmJogo(nome)
+
+	if err != nil {
+		return err
+	}
+
+	jogador.eliminado = true
+	jogador.fichas = 0
+	t.eliminados = append(t.eliminados, jogador)
+	t.lugares.levantar(nome)
+
+	if restantes := t.restantes(); len(restantes) == 1 {
+		t.encerrar(restantes[0].nome)
+	}
+
+	return nil
+}
+
+func (t *torneio) recomprar(nome string, nivel int) error {
+	if err := t.dentroDoPeriodoDeRecompra(nivel); err != nil {
+		return err
+	}
+
+	jogador, err := t.inscrito(nome)
+
+	if err != nil {
+		return err
+	}
+
+	if !jogador.eliminado {
+		return fmt.Errorf("%w: %s", ErrJogadorEmJogo, nome)
+	}
+
+	for i, eliminado := range t.eliminados {
+		if eliminado == jogador {
+			t.eliminados = append(t.eliminados[:i], t.eliminados[i+1:]...)
+			break
+		}
+	}
+
+	jogador.eliminado = false
+	jogador.recompras++
+	jogador.fichas = t.configuracao.FichasIniciais
+	t.fichasEmJogo += t.configuracao.FichasIniciais
+	t.entradas++
+	t.lugares.sentar(nome)
+	return nil
+}
+
+func (t *torneio) addOn(nome string, nivel int) error {
+	if t.configuracao.FichasDoAddOn == 0 {
+		return ErrSemAddOn
+	}
+
+	if err := t.dentroDoPeriodoDeRecompra(nivel); err != nil {
+		return err
+	}
+
+	jogador, err := t.jogadorEmJogo(nome)
+
+	if err != nil {
+		return err
+	}
+
+	if jogador.addOn {
+		return fmt.Errorf("%w: %s", ErrAddOnRepetido, nome)
+	}
+
+	jogador.addOn = true
+	jogador.fichas += t.configuracao.FichasDoAddOn
+	t.fichasEmJogo += t.configuracao.FichasDoAddOn
+	t.entradas++
+	return nil
+}
+
+func (t *torneio) dentroDoPeriodoDeRecompra(nivel int) error {
+	if nivel > t.configuracao.NiveisDeRecompra {
+		return ErrRecomprasEncerradas
+	}
+	return nil
+}
+
+func (t *torneio) atualizarFichas(nome string, fichas int) error {
+	if fichas < 1 {
+		return fmt.Errorf("as fichas devem ser positivas, obtido %d; use %s para quem perdeu todas", fichas, ComandoEliminar)
+	}
+
+	jogador, err := t.jogadorEmJogo(nome)
+
+	if err != nil {
+		return err
+	}
+
+	jogador.fichas = fichas
+	return nil
+}
+
+// restantes retorna quem continua no torneio, do maior para o menor número de fichas
+func (t *torneio) restantes() []*inscrito {
+	var restantes []*inscrito
+
+	for _, jogador := range t.inscritos {
+		if !jogador.eliminado {
+			restantes = append(restantes, jogador)
+		}
+	}
+
+	sort.SliceStable(restantes, func(i, j int) bool {
+		return restantes[i].fichas > restantes[j].fichas
+	})
+
+	return restantes
+}
+
+// encerrar coloca o vencedor em primeiro e os outros que restam atrás dele, pelas fichas. Se o
+// vencedor não está em jogo, quem tem mais fichas fica em primeiro
+func (t *torneio) encerrar(vencedor string) {
+	var campeao *inscrito
+	restantes := t.restantes()
+
+	for i := len(restantes) - 1; i >= 0; i-- {
+		if restantes[i].nome == vencedor {
+			campeao = restantes[i]
+			continue
+		}
+		restantes[i].eliminado = true
+		t.eliminados = append(t.eliminados, restantes[i])
+	}
+
+	if campeao != nil {
+		campeao.eliminado = true
+		campeao.fichas = t.fichasEmJogo
+		t.eliminados = append(t.eliminados, campeao)
+	}
+
+	t.encerrado = true
+}
+
+func (t *torneio) posicao(ordemDeEliminacao int) int {
+	return len(t.inscritos) - ordemDeEliminacao
+}
+
+func (t *torneio) totalDePremios() int {
+	return t.configuracao.BuyIn * t.entradas
+}
+
+// premios retorna o prêmio de cada colocação paga, do primeiro lugar em diante
+func (t *torneio) premios() []int {
+	percentuais := t.configuracao.percentuais()
+
+	if len(percentuais) == 0 {
+		return nil
+	}
+
+	premios, _ := CalcularPremios(t.totalDePremios(), percentuais)
+	return premios
+}
+
+func premioDa(posicao int, premios []int) int {
+	if posicao < 1 || posicao > len(premios) {
+		return 0
+	}
+	return premios[posicao-1]
+}
+
+// colocacoes retorna a colocação de cada eliminado, do primeiro ao último lugar
+func (t *torneio) colocacoes() []Colocacao {
+	colocacoes := make([]Colocacao, 0, len(t.eliminados))
+	premios := t.premios()
+
+	for i := len(t.eliminados) - 1; i >= 0; i-- {
+		posicao := t.posicao(i)
+		colocacoes = append(colocacoes, Colocacao{Nome: t.eliminados[i].nome, Posicao: posicao, Premio: premioDa(posicao, premios)})
+	}
+
+	return colocacoes
+}
+
+func (t *torneio) classificacao() Classificacao {
+	classificacao := Classificacao{
+		FichasEmJogo:   t.fichasEmJogo,
+		TotalDePremios: t.totalDePremios(),
+		Encerrado:      t.encerrado,
+	}
+	premios := t.premios()
+
+	if !t.encerrado {
+		for _, jogador := range t.restantes() {
+			classificacao.Jogadores = append(classificacao.Jogadores, t.posicaoDe(jogador, 0, premios))
+		}
+	}
+
+	for i := len(t.eliminados) - 1; i >= 0; i-- {
+		classificacao.Jogadores = append(classificacao.Jogadores, t.posicaoDe(t.eliminados[i], t.posicao(i), premios))
+	}
+
+	return classificacao
+}
+
+func (t *torneio) posicaoDe(jogador *inscrito, posicao int, premios []int) PosicaoNoTorneio {
+	return PosicaoNoTorneio{
+		Nome:      jogador.nome,
+		Fichas:    jogador.fichas,
+		Posicao:   posicao,
+		Recompras: jogador.recompras,
+		AddOn:     jogador.addOn,
+		Premio:    premioDa(posicao, premios),
+	}
+}
@@ -0,0 +1,312 @@
+package poquer_test
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestTorneio(t *testing.T) {
+	t.Run("termina sozinho quando resta um jogador e grava todas as colocações", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		alertadorDeBlind := &poquer.AlertadorDeBlindEspiao{}
+		jogo := poquer.NovoTexasHoldem(alertadorDeBlind, armazenamento)
+
+		torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris", "Ruth"}, FichasIniciais: 1000})
+
+		verificaSemErro(t, torneio.Eliminar("Chris"))
+		verificaClassificacao(t, torneio, "Cleo: 1000 fichas\nRuth: 1000 fichas\n3º lugar: Chris")
+
+		verificaSemErro(t, torneio.Eliminar("Ruth"))
+		verificaClassificacao(t, torneio, "1º lugar: Cleo\n2º lugar: Ruth\n3º lugar: Chris")
+
+		classificacao := torneio.Classificacao()
+		if !classificacao.Encerrado || classificacao.Vencedor() != "Cleo" {
+			t.Errorf("o torneio deveria ter terminado com Cleo vencendo, obtido %+v", classificacao)
+		}
+
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Cleo")
+		verificaColocacoesGravadas(t, armazenamento, []poquer.Colocacao{
+			{Nome: "Cleo", Posicao: 1},
+			{Nome: "Ruth", Posicao: 2},
+			{Nome: "Chris", Posicao: 3},
+		})
+		verificaAlertasCancelados(t, alertadorDeBlind)
+
+		if jogo.Partidas() != 0 {
+			t.Errorf("a partida deveria ter terminado, obtido %d em andamento", jogo.Partidas())
+		}
+
+		verificaErro(t, torneio.Eliminar("Cleo"), poquer.ErrPartidaFinalizada)
+	})
+
+	t.Run("recompra devolve o eliminado com as fichas iniciais sem deixar buracos na classificação", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+
+		torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris", "Ruth"}, FichasIniciais: 1000, NiveisDeRecompra: 2})
+
+		verificaSemErro(t, torneio.Eliminar("Chris"))
+		verificaSemErro(t, torneio.Recomprar("Chris"))
+		verificaErro(t, torneio.Recomprar("Ruth"), poquer.ErrJogadorEmJogo)
+
+		verificaSemErro(t, torneio.Eliminar("Ruth"))
+		verificaSemErro(t, torneio.Eliminar("Chris"))
+
+		verificaColocacoesGravadas(t, armazenamento, []poquer.Colocacao{
+			{Nome: "Cleo", Posicao: 1},
+			{Nome: "Chris", Posicao: 2},
+			{Nome: "Ruth", Posicao: 3},
+		})
+
+		chris := torneio.Classificacao().Jogadores[1]
+		if chris.Recompras != 1 || torneio.Classificacao().FichasEmJogo != 4000 {
+			t.Errorf("esperava uma recompra de Chris e 4000 fichas em jogo, obtido %+v", torneio.Classificacao())
+		}
+	})
+
+	t.Run("recompras e add-ons só valem nos níveis de recompra", func(t *testing.T) {
+		relogio := poquer.NovoRelogioFalso()
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco, poquer.ComRelogio(relogio))
+
+		torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{
+			Jogadores:        []string{"Cleo", "Chris", "Ruth"},
+			FichasIniciais:   1000,
+			NiveisDeRecompra: 1,
+			FichasDoAddOn:    500,
+		})
+
+		verificaSemErro(t, torneio.AddOn("Cleo"))
+		verificaErro(t, torneio.AddOn("Cleo"), poquer.ErrAddOnRepetido)
+		verificaSemErro(t, torneio.Eliminar("Chris"))
+
+		relogio.Avancar(8 * time.Minute)
+
+		verificaErro(t, torneio.Recomprar("Chris"), poquer.ErrRecomprasEncerradas)
+		verificaErro(t, torneio.AddOn("Ruth"), poquer.ErrRecomprasEncerradas)
+		verificaClassificacao(t, torneio, "Cleo: 1500 fichas\nRuth: 1000 fichas\n3º lugar: Chris")
+	})
+
+	t.Run("ordena quem continua pelas fichas e recusa jogadores fora do torneio", func(t *testing.T) {
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+
+		torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris", "Ruth"}, FichasIniciais: 1000})
+
+		verificaSemErro(t, torneio.AtualizarFichas("Ruth", 1800))
+		verificaSemErro(t, torneio.AtualizarFichas("Cleo", 400))
+		verificaSemErro(t, torneio.AtualizarFichas("Chris", 800))
+		verificaClassificacao(t, torneio, "Ruth: 1800 fichas\nChris: 800 fichas\nCleo: 400 fichas")
+
+		verificaErro(t, torneio.AtualizarFichas("Tiest", 100), poquer.ErrJogadorDesconhecido)
+		verificaErro(t, torneio.AddOn("Cleo"), poquer.ErrSemAddOn)
+
+		verificaSemErro(t, torneio.Eliminar("Cleo"))
+		verificaErro(t, torneio.Eliminar("Cleo"), poquer.ErrJogadorEliminado)
+		verificaErro(t, torneio.AtualizarFichas("Cleo", 100), poquer.ErrJogadorEliminado)
+	})
+
+	t.Run("declarar o vencedor coloca os que restam atrás dele pelas fichas", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+
+		torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris", "Ruth", "Tiest"}, FichasIniciais: 1000})
+
+		verificaSemErro(t, torneio.Eliminar("Tiest"))
+		verificaSemErro(t, torneio.AtualizarFichas("Cleo", 2500))
+		verificaSemErro(t, torneio.AtualizarFichas("Chris", 500))
+
+		torneio.(poquer.Partida).Terminar("Chris")
+
+		poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris")
+		verificaColocacoesGravadas(t, armazenamento, []poquer.Colocacao{
+			{Nome: "Chris", Posicao: 1},
+			{Nome: "Cleo", Posicao: 2},
+			{Nome: "Ruth", Posicao: 3},
+			{Nome: "Tiest", Posicao: 4},
+		})
+	})
+
+	t.Run("recusa como vencedor quem foi eliminado ou não se inscreveu", func(t *testing.T) {
+		casos := map[string]struct {
+			vencedor string
+			esperado error
+		}{
+			"eliminado":    {"Cleo", poquer.ErrJogadorEliminado},
+			"não inscrito": {"Tiest", poquer.ErrJogadorDesconhecido},
+		}
+
+		for nome, caso := range casos {
+			t.Run(nome, func(t *testing.T) {
+				armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+				jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+
+				torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris", "Ruth"}, FichasIniciais: 1000})
+				verificaSemErro(t, torneio.AtualizarFichas("Chris", 1800))
+				verificaSemErro(t, torneio.Eliminar("Cleo"))
+
+				partida := torneio.(poquer.Partida)
+				verificaErro(t, poquer.VerificarVencedor(partida, caso.vencedor), caso.esperado)
+				verificaSemErro(t, poquer.VerificarVencedor(partida, "Ruth"))
+
+				partida.Terminar(caso.vencedor)
+
+				poquer.VerificaVitoriaDoVencedor(t, armazenamento, "Chris")
+				verificaColocacoesGravadas(t, armazenamento, []poquer.Colocacao{
+					{Nome: "Chris", Posicao: 1},
+					{Nome: "Ruth", Posicao: 2},
+					{Nome: "Cleo", Posicao: 3},
+				})
+			})
+		}
+
+		partida := começarPartida(t, poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco), 3, nil)
+		verificaSemErro(t, poquer.VerificarVencedor(partida, "Tiest"))
+	})
+
+	t.Run("paga os prêmios das inscrições, recompras e add-ons às colocações", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+
+		torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{
+			Jogadores:        []string{"Cleo", "Chris", "Ruth"},
+			FichasIniciais:   1000,
+			NiveisDeRecompra: 1,
+			FichasDoAddOn:    500,
+			BuyIn:            100,
+			Premiacao:        []float64{70, 30},
+		})
+
+		verificaSemErro(t, torneio.Eliminar("Chris"))
+		verificaSemErro(t, torneio.Recomprar("Chris"))
+		verificaSemErro(t, torneio.AddOn("Ruth"))
+
+		if total := torneio.Classificacao().TotalDePremios; total != 500 {
+			t.Errorf("esperava 500 em prêmios, obtido %d", total)
+		}
+
+		verificaSemErro(t, torneio.Eliminar("Chris"))
+		verificaClassificacao(t, torneio, "Ruth: 1500 fichas\nCleo: 1000 fichas\n3º lugar: Chris")
+
+		verificaSemErro(t, torneio.Eliminar("Cleo"))
+		verificaClassificacao(t, torneio, "1º lugar: Ruth, prêmio 350\n2º lugar: Cleo, prêmio 150\n3º lugar: Chris")
+
+		verificaColocacoesGravadas(t, armazenamento, []poquer.Colocacao{
+			{Nome: "Ruth", Posicao: 1, Premio: 350},
+			{Nome: "Cleo", Posicao: 2, Premio: 150},
+			{Nome: "Chris", Posicao: 3},
+		})
+	})
+
+	t.Run("usa a premiação pelo número de inscritos quando o torneio não tem uma", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+
+		torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{
+			Jogadores:      []string{"Cleo", "Chris", "Ruth", "Tiest", "Pepper"},
+			FichasIniciais: 1000,
+			BuyIn:          50,
+		})
+
+		torneio.(poquer.Partida).Terminar("Pepper")
+
+		verificaColocacoesGravadas(t, armazenamento, []poquer.Colocacao{
+			{Nome: "Pepper", Posicao: 1, Premio: 163},
+			{Nome: "Cleo", Posicao: 2, Premio: 87},
+			{Nome: "Chris", Posicao: 3},
+			{Nome: "Ruth", Posicao: 4},
+			{Nome: "Tiest", Posicao: 5},
+		})
+	})
+
+	t.Run("não grava colocações quando a partida é abandonada", func(t *testing.T) {
+		armazenamento := &poquer.EsbocoDeArmazenamentoJogador{}
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, armazenamento)
+
+		torneio := começarTorneio(t, jogo, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000})
+		torneio.(poquer.Partida).Abandonar()
+
+		if len(armazenamento.ChamadasDeVitoria) != 0 || len(armazenamento.ChamadasDeColocacoes) != 0 {
+			t.Errorf("nada deveria ser gravado, obtido %v e %v", armazenamento.ChamadasDeVitoria, armazenamento.ChamadasDeColocacoes)
+		}
+	})
+
+	t.Run("partidas fora do modo torneio recusam os comandos de torneio", func(t *testing.T) {
+		jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+
+		partida := começarPartida(t, jogo, 3, ioutil.Discard)
+
+		verificaErro(t, partida.(poquer.ControleDeTorneio).Eliminar("Cleo"), poquer.ErrSemTorneio)
+	})
+}
+
+func TestConfiguracaoDoTorneio(t *testing.T) {
+	invalidas := map[string]poquer.ConfiguracaoDaPartida{
+		"um jogador":                    torneioCom(1, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo"}, FichasIniciais: 1000}),
+		"jogador repetido":              torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Cleo"}, FichasIniciais: 1000}),
+		"jogador sem nome":              torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", " "}, FichasIniciais: 1000}),
+		"sem fichas":                    torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}}),
+		"add-on negativo":               torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000, FichasDoAddOn: -1}),
+		"número de jogadores":           torneioCom(3, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000}),
+		"buy-in negativo":               torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000, BuyIn: -1}),
+		"premiação sem buy-in":          torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000, Premiacao: []float64{100}}),
+		"premiação que não soma 100":    torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000, BuyIn: 10, Premiacao: []float64{60, 30}}),
+		"premiação maior que o torneio": torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000, BuyIn: 10, Premiacao: []float64{50, 30, 20}}),
+		"mesas de um lugar":             torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000, LugaresPorMesa: 1}),
+		"mesas grandes demais":          torneioCom(2, poquer.ConfiguracaoDoTorneio{Jogadores: []string{"Cleo", "Chris"}, FichasIniciais: 1000, LugaresPorMesa: 11}),
+	}
+
+	for nome, configuracao := range invalidas {
+		t.Run("recusa torneio com "+nome, func(t *testing.T) {
+			jogo := poquer.NovoTexasHoldem(&poquer.AlertadorDeBlindEspiao{}, ArmazenamentoJogadorTosco)
+
+			if _, err := jogo.Começar(configuracao, ioutil.Discard); err == nil {
+				t.Error("esperava um erro")
+			}
+
+			if jogo.Partidas() != 0 {
+				t.Error("nenhuma partida deveria ter começado")
+			}
+		})
+	}
+}
+
+func torneioCom(numeroDeJogadores int, torneio poquer.ConfiguracaoDoTorneio) poquer.ConfiguracaoDaPartida {
+	return poquer.ConfiguracaoDaPartida{NumeroDeJogadores: numeroDeJogadores, Torneio: &torneio}
+}
+
+func começarTorneio(t *testing.T, jogo *poquer.TexasHoldem, configuracao poquer.ConfiguracaoDoTorneio) poquer.ControleDeTorneio {
+	t.Helper()
+
+	partida, err := jogo.Começar(torneioCom(len(configuracao.Jogadores), configuracao), ioutil.Discard)
+	verificaSemErro(t, err)
+
+	controle, ok := partida.(poquer.ControleDeTorneio)
+	if !ok {
+		t.Fatalf("a partida %T não controla um torneio", partida)
+	}
+
+	return controle
+}
+
+func verificaClassificacao(t *testing.T, torneio poquer.ControleDeTorneio, esperado string) {
+	t.Helper()
+
+	if obtido := torneio.Classificacao().String(); obtido != esperado {
+		t.Errorf("classificação obtida\n%s\nesperada\n%s", obtido, esperado)
+	}
+}
+
+func verificaColocacoesGravadas(t *testing.T, armazenamento *poquer.EsbocoDeArmazenamentoJogador, esperado []poquer.Colocacao) {
+	t.Helper()
+
+	if len(armazenamento.ChamadasDeColocacoes) != 1 {
+		t.Fatalf("esperava as colocações de um torneio, obtido %v", armazenamento.ChamadasDeColocacoes)
+	}
+
+	if obtido := armazenamento.ChamadasDeColocacoes[0]; !reflect.DeepEqual(obtido, esperado) {
+		t.Errorf("obtido %v, esperado %v", obtido, esperado)
+	}
+}
@@ -1,18 +1,134 @@
 package poquer
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// ConfiguracaoWebsocket define os limites das conexões do /ws. Os campos zerados usam os
+// valores de ConfiguracaoWebsocketPadrao
+type ConfiguracaoWebsocket struct {
+	// OrigensPermitidas lista os hosts (como "poquer.exemplo.com:5000") de onde páginas
+	// podem abrir o websocket, além do próprio servidor. "*" permite qualquer origem
+	OrigensPermitidas []string
+	// TempoDeEscrita é o prazo para cada mensagem ser enviada
+	TempoDeEscrita time.Duration
+	// TempoSemResposta é quanto tempo a conexão pode ficar sem responder a um ping
+	TempoSemResposta time.Duration
+	// IntervaloDePing deve ser menor que TempoSemResposta; zerado, é 90% dele
+	IntervaloDePing time.Duration
+	// TamanhoMaximoDaMensagem é o maior número de bytes aceito em uma mensagem do cliente
+	TamanhoMaximoDaMensagem int64
+}
+
+// ConfiguracaoWebsocketPadrao retorna os limites usados quando nenhum é informado
+func ConfiguracaoWebsocketPadrao() ConfiguracaoWebsocket {
+	return ConfiguracaoWebsocket{
+		TempoDeEscrita:          10 * time.Second,
+		TempoSemResposta:        60 * time.Second,
+		IntervaloDePing:         54 * time.Second,
+		TamanhoMaximoDaMensagem: 4096,
+	}
+}
+
+// completar preenche os campos zerados com os valores padrão
+func (c ConfiguracaoWebsocket) completar() ConfiguracaoWebsocket {
+	padrao := ConfiguracaoWebsocketPadrao()
+
+	if c.TempoDeEscrita == 0 {
+		c.TempoDeEscrita = padrao.TempoDeEscrita
+	}
+
+	if c.TempoSemResposta == 0 {
+		c.TempoSemResposta = padrao.TempoSemResposta
+	}
+
+	if c.IntervaloDePing == 0 {
+		c.IntervaloDePing = c.TempoSemResposta * 9 / 10
+	}
+
+	if c.TamanhoMaximoDaMensagem == 0 {
+		c.TamanhoMaximoDaMensagem = padrao.TamanhoMaximoDaMensagem
+	}
+
+	return c
+}
+
+func (c ConfiguracaoWebsocket) validar() error {
+	if c.TempoDeEscrita < 0 || c.TempoSemResposta < 0 || c.IntervaloDePing < 0 || c.TamanhoMaximoDaMensagem < 0 {
+		return fmt.Errorf("os tempos e o tamanho máximo da mensagem não podem ser negativos, obtido %+v", c)
+	}
+
+	if c.IntervaloDePing >= c.TempoSemResposta {
+		return fmt.Errorf("o intervalo de ping %v deve ser menor que o tempo sem resposta %v", c.IntervaloDePing, c.TempoSemResposta)
+	}
+
+	return nil
+}
+
+func novoAtualizadorDeWebsocket(origensPermitidas []string) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return origemPermitida(r, origensPermitidas)
+		},
+	}
+}
+
+// origemPermitida aceita clientes que não enviam Origin, como os que não são navegadores,
+// a própria origem do servidor e as origens da lista
+func origemPermitida(r *http.Request, origensPermitidas []string) bool {
+	origem := r.Header.Get("Origin")
+
+	if origem == "" {
+		return true
+	}
+
+	u, err := url.Parse(origem)
+
+	if err != nil {
+		return false
+	}
+
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, permitida := range origensPermitidas {
+		if permitida == "*" || strings.EqualFold(u.Host, permitida) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type websocketServidorJogador struct {
 	*websocket.Conn
+	mu             sync.Mutex
+	tempoDeEscrita time.Duration
+	fechado        chan struct{}
+	fechar         sync.Once
 }
 
+// Write envia o texto recebido como um alerta de blind, ignorando textos vazios
 func (w *websocketServidorJogador) Write(p []byte) (n int, err error) {
-	err = w.WriteMessage(1, p)
+	texto := strings.TrimSpace(string(p))
+
+	if texto == "" {
+		return len(p), nil
+	}
+
+	err = w.EnviarMensagem(MensagemAlertaBlind, DadosAlertaBlind{Mensagem: texto})
 
 	if err != nil {
 		return 0, err
@@ -21,20 +137,106 @@ func (w *websocketServidorJogador) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-func novoWebsocketServidorJogador(w http.ResponseWriter, r *http.Request) *websocketServidorJogador {
-	conexão, err := atualizadorDeWebsocket.Upgrade(w, r, nil)
+// AlertarBlind envia um alerta de blind com os blinds do nível
+func (w *websocketServidorJogador) AlertarBlind(blinds Blinds) error {
+	return w.EnviarMensagem(MensagemAlertaBlind, NovosDadosAlertaBlind(blinds))
+}
+
+// AtualizarRelogio envia o estado dos blinds
+func (w *websocketServidorJogador) AtualizarRelogio(estado EstadoDosBlinds) error {
+	return w.EnviarMensagem(MensagemRelogio, NovosDadosRelogio(estado))
+}
+
+func novoWebsocketServidorJogador(w http.ResponseWriter, r *http.Request, atualizador *websocket.Upgrader, configuracao ConfiguracaoWebsocket) (*websocketServidorJogador, error) {
+	conexão, err := atualizador.Upgrade(w, r, nil)
 
 	if err != nil {
 		log.Printf("houve um problema ao atualizar a conexão para websockets %v\n", err)
+		return nil, err
+	}
+
+	ws := &websocketServidorJogador{
+		Conn:           conexão,
+		tempoDeEscrita: configuracao.TempoDeEscrita,
+		fechado:        make(chan struct{}),
 	}
 
-	return &websocketServidorJogador{conexão}
+	conexão.SetReadLimit(configuracao.TamanhoMaximoDaMensagem)
+	conexão.SetReadDeadline(time.Now().Add(configuracao.TempoSemResposta))
+	conexão.SetPongHandler(func(string) error {
+		return conexão.SetReadDeadline(time.Now().Add(configuracao.TempoSemResposta))
+	})
+
+	go ws.manterViva(configuracao.IntervaloDePing)
+
+	return ws, nil
 }
 
-func (w *websocketServidorJogador) EsperarPelaMensagem() string {
-	_, msg, err := w.ReadMessage()
+// manterViva envia pings até a conexão ser fechada; sem os pongs a leitura expira
+func (w *websocketServidorJogador) manterViva(intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			prazo := time.Now().Add(w.tempoDeEscrita)
+			if err := w.WriteControl(websocket.PingMessage, nil, prazo); err != nil {
+				w.Close()
+				return
+			}
+		case <-w.fechado:
+			return
+		}
+	}
+}
+
+// Close fecha a conexão e para os pings; pode ser chamado mais de uma vez
+func (w *websocketServidorJogador) Close() error {
+	w.fechar.Do(func() {
+		close(w.fechado)
+	})
+	return w.Conn.Close()
+}
+
+// EnviarMensagem envia uma mensagem do protocolo; é seguro chamá-la de várias goroutines
+func (w *websocketServidorJogador) EnviarMensagem(tipo string, dados interface{}) error {
+	mensagem, err := NovaMensagemWebsocket(tipo, dados)
+
 	if err != nil {
-		log.Printf("erro ao ler do websocket %v\n", err)
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.SetWriteDeadline(time.Now().Add(w.tempoDeEscrita))
+	return w.WriteJSON(mensagem)
+}
+
+// EnviarErro responde ao cliente explicando o problema com a mensagem que ele enviou
+func (w *websocketServidorJogador) EnviarErro(err error) {
+	if errEnvio := w.EnviarMensagem(MensagemErro, DadosErro{Mensagem: err.Error()}); errEnvio != nil {
+		log.Printf("erro ao enviar erro pelo websocket %v\n", errEnvio)
+	}
+}
+
+// EsperarPelaMensagem lê a próxima mensagem do protocolo. Mensagens que não são JSON
+// são respondidas com um erro e ignoradas
+func (w *websocketServidorJogador) EsperarPelaMensagem() (MensagemWebsocket, error) {
+	for {
+		_, bruta, err := w.ReadMessage()
+
+		if err != nil {
+			return MensagemWebsocket{}, err
+		}
+
+		var mensagem MensagemWebsocket
+		if err := json.Unmarshal(bruta, &mensagem); err != nil {
+			w.EnviarErro(err)
+			continue
+		}
+
+		return mensagem, nil
 	}
-	return string(msg)
 }
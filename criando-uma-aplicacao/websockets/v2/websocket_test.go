@@ -0,0 +1,179 @@
+package poquer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	poquer "github.com/larien/aprenda-go-com-testes/criando-uma-aplicacao/websockets/v2"
+)
+
+func TestHigieneDoWebsocket(t *testing.T) {
+	novoServidor := func(t *testing.T, configuracao poquer.ConfiguracaoWebsocket) string {
+		t.Helper()
+		servidor := httptest.NewServer(deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, &JogoEspiao{}, poquer.ComConfiguracaoWebsocket(configuracao)))
+		t.Cleanup(servidor.Close)
+		return "ws" + strings.TrimPrefix(servidor.URL, "http") + "/ws"
+	}
+
+	t.Run("responde 400 sem derrubar o servidor quando a requisição não é de websocket", func(t *testing.T) {
+		servidor := deveFazerServidorJogador(t, ArmazenamentoJogadorTosco, jogoTosco)
+
+		resposta := httptest.NewRecorder()
+		servidor.ServeHTTP(resposta, httptest.NewRequest(http.MethodGet, "/ws", nil))
+
+		verificaStatus(t, resposta, http.StatusBadRequest)
+	})
+
+	t.Run("recusa origens fora da lista", func(t *testing.T) {
+		configuracao := poquer.ConfiguracaoWebsocketPadrao()
+		configuracao.OrigensPermitidas = []string{"poquer.exemplo.com"}
+		url := novoServidor(t, configuracao)
+
+		_, resposta, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://malicioso.exemplo.com"}})
+
+		if err == nil {
+			t.Fatal("esperava que a conexão fosse recusada")
+		}
+
+		if resposta == nil || resposta.StatusCode != http.StatusForbidden {
+			t.Errorf("esperava status %d, obtido %v", http.StatusForbidden, resposta)
+		}
+	})
+
+	t.Run("aceita origens da lista e a do próprio servidor", func(t *testing.T) {
+		configuracao := poquer.ConfiguracaoWebsocketPadrao()
+		configuracao.OrigensPermitidas = []string{"poquer.exemplo.com"}
+		url := novoServidor(t, configuracao)
+		host := strings.TrimPrefix(strings.TrimSuffix(url, "/ws"), "ws://")
+
+		for _, origem := range []string{"https://poquer.exemplo.com", "http://" + host} {
+			ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {origem}})
+
+			if err != nil {
+				t.Errorf("esperava aceitar a origem %s, %v", origem, err)
+				continue
+			}
+			ws.Close()
+		}
+	})
+
+	t.Run("fecha a conexão quando a mensagem passa do tamanho máximo", func(t *testing.T) {
+		configuracao := poquer.ConfiguracaoWebsocketPadrao()
+		configuracao.TamanhoMaximoDaMensagem = 64
+		ws := deveConectarAoWebSocket(t, novoServidor(t, configuracao))
+		defer ws.Close()
+
+		ws.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("a", 65)))
+
+		err := lerAteFechar(t, ws)
+
+		if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+			t.Errorf("esperava o fechamento %d, obtido %v", websocket.CloseMessageTooBig, err)
+		}
+	})
+
+	t.Run("envia pings periodicamente", func(t *testing.T) {
+		configuracao := poquer.ConfiguracaoWebsocketPadrao()
+		configuracao.IntervaloDePing = 5 * time.Millisecond
+		ws := deveConectarAoWebSocket(t, novoServidor(t, configuracao))
+		defer ws.Close()
+
+		pings := make(chan struct{}, 10)
+		ws.SetPingHandler(func(string) error {
+			pings <- struct{}{}
+			return ws.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second))
+		})
+		go ws.ReadMessage()
+
+		select {
+		case <-pings:
+		case <-time.After(500 * time.Millisecond):
+			t.Error("não recebeu nenhum ping")
+		}
+	})
+
+	t.Run("fecha conexões que não respondem aos pings", func(t *testing.T) {
+		configuracao := poquer.ConfiguracaoWebsocketPadrao()
+		configuracao.IntervaloDePing = 5 * time.Millisecond
+		configuracao.TempoSemResposta = 20 * time.Millisecond
+		ws := deveConectarAoWebSocket(t, novoServidor(t, configuracao))
+		defer ws.Close()
+
+		ws.SetPingHandler(func(string) error { return nil })
+
+		lerAteFechar(t, ws)
+	})
+
+	t.Run("completa uma configuração parcial com os valores padrão", func(t *testing.T) {
+		ws := deveConectarAoWebSocket(t, novoServidor(t, poquer.ConfiguracaoWebsocket{TamanhoMaximoDaMensagem: 64}))
+		defer ws.Close()
+
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 2})
+		lerAte(t, ws, poquer.MensagemSalaCriada)
+
+		ws.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("a", 65)))
+
+		if err := lerAteFechar(t, ws); !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+			t.Errorf("esperava o fechamento %d, obtido %v", websocket.CloseMessageTooBig, err)
+		}
+	})
+
+	t.Run("recusa configurações inválidas", func(t *testing.T) {
+		invalidas := map[string]poquer.ConfiguracaoWebsocket{
+			"ping depois do tempo sem resposta":      {IntervaloDePing: time.Minute, TempoSemResposta: time.Second},
+			"ping no tempo sem resposta":             {IntervaloDePing: time.Second, TempoSemResposta: time.Second},
+			"ping padrão e tempo sem resposta curto": {TempoSemResposta: time.Second, IntervaloDePing: 54 * time.Second},
+			"tamanho negativo":                       {TamanhoMaximoDaMensagem: -1},
+		}
+
+		for nome, configuracao := range invalidas {
+			if _, err := poquer.NovoServidorJogador(ArmazenamentoJogadorTosco, jogoTosco, poquer.ComConfiguracaoWebsocket(configuracao)); err == nil {
+				t.Errorf("esperava um erro para %s", nome)
+			}
+		}
+	})
+
+	t.Run("mantém conexões que respondem aos pings", func(t *testing.T) {
+		configuracao := poquer.ConfiguracaoWebsocketPadrao()
+		configuracao.IntervaloDePing = 5 * time.Millisecond
+		configuracao.TempoSemResposta = 20 * time.Millisecond
+		ws := deveConectarAoWebSocket(t, novoServidor(t, configuracao))
+		defer ws.Close()
+
+		lido := make(chan error, 1)
+		go func() {
+			_, _, err := ws.ReadMessage()
+			lido <- err
+		}()
+
+		select {
+		case err := <-lido:
+			t.Fatalf("a conexão não deveria ter sido fechada, %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		escreverMensagemNoWebsocket(t, ws, poquer.MensagemIniciar, poquer.DadosIniciar{NumeroDeJogadores: 2})
+		if err := <-lido; err != nil {
+			t.Errorf("esperava a resposta do servidor, %v", err)
+		}
+	})
+}
+
+// lerAteFechar lê até o servidor fechar a conexão, retornando o erro da leitura
+func lerAteFechar(t *testing.T, ws *websocket.Conn) error {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+
+	for {
+		if _, _, err := ws.ReadMessage(); err != nil {
+			if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
+				t.Fatal("o servidor não fechou a conexão")
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package poquer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// yamlParaJSON converte um documento YAML em JSON, para que as estruturas em YAML passem
+// pela mesma decodificação e validação das estruturas em JSON. Só o que uma estrutura de
+// blinds precisa é lido: mapas e listas pelo recuo, listas e mapas entre colchetes e chaves
+// em uma linha, textos com ou sem aspas, números, booleanos, null e comentários. Âncoras,
+// tags, textos em várias linhas e vários documentos são recusados
+func yamlParaJSON(dados []byte) ([]byte, error) {
+	linhas, err := linhasDoYAML(string(dados))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(linhas) == 0 {
+		return []byte("null"), nil
+	}
+
+	leitor := &leitorYAML{linhas: linhas}
+	valor, err := leitor.bloco(linhas[0].recuo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if leitor.pos < len(linhas) {
+		return nil, leitor.erro("recuo inesperado")
+	}
+
+	return json.Marshal(valor)
+}
+
+type linhaYAML struct {
+	numero int
+	recuo  int
+	texto  string
+}
+
+// linhasDoYAML separa as linhas com conteúdo, sem comentários e com o recuo medido
+func linhasDoYAML(documento string) ([]linhaYAML, error) {
+	var linhas []linhaYAML
+
+	for i, texto := range strings.Split(documento, "\n") {
+		texto = strings.TrimRight(removerComentario(texto), " \t\r")
+		conteudo := strings.TrimLeft(texto, " ")
+
+		if conteudo == "" || (texto == "---" && len(linhas) == 0) {
+			continue
+		}
+
+		if conteudo[0] == '\t' {
+			return nil, fmt.Errorf("yaml, linha %d: o recuo deve ser feito com espaços", i+1)
+		}
+
+		if texto == "---" || texto == "..." {
+			return nil, fmt.Errorf("yaml, linha %d: só um documento por arquivo é suportado", i+1)
+		}
+
+		linhas = append(linhas, linhaYAML{numero: i + 1, recuo: len(texto) - len(conteudo), texto: conteudo})
+	}
+
+	return linhas, nil
+}
+
+// removerComentario corta a linha no primeiro # que não está entre aspas e começa a linha
+// ou vem depois de um espaço
+func removerComentario(linha string) string {
+	var aspas byte
+
+	for i := 0; i < len(linha); i++ {
+		c := linha[i]
+
+		switch {
+		case aspas == '"' && c == '\\':
+			i++
+		case aspas != 0:
+			if c == aspas {
+				aspas = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t:-[{,", linha[i-1]) >= 0):
+			aspas = c
+		case c == '#' && (i == 0 || linha[i-1] == ' ' || linha[i-1] == '\t'):
+			return linha[:i]
+		}
+	}
+
+	return linha
+}
+
+type leitorYAML struct {
+	linhas []linhaYAML
+	pos    int
+}
+
+func (l *leitorYAML) erro(formato string, argumentos ...interface{}) error {
+	numero := l.linhas[len(l.linhas)-1].numero
+	if l.pos < len(l.linhas) {
+		numero = l.linhas[l.pos].numero
+	}
+	return fmt.Errorf("yaml, linha %d: %s", numero, fmt.Sprintf(formato, argumentos...))
+}
+
+// bloco lê a lista ou o mapa que começa na linha atual, com o recuo informado
+func (l *leitorYAML) bloco(recuo int) (interface{}, error) {
+	if ehItemYAML(l.linhas[l.pos].texto) {
+		return l.lista(recuo)
+	}
+	return l.mapa(recuo)
+}
+
+// filho lê o bloco mais recuado que a linha anterior; sem ele, o valor é null
+func (l *leitorYAML) filho(recuo int) (interface{}, error) {
+	if l.pos < len(l.linhas) && l.linhas[l.pos].recuo > recuo {
+		return l.bloco(l.linhas[l.pos].recuo)
+	}
+	return nil, nil
+}
+
+func (l *leitorYAML) lista(recuo int) (interface{}, error) {
+	itens := []interface{}{}
+
+	for l.pos < len(l.linhas) {
+		linha := l.linhas[l.pos]
+
+		if linha.recuo < recuo || (linha.recuo == recuo && !ehItemYAML(linha.texto)) {
+			break
+		}
+
+		if linha.recuo > recuo {
+			return nil, l.erro("recuo inesperado")
+		}
+
+		resto := strings.TrimLeft(linha.texto[1:], " ")
+
+		var item interface{}
+		var err error
+
+		if _, _, ehChave := separarChaveYAML(resto); ehChave {
+			// o item é um mapa que começa na mesma linha do traço: as chaves seguintes
+			// ficam alinhadas com a primeira
+			recuoDoMapa := linha.recuo + len(linha.texto) - len(resto)
+			l.linhas[l.pos] = linhaYAML{numero: linha.numero, recuo: recuoDoMapa, texto: resto}
+			item, err = l.mapa(recuoDoMapa)
+		} else if resto == "" {
+			l.pos++
+			item, err = l.filho(recuo)
+		} else {
+			item, err = l.valorNaLinha(resto)
+			l.pos++
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		itens = append(itens, item)
+	}
+
+	return itens, nil
+}
+
+func (l *leitorYAML) mapa(recuo int) (interface{}, error) {
+	mapa := map[string]interface{}{}
+
+	for l.pos < len(l.linhas) {
+		linha := l.linhas[l.pos]
+
+		if linha.recuo < recuo {
+			break
+		}
+
+		if linha.recuo > recuo {
+			return nil, l.erro("recuo inesperado")
+		}
+
+		chave, resto, ehChave := separarChaveYAML(linha.texto)
+
+		if !ehChave {
+			return nil, l.erro("esperava \"chave: valor\", obtido %q", linha.texto)
+		}
+
+		if _, repetida := mapa[chave]; repetida {
+			return nil, l.erro("a chave %q aparece mais de uma vez", chave)
+		}
+
+		var valor interface{}
+		var err error
+
+		if resto != "" {
+			valor, err = l.valorNaLinha(resto)
+			l.pos++
+		} else {
+			l.pos++
+			// uma lista pode ficar no mesmo recuo da chave que a contém
+			if l.pos < len(l.linhas) && l.linhas[l.pos].recuo == recuo && ehItemYAML(l.linhas[l.pos].texto) {
+				valor, err = l.lista(recuo)
+			} else {
+				valor, err = l.filho(recuo)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		mapa[chave] = valor
+	}
+
+	return mapa, nil
+}
+
+// valorNaLinha lê um valor escrito inteiro na linha: um texto, um número ou uma lista ou
+// mapa entre colchetes e chaves
+func (l *leitorYAML) valorNaLinha(texto string) (interface{}, error) {
+	if texto[0] != '[' && texto[0] != '{' {
+		valor, err := escalarYAML(texto)
+		if err != nil {
+			return nil, l.erro("%v", err)
+		}
+		return valor, nil
+	}
+
+	fluxo := &fluxoYAML{texto: texto}
+	valor, err := fluxo.valor()
+
+	if err == nil && fluxo.pularEspacos() < len(texto) {
+		err = fmt.Errorf("conteúdo inesperado depois de %q", texto[:fluxo.pos])
+	}
+
+	if err != nil {
+		return nil, l.erro("%v", err)
+	}
+
+	return valor, nil
+}
+
+func ehItemYAML(texto string) bool {
+	return texto == "-" || strings.HasPrefix(texto, "- ")
+}
+
+// separarChaveYAML separa "chave: valor"; listas e mapas entre colchetes e chaves não são chaves
+func separarChaveYAML(texto string) (chave, resto string, ehChave bool) {
+	if texto == "" || texto[0] == '[' || texto[0] == '{' || ehItemYAML(texto) {
+		return "", "", false
+	}
+
+	if texto[0] == '"' || texto[0] == '\'' {
+		chave, depois, err := lerEntreAspas(texto)
+		if err != nil || !strings.HasPrefix(depois, ":") || (len(depois) > 1 && depois[1] != ' ') {
+			return "", "", false
+		}
+		return chave, strings.TrimSpace(depois[1:]), true
+	}
+
+	for i := 0; i < len(texto); i++ {
+		if texto[i] == ':' && (i+1 == len(texto) || texto[i+1] == ' ') {
+			return strings.TrimSpace(texto[:i]), strings.TrimSpace(texto[i+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+// escalarYAML interpreta um valor simples: texto entre aspas, null, booleano, número ou texto
+func escalarYAML(texto string) (interface{}, error) {
+	switch texto[0] {
+	case '"', '\'':
+		valor, depois, err := lerEntreAspas(texto)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(depois) != "" {
+			return nil, fmt.Errorf("conteúdo inesperado depois de %q", texto[:len(texto)-len(depois)])
+		}
+		return valor, nil
+	case '|', '>', '&', '*', '!', '%', '@', '`':
+		return nil, fmt.Errorf("recurso de YAML não suportado em %q", texto)
+	}
+
+	switch texto {
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+
+	if inteiro, err := strconv.ParseInt(texto, 10, 64); err == nil {
+		return inteiro, nil
+	}
+
+	if real, err := strconv.ParseFloat(texto, 64); err == nil && !math.IsInf(real, 0) && !math.IsNaN(real) {
+		return real, nil
+	}
+
+	return texto, nil
+}
+
+// lerEntreAspas lê o texto entre aspas do começo e retorna o que vem depois delas. Entre
+// aspas simples, a aspa é escrita duas vezes; entre aspas duplas, valem os escapes de Go
+func lerEntreAspas(texto string) (valor, depois string, err error) {
+	aspas := texto[0]
+
+	for i := 1; i < len(texto); i++ {
+		switch {
+		case aspas == '"' && texto[i] == '\\':
+			i++
+		case texto[i] != aspas:
+		case aspas == '\'' && i+1 < len(texto) && texto[i+1] == '\'':
+			i++
+		case aspas == '\'':
+			return strings.Replace(texto[1:i], "''", "'", -1), texto[i+1:], nil
+		default:
+			valor, err := strconv.Unquote(texto[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("texto entre aspas %s inválido, %v", texto[:i+1], err)
+			}
+			return valor, texto[i+1:], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("aspas não fechadas em %s", texto)
+}
+
+// fluxoYAML lê listas e mapas escritos em uma linha, como [50, 100] ou {bigBlind: 100}
+type fluxoYAML struct {
+	texto string
+	pos   int
+}
+
+func (f *fluxoYAML) pularEspacos() int {
+	for f.pos < len(f.texto) && f.texto[f.pos] == ' ' {
+		f.pos++
+	}
+	return f.pos
+}
+
+func (f *fluxoYAML) valor() (interface{}, error) {
+	if f.pularEspacos() == len(f.texto) {
+		return nil, fmt.Errorf("valor faltando em %q", f.texto)
+	}
+
+	switch f.texto[f.pos] {
+	case '[':
+		return f.lista()
+	case '{':
+		return f.mapa()
+	}
+
+	texto, err := f.simples(",]}")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return escalarYAML(texto)
+}
+
+// simples lê um texto, com ou sem aspas, até um dos separadores
+func (f *fluxoYAML) simples(separadores string) (string, error) {
+	inicio := f.pularEspacos()
+
+	if inicio < len(f.texto) && (f.texto[inicio] == '"' || f.texto[inicio] == '\'') {
+		_, depois, err := lerEntreAspas(f.texto[inicio:])
+		if err != nil {
+			return "", err
+		}
+		f.pos = len(f.texto) - len(depois)
+		return f.texto[inicio:f.pos], nil
+	}
+
+	for f.pos < len(f.texto) && strings.IndexByte(separadores, f.texto[f.pos]) < 0 {
+		f.pos++
+	}
+
+	texto := strings.TrimSpace(f.texto[inicio:f.pos])
+
+	if texto == "" {
+		return "", fmt.Errorf("valor faltando em %q", f.texto)
+	}
+
+	return texto, nil
+}
+
+// proximo confere se o próximo caractere é um dos esperados e o consome
+func (f *fluxoYAML) proximo(esperados string) (byte, error) {
+	if f.pularEspacos() == len(f.texto) {
+		return 0, fmt.Errorf("%q não foi fechado; listas e mapas entre colchetes e chaves devem caber em uma linha", f.texto)
+	}
+
+	c := f.texto[f.pos]
+
+	if strings.IndexByte(esperados, c) < 0 {
+		return 0, fmt.Errorf("esperava um de %q em %q, obtido %q", esperados, f.texto, c)
+	}
+
+	f.pos++
+	return c, nil
+}
+
+func (f *fluxoYAML) lista() (interface{}, error) {
+	f.pos++
+	itens := []interface{}{}
+
+	if f.pularEspacos() < len(f.texto) && f.texto[f.pos] == ']' {
+		f.pos++
+		return itens, nil
+	}
+
+	for {
+		item, err := f.valor()
+		if err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+
+		fim, err := f.proximo(",]")
+		if err != nil {
+			return nil, err
+		}
+		if fim == ']' {
+			return itens, nil
+		}
+	}
+}
+
+func (f *fluxoYAML) mapa() (interface{}, error) {
+	f.pos++
+	mapa := map[string]interface{}{}
+
+	if f.pularEspacos() < len(f.texto) && f.texto[f.pos] == '}' {
+		f.pos++
+		return mapa, nil
+	}
+
+	for {
+		texto, err := f.simples(":,}")
+		if err != nil {
+			return nil, err
+		}
+
+		chave, err := escalarYAML(texto)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := f.proximo(":"); err != nil {
+			return nil, err
+		}
+
+		nome := fmt.Sprint(chave)
+		if _, repetida := mapa[nome]; repetida {
+			return nil, fmt.Errorf("a chave %q aparece mais de uma vez em %q", nome, f.texto)
+		}
+
+		if mapa[nome], err = f.valor(); err != nil {
+			return nil, err
+		}
+
+		fim, err := f.proximo(",}")
+		if err != nil {
+			return nil, err
+		}
+		if fim == '}' {
+			return mapa, nil
+		}
+	}
+}